@@ -47,6 +47,64 @@ type DiagnosisTaskSpec struct {
 	AlertContext *AlertContext `json:"alertContext,omitempty"`
 	// Approved indicates whether the diagnosis actions are approved by a human
 	Approved bool `json:"approved,omitempty"`
+	// PlanApproval is a human's decision on Status.PlannedActions. The controller
+	// applies it to the plan and clears it when the task resumes
+	// +optional
+	PlanApproval *PlanApproval `json:"planApproval,omitempty"`
+	// Cancelled asks the controller to stop the running agent and move the task to
+	// the Cancelled phase, keeping the task and what it found so far
+	// +optional
+	Cancelled bool `json:"cancelled,omitempty"`
+	// ModelOverride runs this task against a specific model instead of the configured
+	// default, e.g. to compare models. It must be allowlisted in the LLM config
+	// +optional
+	ModelOverride *ModelOverride `json:"modelOverride,omitempty"`
+	// TTLSecondsAfterFinished deletes the task this many seconds after it finishes
+	// (see Status.CompletionTime). Unset falls back to the controller default, which
+	// keeps finished tasks forever unless configured
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// Priority orders Pending tasks when the controller caps concurrent agents: higher
+	// priorities start first. Alert-created tasks derive it from the alert's severity
+	// label. Unset means PriorityNormal
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+// Task priorities set from alert severities (see DiagnosisTaskSpec.Priority)
+const (
+	// PriorityLow is used for info alerts
+	PriorityLow int32 = 100
+	// PriorityNormal is used for warning alerts, unknown severities and tasks without a priority
+	PriorityNormal int32 = 200
+	// PriorityHigh is used for critical alerts
+	PriorityHigh int32 = 300
+)
+
+// EffectivePriority returns Priority, or PriorityNormal when it is unset
+func (s *DiagnosisTaskSpec) EffectivePriority() int32 {
+	if s.Priority == nil {
+		return PriorityNormal
+	}
+	return *s.Priority
+}
+
+// ModelOverride selects the LLM provider and model for a single task
+type ModelOverride struct {
+	// Provider is the llm.providers entry to use; empty selects the default provider
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// Model is the model identifier; it must be listed in the provider's allowedModels
+	Model string `json:"model"`
+}
+
+// PlanApproval selects which steps of a remediation plan may run
+type PlanApproval struct {
+	// ApprovedSteps are the indexes of the approved PlannedActions; all others are rejected
+	// +optional
+	ApprovedSteps []int `json:"approvedSteps,omitempty"`
 }
 
 // AlertContext contains metadata about the alert
@@ -55,10 +113,15 @@ type AlertContext struct {
 	Name string `json:"name,omitempty"`
 	// Labels associated with the alert (e.g., severity=critical, reason=OOMKilled)
 	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations of the alert (e.g., summary, description, runbook_url)
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Count is how many alerts were merged into the group that created this task
+	Count int `json:"count,omitempty"`
 }
 
 // DiagnosisPhase describes the current state of the diagnosis
-// +kubebuilder:validation:Enum=Pending;Running;WaitingApproval;Completed;Failed
+// +kubebuilder:validation:Enum=Pending;Running;WaitingApproval;Completed;Inconclusive;Failed;Cancelled
 type DiagnosisPhase string
 
 const (
@@ -66,9 +129,21 @@ const (
 	PhaseRunning         DiagnosisPhase = "Running"
 	PhaseWaitingApproval DiagnosisPhase = "WaitingApproval"
 	PhaseCompleted       DiagnosisPhase = "Completed"
-	PhaseFailed          DiagnosisPhase = "Failed"
+	// PhaseInconclusive is a finished run whose report names no confident root cause
+	PhaseInconclusive DiagnosisPhase = "Inconclusive"
+	PhaseFailed       DiagnosisPhase = "Failed"
+	PhaseCancelled    DiagnosisPhase = "Cancelled"
 )
 
+// IsTerminal reports whether a task in this phase is finished and will not run again
+func (p DiagnosisPhase) IsTerminal() bool {
+	switch p {
+	case PhaseCompleted, PhaseInconclusive, PhaseFailed, PhaseCancelled:
+		return true
+	}
+	return false
+}
+
 // Finding represents a key discovery made during the diagnosis process
 type Finding struct {
 	// Step index in the diagnosis process
@@ -83,12 +158,114 @@ type Finding struct {
 	Timestamp string `json:"timestamp,omitempty"`
 }
 
+// HistoryEventPhase identifies the stage of the ReAct loop that produced a HistoryEvent
+// +kubebuilder:validation:Enum=Think;Act;Conclude
+type HistoryEventPhase string
+
+const (
+	HistoryEventThink    HistoryEventPhase = "Think"
+	HistoryEventAct      HistoryEventPhase = "Act"
+	HistoryEventConclude HistoryEventPhase = "Conclude"
+)
+
+// HistoryEvent is a structured entry in the agent's history stream, intended for UIs
+// that need typed data instead of parsing the free-form History strings
+type HistoryEvent struct {
+	// Step index in the diagnosis process
+	Step int `json:"step"`
+	// Phase of the ReAct loop that produced this event
+	Phase HistoryEventPhase `json:"phase"`
+	// Name of the tool executed (Act events only)
+	ToolName string `json:"toolName,omitempty"`
+	// Content is the thought, tool output summary, or conclusion
+	Content string `json:"content,omitempty"`
+	// Timestamp of the event
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
 // DiagnosisReport contains the findings of the diagnosis
 type DiagnosisReport struct {
 	// RootCause identified by the agent
 	RootCause string `json:"rootCause,omitempty"`
 	// Suggestion for remediation
 	Suggestion string `json:"suggestion,omitempty"`
+	// Details holds skill-specific structured findings (e.g. memory_limit for OOM)
+	// +optional
+	Details map[string]string `json:"details,omitempty"`
+	// ActionsTaken lists the write tools the agent executed, in order, for audit and rollback
+	// +optional
+	ActionsTaken []RemediationAction `json:"actionsTaken,omitempty"`
+	// ConfidencePercent is the agent's self-reported confidence in the diagnosis (0-100),
+	// so low-confidence diagnoses can be flagged; unset when it reported none
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ConfidencePercent *int `json:"confidencePercent,omitempty"`
+	// SupportingFindings are the Step numbers of the checkpoint findings the agent
+	// cited as supporting the root cause, so the diagnosis can be audited
+	// +optional
+	SupportingFindings []int `json:"supportingFindings,omitempty"`
+}
+
+// RemediationAction records one write tool execution by the agent
+type RemediationAction struct {
+	// Tool is the name of the executed tool (e.g. delete_pod)
+	Tool string `json:"tool"`
+	// Target is the affected resource as namespace/name, when it can be derived from the arguments
+	// +optional
+	Target string `json:"target,omitempty"`
+	// Arguments is the raw JSON arguments the tool was called with
+	// +optional
+	Arguments string `json:"arguments,omitempty"`
+	// Result is the tool output, or the error when execution failed (truncated)
+	// +optional
+	Result string `json:"result,omitempty"`
+	// Timestamp is when the tool was executed (RFC3339)
+	// +optional
+	Timestamp string `json:"timestamp,omitempty"`
+	// RollbackArguments are the arguments for calling Tool again to restore the state
+	// captured before execution. Empty when the action cannot be rolled back (e.g. delete_pod)
+	// +optional
+	RollbackArguments string `json:"rollbackArguments,omitempty"`
+	// RolledBackAt is when the action was rolled back (RFC3339)
+	// +optional
+	RolledBackAt string `json:"rolledBackAt,omitempty"`
+}
+
+// PendingApproval describes the tool call an agent is blocked on until a human approves it
+type PendingApproval struct {
+	// ToolName of the blocked tool call
+	ToolName string `json:"toolName"`
+	// Arguments is the raw JSON arguments the agent passed to the tool
+	// +optional
+	Arguments string `json:"arguments,omitempty"`
+	// RiskLevel is the tool's safety level (e.g. HighRisk)
+	// +optional
+	RiskLevel string `json:"riskLevel,omitempty"`
+	// RequestedAt is when approval was requested (RFC3339)
+	// +optional
+	RequestedAt string `json:"requestedAt,omitempty"`
+	// ToolRequest is set when the agent asked for ToolName outside its skill through
+	// request_tool; approving grants the tool instead of approving a call
+	// +optional
+	ToolRequest bool `json:"toolRequest,omitempty"`
+}
+
+// PlannedAction is one write tool call of the plan an agent proposed for approval
+type PlannedAction struct {
+	// Index is the step's position in the plan, as referenced by PlanApproval.ApprovedSteps
+	Index int `json:"index"`
+	// ToolName of the planned tool call
+	ToolName string `json:"toolName"`
+	// Arguments is the raw JSON arguments the agent passed to the tool
+	// +optional
+	Arguments string `json:"arguments,omitempty"`
+	// RiskLevel is the tool's safety level (e.g. HighRisk)
+	// +optional
+	RiskLevel string `json:"riskLevel,omitempty"`
+	// Approved is set once a PlanApproval selects this step
+	// +optional
+	Approved bool `json:"approved,omitempty"`
 }
 
 // DiagnosisTaskStatus defines the observed state of DiagnosisTask
@@ -99,14 +276,64 @@ type DiagnosisTaskStatus struct {
 	Report *DiagnosisReport `json:"report,omitempty"`
 	// History logs the agent's actions (for debugging/audit)
 	History []string `json:"history,omitempty"`
+	// Events is the typed counterpart of History
+	Events []HistoryEvent `json:"events,omitempty"`
 	// Checkpoint stores the intermediate findings for crash recovery
 	Checkpoint []Finding `json:"checkpoint,omitempty"`
 	// MatchedSkill indicates the name of the skill matched for this task
 	MatchedSkill string `json:"matchedSkill,omitempty"`
 	// Message provides additional information about the current status (e.g. why approval is needed)
 	Message string `json:"message,omitempty"`
+	// TokensUsed is the total number of LLM tokens consumed by this task across all runs
+	// +optional
+	TokensUsed int64 `json:"tokensUsed,omitempty"`
+	// PendingApproval is the typed form of an approval request; set while Phase is WaitingApproval
+	// +optional
+	PendingApproval *PendingApproval `json:"pendingApproval,omitempty"`
+	// PlannedActions is the ordered remediation plan awaiting approval (while WaitingApproval),
+	// or the decided plan the agent executes when it resumes
+	// +optional
+	PlannedActions []PlannedAction `json:"plannedActions,omitempty"`
+	// GrantedTools lists the tools outside the matched skill granted through request_tool;
+	// resumed runs are given them again. Granting does not approve high-risk calls
+	// +optional
+	GrantedTools []string `json:"grantedTools,omitempty"`
+	// ResumedAt is when the task was last resumed after its agent was interrupted (RFC3339)
+	// +optional
+	ResumedAt string `json:"resumedAt,omitempty"`
+	// RestoredFindings is how many checkpoint findings were restored on the last resume
+	// +optional
+	RestoredFindings int `json:"restoredFindings,omitempty"`
+	// RestoreMode describes the context restored on the last resume
+	// +optional
+	RestoreMode RestoreMode `json:"restoreMode,omitempty"`
+	// CompletionTime is when the task reached a terminal phase (RFC3339)
+	// +optional
+	CompletionTime string `json:"completionTime,omitempty"`
+	// ErrorClass classifies why the last run failed when the cause is recognized,
+	// e.g. ContextTooLong when the conversation outgrew the model's context window
+	// +optional
+	ErrorClass string `json:"errorClass,omitempty"`
+	// Attempt is how many times the run was restarted after a transient (e.g. LLM outage) failure
+	// +optional
+	Attempt int32 `json:"attempt,omitempty"`
+	// RetryAt is when the pending retry of a transiently failed run may start (RFC3339)
+	// +optional
+	RetryAt string `json:"retryAt,omitempty"`
 }
 
+// RestoreMode describes how much agent context survives a resume
+// +kubebuilder:validation:Enum=Lossy;None
+type RestoreMode string
+
+const (
+	// RestoreModeLossy means the agent was restored from the checkpointed finding
+	// summaries; the full conversation (thoughts, untruncated tool output) was lost
+	RestoreModeLossy RestoreMode = "Lossy"
+	// RestoreModeNone means there was no checkpoint and the agent started over
+	RestoreModeNone RestoreMode = "None"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
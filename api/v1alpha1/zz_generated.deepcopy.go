@@ -34,6 +34,13 @@ func (in *AlertContext) DeepCopyInto(out *AlertContext) {
 			(*out)[key] = val
 		}
 	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertContext.
@@ -64,6 +71,28 @@ func (in *DiagnosisPolicy) DeepCopy() *DiagnosisPolicy {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiagnosisReport) DeepCopyInto(out *DiagnosisReport) {
 	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ActionsTaken != nil {
+		in, out := &in.ActionsTaken, &out.ActionsTaken
+		*out = make([]RemediationAction, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfidencePercent != nil {
+		in, out := &in.ConfidencePercent, &out.ConfidencePercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.SupportingFindings != nil {
+		in, out := &in.SupportingFindings, &out.SupportingFindings
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosisReport.
@@ -160,6 +189,26 @@ func (in *DiagnosisTaskSpec) DeepCopyInto(out *DiagnosisTaskSpec) {
 		*out = new(AlertContext)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PlanApproval != nil {
+		in, out := &in.PlanApproval, &out.PlanApproval
+		*out = new(PlanApproval)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ModelOverride != nil {
+		in, out := &in.ModelOverride, &out.ModelOverride
+		*out = new(ModelOverride)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosisTaskSpec.
@@ -178,18 +227,38 @@ func (in *DiagnosisTaskStatus) DeepCopyInto(out *DiagnosisTaskStatus) {
 	if in.Report != nil {
 		in, out := &in.Report, &out.Report
 		*out = new(DiagnosisReport)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.History != nil {
 		in, out := &in.History, &out.History
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]HistoryEvent, len(*in))
+		copy(*out, *in)
+	}
 	if in.Checkpoint != nil {
 		in, out := &in.Checkpoint, &out.Checkpoint
 		*out = make([]Finding, len(*in))
 		copy(*out, *in)
 	}
+	if in.PendingApproval != nil {
+		in, out := &in.PendingApproval, &out.PendingApproval
+		*out = new(PendingApproval)
+		**out = **in
+	}
+	if in.PlannedActions != nil {
+		in, out := &in.PlannedActions, &out.PlannedActions
+		*out = make([]PlannedAction, len(*in))
+		copy(*out, *in)
+	}
+	if in.GrantedTools != nil {
+		in, out := &in.GrantedTools, &out.GrantedTools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiagnosisTaskStatus.
@@ -216,3 +285,98 @@ func (in *Finding) DeepCopy() *Finding {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HistoryEvent) DeepCopyInto(out *HistoryEvent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HistoryEvent.
+func (in *HistoryEvent) DeepCopy() *HistoryEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(HistoryEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelOverride) DeepCopyInto(out *ModelOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelOverride.
+func (in *ModelOverride) DeepCopy() *ModelOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingApproval) DeepCopyInto(out *PendingApproval) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingApproval.
+func (in *PendingApproval) DeepCopy() *PendingApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanApproval) DeepCopyInto(out *PlanApproval) {
+	*out = *in
+	if in.ApprovedSteps != nil {
+		in, out := &in.ApprovedSteps, &out.ApprovedSteps
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanApproval.
+func (in *PlanApproval) DeepCopy() *PlanApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedAction) DeepCopyInto(out *PlannedAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedAction.
+func (in *PlannedAction) DeepCopy() *PlannedAction {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationAction) DeepCopyInto(out *RemediationAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationAction.
+func (in *RemediationAction) DeepCopy() *RemediationAction {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationAction)
+	in.DeepCopyInto(out)
+	return out
+}
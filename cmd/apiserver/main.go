@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-logr/zapr"
@@ -15,17 +19,22 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
 	"kubeminds/internal/agent"
 	"kubeminds/internal/alert"
 	"kubeminds/internal/api"
 	"kubeminds/internal/config"
 	"kubeminds/internal/controller"
 	"kubeminds/internal/llm"
+	"kubeminds/internal/notify"
 	"kubeminds/internal/tools"
 )
 
@@ -34,6 +43,9 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// apiShutdownTimeout bounds how long shutdown waits for in-flight API requests.
+const apiShutdownTimeout = 10 * time.Second
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(kubemindsv1alpha1.AddToScheme(scheme))
@@ -104,13 +116,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(restCfg, ctrl.Options{
-		Scheme: scheme,
-		Metrics: metricsserver.Options{
-			BindAddress: metricsAddr,
-		},
-		HealthProbeBindAddress: probeAddr,
-	})
+	mgr, err := ctrl.NewManager(restCfg, managerOptions(cfg, metricsAddr, probeAddr))
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -123,6 +129,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The metrics.k8s.io client backs get_pod_metrics; without it the tool is not offered.
+	var metricsClient metricsclientset.Interface
+	if mc, err := metricsclientset.NewForConfig(restCfg); err != nil {
+		setupLog.Error(err, "unable to build metrics clientset; get_pod_metrics disabled")
+	} else {
+		metricsClient = mc
+	}
+
+	// Global kill switch for automated diagnosis. The flag is persisted in a ConfigMap
+	// so a pause survives restarts; config `paused: true` forces a paused start.
+	pauseSwitch := admin.NewPauseSwitch(admin.NewConfigMapPauseStore(
+		clientset, cfg.AlertAggregator.TargetNamespace, admin.DefaultPauseConfigMapName))
+	if err := pauseSwitch.Restore(context.Background()); err != nil {
+		setupLog.Error(err, "failed to restore pause state; starting unpaused")
+	}
+	if cfg.Paused && !pauseSwitch.Paused() {
+		if err := pauseSwitch.Pause(context.Background()); err != nil {
+			setupLog.Error(err, "failed to persist pause state from config")
+		}
+	}
+	if pauseSwitch.Paused() {
+		setupLog.Info("automated diagnosis is paused; use POST /api/v1/admin/resume to resume")
+	}
+
 	// Initialize SkillManager
 	skillDir := os.Getenv("SKILL_DIR")
 	if skillDir == "" {
@@ -140,20 +170,117 @@ func main() {
 		setupLog.Error(err, "invalid alert aggregator configuration")
 		os.Exit(1)
 	}
+	shutdownGrace, err := config.ParseAlertAggregatorShutdownGrace(cfg.AlertAggregator)
+	if err != nil {
+		setupLog.Error(err, "invalid alert aggregator configuration")
+		os.Exit(1)
+	}
+	cooldown, cooldownByAlert, err := config.ParseAlertAggregatorCooldown(cfg.AlertAggregator)
+	if err != nil {
+		setupLog.Error(err, "invalid alert aggregator configuration")
+		os.Exit(1)
+	}
+	// Receivers share the cooldowns, so one target is not diagnosed once per receiver.
+	var cooldowns alert.CooldownStore = alert.NewMemoryCooldownStore()
 	aggregator := alert.NewAggregator(
 		mgr.GetClient(),
 		cfg.AlertAggregator.TargetNamespace,
 		windowSize,
 		sweepInterval,
 		log.Log.WithName("alert-aggregator"),
-	)
-	alertHandler := alert.NewHandler(aggregator, log.Log.WithName("alert-handler"))
+	).WithPauseSwitch(pauseSwitch).
+		WithIngestBatchSize(cfg.AlertAggregator.IngestBatchSize).
+		WithMaxGroups(cfg.AlertAggregator.MaxGroups).
+		WithShutdownGracePeriod(shutdownGrace).
+		WithPropagateLabels(cfg.AlertAggregator.PropagateLabels).
+		WithAllowedTargetNamespaces(cfg.AlertAggregator.AllowedTargetNamespaces).
+		WithCancelOnResolve(cfg.AlertAggregator.CancelOnResolve).
+		WithCooldown(cooldown, cooldownByAlert).
+		WithCooldownStore(cooldowns).
+		WithGroupBy(cfg.AlertAggregator.GroupBy)
+	alertHandler := alert.NewHandler(aggregator, log.Log.WithName("alert-handler")).
+		WithMaxAlerts(cfg.AlertAggregator.MaxAlertsPerRequest)
+	aggregators := []*alert.Aggregator{aggregator}
+	// aggregatorStoreNames name each aggregator's group checkpoint (see alertAggregator.persistGroups).
+	aggregatorStoreNames := []string{"default"}
+
+	// Named receivers: one aggregator per receiver, served at /api/v1/alerts/webhook/{name}.
+	receiverHandlers := make(map[string]*alert.Handler, len(cfg.AlertAggregator.Receivers))
+	for _, rc := range cfg.AlertAggregator.Receivers {
+		if rc.Name == "" {
+			setupLog.Error(nil, "alert receiver is missing a name")
+			os.Exit(1)
+		}
+		if _, dup := receiverHandlers[rc.Name]; dup {
+			setupLog.Error(nil, "duplicate alert receiver", "receiver", rc.Name)
+			os.Exit(1)
+		}
+		targetNamespace := rc.TargetNamespace
+		if targetNamespace == "" {
+			targetNamespace = cfg.AlertAggregator.TargetNamespace
+		}
+		groupBy := rc.GroupBy
+		if len(groupBy) == 0 {
+			groupBy = cfg.AlertAggregator.GroupBy
+		}
+		recvAggregator := alert.NewAggregator(
+			mgr.GetClient(),
+			targetNamespace,
+			windowSize,
+			sweepInterval,
+			log.Log.WithName("alert-aggregator").WithValues("receiver", rc.Name),
+		).WithPauseSwitch(pauseSwitch).
+			WithIngestBatchSize(cfg.AlertAggregator.IngestBatchSize).
+			WithMaxGroups(cfg.AlertAggregator.MaxGroups).
+			WithShutdownGracePeriod(shutdownGrace).
+			WithPropagateLabels(cfg.AlertAggregator.PropagateLabels).
+			WithAllowedTargetNamespaces(cfg.AlertAggregator.AllowedTargetNamespaces).
+			WithCancelOnResolve(cfg.AlertAggregator.CancelOnResolve).
+			WithCooldown(cooldown, cooldownByAlert).
+			WithCooldownStore(cooldowns).
+			WithGroupBy(groupBy).
+			WithMinSeverity(rc.MinSeverity)
+		aggregators = append(aggregators, recvAggregator)
+		aggregatorStoreNames = append(aggregatorStoreNames, "receiver:"+rc.Name)
+		receiverHandlers[rc.Name] = alert.NewHandler(recvAggregator, log.Log.WithName("alert-handler").WithValues("receiver", rc.Name)).
+			WithMaxAlerts(cfg.AlertAggregator.MaxAlertsPerRequest)
+		setupLog.Info("alert receiver enabled", "receiver", rc.Name, "targetNamespace", targetNamespace)
+	}
 
 	// Create Tool Router
-	toolRouter := tools.NewRouter(slog.Default())
-	toolRouter.AddProvider(tools.NewInternalProvider(clientset))
-	toolRouter.AddProvider(tools.NewMCPProvider())
-	toolRouter.AddProvider(tools.NewGRPCProvider())
+	providerTimeout, toolCacheTTL, err := config.ParseToolsConfig(cfg.Tools)
+	if err != nil {
+		setupLog.Error(err, "invalid tools configuration")
+		os.Exit(1)
+	}
+	toolRouter := tools.NewRouter(slog.Default()).
+		WithMaxConcurrency(cfg.Tools.MaxConcurrentProviders).
+		WithQueryTimeout(providerTimeout).
+		WithCacheTTL(toolCacheTTL).
+		WithDisabledTools(cfg.Tools.Disabled)
+	// The router drops disabled tools from every provider, so the providers still
+	// list them and Validate can tell typos in tools.disabled from real tool names.
+	toolProviders := []agent.ToolProvider{
+		tools.NewInternalProvider(clientset, metricsClient),
+		tools.NewMCPProvider(),
+		tools.NewGRPCProvider(),
+	}
+	if cfg.Prometheus.URL != "" {
+		toolProviders = append(toolProviders, tools.NewPrometheusProvider(cfg.Prometheus.URL))
+		setupLog.Info("Prometheus query tool enabled", "url", cfg.Prometheus.URL)
+	}
+	if cfg.Tools.Sandbox {
+		toolRouter.AddProvider(tools.NewSandboxProvider(slog.Default(), toolProviders...))
+		setupLog.Info("Tool sandbox enabled; tool calls are recorded but not executed")
+	} else {
+		for _, p := range toolProviders {
+			toolRouter.AddProvider(p)
+		}
+	}
+	if err := toolRouter.Validate(context.Background()); err != nil {
+		setupLog.Error(err, "tool schema validation failed")
+		os.Exit(1)
+	}
 
 	// Build LLM Router for the ping endpoint.
 	// A failed router build is non-fatal for the API server — the ping endpoint
@@ -177,14 +304,37 @@ func main() {
 			setupLog.Error(err, "invalid redis.eventTTL configuration")
 			os.Exit(1)
 		}
+		recentMaxAge, recencyHalfLife, err := config.ParseRedisRecency(cfg.Redis)
+		if err != nil {
+			setupLog.Error(err, "invalid redis recency configuration")
+			os.Exit(1)
+		}
+		redisTLS, err := cfg.Redis.TLS.ClientConfig()
+		if err != nil {
+			setupLog.Error(err, "invalid redis.tls configuration")
+			os.Exit(1)
+		}
 		redisClient := goredis.NewClient(&goredis.Options{
-			Addr:     cfg.Redis.Addr,
-			Password: cfg.Redis.Password,
-			DB:       cfg.Redis.DB,
+			Addr:      cfg.Redis.Addr,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			TLSConfig: redisTLS,
 		})
-		l2Store = agent.NewRedisEventStore(redisClient, eventTTL)
-		aggregator.WithL2Store(l2Store)
+		l2Store = agent.NewRedisEventStore(redisClient, eventTTL).WithRecency(recentMaxAge, recencyHalfLife)
+		cooldowns = alert.NewRedisCooldownStore(redisClient)
+		for i, agg := range aggregators {
+			agg.WithL2Store(l2Store).WithL2QueueSize(cfg.Redis.AppendQueueSize)
+			if cfg.AlertAggregator.PersistGroups {
+				agg.WithGroupStore(alert.NewRedisGroupStore(redisClient, aggregatorStoreNames[i]))
+			}
+			agg.WithCooldownStore(cooldowns)
+		}
+		if cfg.AlertAggregator.PersistGroups {
+			setupLog.Info("alert groups are checkpointed to Redis")
+		}
 		setupLog.Info("L2 Redis event store enabled", "addr", cfg.Redis.Addr)
+	} else if cfg.AlertAggregator.PersistGroups {
+		setupLog.Info("alertAggregator.persistGroups requires redis.addr; alert groups are kept in memory only")
 	}
 
 	// Initialize L3 Knowledge Base (optional — enabled when postgres.dsn is set in config).
@@ -195,7 +345,16 @@ func main() {
 		if embedDim == 0 {
 			embedDim = 1536
 		}
-		kb, err := agent.NewPGKnowledgeBaseFromDSN(context.Background(), cfg.PostgreSQL.DSN, embedDim)
+		pgTLS, err := cfg.PostgreSQL.TLS.ClientConfig()
+		if err != nil {
+			setupLog.Error(err, "invalid postgres.tls configuration")
+			os.Exit(1)
+		}
+		var pgRootCAs *x509.CertPool
+		if pgTLS != nil {
+			pgRootCAs = pgTLS.RootCAs
+		}
+		kb, err := agent.NewPGKnowledgeBaseFromDSN(context.Background(), cfg.PostgreSQL.DSN, embedDim, pgRootCAs)
 		if err != nil {
 			setupLog.Error(err, "failed to connect to PostgreSQL for L3 knowledge base")
 			os.Exit(1)
@@ -206,31 +365,121 @@ func main() {
 		}
 		knowledgeBase = kb
 
-		// Reuse the openai provider's API key and base URL for embedding generation.
-		openaiCfg := cfg.LLM.Providers["openai"]
-		embedder = llm.NewOpenAIEmbedder(openaiCfg.APIKey, openaiCfg.BaseURL)
+		// Reuse the selected provider's connection settings for embedding generation.
+		embedder, err = llm.NewEmbedderFromConfig(cfg.LLM, cfg.PostgreSQL)
+		if err != nil {
+			setupLog.Error(err, "invalid postgres.embeddingProvider configuration")
+			os.Exit(1)
+		}
+		embedder = llm.NewLimitingEmbeddingProvider(embedder, cfg.PostgreSQL.EmbeddingMaxConcurrency)
 		setupLog.Info("L3 PostgreSQL knowledge base enabled")
 	}
 
+	// The diagnosis LLM: the router, the canned mock in demo mode, or none, in which
+	// case the controller fails tasks with a clear message.
+	var diagnosisLLM agent.LLMProvider
+	switch {
+	case llmRouter != nil:
+		diagnosisLLM = llmRouter
+	case cfg.LLM.DemoMode:
+		diagnosisLLM = llm.NewMockProvider()
+		setupLog.Info("No LLM configured; demo mode answers diagnoses with mock responses")
+	default:
+		setupLog.Info("No LLM configured; DiagnosisTasks will fail until one is")
+	}
+
+	// Build the triage LLM (optional — only when triage is enabled).
+	var triageLLM agent.LLMProvider
+	if cfg.Agent.Triage.MinAlertCount > 0 && llmRouter != nil {
+		r, err := llm.NewModelRouterFromConfig(cfg.LLM, cfg.Agent.Triage.Provider, cfg.Agent.Triage.Model)
+		if err != nil {
+			setupLog.Error(err, "failed to build triage LLM; triage will use the default provider")
+		} else {
+			triageLLM = r
+		}
+	}
+
+	// Build the notification router (optional — enabled when notifications.sinks is set).
+	notifier, err := notify.NewRouterFromConfig(cfg.Notifications)
+	if err != nil {
+		setupLog.Error(err, "invalid notifications configuration")
+		os.Exit(1)
+	}
+
 	// Register the DiagnosisTask controller with the manager.
 	agentTimeout := time.Duration(cfg.AgentTimeoutMinutes) * time.Minute
+	stepDelay, err := config.ParseAgentStepDelay(cfg.Agent)
+	if err != nil {
+		setupLog.Error(err, "invalid agent configuration")
+		os.Exit(1)
+	}
+	toolTimeout, err := config.ParseAgentToolTimeout(cfg.Agent)
+	if err != nil {
+		setupLog.Error(err, "invalid agent configuration")
+		os.Exit(1)
+	}
+	runRetryBackoff, err := config.ParseAgentRunRetryBackoff(cfg.Agent)
+	if err != nil {
+		setupLog.Error(err, "invalid agent configuration")
+		os.Exit(1)
+	}
+	var transcriptStore agent.TranscriptStore
+	if cfg.Agent.TranscriptDir != "" {
+		transcriptStore = agent.NewFileTranscriptStore(cfg.Agent.TranscriptDir)
+		setupLog.Info("Recording run transcripts", "dir", cfg.Agent.TranscriptDir)
+	}
 	if err := (&controller.DiagnosisTaskReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
 		K8sClient:     clientset,
 		SkillDir:      skillDir,
 		AgentTimeout:  agentTimeout,
-		LLMProvider:   llmRouter,
+		LLMProvider:   diagnosisLLM,
 		ToolRouter:    toolRouter,
 		L2Store:       l2Store,
 		KnowledgeBase: knowledgeBase,
 		Embedder:      embedder,
+		Recorder:      mgr.GetEventRecorderFor("diagnosistask-controller"),
+
+		TranscriptStore:       transcriptStore,
+		KnowledgeEvidenceTopN: cfg.PostgreSQL.EvidenceTopN,
+		L2SameAlertOnly:       cfg.Redis.RecentSameAlertOnly,
+		Pause:                 pauseSwitch,
+		SummaryMaxLen:         cfg.Agent.SummaryMaxLen,
+		ThoughtMaxLen:         cfg.Agent.ThoughtMaxLen,
+		MinWriteConfidence:    cfg.Agent.MinWriteConfidence,
+		MinReadSteps:          cfg.Agent.MinReadSteps,
+		MaxMemoryMessages:     cfg.Agent.MaxMemoryMessages,
+		MaxMemoryBytes:        cfg.Agent.MaxMemoryBytes,
+		StepDelay:             stepDelay,
+		ToolTimeout:           toolTimeout,
+		MaxAgentsPerNamespace: cfg.Agent.MaxConcurrentAgentsPerNamespace,
+		TriageMinAlertCount:   cfg.Agent.Triage.MinAlertCount,
+		TriageLLMProvider:     triageLLM,
+		TriageMaxSteps:        cfg.Agent.Triage.MaxSteps,
+		Notifier:              notifier,
+
+		HistoryTokenBudget:     cfg.Agent.HistoryTokenBudget,
+		HistoryKeepRecentTurns: cfg.Agent.HistoryKeepRecentTurns,
+		InconclusiveConfidence: cfg.Agent.InconclusiveConfidence,
+		ToolRequests:           cfg.Agent.ToolRequests.Enabled,
+		ToolAutoGrant:          cfg.Agent.ToolRequests.AutoGrant,
+		FairReservedFraction:   cfg.Agent.Fairness.ReservedFraction,
+		TTLAfterFinished:       time.Duration(cfg.TaskTTLSecondsAfterFinished) * time.Second,
+		MaxConcurrentAgents:    cfg.Agent.MaxConcurrentAgents,
+		MaxRunRetries:          cfg.Agent.MaxRunRetries,
+		RunRetryBackoff:        runRetryBackoff,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create DiagnosisTask controller")
 		os.Exit(1)
 	}
 
 	// Initialize API Server
+	listCacheTTL, err := config.ParseAPIListCacheTTL(cfg.API)
+	if err != nil {
+		setupLog.Error(err, "invalid api configuration")
+		os.Exit(1)
+	}
 	apiServer := api.NewServer(
 		mgr.GetClient(),
 		clientset,
@@ -238,24 +487,87 @@ func main() {
 		toolRouter,
 		apiPort,
 		log.Log.WithName("api-server"),
-	).WithAlertHandler(alertHandler).WithLLMRouter(llmRouter)
+	).WithAlertHandler(alertHandler).WithLLMRouter(llmRouter).WithPauseSwitch(pauseSwitch).
+		WithKnowledgeBase(knowledgeBase).WithAdminToken(cfg.API.AdminToken).WithListCacheTTL(listCacheTTL)
+	apiToken := cfg.API.AuthToken
+	if t := os.Getenv("KUBEMINDS_API_TOKEN"); t != "" {
+		apiToken = t
+	}
+	if apiToken != "" {
+		apiServer.WithAuthToken(apiToken)
+		setupLog.Info("API bearer-token authentication enabled")
+	}
+	for name, h := range receiverHandlers {
+		apiServer.WithAlertReceiver(name, h)
+	}
+	if cfg.EnableLeaderElection {
+		apiServer.WithLeaderElection(mgr.Elected())
+	}
+	// The manager's cached client cannot watch; task streaming uses a direct one.
+	if watchClient, err := client.NewWithWatch(restCfg, client.Options{Scheme: mgr.GetScheme()}); err != nil {
+		setupLog.Error(err, "unable to create watch client; task streaming disabled")
+	} else {
+		apiServer.WithTaskWatcher(watchClient)
+	}
+
+	sigCtx := ctrl.SetupSignalHandler()
 
 	go func() {
 		setupLog.Info("starting api server", "port", fmt.Sprintf("%d", apiPort))
-		if err := apiServer.Start(); err != nil {
+		if err := apiServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			setupLog.Error(err, "problem running api server")
 			os.Exit(1)
 		}
 	}()
 
-	setupLog.Info("starting manager")
-	sigCtx := ctrl.SetupSignalHandler()
+	// On shutdown let in-flight API requests (e.g. task creation) finish before exiting.
+	var apiServerDone sync.WaitGroup
+	apiServerDone.Go(func() {
+		<-sigCtx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		if err := apiServer.Shutdown(ctx); err != nil {
+			setupLog.Error(err, "problem shutting down api server")
+		}
+	})
 
-	// Start the alert aggregator sweep loop, tied to the process signal context.
-	go aggregator.Run(sigCtx)
+	// Run the alert aggregator sweep loops under the manager, so with leader election
+	// only the leader flushes groups into tasks. On shutdown each aggregator flushes
+	// its pending groups before Run returns, and the manager waits for it.
+	for _, agg := range aggregators {
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			agg.Run(ctx)
+			return nil
+		})); err != nil {
+			setupLog.Error(err, "unable to add alert aggregator to manager")
+			os.Exit(1)
+		}
+	}
 
+	setupLog.Info("starting manager", "leaderElection", cfg.EnableLeaderElection)
 	if err := mgr.Start(sigCtx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	apiServerDone.Wait()
+}
+
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+
+// managerOptions builds the controller manager options from the configuration.
+// With leader election enabled only the elected replica reconciles DiagnosisTasks
+// and runs the alert aggregators; the others wait on the Lease as hot standbys.
+func managerOptions(cfg *config.Config, metricsAddr, probeAddr string) ctrl.Options {
+	return ctrl.Options{
+		Scheme: scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: metricsAddr,
+		},
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         cfg.EnableLeaderElection,
+		LeaderElectionID:       cfg.LeaderElectionID,
+		// The process exits as soon as the manager stops, so the Lease can be given
+		// up right away instead of making a standby wait for it to expire.
+		LeaderElectionReleaseOnCancel: true,
+	}
 }
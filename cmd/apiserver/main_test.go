@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"kubeminds/internal/config"
+)
+
+func TestManagerOptions_LeaderElection(t *testing.T) {
+	cfg := &config.Config{EnableLeaderElection: true, LeaderElectionID: "kubeminds-test.kubeminds.io"}
+	opts := managerOptions(cfg, ":8082", ":8083")
+	if !opts.LeaderElection {
+		t.Error("LeaderElection = false, want true from config")
+	}
+	if opts.LeaderElectionID != cfg.LeaderElectionID {
+		t.Errorf("LeaderElectionID = %q, want %q", opts.LeaderElectionID, cfg.LeaderElectionID)
+	}
+	if opts.Metrics.BindAddress != ":8082" || opts.HealthProbeBindAddress != ":8083" {
+		t.Errorf("bind addresses = %q, %q", opts.Metrics.BindAddress, opts.HealthProbeBindAddress)
+	}
+
+	cfg.EnableLeaderElection = false
+	if managerOptions(cfg, ":8082", ":8083").LeaderElection {
+		t.Error("LeaderElection = true, want false from config")
+	}
+}
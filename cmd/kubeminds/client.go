@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// Client is a minimal REST client for the KubeMinds API server.
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	retryInterval time.Duration
+	// token is sent as a bearer token on every request when set.
+	token string
+}
+
+// NewClient creates a Client for the API server at baseURL (e.g. http://localhost:8081).
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		httpClient:    httpClient,
+		retryInterval: 2 * time.Second,
+	}
+}
+
+// WithRetryInterval sets how long Follow waits before reopening a task stream the
+// server closed early (default 2s).
+func (c *Client) WithRetryInterval(d time.Duration) *Client {
+	if d > 0 {
+		c.retryInterval = d
+	}
+	return c
+}
+
+// WithToken sets the bearer token sent on every request, for API servers with
+// api.authToken set.
+func (c *Client) WithToken(token string) *Client {
+	c.token = token
+	return c
+}
+
+// CreateTask submits a DiagnosisTask via POST /api/v1/tasks and returns the created object.
+func (c *Client) CreateTask(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) (*kubemindsv1alpha1.DiagnosisTask, error) {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("marshal task: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/tasks", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var created kubemindsv1alpha1.DiagnosisTask
+	if err := c.do(req, http.StatusCreated, &created); err != nil {
+		return nil, fmt.Errorf("create task: %w", err)
+	}
+	return &created, nil
+}
+
+// GetTask fetches a DiagnosisTask via GET /api/v1/tasks/{namespace}/{name}.
+func (c *Client) GetTask(ctx context.Context, namespace, name string) (*kubemindsv1alpha1.DiagnosisTask, error) {
+	url := fmt.Sprintf("%s/api/v1/tasks/%s/%s", c.baseURL, namespace, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var task kubemindsv1alpha1.DiagnosisTask
+	if err := c.do(req, http.StatusOK, &task); err != nil {
+		return nil, fmt.Errorf("get task: %w", err)
+	}
+	return &task, nil
+}
+
+// Follow tails a task's typed history events to out until the task reaches a
+// terminal phase (Completed or Failed), returning the final task. It reads the
+// task's Server-Sent Event stream (GET /api/v1/tasks/{namespace}/{name}/stream).
+// A stream closed before the task finished, e.g. by an API server restart, is
+// reopened after the retry interval; the server replays the task's events, so
+// only those not printed yet are.
+func (c *Client) Follow(ctx context.Context, namespace, name string, out io.Writer) (*kubemindsv1alpha1.DiagnosisTask, error) {
+	f := &follower{out: out}
+	for {
+		done, err := c.followStream(ctx, namespace, name, f)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return c.GetTask(ctx, namespace, name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+// follower prints a task's stream events, remembering what it printed across
+// reconnects.
+type follower struct {
+	out   io.Writer
+	steps int
+	phase kubemindsv1alpha1.DiagnosisPhase
+}
+
+// followStream reads one task stream, reporting true once the task reached a
+// terminal phase and false when the stream ended before that.
+func (c *Client) followStream(ctx context.Context, namespace, name string, f *follower) (bool, error) {
+	url := fmt.Sprintf("%s/api/v1/tasks/%s/%s/stream", c.baseURL, namespace, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.send(req, http.StatusOK)
+	if err != nil {
+		return false, fmt.Errorf("follow task: %w", err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, nil
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			done, err := f.handle(event, data)
+			if done || err != nil {
+				return done, err
+			}
+			event, data = "", ""
+		}
+	}
+}
+
+// handle prints one stream event, reporting true once the task is terminal.
+func (f *follower) handle(event, data string) (bool, error) {
+	switch event {
+	case "phase":
+		var phase kubemindsv1alpha1.DiagnosisPhase
+		if err := json.Unmarshal([]byte(data), &phase); err != nil {
+			return false, fmt.Errorf("follow task: decode phase: %w", err)
+		}
+		if phase != f.phase {
+			fmt.Fprintf(f.out, "phase: %s\n", phase)
+			f.phase = phase
+		}
+		return isTerminal(phase), nil
+	case "step":
+		var step struct {
+			Index int                            `json:"index"`
+			Event kubemindsv1alpha1.HistoryEvent `json:"event"`
+		}
+		if err := json.Unmarshal([]byte(data), &step); err != nil {
+			return false, fmt.Errorf("follow task: decode step: %w", err)
+		}
+		if step.Index >= f.steps {
+			fmt.Fprintln(f.out, formatEvent(step.Event))
+			f.steps = step.Index + 1
+		}
+	case "deleted":
+		return false, errors.New("follow task: task was deleted")
+	}
+	return false, nil
+}
+
+func (c *Client) do(req *http.Request, wantStatus int, out interface{}) error {
+	resp, err := c.send(req, wantStatus)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends req with the bearer token, returning the response when it has
+// wantStatus. The caller closes the response body.
+func (c *Client) send(req *http.Request, wantStatus int) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != wantStatus {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func isTerminal(phase kubemindsv1alpha1.DiagnosisPhase) bool {
+	return phase.IsTerminal()
+}
+
+func formatEvent(ev kubemindsv1alpha1.HistoryEvent) string {
+	if ev.ToolName != "" {
+		return fmt.Sprintf("[step %d] %s %s: %s", ev.Step, ev.Phase, ev.ToolName, ev.Content)
+	}
+	return fmt.Sprintf("[step %d] %s: %s", ev.Step, ev.Phase, ev.Content)
+}
@@ -0,0 +1,106 @@
+// kubeminds is a small command-line client for the KubeMinds API server.
+//
+// Usage:
+//
+//	kubeminds diagnose --kind Pod --name nginx-abc --namespace default
+//
+// The diagnose command creates a DiagnosisTask through the REST API and tails
+// its history to stdout until the task completes or fails. The API server
+// address defaults to $KUBEMINDS_API_URL, falling back to http://localhost:8081,
+// and the bearer token for API servers requiring one to $KUBEMINDS_API_TOKEN.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+const defaultAPIURL = "http://localhost:8081"
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "diagnose" {
+		fmt.Fprintln(os.Stderr, "Usage: kubeminds diagnose --kind <Kind> --name <name> [--namespace <ns>]")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := runDiagnose(ctx, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDiagnose(ctx context.Context, args []string) error {
+	apiURL := os.Getenv("KUBEMINDS_API_URL")
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+
+	fs := flag.NewFlagSet("diagnose", flag.ExitOnError)
+	server := fs.String("server", apiURL, "KubeMinds API server URL")
+	token := fs.String("token", os.Getenv("KUBEMINDS_API_TOKEN"), "bearer token for the API server (default $KUBEMINDS_API_TOKEN)")
+	kind := fs.String("kind", "Pod", "kind of the resource to diagnose")
+	name := fs.String("name", "", "name of the resource to diagnose")
+	namespace := fs.String("namespace", "default", "namespace of the resource (and of the created task)")
+	taskName := fs.String("task-name", "", "name of the DiagnosisTask (default: generated by the server)")
+	interval := fs.Duration("interval", 2*time.Second, "wait before reopening the task stream when the server closes it early")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	client := NewClient(*server, nil).WithRetryInterval(*interval).WithToken(*token)
+	task := &kubemindsv1alpha1.DiagnosisTask{
+		ObjectMeta: metav1.ObjectMeta{Name: *taskName, Namespace: *namespace},
+		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+			Target: kubemindsv1alpha1.DiagnosisTarget{
+				Kind:      *kind,
+				Name:      *name,
+				Namespace: *namespace,
+			},
+		},
+	}
+
+	final, err := diagnose(ctx, client, task, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if final.Status.Phase == kubemindsv1alpha1.PhaseFailed {
+		return fmt.Errorf("diagnosis failed: %s", final.Status.Message)
+	}
+	if final.Status.Phase == kubemindsv1alpha1.PhaseCancelled {
+		return fmt.Errorf("diagnosis cancelled")
+	}
+	return nil
+}
+
+// diagnose creates the task, follows it to completion and prints the report.
+func diagnose(ctx context.Context, client *Client, task *kubemindsv1alpha1.DiagnosisTask, out io.Writer) (*kubemindsv1alpha1.DiagnosisTask, error) {
+	created, err := client.CreateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "created diagnosistask %s/%s\n", created.Namespace, created.Name)
+
+	final, err := client.Follow(ctx, created.Namespace, created.Name, out)
+	if err != nil {
+		return nil, err
+	}
+	if report := final.Status.Report; report != nil {
+		fmt.Fprintf(out, "\nRoot cause: %s\nSuggestion: %s\n", report.RootCause, report.Suggestion)
+	}
+	return final, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// fakeAPIServer serves POST /api/v1/tasks and then streams the created task
+// through a scripted sequence of statuses. The first stream is closed after the
+// first status, as on an API server restart; later streams replay every status
+// the way the real server replays a task. GET returns the last status. Requests
+// without the bearer token are rejected when token is set.
+func fakeAPIServer(t *testing.T, token string, statuses []kubemindsv1alpha1.DiagnosisTaskStatus) *httptest.Server {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		created *kubemindsv1alpha1.DiagnosisTask
+		streams int
+	)
+	lookup := func(w http.ResponseWriter, r *http.Request) *kubemindsv1alpha1.DiagnosisTask {
+		mu.Lock()
+		defer mu.Unlock()
+		if created == nil || r.PathValue("name") != created.Name || r.PathValue("namespace") != created.Namespace {
+			http.Error(w, "task not found", http.StatusNotFound)
+			return nil
+		}
+		task := *created
+		return &task
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		var task kubemindsv1alpha1.DiagnosisTask
+		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		task.Name = "manual-1"
+		task.Status.Phase = kubemindsv1alpha1.PhasePending
+		mu.Lock()
+		created = &task
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(task)
+	})
+	mux.HandleFunc("GET /api/v1/tasks/{namespace}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		task := lookup(w, r)
+		if task == nil {
+			return
+		}
+		task.Status = statuses[len(statuses)-1]
+		_ = json.NewEncoder(w).Encode(task)
+	})
+	mux.HandleFunc("GET /api/v1/tasks/{namespace}/{name}/stream", func(w http.ResponseWriter, r *http.Request) {
+		if lookup(w, r) == nil {
+			return
+		}
+		mu.Lock()
+		streams++
+		sent := statuses
+		if streams == 1 {
+			sent = statuses[:1]
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		steps, phase := 0, kubemindsv1alpha1.DiagnosisPhase("")
+		for _, status := range sent {
+			for ; steps < len(status.Events); steps++ {
+				data, _ := json.Marshal(map[string]any{"index": steps, "event": status.Events[steps]})
+				fmt.Fprintf(w, "event: step\ndata: %s\n\n", data)
+			}
+			if status.Phase != phase {
+				phase = status.Phase
+				fmt.Fprintf(w, "event: phase\ndata: %q\n\n", phase)
+			}
+		}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDiagnose_StreamsEventsUntilTerminal(t *testing.T) {
+	think := kubemindsv1alpha1.HistoryEvent{Step: 1, Phase: kubemindsv1alpha1.HistoryEventThink, Content: "checking logs"}
+	act := kubemindsv1alpha1.HistoryEvent{Step: 1, Phase: kubemindsv1alpha1.HistoryEventAct, ToolName: "get_pod_logs", Content: "OOMKilled"}
+	conclude := kubemindsv1alpha1.HistoryEvent{Step: 2, Phase: kubemindsv1alpha1.HistoryEventConclude, Content: "memory limit too low"}
+
+	srv := fakeAPIServer(t, "s3cret", []kubemindsv1alpha1.DiagnosisTaskStatus{
+		{Phase: kubemindsv1alpha1.PhaseRunning, Events: []kubemindsv1alpha1.HistoryEvent{think}},
+		{Phase: kubemindsv1alpha1.PhaseRunning, Events: []kubemindsv1alpha1.HistoryEvent{think, act}},
+		{
+			Phase:  kubemindsv1alpha1.PhaseCompleted,
+			Events: []kubemindsv1alpha1.HistoryEvent{think, act, conclude},
+			Report: &kubemindsv1alpha1.DiagnosisReport{RootCause: "OOM", Suggestion: "raise memory limit"},
+		},
+	})
+
+	client := NewClient(srv.URL, srv.Client()).WithRetryInterval(time.Millisecond).WithToken("s3cret")
+	task := &kubemindsv1alpha1.DiagnosisTask{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+			Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Pod", Name: "nginx-abc", Namespace: "default"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	final, err := diagnose(ctx, client, task, &out)
+	if err != nil {
+		t.Fatalf("diagnose: %v", err)
+	}
+	if final.Status.Phase != kubemindsv1alpha1.PhaseCompleted {
+		t.Errorf("final phase = %q, want Completed", final.Status.Phase)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"created diagnosistask default/manual-1",
+		"phase: Running",
+		"[step 1] Think: checking logs",
+		"[step 1] Act get_pod_logs: OOMKilled",
+		"[step 2] Conclude: memory limit too low",
+		"phase: Completed",
+		"Root cause: OOM",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	// Each event is printed exactly once even though the reopened stream replays it.
+	if n := strings.Count(got, "checking logs"); n != 1 {
+		t.Errorf("think event printed %d times, want 1", n)
+	}
+	if n := strings.Count(got, "phase: Running"); n != 1 {
+		t.Errorf("Running phase printed %d times, want 1", n)
+	}
+}
+
+func TestClient_GetTask_NotFound(t *testing.T) {
+	srv := fakeAPIServer(t, "", []kubemindsv1alpha1.DiagnosisTaskStatus{{}})
+	client := NewClient(srv.URL, srv.Client())
+
+	_, err := client.GetTask(context.Background(), "default", "missing")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("GetTask error = %v, want 404", err)
+	}
+}
+
+func TestClient_SendsBearerToken(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(kubemindsv1alpha1.DiagnosisTask{})
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL, srv.Client()).WithToken("s3cret").GetTask(context.Background(), "default", "app"); err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if _, err := NewClient(srv.URL, srv.Client()).GetTask(context.Background(), "default", "app"); err != nil {
+		t.Fatalf("GetTask without token: %v", err)
+	}
+	if len(got) != 2 || got[0] != "Bearer s3cret" || got[1] != "" {
+		t.Errorf("Authorization headers = %q, want [\"Bearer s3cret\" \"\"]", got)
+	}
+}
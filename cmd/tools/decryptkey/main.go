@@ -0,0 +1,43 @@
+// decryptkey is a CLI helper that decrypts an enc:aes256:... value produced by encryptkey.
+//
+// Usage:
+//
+//	export KUBEMINDS_MASTER_KEY=<64-hex-chars>         # the key the value was encrypted with
+//	make decrypt-key VALUE=enc:aes256:...              # prints the plaintext
+//
+// Use it to check which master key an encrypted config.yaml value belongs to,
+// or to recover a value before rotating the master key.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"kubeminds/internal/crypto"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: decryptkey <enc:aes256:...>")
+		fmt.Fprintln(os.Stderr, "       KUBEMINDS_MASTER_KEY (64 hex chars) or KUBEMINDS_MASTER_KEY_FILE must be set")
+		os.Exit(1)
+	}
+
+	plaintext, err := decrypt(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(plaintext)
+}
+
+// decrypt returns the plaintext of an encrypted config value using the master key
+// from the environment. Unlike crypto.DecryptValue it rejects plaintext input, so
+// a value pasted without its prefix is reported instead of echoed back.
+func decrypt(value string) (string, error) {
+	if !crypto.IsEncrypted(value) {
+		return "", fmt.Errorf("value is not encrypted: expected the enc:aes256: prefix")
+	}
+	return crypto.DecryptValue(value)
+}
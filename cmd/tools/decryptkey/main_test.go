@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"kubeminds/internal/crypto"
+)
+
+// setMasterKey sets a random master key for the test and returns it.
+func setMasterKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read(): %v", err)
+	}
+	t.Setenv(crypto.MasterKeyEnv, hex.EncodeToString(key))
+	t.Setenv(crypto.MasterKeyFileEnv, "")
+	return key
+}
+
+func TestDecrypt_RoundTrip(t *testing.T) {
+	setMasterKey(t)
+	// Same calls as encryptkey.
+	key, err := crypto.MasterKey()
+	if err != nil {
+		t.Fatalf("MasterKey(): %v", err)
+	}
+	encrypted, err := crypto.Encrypt(key, "sk-test-123")
+	if err != nil {
+		t.Fatalf("Encrypt(): %v", err)
+	}
+
+	got, err := decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("decrypt(): %v", err)
+	}
+	if got != "sk-test-123" {
+		t.Errorf("decrypt() = %q, want %q", got, "sk-test-123")
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	key := setMasterKey(t)
+	encrypted, err := crypto.Encrypt(key, "sk-test-123")
+	if err != nil {
+		t.Fatalf("Encrypt(): %v", err)
+	}
+
+	if _, err := decrypt("sk-test-123"); err == nil || !strings.Contains(err.Error(), "not encrypted") {
+		t.Errorf("decrypt(plaintext) error = %v, want a not encrypted error", err)
+	}
+
+	setMasterKey(t)
+	if _, err := decrypt(encrypted); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Errorf("decrypt() with another key error = %v, want a wrong key error", err)
+	}
+
+	t.Setenv(crypto.MasterKeyEnv, "")
+	if _, err := decrypt(encrypted); err == nil || !strings.Contains(err.Error(), crypto.MasterKeyEnv) {
+		t.Errorf("decrypt() without a key error = %v, want it to name %s", err, crypto.MasterKeyEnv)
+	}
+}
@@ -19,14 +19,14 @@ import (
 func main() {
 	if len(os.Args) < 2 || os.Args[1] == "" {
 		fmt.Fprintln(os.Stderr, "Usage: encryptkey <plaintext-api-key>")
-		fmt.Fprintln(os.Stderr, "       KUBEMINDS_MASTER_KEY must be set (64 hex chars)")
+		fmt.Fprintln(os.Stderr, "       KUBEMINDS_MASTER_KEY (64 hex chars) or KUBEMINDS_MASTER_KEY_FILE must be set")
 		os.Exit(1)
 	}
 
 	plaintext := os.Args[1]
 
-	// Read the master key from the environment variable.
-	key, err := crypto.MasterKeyFromEnv()
+	// Read the master key from the environment variable or the key file.
+	key, err := crypto.MasterKey()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		fmt.Fprintln(os.Stderr, "Generate a master key with: openssl rand -hex 32")
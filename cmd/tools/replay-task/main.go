@@ -0,0 +1,85 @@
+// replay-task re-runs a recorded diagnosis conversation against a chosen model to
+// compare its outputs with the original run.
+//
+// Usage:
+//
+//	go run ./cmd/tools/replay-task --task default/oom-task [--config config.yaml] [--provider openai] [--model gpt-4o-mini]
+//	go run ./cmd/tools/replay-task --transcript task.json [--config config.yaml] ...
+//
+// --task loads the transcript the controller saved for the task's latest run under
+// agent.transcriptDir; --transcript reads a JSON file of the same format, with the
+// offered tools and the full message sequence ({"task": ..., "tools": [...],
+// "messages": [...]}). Each original assistant turn is replaced by a live call to the
+// provider; tools are never executed, the recorded tool outputs are replayed instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"kubeminds/internal/agent"
+	"kubeminds/internal/config"
+	"kubeminds/internal/llm"
+)
+
+func main() {
+	transcriptPath := flag.String("transcript", "", "path to a recorded transcript JSON")
+	task := flag.String("task", "", "namespace/name of a task whose stored transcript to replay")
+	configPath := flag.String("config", "cmd/config/config.yaml", "config file with llm.providers and agent.transcriptDir")
+	provider := flag.String("provider", "", "llm.providers entry to replay against (default: llm.defaultProvider)")
+	model := flag.String("model", "", "model override for the provider")
+	flag.Parse()
+
+	if (*transcriptPath == "") == (*task == "") {
+		fmt.Fprintln(os.Stderr, "Usage: replay-task (--task <namespace/name> | --transcript <file>) [--config <file>] [--provider <name>] [--model <model>]")
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	t, err := loadTranscript(context.Background(), *transcriptPath, *task, cfg.Agent.TranscriptDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	router, err := llm.NewModelRouterFromConfig(cfg.LLM, *provider, *model)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if t.Task != "" {
+		fmt.Printf("Replaying task %s\n", t.Task)
+	}
+	steps, err := replay(context.Background(), router, t, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Replay failed after %d step(s): %v\n", steps, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Replayed %d step(s)\n", steps)
+}
+
+// loadTranscript reads the transcript at path, or else the stored transcript of task
+// ("namespace/name") from transcriptDir.
+func loadTranscript(ctx context.Context, path, task, transcriptDir string) (*agent.Transcript, error) {
+	if path != "" {
+		return agent.ReadTranscript(path)
+	}
+	if task == "" {
+		return nil, fmt.Errorf("either a transcript file or a task is required")
+	}
+	namespace, name, ok := strings.Cut(task, "/")
+	if !ok {
+		return nil, fmt.Errorf("task %q must be namespace/name", task)
+	}
+	if transcriptDir == "" {
+		return nil, fmt.Errorf("agent.transcriptDir is not set; the controller records no transcripts")
+	}
+	return agent.NewFileTranscriptStore(transcriptDir).Load(ctx, namespace, name)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"kubeminds/internal/agent"
+)
+
+// replayTool presents a recorded tool to the provider. Replays never execute tools.
+type replayTool struct{ spec agent.TranscriptTool }
+
+func (t replayTool) Name() string        { return t.spec.Name }
+func (t replayTool) Description() string { return t.spec.Description }
+func (t replayTool) Schema() string {
+	if t.spec.Schema == "" {
+		return `{"type":"object","properties":{}}`
+	}
+	return t.spec.Schema
+}
+func (t replayTool) SafetyLevel() agent.SafetyLevel { return agent.SafetyLevelReadOnly }
+func (t replayTool) Execute(context.Context, string) (string, error) {
+	return "", fmt.Errorf("tool %s is not executed during replay", t.spec.Name)
+}
+
+// replay re-sends every request of the recorded conversation to provider. Each
+// original assistant turn is stripped from the request it answered: the messages
+// before it are sent as-is and the new response is printed next to the original.
+// Later requests keep the recorded turns so tool outputs still match their calls.
+// It returns the number of requests replayed.
+func replay(ctx context.Context, provider agent.LLMProvider, t *agent.Transcript, out io.Writer) (int, error) {
+	tools := make([]agent.Tool, len(t.Tools))
+	for i, spec := range t.Tools {
+		tools[i] = replayTool{spec: spec}
+	}
+
+	steps := 0
+	for i, msg := range t.Messages {
+		if msg.Type != agent.MessageTypeAssistant {
+			continue
+		}
+		steps++
+		resp, err := provider.Chat(ctx, t.Messages[:i], tools)
+		if err != nil {
+			return steps - 1, fmt.Errorf("step %d: %w", steps, err)
+		}
+		fmt.Fprintf(out, "=== step %d ===\n", steps)
+		fmt.Fprintf(out, "--- original ---\n%s\n", formatMessage(msg))
+		fmt.Fprintf(out, "--- replayed ---\n%s\n", formatMessage(*resp))
+	}
+	return steps, nil
+}
+
+// formatMessage renders an assistant message's text and tool calls for display.
+func formatMessage(msg agent.Message) string {
+	s := msg.Content
+	for _, tc := range msg.ToolCalls {
+		if s != "" {
+			s += "\n"
+		}
+		s += fmt.Sprintf("-> %s(%s)", tc.Function.Name, tc.Function.Arguments)
+	}
+	if s == "" {
+		s = "(empty)"
+	}
+	return s
+}
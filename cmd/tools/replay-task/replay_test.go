@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubeminds/internal/agent"
+)
+
+// recordingProvider returns canned responses and records each request's messages.
+type recordingProvider struct {
+	responses []string
+	requests  [][]agent.Message
+	tools     [][]agent.Tool
+}
+
+func (p *recordingProvider) Chat(_ context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	p.requests = append(p.requests, messages)
+	p.tools = append(p.tools, tools)
+	return &agent.Message{Type: agent.MessageTypeAssistant, Content: p.responses[len(p.requests)-1]}, nil
+}
+
+const twoStepTranscript = `{
+  "task": "default/oom-task",
+  "tools": [{"name": "get_pod_logs", "description": "Retrieve pod logs", "schema": "{\"type\":\"object\"}"}],
+  "messages": [
+    {"Type": "system", "Content": "You are a Kubernetes expert."},
+    {"Type": "user", "Content": "Diagnosis Goal: diagnose app-1"},
+    {"Type": "assistant", "ToolCalls": [{"ID": "call-1", "Function": {"Name": "get_pod_logs", "Arguments": "{\"pod\":\"app-1\"}"}}]},
+    {"Type": "tool", "ToolCallID": "call-1", "Content": "out of memory"},
+    {"Type": "assistant", "Content": "Root Cause: OOM\nSuggestion: raise limit"}
+  ]
+}`
+
+func TestReplay_TwoStepTranscript(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "default"), 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "default", "oom-task.json"), []byte(twoStepTranscript), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	tr, err := loadTranscript(context.Background(), "", "default/oom-task", dir)
+	if err != nil {
+		t.Fatalf("loadTranscript() error = %v", err)
+	}
+
+	p := &recordingProvider{responses: []string{"new step one", "Root Cause: memory leak"}}
+	var out bytes.Buffer
+	steps, err := replay(context.Background(), p, tr, &out)
+	if err != nil {
+		t.Fatalf("replay() error = %v", err)
+	}
+	if steps != 2 || len(p.requests) != 2 {
+		t.Fatalf("steps = %d, requests = %d; want 2 each", steps, len(p.requests))
+	}
+
+	// Each request ends right before the assistant turn it replaces.
+	if got := len(p.requests[0]); got != 2 {
+		t.Errorf("first request has %d messages, want system+user", got)
+	}
+	if got := len(p.requests[1]); got != 4 {
+		t.Errorf("second request has %d messages, want 4", got)
+	}
+	if last := p.requests[1][3]; last.Type != agent.MessageTypeTool || last.Content != "out of memory" {
+		t.Errorf("second request ends with %+v, want the recorded tool output", last)
+	}
+	if len(p.tools[0]) != 1 || p.tools[0][0].Name() != "get_pod_logs" {
+		t.Errorf("tools = %v, want the recorded get_pod_logs", p.tools[0])
+	}
+
+	for _, want := range []string{
+		"=== step 1 ===", `-> get_pod_logs({"pod":"app-1"})`, "new step one",
+		"=== step 2 ===", "Root Cause: OOM", "Root Cause: memory leak",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadTranscript_Errors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := loadTranscript(ctx, "", "", t.TempDir()); err == nil {
+		t.Error("loadTranscript() without --transcript or --task error = nil, want error")
+	}
+	if _, err := loadTranscript(ctx, "", "oom-task", t.TempDir()); err == nil {
+		t.Error("loadTranscript() with a task without namespace error = nil, want error")
+	}
+	if _, err := loadTranscript(ctx, "", "default/oom-task", ""); err == nil {
+		t.Error("loadTranscript() without agent.transcriptDir error = nil, want error")
+	}
+}
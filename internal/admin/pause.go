@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// DefaultPauseConfigMapName is the ConfigMap used to persist the pause flag.
+const DefaultPauseConfigMapName = "kubeminds-state"
+
+// pausedKey is the ConfigMap data key holding the flag ("true"/"false").
+const pausedKey = "paused"
+
+// PauseStore persists the pause flag so it survives restarts.
+type PauseStore interface {
+	LoadPaused(ctx context.Context) (bool, error)
+	SavePaused(ctx context.Context, paused bool) error
+}
+
+// PauseSwitch is the global kill switch for automated diagnosis.
+// While paused, the alert aggregator stops creating DiagnosisTasks and the
+// controller holds tasks without starting agents.
+//
+// A nil *PauseSwitch is valid and never paused, so callers can leave it unset.
+type PauseSwitch struct {
+	paused atomic.Bool
+	mu     sync.Mutex // serializes Pause/Resume so the store matches the in-memory flag
+	store  PauseStore // optional; nil keeps the flag in memory only
+}
+
+// NewPauseSwitch creates an unpaused switch backed by store (may be nil).
+func NewPauseSwitch(store PauseStore) *PauseSwitch {
+	return &PauseSwitch{store: store}
+}
+
+// Paused reports whether automated diagnosis is currently paused.
+func (p *PauseSwitch) Paused() bool {
+	return p != nil && p.paused.Load()
+}
+
+// Restore loads the persisted flag. Call once at startup.
+func (p *PauseSwitch) Restore(ctx context.Context) error {
+	if p.store == nil {
+		return nil
+	}
+	paused, err := p.store.LoadPaused(ctx)
+	if err != nil {
+		return fmt.Errorf("restore pause state: %w", err)
+	}
+	p.paused.Store(paused)
+	return nil
+}
+
+// Pause stops automated diagnosis and persists the flag.
+func (p *PauseSwitch) Pause(ctx context.Context) error {
+	return p.set(ctx, true)
+}
+
+// Resume re-enables automated diagnosis and persists the flag.
+func (p *PauseSwitch) Resume(ctx context.Context) error {
+	return p.set(ctx, false)
+}
+
+func (p *PauseSwitch) set(ctx context.Context, paused bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.store != nil {
+		if err := p.store.SavePaused(ctx, paused); err != nil {
+			return fmt.Errorf("persist pause state: %w", err)
+		}
+	}
+	p.paused.Store(paused)
+	return nil
+}
+
+// ConfigMapPauseStore persists the pause flag in a ConfigMap.
+type ConfigMapPauseStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapPauseStore creates a store backed by ConfigMap namespace/name.
+// The ConfigMap is created on first save if it does not exist.
+func NewConfigMapPauseStore(client kubernetes.Interface, namespace, name string) *ConfigMapPauseStore {
+	if name == "" {
+		name = DefaultPauseConfigMapName
+	}
+	return &ConfigMapPauseStore{client: client, namespace: namespace, name: name}
+}
+
+// LoadPaused returns the persisted flag, or false when the ConfigMap does not exist.
+func (s *ConfigMapPauseStore) LoadPaused(ctx context.Context) (bool, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get configmap %s/%s: %w", s.namespace, s.name, err)
+	}
+	v, ok := cm.Data[pausedKey]
+	if !ok {
+		return false, nil
+	}
+	paused, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %q value %q in configmap %s/%s: %w", pausedKey, v, s.namespace, s.name, err)
+	}
+	return paused, nil
+}
+
+// SavePaused writes the flag, creating the ConfigMap if needed.
+func (s *ConfigMapPauseStore) SavePaused(ctx context.Context, paused bool) error {
+	cms := s.client.CoreV1().ConfigMaps(s.namespace)
+	value := strconv.FormatBool(paused)
+
+	cm, err := cms.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{pausedKey: value},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create configmap %s/%s: %w", s.namespace, s.name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get configmap %s/%s: %w", s.namespace, s.name, err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[pausedKey] = value
+	if _, err := cms.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update configmap %s/%s: %w", s.namespace, s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPauseSwitch_NilIsNeverPaused(t *testing.T) {
+	var p *PauseSwitch
+	if p.Paused() {
+		t.Error("nil PauseSwitch should not be paused")
+	}
+}
+
+func TestPauseSwitch_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	p := NewPauseSwitch(NewConfigMapPauseStore(client, "kubeminds", ""))
+	if err := p.Restore(ctx); err != nil {
+		t.Fatalf("Restore() with no configmap: %v", err)
+	}
+	if p.Paused() {
+		t.Fatal("expected unpaused when no state is persisted")
+	}
+
+	if err := p.Pause(ctx); err != nil {
+		t.Fatalf("Pause(): %v", err)
+	}
+	if !p.Paused() {
+		t.Fatal("expected paused after Pause()")
+	}
+
+	// Simulate a restart: a fresh switch restores from the same ConfigMap.
+	restarted := NewPauseSwitch(NewConfigMapPauseStore(client, "kubeminds", ""))
+	if err := restarted.Restore(ctx); err != nil {
+		t.Fatalf("Restore(): %v", err)
+	}
+	if !restarted.Paused() {
+		t.Fatal("expected paused state to survive restart")
+	}
+
+	if err := restarted.Resume(ctx); err != nil {
+		t.Fatalf("Resume(): %v", err)
+	}
+	again := NewPauseSwitch(NewConfigMapPauseStore(client, "kubeminds", ""))
+	if err := again.Restore(ctx); err != nil {
+		t.Fatalf("Restore(): %v", err)
+	}
+	if again.Paused() {
+		t.Fatal("expected resumed state to survive restart")
+	}
+}
@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// alertAnnotationMaxLen truncates each annotation value injected into the context.
+const alertAnnotationMaxLen = 1000
+
+// leadingAlertAnnotations are listed first, in this order; other annotations follow
+// sorted by key.
+var leadingAlertAnnotations = []string{"summary", "description", "runbook_url"}
+
+// FormatAlertAnnotations formats the annotations of the alert that triggered a
+// diagnosis (its human-written summary, description, runbook link, ...) as a
+// human-readable string suitable for injection into the agent's LLM context.
+func FormatAlertAnnotations(annotations map[string]string) string {
+	keys := make([]string, 0, len(annotations))
+	for k, v := range annotations {
+		if strings.TrimSpace(v) != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		ra, rb := annotationRank(a), annotationRank(b)
+		if ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(a, b)
+	})
+
+	var b strings.Builder
+	b.WriteString("Annotations of the triggering alert (written by its authors):\n")
+	for _, k := range keys {
+		v := strings.TrimSpace(annotations[k])
+		if len(v) > alertAnnotationMaxLen {
+			v = v[:alertAnnotationMaxLen] + "..."
+		}
+		b.WriteString(fmt.Sprintf("  - %s: %s\n", k, v))
+	}
+	return b.String()
+}
+
+// annotationRank orders leadingAlertAnnotations before all other keys.
+func annotationRank(key string) int {
+	if i := slices.Index(leadingAlertAnnotations, key); i >= 0 {
+		return i
+	}
+	return len(leadingAlertAnnotations)
+}
@@ -0,0 +1,14 @@
+package agent
+
+import "time"
+
+// Clock abstracts waiting so tests can observe step pacing without real delays.
+type Clock interface {
+	// After returns a channel that receives once d has elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
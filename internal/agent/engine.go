@@ -2,23 +2,108 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"kubeminds/api/v1alpha1"
 )
 
+const (
+	// DefaultSummaryMaxLen is the default length at which tool output summaries are truncated.
+	DefaultSummaryMaxLen = 200
+	// DefaultThoughtMaxLen is the default length at which Think history entries are truncated.
+	DefaultThoughtMaxLen = 500
+	// DefaultLoopDetectionWindow is the default number of consecutive identical tool
+	// calls treated as a loop.
+	DefaultLoopDetectionWindow = 3
+	// DefaultKeepRecentTurns is how many of the latest exchanges history compaction
+	// keeps verbatim when no other value is given.
+	DefaultKeepRecentTurns = 4
+	// DefaultToolTimeout is the default time limit for a single tool call.
+	DefaultToolTimeout = 30 * time.Second
+	// maxParallelToolCalls bounds how many read-only tool calls of one step run at once.
+	maxParallelToolCalls = 4
+)
+
 // BaseAgent implements the Agent interface
 type BaseAgent struct {
 	llm            LLMProvider
 	tools          []Tool
+	allTools       []Tool
 	memory         Memory
 	maxSteps       int
 	logger         *slog.Logger
 	onStepComplete func(*v1alpha1.Finding, string)
+	onEvent        func(v1alpha1.HistoryEvent)
 	skill          Skill
+	summaryMaxLen  int
+	thoughtMaxLen  int
+
+	// minWriteConfidence is the self-reported confidence (0-1) required before a
+	// high-risk tool may run. Zero disables the check.
+	minWriteConfidence float64
+
+	// inconclusiveBelow marks a conclusion inconclusive when its confidence is lower.
+	// Zero disables the check.
+	inconclusiveBelow float64
+
+	// minReadSteps is how many steps must gather read-only evidence before any write
+	// tool may run; readSteps counts those steps so far. Zero disables the check.
+	minReadSteps int
+	readSteps    int
+
+	// hasEvidence records that a read-only tool has run successfully, in this run
+	// or before a checkpoint restore (see Skill.RequireEvidence).
+	hasEvidence bool
+
+	// usage accumulates the token usage reported by every LLM call of this agent.
+	usage TokenUsage
+
+	// streamInterval throttles partial Think updates when the LLM streams.
+	streamInterval time.Duration
+
+	// approvedPlan is a decided remediation plan replayed at the start of Run.
+	approvedPlan []v1alpha1.PlannedAction
+
+	// stepDelay is the pause between two steps, spreading LLM calls out over time.
+	stepDelay time.Duration
+	clock     Clock
+
+	// historyTokenBudget triggers history compaction before a Chat call once the
+	// history exceeds it (approximate tokens); keepRecentTurns exchanges stay verbatim.
+	historyTokenBudget int
+	keepRecentTurns    int
+
+	// loopWindow is how many consecutive identical tool calls abort the run; 0 disables.
+	loopWindow int
+
+	// toolTimeout limits each tool call, so one hung tool cannot use up the whole run.
+	toolTimeout time.Duration
+
+	// autoGrant names the read-only tools granted without approval when requested
+	// through request_tool (see WithToolRequests).
+	autoGrant map[string]bool
+
+	// granted names the tools outside the skill granted through request_tool, in
+	// this run or a previous one (see WithGrantedTools).
+	granted []string
+
+	// findingSteps are the steps that produced a finding, in this run or restored
+	// from a checkpoint; the conclusion may cite them as supporting findings.
+	findingSteps map[int]bool
+
+	// stepOffset is the highest step restored from a checkpoint. This run numbers its
+	// steps after it, so checkpointed findings and supporting findings stay unambiguous.
+	stepOffset int
 }
 
 // NewAgent creates a new BaseAgent
@@ -27,7 +112,37 @@ func NewAgent(llm LLMProvider, tools []Tool, maxSteps int, logger *slog.Logger,
 		logger = slog.Default()
 	}
 
-	// Filter tools based on skill
+	agent := &BaseAgent{
+		llm:            llm,
+		tools:          ToolsForSkill(tools, skill),
+		allTools:       tools,
+		memory:         NewL1Memory(0, 0),
+		maxSteps:       maxSteps,
+		logger:         logger,
+		onStepComplete: onStepComplete,
+		skill:          skill,
+		summaryMaxLen:  DefaultSummaryMaxLen,
+		thoughtMaxLen:  DefaultThoughtMaxLen,
+		streamInterval: DefaultStreamInterval,
+		clock:          realClock{},
+		toolTimeout:    DefaultToolTimeout,
+		loopWindow:     DefaultLoopDetectionWindow,
+	}
+	if skill.LoopDetectionWindow != nil {
+		agent.loopWindow = *skill.LoopDetectionWindow
+	}
+
+	// Inject Skill System Prompt
+	if skill.SystemPrompt != "" {
+		agent.memory.AddUserMessage(fmt.Sprintf("SYSTEM INSTRUCTION: %s", skill.SystemPrompt))
+	}
+
+	return agent
+}
+
+// ToolsForSkill returns the subset of tools an agent running skill is offered:
+// the skill's allowed tools (all when unset), and only read-only ones in advise mode.
+func ToolsForSkill(tools []Tool, skill Skill) []Tool {
 	var availableTools []Tool
 	if len(skill.AllowedTools) == 0 {
 		// All tools allowed
@@ -44,57 +159,227 @@ func NewAgent(llm LLMProvider, tools []Tool, maxSteps int, logger *slog.Logger,
 		}
 	}
 
-	agent := &BaseAgent{
-		llm:            llm,
-		tools:          availableTools,
-		memory:         NewL1Memory(),
-		maxSteps:       maxSteps,
-		logger:         logger,
-		onStepComplete: onStepComplete,
-		skill:          skill,
+	// Advise mode: the agent never acts on the cluster, so only offer read-only tools
+	if skill.Mode == SkillModeAdvise {
+		readOnly := make([]Tool, 0, len(availableTools))
+		for _, tool := range availableTools {
+			if tool.SafetyLevel() == SafetyLevelReadOnly {
+				readOnly = append(readOnly, tool)
+			}
+		}
+		availableTools = readOnly
 	}
+	return availableTools
+}
 
-	// Inject Skill System Prompt
-	if skill.SystemPrompt != "" {
-		agent.memory.AddUserMessage(fmt.Sprintf("SYSTEM INSTRUCTION: %s", skill.SystemPrompt))
+// WithEventHandler registers a listener that receives a typed HistoryEvent for every
+// Think, Act and Conclude step, alongside the free-form onStepComplete history entry.
+func (a *BaseAgent) WithEventHandler(onEvent func(v1alpha1.HistoryEvent)) *BaseAgent {
+	a.onEvent = onEvent
+	return a
+}
+
+// WithSummaryLimits sets the truncation lengths for tool output summaries (Finding.Summary)
+// and Think history entries. Values <= 0 keep the defaults.
+func (a *BaseAgent) WithSummaryLimits(summaryMaxLen, thoughtMaxLen int) *BaseAgent {
+	if summaryMaxLen > 0 {
+		a.summaryMaxLen = summaryMaxLen
+	}
+	if thoughtMaxLen > 0 {
+		a.thoughtMaxLen = thoughtMaxLen
 	}
+	return a
+}
 
-	return agent
+// WithMemoryLimits caps the agent's conversation history at maxMessages messages
+// and maxBytes bytes, evicting the oldest tool exchanges first (see L1Memory.SetLimits).
+// Zero leaves a dimension unbounded (default).
+func (a *BaseAgent) WithMemoryLimits(maxMessages, maxBytes int) *BaseAgent {
+	if m, ok := a.memory.(*L1Memory); ok {
+		m.SetLimits(maxMessages, maxBytes)
+	}
+	return a
+}
+
+// WithMinWriteConfidence requires the agent to report a confidence of at least min
+// (0-1, via a "Confidence: <value>" line) before any high-risk tool is executed.
+// Below the threshold the call is refused and the agent is told to gather more evidence.
+// Zero (default) disables the check.
+func (a *BaseAgent) WithMinWriteConfidence(min float64) *BaseAgent {
+	a.minWriteConfidence = min
+	return a
+}
+
+// WithInconclusiveBelow marks a conclusion whose reported confidence (0-1) is below
+// threshold as inconclusive (see Result.Inconclusive). Conclusions without a
+// confidence are judged by their content only. Zero (default) disables the check.
+func (a *BaseAgent) WithInconclusiveBelow(threshold float64) *BaseAgent {
+	a.inconclusiveBelow = threshold
+	return a
+}
+
+// WithMinReadSteps refuses write (low- or high-risk) tools until at least n steps have
+// run a read-only tool successfully, so the agent investigates before it changes
+// anything. A refused call is reported back to the LLM. Zero (default) disables it.
+func (a *BaseAgent) WithMinReadSteps(n int) *BaseAgent {
+	a.minReadSteps = n
+	return a
+}
+
+// WithHistoryCompaction collapses the oldest exchanges into one summary message
+// before a Chat call whenever the history exceeds maxTokens (approximated from its
+// size), so long diagnoses stay within the model's context window. The goal and
+// the keepRecentTurns latest exchanges are kept verbatim; zero keepRecentTurns uses
+// DefaultKeepRecentTurns. Zero maxTokens (default) disables compaction.
+func (a *BaseAgent) WithHistoryCompaction(maxTokens, keepRecentTurns int) *BaseAgent {
+	if keepRecentTurns <= 0 {
+		keepRecentTurns = DefaultKeepRecentTurns
+	}
+	a.historyTokenBudget = maxTokens
+	a.keepRecentTurns = keepRecentTurns
+	return a
+}
+
+// WithStreamInterval sets the minimum time between partial Think updates forwarded
+// while a streaming LLM generates its response. Zero forwards every chunk.
+func (a *BaseAgent) WithStreamInterval(d time.Duration) *BaseAgent {
+	a.streamInterval = d
+	return a
 }
 
-// Run executes the agent loop for a given goal
+// WithStepDelay pauses for d between two consecutive steps, so a burst of diagnoses
+// does not spike the LLM provider's request rate. The pause ends early when the
+// run's context is cancelled. Zero (default) runs steps back to back.
+func (a *BaseAgent) WithStepDelay(d time.Duration) *BaseAgent {
+	a.stepDelay = d
+	return a
+}
+
+// WithToolTimeout limits each read-only tool call to d (default DefaultToolTimeout).
+// A call that runs out of time is reported to the LLM as timed out and the run
+// continues. Write tools are not limited (see executeTool). Zero disables the limit.
+func (a *BaseAgent) WithToolTimeout(d time.Duration) *BaseAgent {
+	a.toolTimeout = d
+	return a
+}
+
+// WithApprovedPlan makes Run first replay a remediation plan a human has decided on:
+// approved steps are executed in order, and rejected ones are reported to the LLM as
+// not executed. The plan comes from ErrWaitingForApproval.Plan of an earlier run.
+func (a *BaseAgent) WithApprovedPlan(plan []v1alpha1.PlannedAction) *BaseAgent {
+	a.approvedPlan = plan
+	return a
+}
+
+// WithClock replaces the clock used to wait between steps (tests only).
+func (a *BaseAgent) WithClock(c Clock) *BaseAgent {
+	a.clock = c
+	return a
+}
+
+// TokenUsage returns the tokens consumed by all LLM calls made so far, including
+// those of a Run that ended in an error or an approval request.
+func (a *BaseAgent) TokenUsage() TokenUsage {
+	return a.usage
+}
+
+// Run executes the agent loop for a given goal. When it fails after executing write
+// tools, the error comes with a Result holding only ActionsTaken, so what the run
+// changed can still be audited and rolled back.
 func (a *BaseAgent) Run(ctx context.Context, goal string, approved bool) (*Result, error) {
 	a.logger.Info("Starting agent run", "goal", goal, "skill", a.skill.Name, "approved", approved)
 
 	// Initialize memory with the goal
 	// If memory is already populated (e.g. via Restore), this appends to it.
-	a.memory.AddUserMessage(fmt.Sprintf("Diagnosis Goal: %s\n\nWhen you have enough information to conclude, respond with:\nRoot Cause: <concise root cause>\nSuggestion: <actionable remediation>", goal))
+	suggestionFormat := "actionable remediation"
+	modeNote := ""
+	if a.skill.Mode == SkillModeAdvise {
+		suggestionFormat = "a runbook of numbered steps (1., 2., ...) for a human operator, each with the exact kubectl command to run and how to verify it"
+		modeNote = "You are in advise mode: do not attempt to change the cluster. "
+	}
+	if a.skill.OutputFormat == SkillOutputFormatJSON {
+		a.memory.AddUserMessage(fmt.Sprintf("Diagnosis Goal: %s\n\n%sWhen you have enough information to conclude, respond with only a JSON object and no other text:\n{\"root_cause\": \"<concise root cause>\", \"suggestion\": \"<%s>\", \"confidence\": <your confidence in the diagnosis, 0.0-1.0>}%s%s%s%s", goal, modeNote, suggestionFormat, supportingFindingsJSONInstruction, a.jsonSchemaInstruction(), stepNumberingNote, inconclusiveInstruction))
+	} else {
+		a.memory.AddUserMessage(fmt.Sprintf("Diagnosis Goal: %s\n\n%sWhen you have enough information to conclude, respond with:\nRoot Cause: <concise root cause>\nSuggestion: <%s>%s%s%s%s", goal, modeNote, suggestionFormat, supportingFindingsInstruction, a.outputSchemaInstruction(), stepNumberingNote, inconclusiveInstruction))
+	}
+
+	if a.minWriteConfidence > 0 {
+		a.memory.AddUserMessage(fmt.Sprintf("Before calling any high-risk (write) tool, state your confidence in the diagnosis on its own line as 'Confidence: <0.0-1.0>'. High-risk tools are refused below %.2f.", a.minWriteConfidence))
+	}
+
+	if a.minReadSteps > 0 {
+		a.memory.AddUserMessage(fmt.Sprintf("Read before you write: gather evidence with read-only tools for at least %d steps before calling any tool that changes the cluster. Earlier write calls are refused.", a.minReadSteps))
+	}
+
+	// confidence is the latest self-reported confidence; -1 means none reported yet
+	confidence := -1.0
 
 	// recentFindings tracks per-step findings for loop detection
 	var recentFindings []v1alpha1.Finding
 
+	// actions records every write tool executed, for the report's audit trail
+	var actions []v1alpha1.RemediationAction
+	// failed returns err with the actions executed so far (see Run).
+	failed := func(err error) (*Result, error) {
+		if len(actions) == 0 {
+			return nil, err
+		}
+		return &Result{ActionsTaken: actions}, err
+	}
+	if len(a.approvedPlan) > 0 {
+		actions = a.executePlan(ctx)
+	}
+
 	for step := 0; step < a.maxSteps; step++ {
+		if step > 0 && a.stepDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return failed(ctx.Err())
+			case <-a.clock.After(a.stepDelay):
+			}
+		}
+
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return failed(ctx.Err())
 		default:
 		}
 
-		a.logger.Info("Executing step", "step", step+1)
+		stepNum := a.stepOffset + step + 1
+		a.logger.Info("Executing step", "step", stepNum)
+		stepStart := time.Now()
+
+		a.compactHistory()
 
 		// Think: Call LLM
-		response, err := a.llm.Chat(ctx, a.memory.GetHistory(), a.tools)
+		chatCtx := ctx
+		if step == a.maxSteps-1 {
+			chatCtx = WithFinalStep(ctx)
+		}
+		response, err := a.chat(chatCtx, stepNum)
+		if ClassOf(err) == ErrorClassContextTooLong && a.forceCompactHistory() {
+			response, err = a.chat(chatCtx, stepNum)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to chat with LLM: %w", err)
+			return failed(fmt.Errorf("failed to chat with LLM: %w", err))
+		}
+		if response.Usage != nil {
+			a.usage.Add(*response.Usage)
 		}
 
 		// Notify status update with Think (LLM thought)
-		if a.onStepComplete != nil {
-			thought := response.Content
-			if len(thought) > 500 {
-				thought = thought[:500] + "..."
-			}
-			a.onStepComplete(nil, fmt.Sprintf("Step %d (Think): %s", step+1, thought))
+		thought := response.Content
+		if len(thought) > a.thoughtMaxLen {
+			thought = thought[:a.thoughtMaxLen] + "..."
+		}
+		a.notify(nil, fmt.Sprintf("Step %d (Think): %s", stepNum, thought), v1alpha1.HistoryEvent{
+			Step:    stepNum,
+			Phase:   v1alpha1.HistoryEventThink,
+			Content: thought,
+		})
+
+		if c, ok := parseConfidence(response.Content); ok {
+			confidence = c
 		}
 
 		// Add assistant response to memory
@@ -104,23 +389,39 @@ func (a *BaseAgent) Run(ctx context.Context, goal string, approved bool) (*Resul
 			a.memory.AddAssistantMessage(response.Content)
 		}
 
+		// Evidence gate: don't accept a conclusion that no tool output supports
+		if len(response.ToolCalls) == 0 && a.needsEvidence() {
+			a.logger.Warn("Conclusion refused: no evidence gathered yet", "step", stepNum)
+			a.memory.AddUserMessage("You have not gathered any evidence yet. Call at least one read-only tool to verify your hypothesis before concluding.")
+			a.observeStep(stepStart)
+			continue
+		}
+
 		// Check if we should stop (no tool calls and has content)
 		if len(response.ToolCalls) == 0 {
 			a.logger.Info("Agent decided to finish")
-			rootCause, suggestion := a.extractRootCause(response.Content)
+			result := a.conclude(response.Content, confidence)
+			result.ActionsTaken = actions
+			rootCause, suggestion := result.RootCause, result.Suggestion
 
-			if a.onStepComplete != nil {
-				a.onStepComplete(nil, fmt.Sprintf("Step %d (Conclude): RootCause: %s | Suggestion: %s", step+1, rootCause, suggestion))
-			}
+			a.notify(nil, fmt.Sprintf("Step %d (Conclude): RootCause: %s | Suggestion: %s", stepNum, rootCause, suggestion), v1alpha1.HistoryEvent{
+				Step:    stepNum,
+				Phase:   v1alpha1.HistoryEventConclude,
+				Content: fmt.Sprintf("RootCause: %s | Suggestion: %s", rootCause, suggestion),
+			})
+			a.observeStep(stepStart)
 
-			return &Result{
-				RootCause:  rootCause,
-				Suggestion: suggestion,
-			}, nil
+			return result, nil
 		}
 
-		// Act: Execute tools
-		for _, toolCall := range response.ToolCalls {
+		// Act: Execute tools. A batch of only read-only calls runs concurrently; its
+		// outputs are still observed below in the order the LLM requested them.
+		var batch []toolResult
+		if a.isReadOnlyBatch(response.ToolCalls) {
+			batch = a.executeReadOnlyBatch(ctx, response.ToolCalls)
+		}
+		readThisStep := false
+		for i, toolCall := range response.ToolCalls {
 			a.logger.Info("Executing tool", "tool", toolCall.Function.Name)
 
 			var toolOutput string
@@ -135,7 +436,15 @@ func (a *BaseAgent) Run(ctx context.Context, goal string, approved bool) (*Resul
 				}
 			}
 
-			if selectedTool == nil {
+			if _, ok := selectedTool.(*requestTool); ok {
+				var grantErr error
+				toolOutput, grantErr = a.grantTool(toolCall.Function.Arguments)
+				if grantErr != nil {
+					return failed(grantErr)
+				}
+			} else if selectedTool == nil && a.skill.Mode == SkillModeAdvise {
+				toolOutput = fmt.Sprintf("Error: Tool %s is not available in advise mode. Only read-only tools can be used; put remediation actions in the runbook instead.", toolCall.Function.Name)
+			} else if selectedTool == nil {
 				toolOutput = fmt.Sprintf("Error: Tool %s not found", toolCall.Function.Name)
 			} else {
 				// Safety Check
@@ -148,15 +457,47 @@ func (a *BaseAgent) Run(ctx context.Context, goal string, approved bool) (*Resul
 					// For Forbidden, we probably feed it back so LLM can try something else.
 					// But for MVP let's feed it back as tool error output.
 					toolOutput = fmt.Sprintf("Error: Tool %s is forbidden by safety policy.", selectedTool.Name())
+				} else if safetyLevel != SafetyLevelReadOnly && a.readSteps < a.minReadSteps {
+					// Defense in depth for the prompt instruction: investigate before changing anything
+					a.logger.Warn("Tool blocked by read-before-write policy", "tool", selectedTool.Name(),
+						"readSteps", a.readSteps, "required", a.minReadSteps)
+					toolOutput = fmt.Sprintf("Error: Tool %s was not executed: write tools are refused until read-only tools have gathered evidence in at least %d steps (%d so far). Investigate further first.",
+						selectedTool.Name(), a.minReadSteps, a.readSteps)
+				} else if safetyLevel == SafetyLevelHighRisk && a.minWriteConfidence > 0 && confidence < a.minWriteConfidence {
+					// Don't act (or ask a human to approve acting) on a shaky hypothesis
+					a.logger.Warn("Tool blocked by confidence policy", "tool", selectedTool.Name(),
+						"confidence", confidence, "required", a.minWriteConfidence)
+					toolOutput = fmt.Sprintf("Error: Tool %s was not executed: reported confidence %s is below the required %.2f for high-risk actions. Gather more evidence with read-only tools, then state 'Confidence: <0.0-1.0>' before retrying.",
+						selectedTool.Name(), formatConfidence(confidence), a.minWriteConfidence)
 				} else if safetyLevel == SafetyLevelHighRisk && !approved {
 					// Blocking required
 					a.logger.Warn("Tool requires approval", "tool", selectedTool.Name())
 					// We must abort the run and signal the controller
-					return nil, &ErrWaitingForApproval{ToolName: selectedTool.Name()}
+					return failed(&ErrWaitingForApproval{
+						ToolName:  selectedTool.Name(),
+						Arguments: toolCall.Function.Arguments,
+						RiskLevel: safetyLevel,
+						Plan:      a.planFrom(response.ToolCalls[i:]),
+					})
 				} else {
-					toolOutput, toolErr = selectedTool.Execute(ctx, toolCall.Function.Arguments)
+					rollbackArgs := a.captureRollback(ctx, selectedTool, toolCall.Function.Arguments)
+					if batch != nil {
+						toolOutput, toolErr = batch[i].output, batch[i].err
+					} else {
+						toolOutput, toolErr = a.executeTool(ctx, selectedTool, toolCall.Function.Arguments)
+					}
 					if toolErr != nil {
 						toolOutput = fmt.Sprintf("Error executing tool: %v", toolErr)
+						// Nothing changed, so there is nothing to roll back.
+						rollbackArgs = ""
+					} else if safetyLevel == SafetyLevelReadOnly {
+						a.hasEvidence = true
+						readThisStep = true
+					}
+					if safetyLevel != SafetyLevelReadOnly {
+						action := a.remediationAction(toolCall, toolOutput)
+						action.RollbackArguments = rollbackArgs
+						actions = append(actions, action)
 					}
 				}
 			}
@@ -166,31 +507,331 @@ func (a *BaseAgent) Run(ctx context.Context, goal string, approved bool) (*Resul
 
 			// Checkpoint: Notify listener and track finding for loop detection
 			summary := toolOutput
-			if len(summary) > 200 {
-				summary = summary[:200] + "..."
+			if len(summary) > a.summaryMaxLen {
+				summary = summary[:a.summaryMaxLen] + "..."
 			}
 			finding := v1alpha1.Finding{
-				Step:      step + 1,
+				Step:      stepNum,
 				ToolName:  toolCall.Function.Name,
 				ToolArgs:  toolCall.Function.Arguments,
 				Summary:   summary,
 				Timestamp: time.Now().Format(time.RFC3339),
 			}
 			recentFindings = append(recentFindings, finding)
+			a.recordFindingStep(finding.Step)
 
-			if a.onStepComplete != nil {
-				a.onStepComplete(&finding, fmt.Sprintf("Step %d (Act): %s(%s) -> %s", step+1, toolCall.Function.Name, toolCall.Function.Arguments, summary))
-			}
+			a.notify(&finding, fmt.Sprintf("Step %d (Act): %s(%s) -> %s", stepNum, toolCall.Function.Name, toolCall.Function.Arguments, summary), v1alpha1.HistoryEvent{
+				Step:      stepNum,
+				Phase:     v1alpha1.HistoryEventAct,
+				ToolName:  toolCall.Function.Name,
+				Content:   summary,
+				Timestamp: finding.Timestamp,
+			})
+		}
+
+		if readThisStep {
+			a.readSteps++
 		}
+		a.observeStep(stepStart)
 
-		// Loop detection: abort if the same tool+args repeats 3 consecutive times
-		if a.detectLoop(recentFindings, 3) {
+		// Loop detection: abort if the same tool+args repeats loopWindow consecutive times
+		if a.loopWindow > 0 && a.detectLoop(recentFindings, a.loopWindow) {
 			last := recentFindings[len(recentFindings)-1]
-			return nil, fmt.Errorf("agent loop detected: tool %q called with identical arguments 3 consecutive times, aborting to prevent infinite token consumption", last.ToolName)
+			return failed(fmt.Errorf("%w: tool %q called with identical arguments %d consecutive times, aborting to prevent infinite token consumption", ErrLoopDetected, last.ToolName, a.loopWindow))
+		}
+	}
+
+	return failed(fmt.Errorf("%w (%d)", ErrMaxStepsExceeded, a.maxSteps))
+}
+
+// planFrom returns the high-risk calls among toolCalls, in order, as a plan for approval.
+func (a *BaseAgent) planFrom(toolCalls []ToolCall) []v1alpha1.PlannedAction {
+	var plan []v1alpha1.PlannedAction
+	for _, tc := range toolCalls {
+		tool := a.findTool(tc.Function.Name)
+		if tool == nil || tool.SafetyLevel() != SafetyLevelHighRisk {
+			continue
+		}
+		plan = append(plan, v1alpha1.PlannedAction{
+			Index:     len(plan),
+			ToolName:  tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+			RiskLevel: string(SafetyLevelHighRisk),
+		})
+	}
+	return plan
+}
+
+// executePlan replays the approved plan as the tool calls the LLM originally asked
+// for: approved steps run, rejected ones get a "not executed" result, so the LLM
+// sees the outcome of its whole plan. It returns the executed write actions.
+func (a *BaseAgent) executePlan(ctx context.Context) []v1alpha1.RemediationAction {
+	calls := make([]ToolCall, len(a.approvedPlan))
+	for i, p := range a.approvedPlan {
+		calls[i] = ToolCall{
+			ID:       fmt.Sprintf("plan_%d", p.Index),
+			Function: FunctionCall{Name: p.ToolName, Arguments: p.Arguments},
+		}
+	}
+	a.memory.AddAssistantToolCall(calls)
+
+	var actions []v1alpha1.RemediationAction
+	for i, p := range a.approvedPlan {
+		var output string
+		tool := a.findTool(p.ToolName)
+		switch {
+		case !p.Approved:
+			output = fmt.Sprintf("Not executed: the operator rejected step %d of the plan (%s). Do not retry it; suggest it as a manual step instead if still needed.", p.Index, p.ToolName)
+		case tool == nil:
+			output = fmt.Sprintf("Error: Tool %s not found", p.ToolName)
+		default:
+			a.logger.Info("Executing approved plan step", "index", p.Index, "tool", p.ToolName)
+			rollbackArgs := a.captureRollback(ctx, tool, p.Arguments)
+			var err error
+			output, err = a.executeTool(ctx, tool, p.Arguments)
+			if err != nil {
+				output = fmt.Sprintf("Error executing tool: %v", err)
+				rollbackArgs = ""
+			}
+			action := a.remediationAction(calls[i], output)
+			action.RollbackArguments = rollbackArgs
+			actions = append(actions, action)
+		}
+		a.memory.AddToolOutput(calls[i].ID, output)
+
+		summary := output
+		if len(summary) > a.summaryMaxLen {
+			summary = summary[:a.summaryMaxLen] + "..."
+		}
+		finding := v1alpha1.Finding{
+			ToolName:  p.ToolName,
+			ToolArgs:  p.Arguments,
+			Summary:   summary,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		a.notify(&finding, fmt.Sprintf("Plan step %d (Act): %s(%s) -> %s", p.Index, p.ToolName, p.Arguments, summary), v1alpha1.HistoryEvent{
+			Phase:     v1alpha1.HistoryEventAct,
+			ToolName:  p.ToolName,
+			Content:   summary,
+			Timestamp: finding.Timestamp,
+		})
+	}
+	return actions
+}
+
+// compactHistory summarizes the oldest exchanges when the history is over budget.
+func (a *BaseAgent) compactHistory() {
+	if a.historyTokenBudget <= 0 {
+		return
+	}
+	m, ok := a.memory.(*L1Memory)
+	if !ok {
+		return
+	}
+	before := m.ApproxTokens()
+	if n := m.Compact(a.historyTokenBudget, a.keepRecentTurns); n > 0 {
+		a.logger.Info("Compacted conversation history", "messages", n,
+			"tokensBefore", before, "tokensAfter", m.ApproxTokens(), "budget", a.historyTokenBudget)
+	}
+}
+
+// forceCompactHistory compacts the history down to the keepRecentTurns latest
+// exchanges regardless of its estimated size, after the LLM rejected it as longer
+// than its context window. It reports whether anything was compacted, i.e. whether
+// a retry can send a shorter conversation. Compaction must be enabled.
+func (a *BaseAgent) forceCompactHistory() bool {
+	if a.historyTokenBudget <= 0 {
+		return false
+	}
+	m, ok := a.memory.(*L1Memory)
+	if !ok {
+		return false
+	}
+	before := m.ApproxTokens()
+	n := m.Compact(1, a.keepRecentTurns)
+	if n > 0 {
+		a.logger.Warn("Context window exceeded, compacted conversation history", "messages", n,
+			"tokensBefore", before, "tokensAfter", m.ApproxTokens())
+	}
+	return n > 0
+}
+
+// toolResult is the outcome of one tool call executed ahead of observation.
+type toolResult struct {
+	output string
+	err    error
+}
+
+// isReadOnlyBatch reports whether toolCalls holds several calls that are all to known
+// read-only tools, so they can safely run concurrently. Any other tool (high-risk,
+// forbidden or unknown) keeps the batch sequential for the usual safety handling.
+func (a *BaseAgent) isReadOnlyBatch(toolCalls []ToolCall) bool {
+	if len(toolCalls) < 2 {
+		return false
+	}
+	for _, toolCall := range toolCalls {
+		t := a.findTool(toolCall.Function.Name)
+		if t == nil || t.SafetyLevel() != SafetyLevelReadOnly || t.Name() == RequestToolName {
+			return false
+		}
+	}
+	return true
+}
+
+// executeReadOnlyBatch runs read-only toolCalls concurrently, at most
+// maxParallelToolCalls at a time, and returns their results in call order.
+func (a *BaseAgent) executeReadOnlyBatch(ctx context.Context, toolCalls []ToolCall) []toolResult {
+	results := make([]toolResult, len(toolCalls))
+	var eg errgroup.Group
+	eg.SetLimit(maxParallelToolCalls)
+	for i, toolCall := range toolCalls {
+		tool := a.findTool(toolCall.Function.Name)
+		eg.Go(func() error {
+			// Tool errors are fed back to the LLM, so they must not cancel the batch.
+			output, err := a.executeTool(ctx, tool, toolCall.Function.Arguments)
+			results[i] = toolResult{output: output, err: err}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return results
+}
+
+// executeTool runs a read-only tool within the agent's per-call timeout. The call is
+// abandoned when the timeout passes even if the tool ignores its context; the
+// goroutine then finishes in the background and its result is dropped. Write tools
+// always run to completion: an abandoned mutation could still apply after being
+// reported as failed, without its rollback recorded, and the LLM might issue it again.
+func (a *BaseAgent) executeTool(ctx context.Context, tool Tool, args string) (string, error) {
+	if a.toolTimeout <= 0 || tool.SafetyLevel() != SafetyLevelReadOnly {
+		return tool.Execute(ctx, args)
+	}
+	toolCtx, cancel := context.WithTimeout(ctx, a.toolTimeout)
+	defer cancel()
+
+	done := make(chan toolResult, 1)
+	go func() {
+		output, err := tool.Execute(toolCtx, args)
+		done <- toolResult{output: output, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil && errors.Is(toolCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return "", fmt.Errorf("tool timed out after %s", a.toolTimeout)
 		}
+		return res.output, res.err
+	case <-toolCtx.Done():
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		a.logger.Warn("Tool call timed out", "tool", tool.Name(), "timeout", a.toolTimeout)
+		return "", fmt.Errorf("tool timed out after %s", a.toolTimeout)
+	}
+}
+
+// findTool returns the agent's tool with the given name, or nil.
+func (a *BaseAgent) findTool(name string) Tool {
+	for _, t := range a.tools {
+		if t.Name() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// needsEvidence reports whether the skill's evidence gate still blocks concluding.
+// The gate is skipped when the agent has no read-only tool to gather evidence with.
+func (a *BaseAgent) needsEvidence() bool {
+	if !a.skill.RequireEvidence || a.hasEvidence {
+		return false
+	}
+	for _, t := range a.tools {
+		if t.SafetyLevel() == SafetyLevelReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// captureRollback returns the arguments that undo a call to tool with args, or ""
+// when the tool is read-only, not reversible, or the current state cannot be read.
+func (a *BaseAgent) captureRollback(ctx context.Context, tool Tool, args string) string {
+	reversible, ok := tool.(ReversibleTool)
+	if !ok || tool.SafetyLevel() == SafetyLevelReadOnly {
+		return ""
+	}
+	rollbackArgs, err := reversible.RollbackArgs(ctx, args)
+	if err != nil {
+		a.logger.Warn("Failed to capture rollback state", "tool", tool.Name(), "error", err)
+		return ""
+	}
+	return rollbackArgs
+}
+
+// remediationAction describes an executed write tool call for Result.ActionsTaken.
+func (a *BaseAgent) remediationAction(toolCall ToolCall, output string) v1alpha1.RemediationAction {
+	if len(output) > a.summaryMaxLen {
+		output = output[:a.summaryMaxLen] + "..."
+	}
+	return v1alpha1.RemediationAction{
+		Tool:      toolCall.Function.Name,
+		Target:    actionTarget(toolCall.Function.Arguments),
+		Arguments: toolCall.Function.Arguments,
+		Result:    output,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// actionTarget derives "namespace/name" from write tool arguments, where the name is
+// the "name" argument or the first "<kind>_name" one (e.g. pod_name, deployment_name).
+// It returns "" when the arguments name no resource.
+func actionTarget(args string) string {
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(args), &parsed); err != nil {
+		return ""
+	}
+	name, _ := parsed["name"].(string)
+	if name == "" {
+		keys := make([]string, 0, len(parsed))
+		for k := range parsed {
+			if strings.HasSuffix(k, "_name") && k != "owner_name" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if name, _ = parsed[k].(string); name != "" {
+				break
+			}
+		}
+	}
+	if name == "" {
+		return ""
 	}
+	if ns, _ := parsed["namespace"].(string); ns != "" {
+		return ns + "/" + name
+	}
+	return name
+}
 
-	return nil, fmt.Errorf("agent exceeded maximum steps (%d)", a.maxSteps)
+// observeStep records a completed step's duration in AgentStepDuration.
+func (a *BaseAgent) observeStep(start time.Time) {
+	AgentStepDuration.WithLabelValues(a.skill.Name).Observe(time.Since(start).Seconds())
+}
+
+// notify reports a step to the registered listeners: the free-form history entry
+// (and optional checkpoint finding) to onStepComplete, and the typed event to onEvent.
+func (a *BaseAgent) notify(finding *v1alpha1.Finding, historyEntry string, event v1alpha1.HistoryEvent) {
+	if a.onStepComplete != nil {
+		a.onStepComplete(finding, historyEntry)
+	}
+	if a.onEvent != nil {
+		if event.Timestamp == "" {
+			event.Timestamp = time.Now().Format(time.RFC3339)
+		}
+		a.onEvent(event)
+	}
 }
 
 // detectLoop returns true if the last windowSize findings all called the same tool with the same args.
@@ -208,9 +849,122 @@ func (a *BaseAgent) detectLoop(findings []v1alpha1.Finding, windowSize int) bool
 	return true
 }
 
+// inconclusiveRootCause is the root cause the agent is asked to give when the evidence
+// supports none; inconclusiveInstruction asks for it.
+const (
+	inconclusiveRootCause   = "Inconclusive"
+	inconclusiveInstruction = "\nIf the evidence does not support a root cause, give \"" + inconclusiveRootCause + "\" as the root cause and state what is missing to determine it as the suggestion."
+)
+
+// conclude builds the run's Result from the final response and marks it inconclusive
+// when the agent says so or its confidence is below the inconclusive threshold.
+// lastConfidence (-1 when none) is the latest confidence reported during the run.
+func (a *BaseAgent) conclude(content string, lastConfidence float64) *Result {
+	result := a.parseConclusion(content, lastConfidence)
+	if isInconclusiveRootCause(result.RootCause) {
+		result.Inconclusive = true
+	}
+	if a.inconclusiveBelow > 0 && result.Confidence != nil && *result.Confidence < a.inconclusiveBelow {
+		result.Inconclusive = true
+	}
+	return result
+}
+
+// isInconclusiveRootCause reports whether the agent declared the diagnosis
+// inconclusive, e.g. "Inconclusive" or "Inconclusive: not enough data".
+func isInconclusiveRootCause(rootCause string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(rootCause)), strings.ToLower(inconclusiveRootCause))
+}
+
+// parseConclusion parses the final response. JSON-format skills are parsed with
+// extractStructuredResult first; the text parser is the fallback.
+func (a *BaseAgent) parseConclusion(content string, lastConfidence float64) *Result {
+	if a.skill.OutputFormat == SkillOutputFormatJSON {
+		if result, ok := a.extractStructuredResult(content); ok {
+			if result.Confidence == nil && lastConfidence >= 0 {
+				result.Confidence = &lastConfidence
+			}
+			result.SupportingFindings = a.resolveSupportingFindings(result.SupportingFindings)
+			return result
+		}
+		a.logger.Warn("Conclusion is not the requested JSON object, falling back to text parsing")
+	}
+
+	result := parseTextConclusion(content, lastConfidence)
+	result.Details = a.extractDetails(content)
+	result.SupportingFindings = a.resolveSupportingFindings(parseSupportingFindings(content))
+	return result
+}
+
+// parseTextConclusion parses a "Root Cause:" / "Suggestion:" conclusion into a
+// Result, without skill details or cited findings. A negative lastConfidence means
+// none was reported.
+func parseTextConclusion(content string, lastConfidence float64) *Result {
+	rootCause, suggestion := extractRootCause(content)
+	result := &Result{RootCause: rootCause, Suggestion: suggestion}
+	if lastConfidence >= 0 {
+		result.Confidence = &lastConfidence
+	}
+	return result
+}
+
+// structuredConclusion is the JSON object requested from JSON-format skills.
+type structuredConclusion struct {
+	RootCause  string   `json:"root_cause"`
+	Suggestion string   `json:"suggestion"`
+	Confidence *float64 `json:"confidence"`
+	// SupportingFindings is kept raw: models cite steps as numbers or strings.
+	SupportingFindings json.RawMessage `json:"supporting_findings"`
+}
+
+// extractStructuredResult parses a JSON conclusion, tolerating a markdown code
+// fence or text around the object. It reports false when no JSON object with a
+// root_cause can be parsed. Output schema fields are read from the same object.
+func (a *BaseAgent) extractStructuredResult(content string) (*Result, bool) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, false
+	}
+	raw := []byte(content[start : end+1])
+
+	var conclusion structuredConclusion
+	if err := json.Unmarshal(raw, &conclusion); err != nil || strings.TrimSpace(conclusion.RootCause) == "" {
+		return nil, false
+	}
+	result := &Result{
+		RootCause:          strings.TrimSpace(conclusion.RootCause),
+		Suggestion:         strings.TrimSpace(conclusion.Suggestion),
+		SupportingFindings: parseStepNumbers(string(conclusion.SupportingFindings)),
+	}
+	if c := conclusion.Confidence; c != nil {
+		v := *c
+		if v > 1 {
+			v /= 100
+		}
+		if v >= 0 && v <= 1 {
+			result.Confidence = &v
+		}
+	}
+
+	if len(a.skill.OutputSchema) > 0 {
+		var fields map[string]any
+		_ = json.Unmarshal(raw, &fields)
+		details := make(map[string]string)
+		for name := range a.skill.OutputSchema {
+			if v, ok := fields[name]; ok && v != nil {
+				details[name] = strings.TrimSpace(fmt.Sprint(v))
+			}
+		}
+		if len(details) > 0 {
+			result.Details = details
+		}
+	}
+	return result, true
+}
+
 // extractRootCause parses the LLM final response for "Root Cause:" and "Suggestion:" markers.
 // Falls back to using the first sentence as root cause and the full content as suggestion.
-func (a *BaseAgent) extractRootCause(content string) (rootCause, suggestion string) {
+func extractRootCause(content string) (rootCause, suggestion string) {
 	var rootCauseLines, suggestionLines []string
 	inRootCause, inSuggestion := false, false
 
@@ -227,6 +981,8 @@ func (a *BaseAgent) extractRootCause(content string) (rootCause, suggestion stri
 			if val := strings.TrimSpace(line[strings.Index(line, ":")+1:]); val != "" {
 				suggestionLines = append(suggestionLines, val)
 			}
+		case strings.HasPrefix(lower, supportingFindingsPrefix):
+			inRootCause, inSuggestion = false, false
 		case inRootCause:
 			rootCauseLines = append(rootCauseLines, line)
 		case inSuggestion:
@@ -246,6 +1002,74 @@ func (a *BaseAgent) extractRootCause(content string) (rootCause, suggestion stri
 	return strings.TrimSpace(content), strings.TrimSpace(content)
 }
 
+// extractDetails runs the skill's FindingExtractor over the final response.
+// Skills without an output schema produce no details.
+func (a *BaseAgent) extractDetails(content string) map[string]string {
+	if len(a.skill.OutputSchema) == 0 {
+		return nil
+	}
+	return findingExtractorFor(a.skill.Name)(content, a.skill.OutputSchema)
+}
+
+// outputSchemaInstruction asks for the skill's structured output fields, if any.
+func (a *BaseAgent) outputSchemaInstruction() string {
+	if len(a.skill.OutputSchema) == 0 {
+		return ""
+	}
+	return outputSchemaPrompt(a.skill.OutputSchema)
+}
+
+// jsonSchemaInstruction asks JSON-format skills for their output fields as extra
+// string properties of the conclusion object.
+func (a *BaseAgent) jsonSchemaInstruction() string {
+	if len(a.skill.OutputSchema) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(a.skill.OutputSchema))
+	for name := range a.skill.OutputSchema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("\nAlso include each of the following fields as a string property of the object:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%q: <%s>", name, a.skill.OutputSchema[name])
+	}
+	return b.String()
+}
+
+// confidencePattern matches a self-reported confidence line such as
+// "Confidence: 0.85", "confidence = 85%" or "Confidence: 0.9 (high)".
+var confidencePattern = regexp.MustCompile(`(?i)confidence\s*[:=]\s*([0-9]*\.?[0-9]+)\s*(%)?`)
+
+// parseConfidence extracts a confidence in [0, 1] from LLM output.
+// Percentages and values above 1 are treated as percent.
+func parseConfidence(content string) (float64, bool) {
+	m := confidencePattern.FindStringSubmatch(content)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[2] == "%" || v > 1 {
+		v /= 100
+	}
+	if v < 0 || v > 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+func formatConfidence(c float64) string {
+	if c < 0 {
+		return "(none reported)"
+	}
+	return strconv.FormatFloat(c, 'f', 2, 64)
+}
+
 // InjectContext adds a user message to the agent's memory before Run() is called.
 // The controller uses this to inject L2 (recent alert events) and L3 (historical
 // similar diagnoses) context retrieved from external stores.
@@ -253,6 +1077,18 @@ func (a *BaseAgent) InjectContext(msg string) {
 	a.memory.AddUserMessage(msg)
 }
 
+// restoredReadSteps counts the distinct steps among checkpointed findings that ran a
+// read-only tool, so a resumed agent keeps credit for the investigation it already did.
+func restoredReadSteps(findings []v1alpha1.Finding, findTool func(string) Tool) int {
+	steps := make(map[int]bool)
+	for _, f := range findings {
+		if t := findTool(f.ToolName); t != nil && t.SafetyLevel() == SafetyLevelReadOnly {
+			steps[f.Step] = true
+		}
+	}
+	return len(steps)
+}
+
 // Restore restores the agent's memory from a list of findings
 func (a *BaseAgent) Restore(findings []v1alpha1.Finding) {
 	if len(findings) == 0 {
@@ -260,11 +1096,16 @@ func (a *BaseAgent) Restore(findings []v1alpha1.Finding) {
 	}
 
 	a.logger.Info("Restoring from checkpoint", "findings_count", len(findings))
+	// Checkpointed tool results were gathered before the restart and still count as evidence.
+	a.hasEvidence = true
+	a.readSteps = restoredReadSteps(findings, a.findTool)
 
 	var summary string
 	summary += "Previous diagnosis findings (restored from checkpoint):\n"
 	for _, f := range findings {
 		summary += fmt.Sprintf("- Step %d [%s]: %s\n", f.Step, f.ToolName, f.Summary)
+		a.recordFindingStep(f.Step)
+		a.stepOffset = max(a.stepOffset, f.Step)
 	}
 
 	// Inject as User message for MVP. Ideally this would be System message or specialized context injection.
@@ -2,8 +2,17 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 
 	"kubeminds/api/v1alpha1"
 )
@@ -181,6 +190,320 @@ func TestAgent_Run_TruncatesThink(t *testing.T) {
 	}
 }
 
+func TestAgent_Run_ConfiguredSummaryLimits(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: strings.Repeat("t", 100),
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: "{}"}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: x\nSuggestion: y",
+	}
+
+	mockTool := &MockTool{
+		NameVal: "get_logs",
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			return strings.Repeat("o", 100), nil
+		},
+	}
+
+	var findings []*v1alpha1.Finding
+	var history []string
+	onStepComplete := func(finding *v1alpha1.Finding, historyEntry string) {
+		if finding != nil {
+			findings = append(findings, finding)
+		}
+		history = append(history, historyEntry)
+	}
+
+	ag := NewAgent(mockLLM, []Tool{mockTool}, 5, nil, onStepComplete, Skill{}).
+		WithSummaryLimits(10, 20)
+
+	if _, err := ag.Run(context.Background(), "Diagnose", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if want := strings.Repeat("o", 10) + "..."; findings[0].Summary != want {
+		t.Errorf("Summary = %q, want %q", findings[0].Summary, want)
+	}
+	if !contains(history[0], strings.Repeat("t", 20)+"...") || contains(history[0], strings.Repeat("t", 21)) {
+		t.Errorf("Think entry not truncated to 20 chars: %q", history[0])
+	}
+}
+
+// toolRecordingLLM wraps MockLLMProvider and records the tool names offered on each call.
+type toolRecordingLLM struct {
+	*MockLLMProvider
+	offered [][]string
+}
+
+func (r *toolRecordingLLM) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	var names []string
+	for _, t := range tools {
+		names = append(names, t.Name())
+	}
+	r.offered = append(r.offered, names)
+	return r.MockLLMProvider.Chat(ctx, messages, tools)
+}
+
+func TestAgent_Run_AdviseMode(t *testing.T) {
+	mockLLM := &toolRecordingLLM{MockLLMProvider: NewMockLLMProvider()}
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "The pod is stuck, I will delete it.",
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: "{\"pod_name\":\"app-1\"}"}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type: MessageTypeAssistant,
+		Content: "Root Cause: pod stuck terminating\n" +
+			"Suggestion:\n" +
+			"1. Inspect the pod: kubectl -n prod describe pod app-1\n" +
+			"2. Force delete it: kubectl -n prod delete pod app-1 --grace-period=0 --force\n" +
+			"3. Verify the replacement is Ready: kubectl -n prod get pods -l app=web",
+	}
+
+	readTool := &MockTool{NameVal: "get_logs"}
+	writeTool := &MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}
+
+	ag := NewAgent(mockLLM, []Tool{readTool, writeTool}, 5, nil, nil, Skill{Name: "runbook", Mode: SkillModeAdvise})
+
+	// approved=false: in act mode the write tool would stop the run for approval.
+	result, err := ag.Run(context.Background(), "Diagnose pod app-1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if writeTool.ExecutionCount != 0 {
+		t.Errorf("write tool executed %d times in advise mode", writeTool.ExecutionCount)
+	}
+	for i, names := range mockLLM.offered {
+		for _, n := range names {
+			if n == "delete_pod" {
+				t.Errorf("call %d offered write tool delete_pod in advise mode", i)
+			}
+		}
+	}
+
+	steps := []string{"1. Inspect", "2. Force delete", "3. Verify"}
+	last := -1
+	for _, step := range steps {
+		idx := strings.Index(result.Suggestion, step)
+		if idx <= last {
+			t.Fatalf("expected ordered runbook steps %v in suggestion, got %q", steps, result.Suggestion)
+		}
+		last = idx
+	}
+	if !contains(result.Suggestion, "kubectl") {
+		t.Errorf("expected concrete commands in suggestion, got %q", result.Suggestion)
+	}
+
+	// The tool call was answered with an advise-mode error instead of being executed.
+	foundNotice := false
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeTool && contains(msg.Content, "not available in advise mode") {
+			foundNotice = true
+		}
+	}
+	if !foundNotice {
+		t.Error("expected advise-mode notice in tool output")
+	}
+}
+
+func TestAgent_Run_MinWriteConfidence(t *testing.T) {
+	newLLM := func(thought string) *MockLLMProvider {
+		mockLLM := NewMockLLMProvider()
+		mockLLM.Responses[0] = &Message{
+			Type:    MessageTypeAssistant,
+			Content: thought,
+			ToolCalls: []ToolCall{
+				{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: "{}"}},
+			},
+		}
+		mockLLM.Responses[1] = &Message{
+			Type:    MessageTypeAssistant,
+			Content: "Root Cause: stuck pod\nSuggestion: done",
+		}
+		return mockLLM
+	}
+
+	tests := []struct {
+		name        string
+		thought     string
+		wantExecute int
+	}{
+		{"low confidence blocks write", "Maybe the pod is stuck.\nConfidence: 0.4", 0},
+		{"missing confidence blocks write", "The pod is stuck.", 0},
+		{"high confidence permits write", "The pod is definitely stuck.\nConfidence: 0.9", 1},
+		{"percent confidence permits write", "The pod is stuck. Confidence: 95%", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTool := &MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}
+			ag := NewAgent(newLLM(tt.thought), []Tool{writeTool}, 5, nil, nil, Skill{}).
+				WithMinWriteConfidence(0.8)
+
+			if _, err := ag.Run(context.Background(), "Diagnose", true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if writeTool.ExecutionCount != tt.wantExecute {
+				t.Errorf("write tool executed %d times, want %d", writeTool.ExecutionCount, tt.wantExecute)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_MinWriteConfidence_BeforeApproval(t *testing.T) {
+	// A low-confidence write must not escalate to approval: the agent is told to keep investigating.
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		Content:   "Confidence: 0.3",
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: "{}"}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: x\nSuggestion: y"}
+
+	writeTool := &MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}
+	ag := NewAgent(mockLLM, []Tool{writeTool}, 5, nil, nil, Skill{}).WithMinWriteConfidence(0.8)
+
+	if _, err := ag.Run(context.Background(), "Diagnose", false); err != nil {
+		t.Fatalf("expected run to continue instead of waiting for approval, got %v", err)
+	}
+	if writeTool.ExecutionCount != 0 {
+		t.Errorf("write tool executed %d times, want 0", writeTool.ExecutionCount)
+	}
+}
+
+func TestAgent_Run_MinReadSteps(t *testing.T) {
+	readCall := func(id string) ToolCall {
+		return ToolCall{ID: id, Function: FunctionCall{Name: "get_pod_logs", Arguments: "{}"}}
+	}
+	writeCall := func(id string) ToolCall {
+		return ToolCall{ID: id, Function: FunctionCall{Name: "delete_pod", Arguments: "{}"}}
+	}
+
+	mockLLM := NewMockLLMProvider()
+	// Step 1: writes straight away, before any evidence
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, ToolCalls: []ToolCall{writeCall("call_1")}}
+	// Step 2: one read step, still one short of the minimum
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, ToolCalls: []ToolCall{readCall("call_2")}}
+	// Step 3: reads and writes in the same step; the read only counts once the step ends
+	mockLLM.Responses[2] = &Message{Type: MessageTypeAssistant, ToolCalls: []ToolCall{readCall("call_3"), writeCall("call_4")}}
+	// Step 4: two read steps done, so the write is permitted
+	mockLLM.Responses[3] = &Message{Type: MessageTypeAssistant, ToolCalls: []ToolCall{writeCall("call_5")}}
+	mockLLM.Responses[4] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: stuck pod\nSuggestion: done"}
+
+	readTool := &MockTool{NameVal: "get_pod_logs", SafetyLevelVal: SafetyLevelReadOnly}
+	writeTool := &MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}
+	ag := NewAgent(mockLLM, []Tool{readTool, writeTool}, 10, nil, nil, Skill{}).WithMinReadSteps(2)
+
+	result, err := ag.Run(context.Background(), "Diagnose", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if writeTool.ExecutionCount != 1 {
+		t.Errorf("write tool executed %d times, want 1 (only after the minimum read steps)", writeTool.ExecutionCount)
+	}
+	if len(result.ActionsTaken) != 1 {
+		t.Errorf("ActionsTaken = %+v, want only the permitted write", result.ActionsTaken)
+	}
+
+	refused := 0
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeTool && strings.Contains(msg.Content, "write tools are refused") {
+			refused++
+		}
+	}
+	if refused != 2 {
+		t.Errorf("refused write outputs = %d, want 2", refused)
+	}
+}
+
+func TestAgent_Run_MinReadSteps_CountsRestoredFindings(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: "{}"}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: x\nSuggestion: y"}
+
+	readTool := &MockTool{NameVal: "get_pod_logs", SafetyLevelVal: SafetyLevelReadOnly}
+	writeTool := &MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}
+	ag := NewAgent(mockLLM, []Tool{readTool, writeTool}, 5, nil, nil, Skill{}).WithMinReadSteps(2)
+	ag.Restore([]v1alpha1.Finding{
+		{Step: 1, ToolName: "get_pod_logs", Summary: "OOMKilled"},
+		{Step: 2, ToolName: "get_pod_logs", Summary: "OOMKilled again"},
+	})
+
+	if _, err := ag.Run(context.Background(), "Diagnose", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if writeTool.ExecutionCount != 1 {
+		t.Errorf("write tool executed %d times, want 1 after restored read steps", writeTool.ExecutionCount)
+	}
+}
+
+func TestAgent_Run_NumbersStepsAfterRestoredFindings(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_pod_logs", Arguments: "{}"}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: x\nSuggestion: y\nSupporting Findings: 2, 3"}
+
+	var steps []int
+	onStep := func(f *v1alpha1.Finding, _ string) {
+		if f != nil {
+			steps = append(steps, f.Step)
+		}
+	}
+	ag := NewAgent(mockLLM, []Tool{&MockTool{NameVal: "get_pod_logs", SafetyLevelVal: SafetyLevelReadOnly}}, 5, nil, onStep, Skill{})
+	ag.Restore([]v1alpha1.Finding{
+		{Step: 1, ToolName: "get_pod_logs", Summary: "OOMKilled"},
+		{Step: 2, ToolName: "get_pod_logs", Summary: "OOMKilled again"},
+	})
+
+	result, err := ag.Run(context.Background(), "Diagnose", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(steps, []int{3}) {
+		t.Errorf("finding steps = %v, want [3] after restored steps 1-2", steps)
+	}
+	if want := []int{2, 3}; !slices.Equal(result.SupportingFindings, want) {
+		t.Errorf("SupportingFindings = %v, want %v", result.SupportingFindings, want)
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		{"Confidence: 0.85", 0.85, true},
+		{"confidence = 70%", 0.70, true},
+		{"Confidence: 90", 0.90, true},
+		{"no confidence here", 0, false},
+		{"Confidence: 250", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseConfidence(tt.in)
+		if ok != tt.wantOK || (ok && (got < tt.want-1e-9 || got > tt.want+1e-9)) {
+			t.Errorf("parseConfidence(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
 func TestAgent_Run_MaxStepsExceeded(t *testing.T) {
 	// Setup
 	mockLLM := NewMockLLMProvider()
@@ -225,38 +548,57 @@ func TestAgent_Run_MaxStepsExceeded(t *testing.T) {
 }
 
 func TestAgent_Run_LoopDetected(t *testing.T) {
-	// Setup: agent always calls same tool with same args -> triggers loop detection
-	mockLLM := NewMockLLMProvider()
-	for i := 0; i < 5; i++ {
-		mockLLM.Responses[i] = &Message{
-			Type:    MessageTypeAssistant,
-			Content: "Thinking...",
-			ToolCalls: []ToolCall{
-				{
-					ID: fmt.Sprintf("call_%d", i),
-					Function: FunctionCall{
-						Name:      "get_logs",
-						Arguments: "{}",
-					},
-				},
-			},
-		}
+	window := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		window      *int
+		wantErr     string
+		wantExecute int
+	}{
+		{"default window", nil, "3 consecutive times", 3},
+		{"custom window", window(5), "5 consecutive times", 5},
+		{"disabled", window(0), "exceeded maximum steps", 8},
 	}
 
-	mockTool := &MockTool{
-		NameVal: "get_logs",
-		DescVal: "Get logs",
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup: agent always calls same tool with same args -> triggers loop detection
+			mockLLM := NewMockLLMProvider()
+			for i := 0; i < 8; i++ {
+				mockLLM.Responses[i] = &Message{
+					Type:    MessageTypeAssistant,
+					Content: "Thinking...",
+					ToolCalls: []ToolCall{
+						{
+							ID: fmt.Sprintf("call_%d", i),
+							Function: FunctionCall{
+								Name:      "get_logs",
+								Arguments: "{}",
+							},
+						},
+					},
+				}
+			}
 
-	ag := NewAgent(mockLLM, []Tool{mockTool}, 10, nil, nil, Skill{})
+			mockTool := &MockTool{
+				NameVal: "get_logs",
+				DescVal: "Get logs",
+			}
 
-	_, err := ag.Run(context.Background(), "Diagnose", true)
+			ag := NewAgent(mockLLM, []Tool{mockTool}, 8, nil, nil, Skill{LoopDetectionWindow: tt.window})
 
-	if err == nil {
-		t.Fatal("expected loop detection error, got nil")
-	}
-	if !contains(err.Error(), "loop detected") {
-		t.Errorf("expected loop detection error, got: %v", err)
+			_, err := ag.Run(context.Background(), "Diagnose", true)
+
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if mockTool.ExecutionCount != tt.wantExecute {
+				t.Errorf("tool executed %d times, want %d", mockTool.ExecutionCount, tt.wantExecute)
+			}
+		})
 	}
 }
 
@@ -416,3 +758,1143 @@ func TestAgent_Run_SafetyCheck(t *testing.T) {
 		t.Errorf("expected tool to be executed, got count %d", mockTool.ExecutionCount)
 	}
 }
+
+func TestAgent_Run_EmitsTypedEvents(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "I need to check the pod logs.",
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: "{\"pod\":\"test-pod\"}"}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: panic in handler\nSuggestion: fix the nil check",
+	}
+
+	mockTool := &MockTool{
+		NameVal: "get_logs",
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			return "panic: index out of range", nil
+		},
+	}
+
+	var events []v1alpha1.HistoryEvent
+	ag := NewAgent(mockLLM, []Tool{mockTool}, 5, nil, nil, Skill{}).
+		WithEventHandler(func(e v1alpha1.HistoryEvent) {
+			events = append(events, e)
+		})
+
+	if _, err := ag.Run(context.Background(), "Diagnose pod failure", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantPhases := []v1alpha1.HistoryEventPhase{
+		v1alpha1.HistoryEventThink,
+		v1alpha1.HistoryEventAct,
+		v1alpha1.HistoryEventThink,
+		v1alpha1.HistoryEventConclude,
+	}
+	if len(events) != len(wantPhases) {
+		t.Fatalf("expected %d events, got %d: %+v", len(wantPhases), len(events), events)
+	}
+	for i, want := range wantPhases {
+		if events[i].Phase != want {
+			t.Errorf("events[%d].Phase = %s, want %s", i, events[i].Phase, want)
+		}
+		if events[i].Timestamp == "" {
+			t.Errorf("events[%d].Timestamp is empty", i)
+		}
+	}
+
+	act := events[1]
+	if act.Step != 1 || act.ToolName != "get_logs" || act.Content != "panic: index out of range" {
+		t.Errorf("unexpected Act event: %+v", act)
+	}
+	conclude := events[3]
+	if conclude.Step != 2 || !contains(conclude.Content, "panic in handler") {
+		t.Errorf("unexpected Conclude event: %+v", conclude)
+	}
+}
+
+func TestAgent_Run_ObservesStepDuration(t *testing.T) {
+	before := stepSampleCount(t, "metrics_skill")
+
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: x\nSuggestion: y"}
+	if _, err := NewAgent(mockLLM, nil, 3, nil, nil, Skill{Name: "metrics_skill"}).Run(context.Background(), "goal", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := stepSampleCount(t, "metrics_skill"); got != before+1 {
+		t.Errorf("step samples = %d, want %d", got, before+1)
+	}
+}
+
+func stepSampleCount(t *testing.T, skill string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := AgentStepDuration.WithLabelValues(skill).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestAgent_Run_AccumulatesTokenUsage(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: "{}"}}},
+		Usage:     &TokenUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_2", Function: FunctionCall{Name: "get_logs", Arguments: `{"tail":50}`}}},
+		// A provider that does not report usage must not reset the running total.
+	}
+	mockLLM.Responses[2] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: panic\nSuggestion: fix the bug",
+		Usage:   &TokenUsage{PromptTokens: 300, CompletionTokens: 30, TotalTokens: 330},
+	}
+
+	ag := NewAgent(mockLLM, []Tool{&MockTool{NameVal: "get_logs"}}, 5, nil, nil, Skill{})
+	if _, err := ag.Run(context.Background(), "Diagnose pod failure", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := TokenUsage{PromptTokens: 400, CompletionTokens: 50, TotalTokens: 450}
+	if got := ag.TokenUsage(); got != want {
+		t.Errorf("TokenUsage() = %+v, want %+v", got, want)
+	}
+}
+
+// streamingLLM streams its response one chunk per entry of parts.
+type streamingLLM struct {
+	parts []string
+	calls int
+}
+
+func (s *streamingLLM) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	return nil, fmt.Errorf("Chat should not be called on a streaming provider")
+}
+
+func (s *streamingLLM) ChatStream(ctx context.Context, messages []Message, tools []Tool) (<-chan StreamChunk, error) {
+	s.calls++
+	chunks := make(chan StreamChunk, len(s.parts)+1)
+	for _, p := range s.parts {
+		chunks <- StreamChunk{Content: p}
+	}
+	chunks <- StreamChunk{Message: &Message{Type: MessageTypeAssistant, Content: strings.Join(s.parts, "")}}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestAgent_Run_StreamsPartialThink(t *testing.T) {
+	llm := &streamingLLM{parts: []string{"Root Cause: ", "OOM\n", "Suggestion: raise limit"}}
+
+	var history []string
+	onStep := func(_ *v1alpha1.Finding, entry string) {
+		history = append(history, entry)
+	}
+	ag := NewAgent(llm, nil, 3, nil, onStep, Skill{}).WithStreamInterval(0)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "OOM" || llm.calls != 1 {
+		t.Errorf("RootCause = %q after %d calls, want OOM after 1", result.RootCause, llm.calls)
+	}
+
+	want := []string{
+		"Step 1 (Thinking): Root Cause: ",
+		"Step 1 (Thinking): Root Cause: OOM\n",
+		"Step 1 (Thinking): Root Cause: OOM\nSuggestion: raise limit",
+		"Step 1 (Think): Root Cause: OOM\nSuggestion: raise limit",
+	}
+	if len(history) < len(want) {
+		t.Fatalf("history = %q, want partial updates followed by the Think entry", history)
+	}
+	for i, w := range want {
+		if history[i] != w {
+			t.Errorf("history[%d] = %q, want %q", i, history[i], w)
+		}
+	}
+	if !IsPartialThought(history[0]) || IsPartialThought(history[3]) {
+		t.Errorf("IsPartialThought misclassified %q or %q", history[0], history[3])
+	}
+}
+
+func TestAgent_Run_StreamIntervalThrottlesUpdates(t *testing.T) {
+	llm := &streamingLLM{parts: []string{"Root Cause: ", "OOM\n", "Suggestion: raise limit"}}
+
+	partials := 0
+	onStep := func(_ *v1alpha1.Finding, entry string) {
+		if IsPartialThought(entry) {
+			partials++
+		}
+	}
+	ag := NewAgent(llm, nil, 3, nil, onStep, Skill{})
+	if _, err := ag.Run(context.Background(), "Diagnose pod failure", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The chunks arrive well within DefaultStreamInterval, so only the first is forwarded.
+	if partials != 1 {
+		t.Errorf("partial updates = %d, want 1", partials)
+	}
+}
+
+func TestAgent_Run_RecordsActionsTaken(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: `{"namespace":"default","pod_name":"app-1"}`}},
+			{ID: "call_2", Function: FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: stuck\nSuggestion: recreated"}
+
+	tools := []Tool{
+		&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly},
+		&MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk, ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			return "Pod default/app-1 deleted", nil
+		}},
+	}
+	result, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ActionsTaken) != 1 {
+		t.Fatalf("ActionsTaken = %+v, want only the write tool", result.ActionsTaken)
+	}
+	a := result.ActionsTaken[0]
+	if a.Tool != "delete_pod" || a.Target != "default/app-1" || a.Result != "Pod default/app-1 deleted" {
+		t.Errorf("action = %+v, want delete_pod on default/app-1", a)
+	}
+}
+
+func TestAgent_Run_ReturnsActionsTakenWithError(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`}}},
+	}
+	tools := []Tool{&MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk}}
+
+	result, err := NewAgent(mockLLM, tools, 1, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", true)
+	if !errors.Is(err, ErrMaxStepsExceeded) {
+		t.Fatalf("Run() error = %v, want ErrMaxStepsExceeded", err)
+	}
+	if result == nil || len(result.ActionsTaken) != 1 || result.ActionsTaken[0].Tool != "delete_pod" {
+		t.Errorf("Run() result = %+v, want the executed delete_pod in ActionsTaken", result)
+	}
+}
+
+// reversibleMockTool is a MockTool that reports fixed rollback arguments.
+type reversibleMockTool struct {
+	MockTool
+	rollbackArgs string
+}
+
+func (m *reversibleMockTool) RollbackArgs(ctx context.Context, args string) (string, error) {
+	return m.rollbackArgs, nil
+}
+
+func TestAgent_Run_CapturesRollbackArgs(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "scale_statefulset", Arguments: `{"namespace":"default","statefulset_name":"db","replicas":0}`}},
+			{ID: "call_2", Function: FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"db-0"}`}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: stuck\nSuggestion: restarted"}
+
+	tools := []Tool{
+		&reversibleMockTool{
+			MockTool:     MockTool{NameVal: "scale_statefulset", SafetyLevelVal: SafetyLevelHighRisk},
+			rollbackArgs: `{"namespace":"default","statefulset_name":"db","replicas":3}`,
+		},
+		&MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk},
+	}
+	result, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ActionsTaken) != 2 {
+		t.Fatalf("ActionsTaken = %+v, want 2 actions", result.ActionsTaken)
+	}
+	if got := result.ActionsTaken[0].RollbackArguments; got != `{"namespace":"default","statefulset_name":"db","replicas":3}` {
+		t.Errorf("scale RollbackArguments = %q, want the pre-change replicas", got)
+	}
+	if got := result.ActionsTaken[1].RollbackArguments; got != "" {
+		t.Errorf("delete_pod RollbackArguments = %q, want none", got)
+	}
+}
+
+func TestActionTarget(t *testing.T) {
+	tests := map[string]string{
+		`{"namespace":"prod","deployment_name":"api","patch_json":"{}"}`: "prod/api",
+		`{"namespace":"prod","pod_name":"api-1","owner_name":"api"}`:     "prod/api-1",
+		`{"name":"node-1"}`: "node-1",
+		`{"replicas":3}`:    "",
+		`not json`:          "",
+	}
+	for args, want := range tests {
+		if got := actionTarget(args); got != want {
+			t.Errorf("actionTarget(%s) = %q, want %q", args, got, want)
+		}
+	}
+}
+
+// fakeClock is a Clock that records every wait and, unless blocked, fires at once.
+type fakeClock struct {
+	waits   []time.Duration
+	blocked bool
+	// onWait runs before each wait is recorded, e.g. to observe the LLM call count.
+	onWait func()
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	if c.onWait != nil {
+		c.onWait()
+	}
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	if !c.blocked {
+		ch <- time.Time{}
+	}
+	return ch
+}
+
+func TestAgent_Run_StepDelayBetweenSteps(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: `{}`}}},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_2", Function: FunctionCall{Name: "get_logs", Arguments: `{"tail":10}`}}},
+	}
+	mockLLM.Responses[2] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+
+	var callsAtWait []int
+	clock := &fakeClock{onWait: func() { callsAtWait = append(callsAtWait, mockLLM.CallCount) }}
+	tools := []Tool{&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly}}
+	_, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).
+		WithStepDelay(2*time.Second).
+		WithClock(clock).
+		Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Three steps: no wait before the first, one before each following step.
+	if len(clock.waits) != 2 || clock.waits[0] != 2*time.Second || clock.waits[1] != 2*time.Second {
+		t.Errorf("waits = %v, want [2s 2s]", clock.waits)
+	}
+	if len(callsAtWait) != 2 || callsAtWait[0] != 1 || callsAtWait[1] != 2 {
+		t.Errorf("LLM calls made before each wait = %v, want [1 2]", callsAtWait)
+	}
+}
+
+func TestAgent_Run_StepDelayCancelledByContext(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: `{}`}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &fakeClock{blocked: true, onWait: cancel}
+	tools := []Tool{&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly}}
+	_, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).
+		WithStepDelay(time.Hour).
+		WithClock(clock).
+		Run(ctx, "Diagnose pod failure", false)
+	if err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if mockLLM.CallCount != 1 {
+		t.Errorf("LLM calls = %d, want 1 (no call after the cancelled delay)", mockLLM.CallCount)
+	}
+}
+
+func TestAgent_Run_NoStepDelayByDefault(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: `{}`}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+
+	clock := &fakeClock{blocked: true}
+	tools := []Tool{&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly}}
+	if _, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).WithClock(clock).Run(context.Background(), "Diagnose pod failure", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(clock.waits) != 0 {
+		t.Errorf("waits = %v, want none", clock.waits)
+	}
+}
+
+func TestAgent_Run_EvidenceRequiredBeforeConclusion(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	// Step 0: concludes straight away, without evidence
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: guess\nSuggestion: restart"}
+	// Step 1: after the nudge, gathers evidence
+	mockLLM.Responses[1] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_logs", Arguments: `{}`}}},
+	}
+	// Step 2: concludes again, now backed by the tool output
+	mockLLM.Responses[2] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+
+	tools := []Tool{&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly}}
+	ag := NewAgent(mockLLM, tools, 5, nil, nil, Skill{RequireEvidence: true})
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "oom" {
+		t.Errorf("RootCause = %q, want the conclusion made after gathering evidence", result.RootCause)
+	}
+	if mockLLM.CallCount != 3 {
+		t.Errorf("LLM calls = %d, want 3", mockLLM.CallCount)
+	}
+
+	nudged := false
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeUser && strings.Contains(msg.Content, "not gathered any evidence") {
+			nudged = true
+		}
+	}
+	if !nudged {
+		t.Error("expected a gather-evidence nudge in memory")
+	}
+}
+
+func TestAgent_Run_EvidenceGateOffByDefault(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: guess\nSuggestion: restart"}
+
+	tools := []Tool{&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly}}
+	result, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "guess" || mockLLM.CallCount != 1 {
+		t.Errorf("RootCause = %q after %d calls, want first-turn conclusion accepted", result.RootCause, mockLLM.CallCount)
+	}
+}
+
+func TestAgent_Run_ProposesPlanForApproval(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "delete_pod", Arguments: `{"pod_name":"app-1"}`}},
+			{ID: "call_2", Function: FunctionCall{Name: "get_logs", Arguments: `{}`}},
+			{ID: "call_3", Function: FunctionCall{Name: "scale", Arguments: `{"replicas":3}`}},
+		},
+	}
+
+	tools := []Tool{
+		&MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk},
+		&MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly},
+		&MockTool{NameVal: "scale", SafetyLevelVal: SafetyLevelHighRisk},
+	}
+	_, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", false)
+
+	var waitingErr *ErrWaitingForApproval
+	if !errors.As(err, &waitingErr) {
+		t.Fatalf("err = %v, want ErrWaitingForApproval", err)
+	}
+	want := []v1alpha1.PlannedAction{
+		{Index: 0, ToolName: "delete_pod", Arguments: `{"pod_name":"app-1"}`, RiskLevel: "HighRisk"},
+		{Index: 1, ToolName: "scale", Arguments: `{"replicas":3}`, RiskLevel: "HighRisk"},
+	}
+	if len(waitingErr.Plan) != len(want) || waitingErr.Plan[0] != want[0] || waitingErr.Plan[1] != want[1] {
+		t.Errorf("Plan = %+v, want the two high-risk calls in order", waitingErr.Plan)
+	}
+}
+
+func TestAgent_Run_ExecutesOnlyApprovedPlanSteps(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: stuck pods\nSuggestion: restarted app-1 and scaled up"}
+
+	var executed []string
+	record := func(name string) func(ctx context.Context, args string) (string, error) {
+		return func(ctx context.Context, args string) (string, error) {
+			executed = append(executed, name+" "+args)
+			return name + " done", nil
+		}
+	}
+	tools := []Tool{
+		&MockTool{NameVal: "delete_pod", SafetyLevelVal: SafetyLevelHighRisk, ExecuteFunc: record("delete_pod")},
+		&MockTool{NameVal: "scale", SafetyLevelVal: SafetyLevelHighRisk, ExecuteFunc: record("scale")},
+	}
+	plan := []v1alpha1.PlannedAction{
+		{Index: 0, ToolName: "delete_pod", Arguments: `{"pod_name":"app-1"}`, Approved: true},
+		{Index: 1, ToolName: "delete_pod", Arguments: `{"pod_name":"app-2"}`},
+		{Index: 2, ToolName: "scale", Arguments: `{"replicas":3}`, Approved: true},
+	}
+
+	ag := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).WithApprovedPlan(plan)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantExecuted := []string{`delete_pod {"pod_name":"app-1"}`, `scale {"replicas":3}`}
+	if strings.Join(executed, "|") != strings.Join(wantExecuted, "|") {
+		t.Errorf("executed = %v, want %v", executed, wantExecuted)
+	}
+	if len(result.ActionsTaken) != 2 || result.ActionsTaken[0].Tool != "delete_pod" || result.ActionsTaken[1].Tool != "scale" {
+		t.Errorf("ActionsTaken = %+v, want the two approved steps", result.ActionsTaken)
+	}
+
+	// The LLM sees the outcome of every step, including the rejected one.
+	var outputs []string
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeTool {
+			outputs = append(outputs, msg.Content)
+		}
+	}
+	if len(outputs) != 3 || !strings.Contains(outputs[1], "rejected step 1") {
+		t.Errorf("tool outputs = %q, want the rejected step reported as not executed", outputs)
+	}
+}
+
+func TestAgent_Run_ReadOnlyBatchRunsConcurrently(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "get_pod_logs", Arguments: `{}`}},
+			{ID: "call_2", Function: FunctionCall{Name: "get_pod_events", Arguments: `{}`}},
+			{ID: "call_3", Function: FunctionCall{Name: "get_pod_spec", Arguments: `{}`}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+
+	// Every tool waits until all three have started, so a sequential run times out.
+	// Earlier calls then finish later, so completion order differs from call order.
+	var started sync.WaitGroup
+	started.Add(3)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	barrierTool := func(name string, finishDelay time.Duration) Tool {
+		return &MockTool{
+			NameVal: name,
+			ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+				started.Done()
+				select {
+				case <-allStarted:
+				case <-time.After(2 * time.Second):
+					return "", errors.New("tools did not run concurrently")
+				}
+				time.Sleep(finishDelay)
+				return name + " output", nil
+			},
+		}
+	}
+	tools := []Tool{
+		barrierTool("get_pod_logs", 30*time.Millisecond),
+		barrierTool("get_pod_events", 15*time.Millisecond),
+		barrierTool("get_pod_spec", 0),
+	}
+
+	ag := NewAgent(mockLLM, tools, 5, nil, nil, Skill{})
+	if _, err := ag.Run(context.Background(), "Diagnose pod failure", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var outputs []string
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeTool {
+			outputs = append(outputs, msg.ToolCallID+"="+msg.Content)
+		}
+	}
+	want := []string{"call_1=get_pod_logs output", "call_2=get_pod_events output", "call_3=get_pod_spec output"}
+	if strings.Join(outputs, ",") != strings.Join(want, ",") {
+		t.Errorf("tool outputs = %v, want %v in call order", outputs, want)
+	}
+}
+
+func TestAgent_Run_BatchWithWriteToolRunsSequentially(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "get_pod_logs", Arguments: `{}`}},
+			{ID: "call_2", Function: FunctionCall{Name: "delete_pod", Arguments: `{}`}},
+			{ID: "call_3", Function: FunctionCall{Name: "get_pod_events", Arguments: `{}`}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+
+	var inFlight, maxInFlight atomic.Int32
+	trackedTool := func(name string, level SafetyLevel) Tool {
+		return &MockTool{
+			NameVal:        name,
+			SafetyLevelVal: level,
+			ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return name + " output", nil
+			},
+		}
+	}
+	tools := []Tool{
+		trackedTool("get_pod_logs", SafetyLevelReadOnly),
+		trackedTool("delete_pod", SafetyLevelHighRisk),
+		trackedTool("get_pod_events", SafetyLevelReadOnly),
+	}
+
+	result, err := NewAgent(mockLLM, tools, 5, nil, nil, Skill{}).Run(context.Background(), "Diagnose pod failure", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := maxInFlight.Load(); got != 1 {
+		t.Errorf("max concurrent tool calls = %d, want 1 for a batch with a high-risk tool", got)
+	}
+	if len(result.ActionsTaken) != 1 || result.ActionsTaken[0].Tool != "delete_pod" {
+		t.Errorf("ActionsTaken = %+v, want the approved delete_pod", result.ActionsTaken)
+	}
+}
+
+func TestAgent_Run_ToolTimeoutContinuesLoop(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_pod_logs", Arguments: `{}`}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: logs unavailable\nSuggestion: check the node"}
+
+	release := make(chan struct{})
+	defer close(release)
+	// The hung tool ignores its context, like a stuck log stream.
+	hungTool := &MockTool{
+		NameVal: "get_pod_logs",
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			<-release
+			return "too late", nil
+		},
+	}
+
+	ag := NewAgent(mockLLM, []Tool{hungTool}, 5, nil, nil, Skill{}).WithToolTimeout(20 * time.Millisecond)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("expected the run to continue after a tool timeout, got %v", err)
+	}
+	if result.RootCause != "logs unavailable" {
+		t.Errorf("RootCause = %q, want the conclusion after the timeout", result.RootCause)
+	}
+
+	var output string
+	for _, msg := range ag.memory.GetHistory() {
+		if msg.Type == MessageTypeTool && msg.ToolCallID == "call_1" {
+			output = msg.Content
+		}
+	}
+	if !strings.Contains(output, "tool timed out after 20ms") {
+		t.Errorf("tool output = %q, want a timeout message", output)
+	}
+}
+
+func TestAgent_Run_ToolTimeoutSparesWriteTools(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "restart_deployment", Arguments: `{"namespace":"default","name":"app"}`}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: stuck rollout\nSuggestion: restarted it"}
+
+	slowWrite := &MockTool{
+		NameVal:        "restart_deployment",
+		SafetyLevelVal: SafetyLevelHighRisk,
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "restarted", nil
+		},
+	}
+
+	ag := NewAgent(mockLLM, []Tool{slowWrite}, 5, nil, nil, Skill{}).WithToolTimeout(10 * time.Millisecond)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ActionsTaken) != 1 || result.ActionsTaken[0].Result != "restarted" {
+		t.Errorf("ActionsTaken = %+v, want the completed write recorded", result.ActionsTaken)
+	}
+}
+
+// historyRecordingLLM wraps MockLLMProvider and records the history sent on each call.
+type historyRecordingLLM struct {
+	*MockLLMProvider
+	histories [][]Message
+}
+
+func (r *historyRecordingLLM) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	r.histories = append(r.histories, messages)
+	return r.MockLLMProvider.Chat(ctx, messages, tools)
+}
+
+func TestAgent_Run_CompactsHistoryBeforeChat(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	for i := 0; i < 6; i++ {
+		mockLLM.Responses[i] = &Message{
+			Type:      MessageTypeAssistant,
+			ToolCalls: []ToolCall{{ID: fmt.Sprintf("call_%d", i), Function: FunctionCall{Name: "get_logs", Arguments: fmt.Sprintf(`{"page":%d}`, i)}}},
+		}
+	}
+	mockLLM.Responses[6] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+	llm := &historyRecordingLLM{MockLLMProvider: mockLLM}
+
+	bigTool := &MockTool{
+		NameVal: "get_logs",
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			return strings.Repeat("log line\n", 1000), nil
+		},
+	}
+
+	const budget = 5000
+	ag := NewAgent(llm, []Tool{bigTool}, 10, nil, nil, Skill{}).WithHistoryCompaction(budget, 2)
+	if _, err := ag.Run(context.Background(), "Diagnose pod failure", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := llm.histories[len(llm.histories)-1]
+	if tokens := approxTokens(last); tokens > budget {
+		t.Errorf("last Chat history ~%d tokens, want compacted under %d", tokens, budget)
+	}
+	if !strings.Contains(last[0].Content, "Diagnosis Goal: Diagnose pod failure") {
+		t.Errorf("goal not preserved at the start of the history: %+v", last[0])
+	}
+	summaries := 0
+	for _, msg := range last {
+		if isCompactionSummary(msg) {
+			summaries++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("history holds %d summary messages, want 1", summaries)
+	}
+	if got := last[len(last)-1].ToolCallID; got != "call_5" {
+		t.Errorf("latest tool output = %q, want call_5 kept verbatim", got)
+	}
+}
+
+func TestAgent_Run_CompactsHistoryOnContextTooLong(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	for i := 0; i < 3; i++ {
+		mockLLM.Responses[i] = &Message{
+			Type:      MessageTypeAssistant,
+			ToolCalls: []ToolCall{{ID: fmt.Sprintf("call_%d", i), Function: FunctionCall{Name: "get_logs", Arguments: fmt.Sprintf(`{"page":%d}`, i)}}},
+		}
+	}
+	mockLLM.ErrorTrigger[3] = &LLMError{Class: ErrorClassContextTooLong, Err: errors.New("prompt is too long")}
+	mockLLM.Responses[4] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: oom\nSuggestion: raise limits"}
+	llm := &historyRecordingLLM{MockLLMProvider: mockLLM}
+
+	logsTool := &MockTool{
+		NameVal: "get_logs",
+		ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+			return strings.Repeat("log line\n", 100), nil
+		},
+	}
+
+	// The budget is never reached, so only the rejected call triggers compaction.
+	ag := NewAgent(llm, []Tool{logsTool}, 10, nil, nil, Skill{}).WithHistoryCompaction(1_000_000, 1)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "oom" {
+		t.Errorf("RootCause = %q, want oom", result.RootCause)
+	}
+
+	if len(llm.histories) != 5 {
+		t.Fatalf("Chat called %d times, want 5 (one retry after compaction)", len(llm.histories))
+	}
+	rejected, retried := llm.histories[3], llm.histories[4]
+	if approxTokens(retried) >= approxTokens(rejected) {
+		t.Errorf("retried history ~%d tokens, want fewer than the rejected ~%d", approxTokens(retried), approxTokens(rejected))
+	}
+	if !slices.ContainsFunc(retried, isCompactionSummary) {
+		t.Error("retried history holds no compaction summary")
+	}
+}
+
+func TestAgent_Run_ContextTooLongWithoutCompactionFails(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.ErrorTrigger[0] = fmt.Errorf("openai api error: %w",
+		&LLMError{Class: ErrorClassContextTooLong, Err: errors.New("maximum context length exceeded")})
+
+	ag := NewAgent(mockLLM, nil, 5, nil, nil, Skill{})
+	_, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if ClassOf(err) != ErrorClassContextTooLong {
+		t.Fatalf("error = %v, want class %s", err, ErrorClassContextTooLong)
+	}
+	if calls := mockLLM.Calls(); calls != 1 {
+		t.Errorf("Chat called %d times, want 1 (no retry)", calls)
+	}
+}
+
+// formatRecordingLLM wraps MockLLMProvider and records the response format requested on each call.
+type formatRecordingLLM struct {
+	*MockLLMProvider
+	formats []ResponseFormat
+}
+
+func (r *formatRecordingLLM) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	r.formats = append(r.formats, ResponseFormatFromContext(ctx))
+	return r.MockLLMProvider.Chat(ctx, messages, tools)
+}
+
+func TestAgent_Run_RequestsJSONResponseFormat(t *testing.T) {
+	for _, tt := range []struct {
+		format SkillOutputFormat
+		want   ResponseFormat
+	}{
+		{SkillOutputFormatText, ""},
+		{SkillOutputFormatJSON, ResponseFormatJSON},
+	} {
+		mockLLM := NewMockLLMProvider()
+		mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: `{"root_cause": "oom", "suggestion": "raise limits"}`}
+		llm := &formatRecordingLLM{MockLLMProvider: mockLLM}
+
+		ag := NewAgent(llm, nil, 5, nil, nil, Skill{OutputFormat: tt.format})
+		if _, err := ag.Run(context.Background(), "Diagnose pod failure", false); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.format, err)
+		}
+		if len(llm.formats) != 1 || llm.formats[0] != tt.want {
+			t.Errorf("%s: response formats = %q, want [%q]", tt.format, llm.formats, tt.want)
+		}
+	}
+}
+
+func TestAgent_Run_StructuredJSONConclusion(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "```json\n" + `{"root_cause": "Container exceeded its **256Mi** limit", "suggestion": "1. Raise the limit\n2. Profile the heap", "confidence": 0.85, "memory_limit": "256Mi"}` + "\n```",
+	}
+
+	skill := Skill{OutputFormat: SkillOutputFormatJSON, OutputSchema: map[string]string{"memory_limit": "the container memory limit"}}
+	ag := NewAgent(mockLLM, nil, 5, nil, nil, skill)
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.RootCause != "Container exceeded its **256Mi** limit" {
+		t.Errorf("RootCause = %q", result.RootCause)
+	}
+	if result.Suggestion != "1. Raise the limit\n2. Profile the heap" {
+		t.Errorf("Suggestion = %q", result.Suggestion)
+	}
+	if result.Confidence == nil || *result.Confidence != 0.85 {
+		t.Errorf("Confidence = %v, want 0.85", result.Confidence)
+	}
+	if result.Details["memory_limit"] != "256Mi" {
+		t.Errorf("Details = %v, want memory_limit from the JSON object", result.Details)
+	}
+
+	goal := ag.memory.GetHistory()[0].Content
+	if !strings.Contains(goal, `"root_cause"`) || !strings.Contains(goal, `"memory_limit": <the container memory limit>`) {
+		t.Errorf("goal does not request the JSON conclusion: %q", goal)
+	}
+}
+
+func TestAgent_Run_StructuredConclusionFallsBackToText(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: disk pressure evicted the pod\nSuggestion: clean up the node\nConfidence: 70%",
+	}
+
+	ag := NewAgent(mockLLM, nil, 5, nil, nil, Skill{OutputFormat: SkillOutputFormatJSON})
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RootCause != "disk pressure evicted the pod" || result.Suggestion != "clean up the node\nConfidence: 70%" {
+		t.Errorf("result = %q / %q, want the text parser's fields", result.RootCause, result.Suggestion)
+	}
+	if result.Confidence == nil || *result.Confidence != 0.7 {
+		t.Errorf("Confidence = %v, want 0.7 from the text", result.Confidence)
+	}
+}
+
+func TestExtractStructuredResult_RejectsInvalidJSON(t *testing.T) {
+	ag := NewAgent(NewMockLLMProvider(), nil, 5, nil, nil, Skill{OutputFormat: SkillOutputFormatJSON})
+	for _, content := range []string{
+		"Root Cause: no json here",
+		`{"root_cause": "unterminated`,
+		`{"suggestion": "missing root cause"}`,
+	} {
+		if _, ok := ag.extractStructuredResult(content); ok {
+			t.Errorf("extractStructuredResult(%q) succeeded, want fallback", content)
+		}
+	}
+}
+
+func TestAgent_Run_Inconclusive(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		skill     Skill
+		threshold float64
+		want      bool
+	}{
+		{
+			name:    "confident text conclusion",
+			content: "Root Cause: memory limit too low\nSuggestion: raise it\nConfidence: 0.9",
+			want:    false,
+		},
+		{
+			name:    "agent declares it in text",
+			content: "Root Cause: Inconclusive - logs were rotated away\nSuggestion: enable log retention",
+			want:    true,
+		},
+		{
+			name:    "agent declares it in JSON",
+			content: `{"root_cause": "Inconclusive", "suggestion": "collect a heap dump", "confidence": 0.2}`,
+			skill:   Skill{OutputFormat: SkillOutputFormatJSON},
+			want:    true,
+		},
+		{
+			name:      "confidence below the threshold",
+			content:   "Root Cause: maybe a bad deploy\nSuggestion: roll back\nConfidence: 0.3",
+			threshold: 0.5,
+			want:      true,
+		},
+		{
+			name:      "confidence at the threshold",
+			content:   "Root Cause: bad deploy\nSuggestion: roll back\nConfidence: 0.5",
+			threshold: 0.5,
+			want:      false,
+		},
+		{
+			name:      "no confidence reported",
+			content:   "Root Cause: bad deploy\nSuggestion: roll back",
+			threshold: 0.5,
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLM := NewMockLLMProvider()
+			mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: tt.content}
+
+			ag := NewAgent(mockLLM, nil, 5, nil, nil, tt.skill).WithInconclusiveBelow(tt.threshold)
+			result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Inconclusive != tt.want {
+				t.Errorf("Inconclusive = %v, want %v", result.Inconclusive, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_GrantsRequestedTool(t *testing.T) {
+	mockLLM := &toolRecordingLLM{MockLLMProvider: NewMockLLMProvider()}
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: RequestToolName, Arguments: `{"tool_name":"get_events","reason":"logs show no OOM"}`}},
+		},
+	}
+	mockLLM.Responses[1] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_2", Function: FunctionCall{Name: "get_events", Arguments: `{}`}},
+		},
+	}
+	mockLLM.Responses[2] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: image pull failure\nSuggestion: fix the image tag",
+	}
+
+	logsTool := &MockTool{NameVal: "get_logs"}
+	eventsTool := &MockTool{NameVal: "get_events"}
+	skill := Skill{Name: "oom", AllowedTools: []string{"get_logs"}}
+
+	ag := NewAgent(mockLLM, []Tool{logsTool, eventsTool}, 5, nil, nil, skill).
+		WithToolRequests([]string{"get_events"})
+	if _, err := ag.Run(context.Background(), "Diagnose pod app-1", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if eventsTool.Executions() != 1 {
+		t.Errorf("granted tool executed %d times, want 1", eventsTool.Executions())
+	}
+	offered := func(call int, name string) bool {
+		for _, n := range mockLLM.offered[call] {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	if offered(0, "get_events") {
+		t.Error("out-of-skill tool offered before it was requested")
+	}
+	if !offered(0, RequestToolName) {
+		t.Errorf("%s not offered", RequestToolName)
+	}
+	if !offered(1, "get_events") {
+		t.Error("granted tool not offered after the request")
+	}
+}
+
+func TestAgent_Run_RequestedToolNeedsApproval(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: RequestToolName, Arguments: `{"tool_name":"get_secrets","reason":"check credentials"}`}},
+		},
+	}
+
+	skill := Skill{Name: "oom", AllowedTools: []string{"get_logs"}}
+	tools := []Tool{&MockTool{NameVal: "get_logs"}, &MockTool{NameVal: "get_secrets"}}
+	_, err := NewAgent(mockLLM, tools, 5, nil, nil, skill).
+		WithToolRequests(nil).
+		Run(context.Background(), "Diagnose pod app-1", false)
+
+	var waitingErr *ErrWaitingForApproval
+	if !errors.As(err, &waitingErr) {
+		t.Fatalf("err = %v, want ErrWaitingForApproval", err)
+	}
+	if waitingErr.ToolName != "get_secrets" || waitingErr.RiskLevel != SafetyLevelReadOnly {
+		t.Errorf("waiting for %s (%s), want get_secrets (ReadOnly)", waitingErr.ToolName, waitingErr.RiskLevel)
+	}
+}
+
+func TestAgent_Run_CapturesSupportingFindings(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	for i, pod := range []string{"a", "b"} {
+		mockLLM.Responses[i] = &Message{
+			Type: MessageTypeAssistant,
+			ToolCalls: []ToolCall{{
+				ID:       fmt.Sprintf("call_%d", i+1),
+				Function: FunctionCall{Name: "get_logs", Arguments: fmt.Sprintf(`{"pod":%q}`, pod)},
+			}},
+		}
+	}
+	// Step 7 never ran and step 2 is cited twice: only real findings are kept, once.
+	mockLLM.Responses[2] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: OOM kill\nSuggestion: Raise the memory limit\nSupporting Findings: 2, 1, 7, step 2",
+	}
+	mockTool := &MockTool{NameVal: "get_logs", SafetyLevelVal: SafetyLevelReadOnly, ExecuteFunc: func(ctx context.Context, args string) (string, error) {
+		return "OOMKilled", nil
+	}}
+
+	ag := NewAgent(mockLLM, []Tool{mockTool}, 5, nil, nil, Skill{})
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []int{1, 2}; !slices.Equal(result.SupportingFindings, want) {
+		t.Errorf("SupportingFindings = %v, want %v", result.SupportingFindings, want)
+	}
+	if result.Suggestion != "Raise the memory limit" {
+		t.Errorf("Suggestion = %q, want the citation line excluded", result.Suggestion)
+	}
+	if goal := ag.memory.GetHistory()[0].Content; !strings.Contains(goal, "Supporting Findings:") {
+		t.Errorf("goal does not ask for supporting findings: %q", goal)
+	}
+}
+
+func TestAgent_Run_StructuredConclusionSupportingFindings(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: `{"root_cause": "OOM kill", "suggestion": "Raise the limit", "supporting_findings": [3, "1"]}`,
+	}
+
+	ag := NewAgent(mockLLM, nil, 5, nil, nil, Skill{OutputFormat: SkillOutputFormatJSON})
+	ag.Restore([]v1alpha1.Finding{{Step: 1, ToolName: "get_logs"}, {Step: 3, ToolName: "get_events"}})
+	result, err := ag.Run(context.Background(), "Diagnose pod failure", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []int{1, 3}; !slices.Equal(result.SupportingFindings, want) {
+		t.Errorf("SupportingFindings = %v, want restored steps %v", result.SupportingFindings, want)
+	}
+}
+
+func TestAgent_Run_ApprovedRunDoesNotGrantRequests(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: RequestToolName, Arguments: `{"tool_name":"get_secrets","reason":"check credentials"}`}},
+		},
+	}
+
+	skill := Skill{Name: "oom", AllowedTools: []string{"get_logs"}}
+	tools := []Tool{&MockTool{NameVal: "get_logs"}, &MockTool{NameVal: "get_secrets"}}
+	_, err := NewAgent(mockLLM, tools, 5, nil, nil, skill).
+		WithToolRequests(nil).
+		Run(context.Background(), "Diagnose pod app-1", true)
+
+	var waitingErr *ErrWaitingForApproval
+	if !errors.As(err, &waitingErr) || !waitingErr.ToolRequest {
+		t.Fatalf("err = %v, want a tool request ErrWaitingForApproval despite the approved run", err)
+	}
+}
+
+func TestAgent_WithGrantedTools(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type: MessageTypeAssistant,
+		ToolCalls: []ToolCall{
+			{ID: "call_1", Function: FunctionCall{Name: "scale_down", Arguments: `{}`}},
+		},
+	}
+
+	scaleTool := &MockTool{NameVal: "scale_down", SafetyLevelVal: SafetyLevelHighRisk}
+	tools := []Tool{
+		&MockTool{NameVal: "get_logs"},
+		scaleTool,
+		&MockTool{NameVal: "exec", SafetyLevelVal: SafetyLevelForbidden},
+	}
+	skill := Skill{Name: "oom", AllowedTools: []string{"get_logs"}}
+	ag := NewAgent(mockLLM, tools, 5, nil, nil, skill).
+		WithGrantedTools([]string{"scale_down", "exec", "missing"})
+
+	if want := []string{"scale_down"}; !slices.Equal(ag.GrantedTools(), want) {
+		t.Errorf("GrantedTools() = %v, want %v", ag.GrantedTools(), want)
+	}
+	// A granted high-risk tool still needs approval for each call.
+	_, err := ag.Run(context.Background(), "Diagnose pod app-1", false)
+	var waitingErr *ErrWaitingForApproval
+	if !errors.As(err, &waitingErr) || waitingErr.ToolName != "scale_down" || waitingErr.ToolRequest {
+		t.Fatalf("err = %v, want approval of the scale_down call", err)
+	}
+	if scaleTool.Executions() != 0 {
+		t.Errorf("granted high-risk tool executed %d times without approval", scaleTool.Executions())
+	}
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FindingExtractor parses the agent's final response into skill-specific structured
+// fields (e.g. an OOM skill's memory limit), keyed by the skill's output schema.
+type FindingExtractor func(content string, schema map[string]string) map[string]string
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = map[string]FindingExtractor{}
+)
+
+// RegisterFindingExtractor registers the extractor used for the named skill,
+// replacing any previous registration.
+func RegisterFindingExtractor(skillName string, fn FindingExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[skillName] = fn
+}
+
+// findingExtractorFor returns the extractor registered for skillName, falling back
+// to ExtractSchemaFields.
+func findingExtractorFor(skillName string) FindingExtractor {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	if fn, ok := extractors[skillName]; ok {
+		return fn
+	}
+	return ExtractSchemaFields
+}
+
+// ExtractSchemaFields is the generic extractor: for every field in schema it takes
+// the value of the first "<field>: <value>" line in content (case-insensitive).
+// Fields that are not found are omitted.
+func ExtractSchemaFields(content string, schema map[string]string) map[string]string {
+	if len(schema) == 0 {
+		return nil
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		key, val, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.Trim(strings.TrimSpace(key), "-*` "))
+		for name := range schema {
+			if _, seen := fields[name]; !seen && strings.ToLower(name) == key {
+				if v := strings.TrimSpace(val); v != "" {
+					fields[name] = v
+				}
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// outputSchemaPrompt renders a skill's output schema as concluding instructions.
+func outputSchemaPrompt(schema map[string]string) string {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("\nAlso include each of the following fields on its own line as '<field>: <value>':")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s: <%s>", name, schema[name])
+	}
+	return b.String()
+}
+
+// OOMMemoryLimitField is the output field the OOM extractor fills with the
+// container memory limit (a Kubernetes quantity such as "512Mi").
+const OOMMemoryLimitField = "memory_limit"
+
+// memoryLimitPattern finds a Kubernetes memory quantity mentioned as a limit, e.g.
+// "memory limit of 512Mi", "limits.memory=1Gi" or "limit: 256 MiB".
+var memoryLimitPattern = regexp.MustCompile(`(?i)limit[^0-9\n]{0,30}?(\d+(?:\.\d+)?)\s*(Ki|Mi|Gi|Ti|K|M|G|T)i?B?\b`)
+
+// ExtractOOMFindings extracts the schema fields and, when the model did not state
+// memory_limit explicitly, recovers it from a limit mentioned in free text.
+func ExtractOOMFindings(content string, schema map[string]string) map[string]string {
+	fields := ExtractSchemaFields(content, schema)
+	if _, ok := fields[OOMMemoryLimitField]; ok {
+		return fields
+	}
+	if m := memoryLimitPattern.FindStringSubmatch(content); m != nil {
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[OOMMemoryLimitField] = m[1] + m[2]
+	}
+	return fields
+}
+
+func init() {
+	RegisterFindingExtractor("oom_diagnosis", ExtractOOMFindings)
+}
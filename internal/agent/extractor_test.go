@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExtractSchemaFields(t *testing.T) {
+	schema := map[string]string{"verdict": "reachable or unreachable", "port": "the failing port"}
+	content := "Root Cause: NetworkPolicy blocks egress\n- Verdict: unreachable\nPort: 5432\nunrelated: line"
+
+	got := ExtractSchemaFields(content, schema)
+	if got["verdict"] != "unreachable" || got["port"] != "5432" {
+		t.Errorf("ExtractSchemaFields = %v", got)
+	}
+	if _, ok := got["unrelated"]; ok {
+		t.Errorf("field outside the schema extracted: %v", got)
+	}
+	if ExtractSchemaFields(content, nil) != nil {
+		t.Error("expected nil details without a schema")
+	}
+}
+
+func TestExtractOOMFindings(t *testing.T) {
+	schema := map[string]string{OOMMemoryLimitField: "memory limit"}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"explicit field", "Root Cause: leak\nmemory_limit: 512Mi", "512Mi"},
+		{"free text", "Root Cause: the container hit its memory limit of 256Mi under load.", "256Mi"},
+		{"limits.memory", "Root Cause: resources.limits.memory=1Gi is too low for the JVM heap.", "1Gi"},
+		{"unit suffix", "Root Cause: working set exceeds the limit (128 MiB).", "128Mi"},
+		{"no limit", "Root Cause: kernel OOM killer on the node.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractOOMFindings(tt.content, schema)[OOMMemoryLimitField]; got != tt.want {
+				t.Errorf("memory_limit = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_SkillExtractor(t *testing.T) {
+	mockLLM := &toolRecordingLLM{MockLLMProvider: NewMockLLMProvider()}
+	mockLLM.Responses[0] = &Message{
+		Type:    MessageTypeAssistant,
+		Content: "Root Cause: the JVM heap exceeds the container memory limit of 512Mi\nSuggestion: cap -Xmx below the limit",
+	}
+
+	skill := Skill{
+		Name:         "oom_diagnosis",
+		OutputSchema: map[string]string{OOMMemoryLimitField: "the container memory limit"},
+	}
+	ag := NewAgent(mockLLM, nil, 3, nil, nil, skill)
+
+	result, err := ag.Run(context.Background(), "Diagnose OOMKilled pod", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := result.Details[OOMMemoryLimitField]; got != "512Mi" {
+		t.Errorf("Details[%s] = %q, want 512Mi (details: %v)", OOMMemoryLimitField, got, result.Details)
+	}
+
+	// The goal prompt asks for the schema fields.
+	history := ag.memory.GetHistory()
+	var asked bool
+	for _, m := range history {
+		if strings.Contains(m.Content, "memory_limit: <the container memory limit>") {
+			asked = true
+		}
+	}
+	if !asked {
+		t.Error("goal prompt does not request the output schema fields")
+	}
+}
+
+func TestAgent_Run_NoOutputSchema_NoDetails(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: limit 512Mi\nSuggestion: none"}
+
+	result, err := NewAgent(mockLLM, nil, 3, nil, nil, Skill{Name: "oom_diagnosis"}).
+		Run(context.Background(), "goal", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Details != nil {
+		t.Errorf("Details = %v, want nil for a skill without output_schema", result.Details)
+	}
+}
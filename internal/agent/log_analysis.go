@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// analyzeLogsPrompt asks for a conclusion on a pasted log excerpt in the format
+// parsed by parseTextConclusion. The logs are fenced with the second argument.
+const analyzeLogsPrompt = `You are a Kubernetes SRE. Analyze the log excerpt below and determine the most likely root cause of the problem it shows. You cannot run any tools or query the cluster: base the analysis only on the text provided.
+%s
+Respond with:
+Confidence: <your confidence in the diagnosis, 0.0-1.0>
+Root Cause: <concise root cause>
+Suggestion: <actionable remediation>` + inconclusiveInstruction + `
+
+Logs:
+%[2]s
+%[3]s
+%[2]s`
+
+// AnalyzeLogs asks llm for the root cause of a log excerpt in a single call, without
+// tools or access to the cluster. extra is optional free-text context from the user
+// (e.g. what the service does or what changed). The reply is parsed like the
+// conclusion of an agent run.
+func AnalyzeLogs(ctx context.Context, llm LLMProvider, logs, extra string) (*Result, error) {
+	if strings.TrimSpace(logs) == "" {
+		return nil, fmt.Errorf("logs are empty")
+	}
+	contextNote := ""
+	if extra = strings.TrimSpace(extra); extra != "" {
+		contextNote = fmt.Sprintf("\nContext from the user: %s\n", extra)
+	}
+
+	response, err := llm.Chat(ctx, []Message{{
+		Type:    MessageTypeUser,
+		Content: fmt.Sprintf(analyzeLogsPrompt, contextNote, codeFence(logs), logs),
+	}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chat with LLM: %w", err)
+	}
+
+	confidence := -1.0
+	if c, ok := parseConfidence(response.Content); ok {
+		confidence = c
+	}
+	result := parseTextConclusion(response.Content, confidence)
+	result.Inconclusive = isInconclusiveRootCause(result.RootCause)
+	return result, nil
+}
+
+// codeFence returns a backtick fence longer than any backtick run in text, so
+// pasted logs containing ``` cannot close the block they are quoted in.
+func codeFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
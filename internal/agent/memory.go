@@ -1,20 +1,60 @@
 package agent
 
-import "sync"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
-// L1Memory implements a simple in-memory storage for conversation history
+// compactedEntryMaxLen truncates each tool output or thought kept in a compaction summary.
+const compactedEntryMaxLen = 200
+
+// compactionHeader starts every summary message written by Compact.
+const compactionHeader = "Summary of earlier diagnosis steps (compacted to fit the context window):"
+
+// L1Memory implements a simple in-memory storage for conversation history.
+// It optionally caps the history by message count and byte size; see SetLimits.
 type L1Memory struct {
 	mu       sync.RWMutex
 	messages []Message
+
+	maxMessages int
+	maxBytes    int
+	evicted     int
 }
 
-// NewL1Memory creates a new instance of L1Memory
-func NewL1Memory() *L1Memory {
+// NewL1Memory creates a new instance of L1Memory holding at most maxMessages
+// messages and maxBytes bytes of content. Zero leaves that dimension unbounded.
+func NewL1Memory(maxMessages, maxBytes int) *L1Memory {
 	return &L1Memory{
-		messages: make([]Message, 0),
+		messages:    make([]Message, 0),
+		maxMessages: maxMessages,
+		maxBytes:    maxBytes,
 	}
 }
 
+// SetLimits changes the caps and enforces them immediately. When the history
+// exceeds a cap, the oldest exchanges are evicted: an assistant message together
+// with the tool outputs answering it, or a lone user message. The leading
+// instructions (everything before the first assistant message, e.g. the skill
+// prompt, injected context and goal), system messages and the most recent
+// exchange are never evicted, so the history may still exceed a cap when those
+// alone are too large. Zero leaves a dimension unbounded.
+func (m *L1Memory) SetLimits(maxMessages, maxBytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxMessages = maxMessages
+	m.maxBytes = maxBytes
+	m.enforceLimitsLocked()
+}
+
+// Evicted returns how many messages have been dropped to stay within the limits.
+func (m *L1Memory) Evicted() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.evicted
+}
+
 // AddUserMessage adds a user message to the history
 func (m *L1Memory) AddUserMessage(content string) {
 	m.mu.Lock()
@@ -23,6 +63,7 @@ func (m *L1Memory) AddUserMessage(content string) {
 		Type:    MessageTypeUser,
 		Content: content,
 	})
+	m.enforceLimitsLocked()
 }
 
 // AddAssistantMessage adds an assistant message to the history
@@ -33,6 +74,7 @@ func (m *L1Memory) AddAssistantMessage(content string) {
 		Type:    MessageTypeAssistant,
 		Content: content,
 	})
+	m.enforceLimitsLocked()
 }
 
 // AddToolOutput adds a tool execution result to the history
@@ -44,6 +86,7 @@ func (m *L1Memory) AddToolOutput(toolCallID string, content string) {
 		Content:    content,
 		ToolCallID: toolCallID,
 	})
+	m.enforceLimitsLocked()
 }
 
 // AddAssistantToolCall adds an assistant message that requests a tool call
@@ -54,6 +97,7 @@ func (m *L1Memory) AddAssistantToolCall(toolCalls []ToolCall) {
 		Type:      MessageTypeAssistant,
 		ToolCalls: toolCalls,
 	})
+	m.enforceLimitsLocked()
 }
 
 // GetHistory returns the full conversation history
@@ -65,3 +109,166 @@ func (m *L1Memory) GetHistory() []Message {
 	copy(history, m.messages)
 	return history
 }
+
+// ApproxTokens estimates the history's token count at roughly four bytes per token.
+func (m *L1Memory) ApproxTokens() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return approxTokens(m.messages)
+}
+
+// Compact collapses the oldest exchanges into a single system message summarizing
+// them when the history exceeds maxTokens (approximately). The leading instructions
+// (including the goal) and the keepRecent most recent exchanges are kept verbatim.
+// Each summarized tool output and thought is truncated deterministically, so no LLM
+// call is needed. Returns how many messages were collapsed.
+func (m *L1Memory) Compact(maxTokens, keepRecent int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if maxTokens <= 0 || approxTokens(m.messages) <= maxTokens {
+		return 0
+	}
+
+	// Exchange boundaries after the leading instructions: each assistant or user
+	// message starts a new exchange; tool outputs and system messages belong to the
+	// exchange before them.
+	var starts []int
+	seenAssistant := false
+	for i, msg := range m.messages {
+		if msg.Type == MessageTypeAssistant {
+			seenAssistant = true
+		}
+		if seenAssistant && (msg.Type == MessageTypeAssistant || msg.Type == MessageTypeUser) {
+			starts = append(starts, i)
+		}
+	}
+	if keepRecent < 1 {
+		keepRecent = 1
+	}
+	if len(starts) <= keepRecent {
+		return 0
+	}
+	start, end := starts[0], starts[len(starts)-keepRecent]
+	// A summary left by an earlier compaction sits just before the first exchange;
+	// fold it into the new one.
+	if start > 0 && isCompactionSummary(m.messages[start-1]) {
+		start--
+	}
+
+	summary := Message{Type: MessageTypeSystem, Content: summarizeMessages(m.messages[start:end])}
+	collapsed := end - start
+	m.messages = append(m.messages[:start], append([]Message{summary}, m.messages[end:]...)...)
+	return collapsed
+}
+
+// summarizeMessages renders messages as a compact, deterministic digest.
+func summarizeMessages(messages []Message) string {
+	var b strings.Builder
+	b.WriteString(compactionHeader)
+	for _, msg := range messages {
+		switch {
+		case isCompactionSummary(msg):
+			b.WriteString(strings.TrimPrefix(msg.Content, compactionHeader))
+		case msg.Type == MessageTypeAssistant && len(msg.ToolCalls) > 0:
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(&b, "\n- called %s(%s)", tc.Function.Name, truncateEntry(tc.Function.Arguments))
+			}
+		case msg.Type == MessageTypeTool:
+			fmt.Fprintf(&b, "\n  -> %s", truncateEntry(msg.Content))
+		case msg.Type == MessageTypeAssistant:
+			fmt.Fprintf(&b, "\n- thought: %s", truncateEntry(msg.Content))
+		default:
+			fmt.Fprintf(&b, "\n- note: %s", truncateEntry(msg.Content))
+		}
+	}
+	return b.String()
+}
+
+func isCompactionSummary(msg Message) bool {
+	return msg.Type == MessageTypeSystem && strings.HasPrefix(msg.Content, compactionHeader)
+}
+
+// truncateEntry flattens s onto one line and truncates it to compactedEntryMaxLen.
+func truncateEntry(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > compactedEntryMaxLen {
+		return s[:compactedEntryMaxLen] + "..."
+	}
+	return s
+}
+
+// approxTokens estimates the token count of messages at roughly four bytes per token.
+func approxTokens(messages []Message) int {
+	size := 0
+	for _, msg := range messages {
+		size += messageSize(msg)
+	}
+	return size / 4
+}
+
+// enforceLimitsLocked evicts the oldest evictable exchanges until the history fits
+// the limits or nothing more can be evicted. The caller must hold m.mu.
+func (m *L1Memory) enforceLimitsLocked() {
+	for m.overLimitLocked() {
+		start, end := m.oldestEvictableLocked()
+		if start < 0 {
+			return
+		}
+		m.messages = append(m.messages[:start], m.messages[end:]...)
+		m.evicted += end - start
+	}
+}
+
+// overLimitLocked reports whether the history exceeds either limit.
+func (m *L1Memory) overLimitLocked() bool {
+	if m.maxMessages > 0 && len(m.messages) > m.maxMessages {
+		return true
+	}
+	if m.maxBytes <= 0 {
+		return false
+	}
+	size := 0
+	for _, msg := range m.messages {
+		size += messageSize(msg)
+	}
+	return size > m.maxBytes
+}
+
+// oldestEvictableLocked returns the [start, end) range of the oldest exchange that
+// may be evicted, or -1, -1 when none may be. Tool outputs are evicted together
+// with the assistant message that requested them so no output is left orphaned.
+func (m *L1Memory) oldestEvictableLocked() (int, int) {
+	start := -1
+	seenAssistant := false
+	for i, msg := range m.messages {
+		if msg.Type == MessageTypeAssistant {
+			seenAssistant = true
+		}
+		if seenAssistant && msg.Type != MessageTypeSystem {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+
+	end := start + 1
+	for end < len(m.messages) && m.messages[end].Type == MessageTypeTool {
+		end++
+	}
+	if end >= len(m.messages) {
+		// Never evict the most recent exchange.
+		return -1, -1
+	}
+	return start, end
+}
+
+// messageSize approximates a message's size as its content plus tool call payloads.
+func messageSize(msg Message) int {
+	size := len(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		size += len(tc.Function.Name) + len(tc.Function.Arguments)
+	}
+	return size
+}
@@ -3,6 +3,8 @@ package agent
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,16 +13,27 @@ import (
 )
 
 const (
-	l2StreamPrefix = "kubeminds:events:"
-	l2StreamMaxLen = 500 // max entries per namespace stream (approximate MAXLEN)
+	l2StreamPrefix      = "kubeminds:events:"
+	l2AlertStreamPrefix = "kubeminds:events-by-alert:"
+	l2StreamMaxLen      = 500 // max entries per namespace stream (approximate MAXLEN)
+	l2AlertStreamMaxLen = 100 // max entries per alertname index stream (approximate MAXLEN)
 )
 
 // RedisEventStore implements EventStore using Redis Streams.
-// Each namespace has its own stream at key "kubeminds:events:{namespace}".
+// Each namespace has its own stream at key "kubeminds:events:{namespace}", and each
+// alertname within it a secondary index stream at
+// "kubeminds:events-by-alert:{namespace}/{alertname}" holding copies of its events.
 // Entries older than eventTTL are automatically expired via Redis key TTL.
 type RedisEventStore struct {
 	client   *redis.Client
 	eventTTL time.Duration
+
+	// maxAge drops events whose LastSeen is older than this from GetRecentEvents.
+	// Zero means no bound beyond eventTTL.
+	maxAge time.Duration
+	// halfLife ranks GetRecentEvents results by count decayed with this half-life
+	// instead of plain newest-first. Zero keeps newest-first ordering.
+	halfLife time.Duration
 }
 
 // NewRedisEventStore returns a RedisEventStore backed by the provided redis.Client.
@@ -28,45 +41,79 @@ func NewRedisEventStore(client *redis.Client, eventTTL time.Duration) *RedisEven
 	return &RedisEventStore{client: client, eventTTL: eventTTL}
 }
 
-// AppendAlertEvent writes an alert event to the Redis Stream for the event's namespace.
-// The stream is capped at l2StreamMaxLen entries (approximate) and its TTL is refreshed.
+// WithRecency bounds how far back GetRecentEvents looks (maxAge) and ranks its results
+// by recency-weighted count (halfLife): an event's weight is its count halved for every
+// halfLife elapsed since it was last seen, so recent bursts outrank older, larger ones.
+// Zero values disable the bound and the weighting respectively.
+func (s *RedisEventStore) WithRecency(maxAge, halfLife time.Duration) *RedisEventStore {
+	s.maxAge = maxAge
+	s.halfLife = halfLife
+	return s
+}
+
+// l2AlertStreamKey returns the key of the alertname index stream. Namespaces cannot
+// contain "/", so the key is unambiguous.
+func l2AlertStreamKey(namespace, alertName string) string {
+	return l2AlertStreamPrefix + namespace + "/" + alertName
+}
+
+// AppendAlertEvent writes an alert event to the Redis Stream for the event's namespace
+// and to the index stream of its alertname. The streams are capped at l2StreamMaxLen
+// and l2AlertStreamMaxLen entries (approximate) and their TTLs are refreshed.
 func (s *RedisEventStore) AppendAlertEvent(ctx context.Context, event AlertEvent) error {
 	key := l2StreamPrefix + event.Namespace
+	values := map[string]interface{}{
+		"alert_name": event.AlertName,
+		"namespace":  event.Namespace,
+		"pod":        event.Pod,
+		"count":      strconv.Itoa(event.Count),
+		"first_seen": strconv.FormatInt(event.FirstSeen.Unix(), 10),
+		"last_seen":  strconv.FormatInt(event.LastSeen.Unix(), 10),
+	}
 
-	args := &redis.XAddArgs{
+	// Both streams and their TTLs are written in one MULTI/EXEC, so the per-alert
+	// stream never misses an event the namespace stream has, and no stream is left
+	// without a TTL.
+	pipe := s.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
 		Stream: key,
 		MaxLen: l2StreamMaxLen,
 		Approx: true,
-		Values: map[string]interface{}{
-			"alert_name": event.AlertName,
-			"namespace":  event.Namespace,
-			"pod":        event.Pod,
-			"count":      strconv.Itoa(event.Count),
-			"first_seen": strconv.FormatInt(event.FirstSeen.Unix(), 10),
-			"last_seen":  strconv.FormatInt(event.LastSeen.Unix(), 10),
-		},
+		Values: values,
+	})
+	// Refresh TTL so the stream expires if no new alerts arrive.
+	pipe.Expire(ctx, key, s.eventTTL)
+	if event.AlertName != "" {
+		alertKey := l2AlertStreamKey(event.Namespace, event.AlertName)
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: alertKey,
+			MaxLen: l2AlertStreamMaxLen,
+			Approx: true,
+			Values: values,
+		})
+		pipe.Expire(ctx, alertKey, s.eventTTL)
 	}
-
-	if err := s.client.XAdd(ctx, args).Err(); err != nil {
-		return fmt.Errorf("l2: xadd to stream %s: %w", key, err)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("l2: append event to stream %s: %w", key, err)
 	}
 
-	// Refresh TTL so the stream expires if no new alerts arrive.
-	// Errors are non-fatal; the stream will still be readable.
-	_ = s.client.Expire(ctx, key, s.eventTTL).Err()
-
 	return nil
 }
 
 // GetRecentEvents returns the most recent alert events for the given namespace from
-// the Redis Stream. If pod is non-empty, results are filtered to that pod only.
-// The returned slice is ordered newest-first.
-func (s *RedisEventStore) GetRecentEvents(ctx context.Context, namespace, pod string, limit int) ([]AlertEvent, error) {
+// the Redis Stream. If pod is non-empty, results are filtered to that pod only; if
+// alertName is non-empty, they are read from that alertname's index stream.
+// The returned slice is ordered newest-first, or by recency-weighted count when a
+// half-life is configured (see WithRecency).
+func (s *RedisEventStore) GetRecentEvents(ctx context.Context, namespace, pod, alertName string, limit int) ([]AlertEvent, error) {
 	key := l2StreamPrefix + namespace
+	if alertName != "" {
+		key = l2AlertStreamKey(namespace, alertName)
+	}
 
-	// Over-fetch to allow pod filtering without a second round-trip.
+	// Over-fetch to allow filtering and re-ranking without a second round-trip.
 	fetchN := int64(limit)
-	if pod != "" {
+	if pod != "" || s.maxAge > 0 || s.halfLife > 0 {
 		fetchN = int64(limit * 4)
 	}
 
@@ -75,20 +122,46 @@ func (s *RedisEventStore) GetRecentEvents(ctx context.Context, namespace, pod st
 		return nil, fmt.Errorf("l2: xrevrange on stream %s: %w", key, err)
 	}
 
+	now := time.Now()
 	var events []AlertEvent
 	for _, e := range entries {
 		ev := parseL2StreamEntry(e)
-		if pod == "" || ev.Pod == pod {
-			events = append(events, ev)
+		if pod != "" && ev.Pod != pod {
+			continue
+		}
+		if s.maxAge > 0 && !ev.LastSeen.IsZero() && now.Sub(ev.LastSeen) > s.maxAge {
+			continue
 		}
-		if len(events) >= limit {
+		events = append(events, ev)
+		// Weighted ranking needs every candidate before truncating.
+		if s.halfLife == 0 && len(events) >= limit {
 			break
 		}
 	}
 
+	if s.halfLife > 0 {
+		sort.SliceStable(events, func(i, j int) bool {
+			return recencyWeight(events[i], now, s.halfLife) > recencyWeight(events[j], now, s.halfLife)
+		})
+		if len(events) > limit {
+			events = events[:limit]
+		}
+	}
+
 	return events, nil
 }
 
+// recencyWeight is an event's count decayed by half for every halfLife since LastSeen.
+// Events without a count weigh as a single occurrence.
+func recencyWeight(ev AlertEvent, now time.Time, halfLife time.Duration) float64 {
+	count := float64(max(ev.Count, 1))
+	age := now.Sub(ev.LastSeen)
+	if ev.LastSeen.IsZero() || age < 0 {
+		age = 0
+	}
+	return count * math.Exp2(-float64(age)/float64(halfLife))
+}
+
 // parseL2StreamEntry converts a raw Redis XMessage into an AlertEvent.
 func parseL2StreamEntry(e redis.XMessage) AlertEvent {
 	str := func(k string) string {
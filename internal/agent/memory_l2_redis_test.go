@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedisEventStore returns a RedisEventStore backed by an in-process miniredis.
+func newTestRedisEventStore(t *testing.T, ttl time.Duration) (*RedisEventStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisEventStore(client, ttl), mr
+}
+
+func TestRedisEventStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestRedisEventStore(t, time.Hour)
+
+	first := time.Unix(1700000000, 0)
+	want := AlertEvent{
+		AlertName: "KubePodCrashLooping",
+		Namespace: "default",
+		Pod:       "app-1",
+		Count:     3,
+		FirstSeen: first,
+		LastSeen:  first.Add(5 * time.Minute),
+	}
+	if err := store.AppendAlertEvent(ctx, want); err != nil {
+		t.Fatalf("AppendAlertEvent() error = %v", err)
+	}
+
+	got, err := store.GetRecentEvents(ctx, "default", "", "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len(events) = %d, want 1", len(got))
+	}
+	if !got[0].FirstSeen.Equal(want.FirstSeen) || !got[0].LastSeen.Equal(want.LastSeen) {
+		t.Errorf("times = %v/%v, want %v/%v", got[0].FirstSeen, got[0].LastSeen, want.FirstSeen, want.LastSeen)
+	}
+	got[0].FirstSeen, got[0].LastSeen = want.FirstSeen, want.LastSeen
+	if got[0] != want {
+		t.Errorf("event = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestRedisEventStore_NewestFirstAndPodFilter(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestRedisEventStore(t, time.Hour)
+
+	for i, pod := range []string{"app-1", "app-2", "app-1", "app-2", "app-1"} {
+		if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "default", Pod: pod, Count: i}); err != nil {
+			t.Fatalf("AppendAlertEvent() error = %v", err)
+		}
+	}
+	// Another namespace lives in its own stream.
+	if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "other", Pod: "app-1", Count: 99}); err != nil {
+		t.Fatalf("AppendAlertEvent() error = %v", err)
+	}
+
+	all, err := store.GetRecentEvents(ctx, "default", "", "", 3)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if counts := eventCounts(all); fmt.Sprint(counts) != "[4 3 2]" {
+		t.Errorf("counts = %v, want newest-first [4 3 2]", counts)
+	}
+
+	pod1, err := store.GetRecentEvents(ctx, "default", "app-1", "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if counts := eventCounts(pod1); fmt.Sprint(counts) != "[4 2 0]" {
+		t.Errorf("app-1 counts = %v, want [4 2 0]", counts)
+	}
+}
+
+func TestRedisEventStore_AlertNameFilter(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestRedisEventStore(t, time.Hour)
+
+	for i, name := range []string{"KubePodCrashLooping", "KubePodOOMKilled", "KubePodCrashLooping", "KubePodOOMKilled"} {
+		if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: name, Namespace: "default", Pod: "app-1", Count: i}); err != nil {
+			t.Fatalf("AppendAlertEvent() error = %v", err)
+		}
+	}
+	// The same alert in another namespace is indexed separately.
+	if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "KubePodCrashLooping", Namespace: "other", Pod: "app-1", Count: 99}); err != nil {
+		t.Fatalf("AppendAlertEvent() error = %v", err)
+	}
+
+	all, err := store.GetRecentEvents(ctx, "default", "", "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if counts := eventCounts(all); fmt.Sprint(counts) != "[3 2 1 0]" {
+		t.Errorf("unfiltered counts = %v, want [3 2 1 0]", counts)
+	}
+
+	crash, err := store.GetRecentEvents(ctx, "default", "app-1", "KubePodCrashLooping", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if counts := eventCounts(crash); fmt.Sprint(counts) != "[2 0]" {
+		t.Errorf("KubePodCrashLooping counts = %v, want [2 0]", counts)
+	}
+	for _, ev := range crash {
+		if ev.AlertName != "KubePodCrashLooping" {
+			t.Errorf("event %+v is not a KubePodCrashLooping alert", ev)
+		}
+	}
+
+	if ttl := mr.TTL(l2AlertStreamKey("default", "KubePodCrashLooping")); ttl != time.Hour {
+		t.Errorf("index stream TTL = %v, want 1h", ttl)
+	}
+}
+
+func TestRedisEventStore_MaxLen(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestRedisEventStore(t, time.Hour)
+
+	for i := range l2StreamMaxLen + 20 {
+		if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "default", Count: i}); err != nil {
+			t.Fatalf("AppendAlertEvent() error = %v", err)
+		}
+	}
+
+	entries, err := mr.Stream(l2StreamPrefix + "default")
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if len(entries) > l2StreamMaxLen {
+		t.Errorf("stream length = %d, want <= %d", len(entries), l2StreamMaxLen)
+	}
+	latest, err := store.GetRecentEvents(ctx, "default", "", "", 1)
+	if err != nil || len(latest) != 1 || latest[0].Count != l2StreamMaxLen+19 {
+		t.Errorf("latest = %+v (err %v), want the last appended event", latest, err)
+	}
+}
+
+func TestRedisEventStore_TTLRefresh(t *testing.T) {
+	ctx := context.Background()
+	store, mr := newTestRedisEventStore(t, 10*time.Minute)
+	key := l2StreamPrefix + "default"
+
+	if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "default"}); err != nil {
+		t.Fatalf("AppendAlertEvent() error = %v", err)
+	}
+	if ttl := mr.TTL(key); ttl != 10*time.Minute {
+		t.Fatalf("TTL = %v, want 10m", ttl)
+	}
+
+	mr.FastForward(6 * time.Minute)
+	if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "default"}); err != nil {
+		t.Fatalf("AppendAlertEvent() error = %v", err)
+	}
+	if ttl := mr.TTL(key); ttl != 10*time.Minute {
+		t.Errorf("TTL after second append = %v, want refreshed to 10m", ttl)
+	}
+
+	// Without new alerts the stream expires.
+	mr.FastForward(11 * time.Minute)
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v after TTL, want none", events)
+	}
+}
+
+func TestRedisEventStore_RecentMaxAge(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestRedisEventStore(t, 24*time.Hour)
+	store.WithRecency(time.Hour, 0)
+
+	now := time.Now()
+	for i, lastSeen := range []time.Time{now.Add(-3 * time.Hour), now.Add(-30 * time.Minute), now.Add(-2 * time.Hour), now} {
+		if err := store.AppendAlertEvent(ctx, AlertEvent{AlertName: "A", Namespace: "default", Count: i, LastSeen: lastSeen}); err != nil {
+			t.Fatalf("AppendAlertEvent() error = %v", err)
+		}
+	}
+
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	if counts := eventCounts(events); fmt.Sprint(counts) != "[3 1]" {
+		t.Errorf("counts = %v, want only events within 1h, newest-first [3 1]", counts)
+	}
+}
+
+func TestRedisEventStore_RecencyWeighting(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestRedisEventStore(t, 24*time.Hour)
+	store.WithRecency(0, 30*time.Minute)
+
+	now := time.Now()
+	for _, ev := range []AlertEvent{
+		{Pod: "old-storm", Count: 40, LastSeen: now.Add(-3 * time.Hour)}, // 40 / 2^6 = 0.6
+		{Pod: "recent-burst", Count: 10, LastSeen: now.Add(-10 * time.Minute)},
+		{Pod: "single", Count: 1, LastSeen: now},
+		{Pod: "fresh-storm", Count: 5, LastSeen: now.Add(-time.Minute)},
+	} {
+		ev.AlertName, ev.Namespace = "A", "default"
+		if err := store.AppendAlertEvent(ctx, ev); err != nil {
+			t.Fatalf("AppendAlertEvent() error = %v", err)
+		}
+	}
+
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 3)
+	if err != nil {
+		t.Fatalf("GetRecentEvents() error = %v", err)
+	}
+	var pods []string
+	for _, e := range events {
+		pods = append(pods, e.Pod)
+	}
+	if fmt.Sprint(pods) != "[recent-burst fresh-storm single]" {
+		t.Errorf("pods = %v, want [recent-burst fresh-storm single] ranked by decayed count", pods)
+	}
+}
+
+func TestParseL2StreamEntry(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestRedisEventStore(t, time.Hour)
+
+	// Write with the real client so the values come back the way go-redis decodes them.
+	err := store.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: l2StreamPrefix + "default",
+		Values: map[string]interface{}{
+			"alert_name": "KubePodNotReady",
+			"pod":        "app-1",
+			"count":      "not-a-number",
+			"last_seen":  "1700000000",
+		},
+	}).Err()
+	if err != nil {
+		t.Fatalf("XAdd() error = %v", err)
+	}
+	msgs, err := store.client.XRange(ctx, l2StreamPrefix+"default", "-", "+").Result()
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("XRange() = %v, %v", msgs, err)
+	}
+
+	ev := parseL2StreamEntry(msgs[0])
+	if ev.AlertName != "KubePodNotReady" || ev.Pod != "app-1" {
+		t.Errorf("event = %+v, want alert and pod parsed", ev)
+	}
+	if ev.Namespace != "" || ev.Count != 0 || !ev.FirstSeen.IsZero() {
+		t.Errorf("missing or malformed fields = %+v, want zero values", ev)
+	}
+	if !ev.LastSeen.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("LastSeen = %v, want unix 1700000000", ev.LastSeen)
+	}
+}
+
+func eventCounts(events []AlertEvent) []int {
+	counts := make([]int, len(events))
+	for i, e := range events {
+		counts[i] = e.Count
+	}
+	return counts
+}
@@ -22,7 +22,7 @@ func (m *mockEventStore) AppendAlertEvent(_ context.Context, event AlertEvent) e
 	return nil
 }
 
-func (m *mockEventStore) GetRecentEvents(_ context.Context, namespace, pod string, limit int) ([]AlertEvent, error) {
+func (m *mockEventStore) GetRecentEvents(_ context.Context, namespace, pod, alertName string, limit int) ([]AlertEvent, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -34,6 +34,9 @@ func (m *mockEventStore) GetRecentEvents(_ context.Context, namespace, pod strin
 		if pod != "" && e.Pod != pod {
 			continue
 		}
+		if alertName != "" && e.AlertName != alertName {
+			continue
+		}
 		out = append(out, e)
 		if len(out) >= limit {
 			break
@@ -66,7 +69,7 @@ func TestMockEventStore_AppendAndGet(t *testing.T) {
 		t.Fatalf("AppendAlertEvent: %v", err)
 	}
 
-	events, err := store.GetRecentEvents(ctx, "default", "", 10)
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 10)
 	if err != nil {
 		t.Fatalf("GetRecentEvents: %v", err)
 	}
@@ -75,6 +78,23 @@ func TestMockEventStore_AppendAndGet(t *testing.T) {
 	}
 }
 
+// TestMockEventStore_AlertNameFilter validates that alertname filtering narrows results.
+func TestMockEventStore_AlertNameFilter(t *testing.T) {
+	store := &mockEventStore{}
+	ctx := context.Background()
+
+	_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-a", 3))
+	_ = store.AppendAlertEvent(ctx, sampleEvent("CrashLoopBackOff", "default", "pod-a", 1))
+
+	events, err := store.GetRecentEvents(ctx, "default", "", "OOMKilled", 10)
+	if err != nil {
+		t.Fatalf("GetRecentEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].AlertName != "OOMKilled" {
+		t.Errorf("events = %+v, want only the OOMKilled event", events)
+	}
+}
+
 // TestMockEventStore_PodFilter validates that pod filtering works as expected.
 func TestMockEventStore_PodFilter(t *testing.T) {
 	store := &mockEventStore{}
@@ -83,7 +103,7 @@ func TestMockEventStore_PodFilter(t *testing.T) {
 	_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-a", 3))
 	_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-b", 1))
 
-	events, err := store.GetRecentEvents(ctx, "default", "pod-a", 10)
+	events, err := store.GetRecentEvents(ctx, "default", "pod-a", "", 10)
 	if err != nil {
 		t.Fatalf("GetRecentEvents: %v", err)
 	}
@@ -104,7 +124,7 @@ func TestMockEventStore_Limit(t *testing.T) {
 		_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-a", i+1))
 	}
 
-	events, err := store.GetRecentEvents(ctx, "default", "", 3)
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 3)
 	if err != nil {
 		t.Fatalf("GetRecentEvents: %v", err)
 	}
@@ -121,7 +141,7 @@ func TestMockEventStore_NamespaceIsolation(t *testing.T) {
 	_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-a", 1))
 	_ = store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "kube-system", "pod-b", 2))
 
-	events, err := store.GetRecentEvents(ctx, "default", "", 10)
+	events, err := store.GetRecentEvents(ctx, "default", "", "", 10)
 	if err != nil {
 		t.Fatalf("GetRecentEvents: %v", err)
 	}
@@ -138,7 +158,7 @@ func TestMockEventStore_Error(t *testing.T) {
 	if err := store.AppendAlertEvent(ctx, sampleEvent("OOMKilled", "default", "pod-a", 1)); err == nil {
 		t.Fatal("expected error, got nil")
 	}
-	if _, err := store.GetRecentEvents(ctx, "default", "", 10); err == nil {
+	if _, err := store.GetRecentEvents(ctx, "default", "", "", 10); err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
@@ -171,6 +191,36 @@ func TestFormatAlertEvents(t *testing.T) {
 	})
 }
 
+func TestFormatAlertAnnotations(t *testing.T) {
+	if got := FormatAlertAnnotations(map[string]string{"summary": "  "}); got != "" {
+		t.Errorf("expected empty string for blank annotations, got %q", got)
+	}
+
+	got := FormatAlertAnnotations(map[string]string{
+		"runbook_url": "https://runbooks.example.com/oom",
+		"dashboard":   "https://grafana.example.com/d/pods",
+		"description": "Container app was OOMKilled",
+		"summary":     "Pod is crash looping",
+	})
+	want := []string{
+		"summary: Pod is crash looping",
+		"description: Container app was OOMKilled",
+		"runbook_url: https://runbooks.example.com/oom",
+		"dashboard: https://grafana.example.com/d/pods",
+	}
+	last := -1
+	for _, line := range want {
+		i := strings.Index(got, line)
+		if i < 0 {
+			t.Fatalf("expected %q in output, got: %s", line, got)
+		}
+		if i < last {
+			t.Errorf("%q out of order in: %s", line, got)
+		}
+		last = i
+	}
+}
+
 // TestInjectContext_L2Integration verifies that InjectContext adds context to agent memory.
 func TestInjectContext_L2Integration(t *testing.T) {
 	mockLLM := &MockLLMProvider{
@@ -187,7 +237,7 @@ func TestInjectContext_L2Integration(t *testing.T) {
 	store := &mockEventStore{}
 	_ = store.AppendAlertEvent(context.Background(), sampleEvent("OOMKilled", "default", "pod-a", 2))
 
-	events, _ := store.GetRecentEvents(context.Background(), "default", "pod-a", 10)
+	events, _ := store.GetRecentEvents(context.Background(), "default", "pod-a", "", 10)
 	if formatted := FormatAlertEvents(events); formatted != "" {
 		a.InjectContext(formatted)
 	}
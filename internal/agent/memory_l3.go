@@ -2,10 +2,12 @@ package agent
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	pgvector "github.com/pgvector/pgvector-go"
@@ -27,12 +29,24 @@ func NewPGKnowledgeBase(pool *pgxpool.Pool, dim int) *PGKnowledgeBase {
 }
 
 // NewPGKnowledgeBaseFromDSN creates a pgxpool with pgvector type registration
-// and returns a PGKnowledgeBase backed by it.
-func NewPGKnowledgeBaseFromDSN(ctx context.Context, dsn string, dim int) (*PGKnowledgeBase, error) {
+// and returns a PGKnowledgeBase backed by it. rootCAs, when non-nil, replaces the
+// trusted CAs of every TLS connection the DSN's sslmode enables.
+func NewPGKnowledgeBaseFromDSN(ctx context.Context, dsn string, dim int, rootCAs *x509.CertPool) (*PGKnowledgeBase, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("l3: failed to parse dsn: %w", err)
 	}
+	if rootCAs != nil {
+		// sslmode=prefer/allow parse into TLS plus fallback configs; set the pool on each.
+		if tlsCfg := cfg.ConnConfig.TLSConfig; tlsCfg != nil {
+			tlsCfg.RootCAs = rootCAs
+		}
+		for _, fb := range cfg.ConnConfig.Fallbacks {
+			if fb.TLSConfig != nil {
+				fb.TLSConfig.RootCAs = rootCAs
+			}
+		}
+	}
 
 	// Register the pgvector type codec for every new connection in the pool.
 	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
@@ -70,7 +84,22 @@ func (kb *PGKnowledgeBase) InitSchema(ctx context.Context) error {
 		CREATE INDEX IF NOT EXISTS diagnosis_findings_embedding_idx
 			ON diagnosis_findings USING ivfflat (embedding vector_cosine_ops)
 			WITH (lists = 100);
-	`, kb.dim)
+
+		CREATE TABLE IF NOT EXISTS diagnosis_evidence (
+			id          UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+			finding_id  UUID NOT NULL REFERENCES diagnosis_findings(id) ON DELETE CASCADE,
+			content     TEXT NOT NULL,
+			embedding   vector(%d),
+			created_at  TIMESTAMPTZ DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS diagnosis_evidence_finding_idx
+			ON diagnosis_evidence (finding_id);
+
+		CREATE INDEX IF NOT EXISTS diagnosis_evidence_embedding_idx
+			ON diagnosis_evidence USING ivfflat (embedding vector_cosine_ops)
+			WITH (lists = 100);
+	`, kb.dim, kb.dim)
 
 	if _, err := kb.pool.Exec(ctx, ddl); err != nil {
 		return fmt.Errorf("l3: failed to init schema: %w", err)
@@ -79,24 +108,62 @@ func (kb *PGKnowledgeBase) InitSchema(ctx context.Context) error {
 }
 
 // SaveDiagnosis inserts a completed diagnosis and its embedding into the knowledge base.
-func (kb *PGKnowledgeBase) SaveDiagnosis(ctx context.Context, finding KnowledgeFinding, embedding []float32) error {
-	var vec *pgvector.Vector
-	if len(embedding) > 0 {
-		v := pgvector.NewVector(embedding)
-		vec = &v
+// When evidence vectors are given, they are stored in diagnosis_evidence linked to the
+// new row, in the same transaction.
+func (kb *PGKnowledgeBase) SaveDiagnosis(ctx context.Context, finding KnowledgeFinding, embedding []float32, evidence ...EvidenceVector) error {
+	if len(evidence) == 0 {
+		_, err := kb.pool.Exec(ctx, `
+			INSERT INTO diagnosis_findings (alert_name, namespace, root_cause, suggestion, embedding)
+			VALUES ($1, $2, $3, $4, $5)
+		`, finding.AlertName, finding.Namespace, finding.RootCause, finding.Suggestion, toPGVector(embedding))
+		if err != nil {
+			return fmt.Errorf("l3: failed to save diagnosis: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := kb.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("l3: failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	_, err := kb.pool.Exec(ctx, `
+	var findingID string
+	err = tx.QueryRow(ctx, `
 		INSERT INTO diagnosis_findings (alert_name, namespace, root_cause, suggestion, embedding)
 		VALUES ($1, $2, $3, $4, $5)
-	`, finding.AlertName, finding.Namespace, finding.RootCause, finding.Suggestion, vec)
+		RETURNING id
+	`, finding.AlertName, finding.Namespace, finding.RootCause, finding.Suggestion, toPGVector(embedding)).Scan(&findingID)
 	if err != nil {
 		return fmt.Errorf("l3: failed to save diagnosis: %w", err)
 	}
+
+	for _, ev := range evidence {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO diagnosis_evidence (finding_id, content, embedding)
+			VALUES ($1, $2, $3)
+		`, findingID, ev.Text, toPGVector(ev.Embedding)); err != nil {
+			return fmt.Errorf("l3: failed to save diagnosis evidence: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("l3: failed to commit diagnosis: %w", err)
+	}
 	return nil
 }
 
+// toPGVector converts an embedding to a nullable pgvector value.
+func toPGVector(embedding []float32) *pgvector.Vector {
+	if len(embedding) == 0 {
+		return nil
+	}
+	v := pgvector.NewVector(embedding)
+	return &v
+}
+
 // SearchSimilar returns the top-limit diagnoses closest to queryEmbedding by cosine distance.
+// A diagnosis is ranked by its closest vector: its own embedding or any linked evidence.
 // Returns an empty slice (no error) when queryEmbedding is nil or the table is empty.
 func (kb *PGKnowledgeBase) SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int) ([]KnowledgeFinding, error) {
 	if len(queryEmbedding) == 0 {
@@ -106,9 +173,19 @@ func (kb *PGKnowledgeBase) SearchSimilar(ctx context.Context, queryEmbedding []f
 	vec := pgvector.NewVector(queryEmbedding)
 
 	rows, err := kb.pool.Query(ctx, `
-		SELECT id, alert_name, namespace, root_cause, suggestion, created_at
-		FROM diagnosis_findings
-		ORDER BY embedding <=> $1
+		SELECT f.id, f.alert_name, f.namespace, f.root_cause, f.suggestion, f.created_at
+		FROM diagnosis_findings f
+		JOIN (
+			SELECT id AS finding_id, embedding <=> $1 AS distance
+			FROM diagnosis_findings
+			WHERE embedding IS NOT NULL
+			UNION ALL
+			SELECT finding_id, embedding <=> $1 AS distance
+			FROM diagnosis_evidence
+			WHERE embedding IS NOT NULL
+		) m ON m.finding_id = f.id
+		GROUP BY f.id
+		ORDER BY MIN(m.distance)
 		LIMIT $2
 	`, vec, limit)
 	if err != nil {
@@ -131,6 +208,22 @@ func (kb *PGKnowledgeBase) SearchSimilar(ctx context.Context, queryEmbedding []f
 	return findings, nil
 }
 
+// DeleteDiagnosis removes the diagnosis with the given ID; its evidence rows are
+// removed by the ON DELETE CASCADE foreign key.
+func (kb *PGKnowledgeBase) DeleteDiagnosis(ctx context.Context, id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return ErrKnowledgeNotFound
+	}
+	tag, err := kb.pool.Exec(ctx, `DELETE FROM diagnosis_findings WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("l3: failed to delete diagnosis: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKnowledgeNotFound
+	}
+	return nil
+}
+
 // FormatHistoricalFindings formats a list of historical diagnoses as a human-readable
 // string suitable for injection into the agent's LLM context before the ReAct loop.
 func FormatHistoricalFindings(findings []KnowledgeFinding) string {
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -17,17 +18,18 @@ type mockKnowledgeBase struct {
 type storedFinding struct {
 	finding   KnowledgeFinding
 	embedding []float32
+	evidence  []EvidenceVector
 }
 
 func (m *mockKnowledgeBase) InitSchema(_ context.Context) error {
 	return m.err
 }
 
-func (m *mockKnowledgeBase) SaveDiagnosis(_ context.Context, finding KnowledgeFinding, embedding []float32) error {
+func (m *mockKnowledgeBase) SaveDiagnosis(_ context.Context, finding KnowledgeFinding, embedding []float32, evidence ...EvidenceVector) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.findings = append(m.findings, storedFinding{finding: finding, embedding: embedding})
+	m.findings = append(m.findings, storedFinding{finding: finding, embedding: embedding, evidence: evidence})
 	return nil
 }
 
@@ -48,6 +50,19 @@ func (m *mockKnowledgeBase) SearchSimilar(_ context.Context, queryEmbedding []fl
 	return out, nil
 }
 
+func (m *mockKnowledgeBase) DeleteDiagnosis(_ context.Context, id string) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, s := range m.findings {
+		if s.finding.ID == id {
+			m.findings = append(m.findings[:i], m.findings[i+1:]...)
+			return nil
+		}
+	}
+	return ErrKnowledgeNotFound
+}
+
 // mockEmbeddingProvider returns a fixed-length zero vector for any input.
 type mockEmbeddingProvider struct {
 	dim int
@@ -222,3 +237,34 @@ func TestInjectContext_L3Integration(t *testing.T) {
 		t.Error("expected L3 historical context in agent memory history")
 	}
 }
+
+// TestMockKnowledgeBase_Delete validates that a deleted diagnosis no longer appears
+// in search results and that unknown IDs report ErrKnowledgeNotFound.
+func TestMockKnowledgeBase_Delete(t *testing.T) {
+	kb := &mockKnowledgeBase{}
+	ctx := context.Background()
+	emb := []float32{0, 0, 0, 0}
+
+	keep := sampleFinding("OOMKilled", "default", "memory limit", "raise limit")
+	keep.ID = "keep"
+	drop := sampleFinding("CrashLoop", "default", "leaked secret in logs", "rotate")
+	drop.ID = "drop"
+	_ = kb.SaveDiagnosis(ctx, keep, emb)
+	_ = kb.SaveDiagnosis(ctx, drop, emb)
+
+	if err := kb.DeleteDiagnosis(ctx, "drop"); err != nil {
+		t.Fatalf("DeleteDiagnosis: %v", err)
+	}
+
+	results, err := kb.SearchSimilar(ctx, emb, 10)
+	if err != nil {
+		t.Fatalf("SearchSimilar: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "keep" {
+		t.Errorf("results after delete = %+v, want only %q", results, "keep")
+	}
+
+	if err := kb.DeleteDiagnosis(ctx, "drop"); !errors.Is(err, ErrKnowledgeNotFound) {
+		t.Errorf("second DeleteDiagnosis error = %v, want ErrKnowledgeNotFound", err)
+	}
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+// addToolExchange appends one assistant tool call and its output.
+func addToolExchange(m *L1Memory, id, output string) {
+	m.AddAssistantToolCall([]ToolCall{{ID: id, Function: FunctionCall{Name: "get_pod_logs", Arguments: "{}"}}})
+	m.AddToolOutput(id, output)
+}
+
+func TestL1Memory_MaxMessages(t *testing.T) {
+	m := NewL1Memory(6, 0)
+	m.AddUserMessage("SYSTEM INSTRUCTION: skill prompt")
+	m.AddUserMessage("Diagnosis Goal: app-1")
+	for _, id := range []string{"call_1", "call_2", "call_3", "call_4"} {
+		addToolExchange(m, id, "output "+id)
+	}
+
+	history := m.GetHistory()
+	if len(history) != 6 {
+		t.Fatalf("len(history) = %d, want 6", len(history))
+	}
+	// Instructions are kept; the two oldest exchanges are evicted as whole pairs.
+	if history[0].Content != "SYSTEM INSTRUCTION: skill prompt" || history[1].Content != "Diagnosis Goal: app-1" {
+		t.Errorf("leading instructions evicted: %+v", history[:2])
+	}
+	if history[2].ToolCalls[0].ID != "call_3" || history[3].ToolCallID != "call_3" {
+		t.Errorf("oldest kept exchange = %+v, want call_3", history[2:4])
+	}
+	if got := m.Evicted(); got != 4 {
+		t.Errorf("Evicted() = %d, want 4", got)
+	}
+}
+
+func TestL1Memory_MaxBytes(t *testing.T) {
+	m := NewL1Memory(0, 2500)
+	m.AddUserMessage("Diagnosis Goal: app-1")
+	big := strings.Repeat("x", 1000)
+	for _, id := range []string{"call_1", "call_2", "call_3"} {
+		addToolExchange(m, id, big)
+	}
+
+	size := 0
+	for _, msg := range m.GetHistory() {
+		size += messageSize(msg)
+	}
+	if size > 2500 {
+		t.Errorf("history size = %d bytes, want <= 2500", size)
+	}
+	history := m.GetHistory()
+	if last := history[len(history)-1]; last.ToolCallID != "call_3" {
+		t.Errorf("latest exchange evicted, last message = %+v", last)
+	}
+	for _, msg := range history {
+		if msg.Type == MessageTypeTool && msg.ToolCallID == "call_1" {
+			t.Error("oldest tool output still present")
+		}
+	}
+}
+
+func TestL1Memory_KeepsLatestExchange(t *testing.T) {
+	m := NewL1Memory(0, 10)
+	m.AddUserMessage("Diagnosis Goal: app-1")
+	addToolExchange(m, "call_1", strings.Repeat("x", 100))
+
+	// Over the byte cap, but only the instructions and the latest exchange remain.
+	if got := len(m.GetHistory()); got != 3 {
+		t.Errorf("len(history) = %d, want 3", got)
+	}
+}
+
+func TestL1Memory_Unbounded(t *testing.T) {
+	m := NewL1Memory(0, 0)
+	for range 50 {
+		addToolExchange(m, "call", "output")
+	}
+	if got := len(m.GetHistory()); got != 100 {
+		t.Errorf("len(history) = %d, want 100", got)
+	}
+}
+
+func TestL1Memory_Compact(t *testing.T) {
+	m := NewL1Memory(0, 0)
+	m.AddUserMessage("SYSTEM INSTRUCTION: skill prompt")
+	m.AddUserMessage("Diagnosis Goal: app-1")
+	for _, id := range []string{"call_1", "call_2", "call_3", "call_4", "call_5"} {
+		addToolExchange(m, id, id+" "+strings.Repeat("x", 4000))
+	}
+
+	if n := m.Compact(100000, 2); n != 0 {
+		t.Fatalf("Compact() under budget collapsed %d messages, want 0", n)
+	}
+	if n := m.Compact(2500, 2); n != 6 {
+		t.Fatalf("Compact() collapsed %d messages, want the 3 oldest exchanges (6)", n)
+	}
+
+	history := m.GetHistory()
+	if len(history) != 7 {
+		t.Fatalf("len(history) = %d, want 2 instructions + summary + 2 exchanges", len(history))
+	}
+	if history[1].Content != "Diagnosis Goal: app-1" {
+		t.Errorf("goal not preserved: %+v", history[1])
+	}
+	summary := history[2]
+	if summary.Type != MessageTypeSystem || !strings.Contains(summary.Content, "called get_pod_logs({})") || !strings.Contains(summary.Content, "-> call_1 xxx") {
+		t.Errorf("summary = %+v, want a system digest of the old tool calls", summary)
+	}
+	if len(summary.Content) > 1000 {
+		t.Errorf("summary is %d bytes, want tool outputs truncated", len(summary.Content))
+	}
+	if history[3].ToolCalls[0].ID != "call_4" || history[6].ToolCallID != "call_5" {
+		t.Errorf("recent exchanges not kept verbatim: %+v", history[3:])
+	}
+
+	// A later compaction folds the earlier summary into the new one.
+	addToolExchange(m, "call_6", "call_6 "+strings.Repeat("y", 4000))
+	if n := m.Compact(2500, 2); n != 3 {
+		t.Fatalf("second Compact() collapsed %d messages, want summary + 1 exchange (3)", n)
+	}
+	history = m.GetHistory()
+	if !strings.Contains(history[2].Content, "-> call_1") || !strings.Contains(history[2].Content, "-> call_4") {
+		t.Errorf("merged summary = %q, want both old and newly compacted steps", history[2].Content)
+	}
+	if strings.Count(history[2].Content, compactionHeader) != 1 {
+		t.Errorf("merged summary repeats its header: %q", history[2].Content)
+	}
+}
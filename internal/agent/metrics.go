@@ -0,0 +1,10 @@
+package agent
+
+import "kubeminds/internal/metrics"
+
+// AgentStepDuration measures one ReAct step: the LLM call plus any tool executions.
+var AgentStepDuration = metrics.NewLatencyHistogramVec(
+	"kubeminds_agent_step_duration_seconds",
+	"Duration of one agent step (LLM call plus tool executions), by skill.",
+	[]string{"skill"},
+)
@@ -2,16 +2,22 @@ package agent
 
 import (
 	"context"
+	"sync"
 )
 
-// MockLLMProvider is a mock implementation of LLMProvider for testing
+// MockLLMProvider is a mock implementation of LLMProvider for testing.
+// Chat is safe for concurrent use: each call claims the next call index under a lock,
+// so concurrent callers receive the configured responses deterministically, each once.
 type MockLLMProvider struct {
 	// Responses is a map where key is the step number (0-indexed) and value is the message to return
 	Responses map[int]*Message
 	// ErrorTrigger is a map where key is the step number and value is the error to return
 	ErrorTrigger map[int]error
-	// CallCount tracks how many times Chat has been called
+	// CallCount tracks how many times Chat has been called. Use Calls while Chat may
+	// still be running in other goroutines.
 	CallCount int
+
+	mu sync.Mutex
 }
 
 func NewMockLLMProvider() *MockLLMProvider {
@@ -21,9 +27,32 @@ func NewMockLLMProvider() *MockLLMProvider {
 	}
 }
 
+// SetResponse sets the message returned by the step-th call. Safe to call while Chat is in use.
+func (m *MockLLMProvider) SetResponse(step int, msg *Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Responses[step] = msg
+}
+
+// SetError sets the error returned by the step-th call. Safe to call while Chat is in use.
+func (m *MockLLMProvider) SetError(step int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorTrigger[step] = err
+}
+
+// Calls returns how many times Chat has been called.
+func (m *MockLLMProvider) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.CallCount
+}
+
 func (m *MockLLMProvider) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
 	// Simple Chat Mock: return configured response for the current call count
 	// We use CallCount to map to steps in the test.
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	currentStep := m.CallCount
 	m.CallCount++
 
@@ -48,7 +77,11 @@ type MockTool struct {
 	DescVal        string
 	SafetyLevelVal SafetyLevel
 	ExecuteFunc    func(ctx context.Context, args string) (string, error)
+	// ExecutionCount tracks how many times Execute has been called. Use Executions
+	// while Execute may still be running in other goroutines.
 	ExecutionCount int
+
+	mu sync.Mutex
 }
 
 func (m *MockTool) Name() string {
@@ -59,8 +92,17 @@ func (m *MockTool) Description() string {
 	return m.DescVal
 }
 
+// Executions returns how many times Execute has been called.
+func (m *MockTool) Executions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ExecutionCount
+}
+
 func (m *MockTool) Execute(ctx context.Context, args string) (string, error) {
+	m.mu.Lock()
 	m.ExecutionCount++
+	m.mu.Unlock()
 	if m.ExecuteFunc != nil {
 		return m.ExecuteFunc(ctx, args)
 	}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestMockLLMProvider_ConcurrentChat exercises the mock from many goroutines; run
+// with -race. Each configured response must be handed out exactly once.
+func TestMockLLMProvider_ConcurrentChat(t *testing.T) {
+	const callers = 50
+	mock := NewMockLLMProvider()
+	for i := 0; i < callers; i++ {
+		mock.SetResponse(i, &Message{Type: MessageTypeAssistant, Content: fmt.Sprintf("response-%d", i)})
+	}
+	mock.SetError(callers, errors.New("boom"))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[string]int)
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := mock.Chat(context.Background(), nil, nil)
+			if err != nil {
+				t.Errorf("Chat(): %v", err)
+				return
+			}
+			_ = mock.Calls()
+			mu.Lock()
+			seen[msg.Content]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if got := mock.Calls(); got != callers {
+		t.Errorf("Calls() = %d, want %d", got, callers)
+	}
+	for i := 0; i < callers; i++ {
+		if n := seen[fmt.Sprintf("response-%d", i)]; n != 1 {
+			t.Errorf("response-%d returned %d times, want 1", i, n)
+		}
+	}
+	if _, err := mock.Chat(context.Background(), nil, nil); err == nil {
+		t.Error("expected the configured error on the next call")
+	}
+}
+
+func TestMockTool_ConcurrentExecute(t *testing.T) {
+	const callers = 20
+	tool := &MockTool{NameVal: "get_logs"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tool.Execute(context.Background(), "{}"); err != nil {
+				t.Errorf("Execute(): %v", err)
+			}
+			_ = tool.Executions()
+		}()
+	}
+	wg.Wait()
+
+	if got := tool.Executions(); got != callers {
+		t.Errorf("Executions() = %d, want %d", got, callers)
+	}
+}
@@ -13,6 +13,28 @@ type MemoryPolicy struct {
 	RelevantMetrics []string `yaml:"relevant_metrics"`
 }
 
+// SkillMode controls whether the agent may act on the cluster or only advise.
+type SkillMode string
+
+const (
+	// SkillModeAct (default) lets the agent call write tools, subject to approval.
+	SkillModeAct SkillMode = "act"
+	// SkillModeAdvise restricts the agent to read-only tools and asks it to conclude
+	// with a step-by-step runbook for a human to execute.
+	SkillModeAdvise SkillMode = "advise"
+)
+
+// SkillOutputFormat selects how the agent is asked to format its conclusion.
+type SkillOutputFormat string
+
+const (
+	// SkillOutputFormatText (default) asks for "Root Cause:" / "Suggestion:" lines.
+	SkillOutputFormatText SkillOutputFormat = "text"
+	// SkillOutputFormatJSON asks for a single JSON object with root_cause, suggestion
+	// and confidence, falling back to the text parser when the reply is not valid JSON.
+	SkillOutputFormatJSON SkillOutputFormat = "json"
+)
+
 // Skill defines a specific diagnosis capability (e.g., OOM Diagnosis, CrashLoopBackOff Diagnosis)
 type Skill struct {
 	// Name of the skill (e.g., "oom_diagnosis")
@@ -30,6 +52,22 @@ type Skill struct {
 	AllowedTools []string `yaml:"allowed_tools,omitempty"`
 	// MemoryPolicy for this skill
 	MemoryPolicy *MemoryPolicy `yaml:"memory_policy,omitempty"`
+	// Mode is "act" (default) or "advise". In advise mode only read-only tools are
+	// offered and the conclusion is a runbook of concrete commands.
+	Mode SkillMode `yaml:"mode,omitempty"`
+	// OutputSchema lists structured fields (name -> description) the agent must
+	// include in its conclusion. They are parsed by the skill's FindingExtractor
+	// into Result.Details.
+	OutputSchema map[string]string `yaml:"output_schema,omitempty"`
+	// RequireEvidence refuses a conclusion until at least one read-only tool has run
+	// successfully, nudging the agent to gather evidence first.
+	RequireEvidence bool `yaml:"require_evidence,omitempty"`
+	// LoopDetectionWindow is how many consecutive identical tool calls (same tool and
+	// arguments) abort the run as a loop. Unset uses DefaultLoopDetectionWindow; raise
+	// it for skills that poll, e.g. while waiting for a rollout. 0 disables detection.
+	LoopDetectionWindow *int `yaml:"loop_detection_window,omitempty"`
+	// OutputFormat is "text" (default) or "json"; see SkillOutputFormat.
+	OutputFormat SkillOutputFormat `yaml:"output_format,omitempty"`
 }
 
 // MergeWith merges a domain skill into a base skill
@@ -55,6 +93,31 @@ func (base *Skill) MergeWith(domain *Skill) *Skill {
 		merged.MemoryPolicy = domain.MemoryPolicy
 	}
 
+	// Override Mode
+	if domain.Mode != "" {
+		merged.Mode = domain.Mode
+	}
+
+	// Override Output Schema
+	if len(domain.OutputSchema) > 0 {
+		merged.OutputSchema = domain.OutputSchema
+	}
+
+	// Override Output Format
+	if domain.OutputFormat != "" {
+		merged.OutputFormat = domain.OutputFormat
+	}
+
+	// Override Loop Detection Window
+	if domain.LoopDetectionWindow != nil {
+		merged.LoopDetectionWindow = domain.LoopDetectionWindow
+	}
+
+	// Evidence gate: enabled when either skill asks for it
+	if domain.RequireEvidence {
+		merged.RequireEvidence = true
+	}
+
 	return &merged
 }
 
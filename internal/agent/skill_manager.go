@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 
@@ -52,12 +53,19 @@ func (sm *SkillManager) Register(skill Skill) {
 
 // Match selects the most appropriate skill for a given task
 func (sm *SkillManager) Match(task *v1alpha1.DiagnosisTask) Skill {
+	skill, _ := sm.MatchWithReason(task)
+	return skill
+}
+
+// MatchWithReason selects the skill for a task like Match, and also explains why
+// it was chosen (e.g. which trigger matched, or which fallback applied).
+func (sm *SkillManager) MatchWithReason(task *v1alpha1.DiagnosisTask) (Skill, string) {
 	// 1. Iterate over all skills and check their triggers
 	for _, skill := range sm.skills {
 		for _, trigger := range skill.Triggers {
 			if sm.matchesTrigger(task, trigger) {
 				sm.logger.Info("Matched skill via trigger", "skill", skill.Name, "trigger", trigger)
-				return skill
+				return skill, fmt.Sprintf("matched trigger alert_name=%q labels=%v", trigger.AlertName, trigger.Labels)
 			}
 		}
 	}
@@ -67,18 +75,18 @@ func (sm *SkillManager) Match(task *v1alpha1.DiagnosisTask) Skill {
 		labels := task.Spec.AlertContext.Labels
 		if labels["reason"] == "OOMKilled" || labels["alertname"] == "KubeContainerOOMKilled" {
 			if skill, ok := sm.GetSkillByName("oom_diagnosis"); ok {
-				return skill
+				return skill, "legacy OOMKilled label fallback"
 			}
 		}
 	}
 
 	// 3. Fallback to BaseSkill
 	if skill, ok := sm.GetSkillByName("base_skill"); ok {
-		return skill
+		return skill, "no trigger matched; using base_skill"
 	}
 
 	// Absolute fallback if base_skill is missing (should not happen if loaded correctly)
-	return BaseSkill
+	return BaseSkill, "no trigger matched and base_skill is not loaded; using built-in base skill"
 }
 
 // matchesTrigger checks if a task matches a trigger rule
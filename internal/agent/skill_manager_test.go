@@ -2,6 +2,7 @@ package agent
 
 import (
 	"log/slog"
+	"strings"
 	"testing"
 
 	"kubeminds/api/v1alpha1"
@@ -77,3 +78,23 @@ func TestSkillManager_Match(t *testing.T) {
 		})
 	}
 }
+
+func TestSkillManager_MatchWithReason(t *testing.T) {
+	sm, err := NewSkillManager("", slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create skill manager: %v", err)
+	}
+
+	skill, reason := sm.MatchWithReason(&v1alpha1.DiagnosisTask{})
+	if skill.Name != "base_skill" || !strings.Contains(reason, "base_skill") {
+		t.Errorf("MatchWithReason() = %q, %q; want base_skill fallback", skill.Name, reason)
+	}
+
+	sm.Register(Skill{Name: "network", Triggers: []TriggerRule{{AlertName: "KubeDNSDown"}}})
+	skill, reason = sm.MatchWithReason(&v1alpha1.DiagnosisTask{
+		Spec: v1alpha1.DiagnosisTaskSpec{AlertContext: &v1alpha1.AlertContext{Name: "KubeDNSDown"}},
+	})
+	if skill.Name != "network" || !strings.Contains(reason, `alert_name="KubeDNSDown"`) {
+		t.Errorf("MatchWithReason() = %q, %q; want network via trigger", skill.Name, reason)
+	}
+}
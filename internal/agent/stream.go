@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultStreamInterval is the minimum time between two partial Think updates
+// forwarded to onStepComplete while a response is being streamed.
+const DefaultStreamInterval = 500 * time.Millisecond
+
+// partialThoughtPattern matches history entries produced for an in-progress Think phase.
+var partialThoughtPattern = regexp.MustCompile(`^Step \d+ \(Thinking\): `)
+
+// IsPartialThought reports whether a history entry is a partial Think update that
+// a later update of the same step supersedes.
+func IsPartialThought(entry string) bool {
+	return partialThoughtPattern.MatchString(entry)
+}
+
+// chat calls the LLM for one step. When the provider streams and a step listener
+// is registered, the text generated so far is forwarded as partial Think updates,
+// at most once per streamInterval.
+func (a *BaseAgent) chat(ctx context.Context, step int) (*Message, error) {
+	if a.skill.OutputFormat == SkillOutputFormatJSON {
+		ctx = WithResponseFormat(ctx, ResponseFormatJSON)
+	}
+	streamer, ok := a.llm.(StreamingLLMProvider)
+	if !ok || a.onStepComplete == nil {
+		return a.llm.Chat(ctx, a.memory.GetHistory(), a.tools)
+	}
+
+	chunks, err := streamer.ChatStream(ctx, a.memory.GetHistory(), a.tools)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	var lastFlush time.Time
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		if chunk.Message != nil {
+			return chunk.Message, nil
+		}
+		text += chunk.Content
+		if chunk.Content == "" || time.Since(lastFlush) < a.streamInterval {
+			continue
+		}
+		lastFlush = time.Now()
+		partial := text
+		if len(partial) > a.thoughtMaxLen {
+			partial = partial[:a.thoughtMaxLen] + "..."
+		}
+		a.onStepComplete(nil, fmt.Sprintf("Step %d (Thinking): %s", step, partial))
+	}
+	return nil, fmt.Errorf("stream ended without a final response")
+}
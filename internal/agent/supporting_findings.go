@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// supportingFindingsPrefix marks the conclusion line citing the findings that
+// support the root cause by step number, e.g. "Supporting Findings: 2, 4".
+const supportingFindingsPrefix = "supporting findings:"
+
+// Conclusion format instructions for citing supporting findings.
+const (
+	supportingFindingsInstruction     = "\nSupporting Findings: <step numbers of the tool results that support the root cause, e.g. 2, 4>"
+	supportingFindingsJSONInstruction = "\nAlso include \"supporting_findings\": [<step numbers of the tool results that support the root cause>] as a property of the object."
+	stepNumberingNote                 = "\nEach round of tool calls is one step, numbered from 1; steps restored from a checkpoint keep their numbers and new steps continue after them."
+)
+
+var stepNumberPattern = regexp.MustCompile(`\d+`)
+
+// parseSupportingFindings returns the step numbers cited on the "Supporting
+// Findings:" line of a text conclusion.
+func parseSupportingFindings(content string) []int {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(strings.ToLower(trimmed), supportingFindingsPrefix) {
+			return parseStepNumbers(trimmed[len(supportingFindingsPrefix):])
+		}
+	}
+	return nil
+}
+
+// parseStepNumbers returns every number in s, e.g. "steps 2 and 4" or "[2, 4]".
+func parseStepNumbers(s string) []int {
+	var steps []int
+	for _, m := range stepNumberPattern.FindAllString(s, -1) {
+		if n, err := strconv.Atoi(m); err == nil {
+			steps = append(steps, n)
+		}
+	}
+	return steps
+}
+
+// resolveSupportingFindings keeps the cited steps that produced a finding, in this
+// run or a restored checkpoint, sorted and without duplicates. Citations of steps
+// the agent never ran are dropped rather than reported as evidence.
+func (a *BaseAgent) resolveSupportingFindings(cited []int) []int {
+	var steps []int
+	for _, step := range cited {
+		if a.findingSteps[step] && !slices.Contains(steps, step) {
+			steps = append(steps, step)
+		}
+	}
+	slices.Sort(steps)
+	return steps
+}
+
+// recordFindingStep notes that step produced a finding the conclusion may cite.
+func (a *BaseAgent) recordFindingStep(step int) {
+	if a.findingSteps == nil {
+		a.findingSteps = make(map[int]bool)
+	}
+	a.findingSteps[step] = true
+}
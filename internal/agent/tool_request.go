@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RequestToolName is the built-in tool through which the agent asks for a tool its
+// skill does not allow (see WithToolRequests).
+const RequestToolName = "request_tool"
+
+// requestToolArgs are the arguments of a request_tool call.
+type requestToolArgs struct {
+	ToolName string `json:"tool_name"`
+	Reason   string `json:"reason"`
+}
+
+// requestTool is the definition the LLM sees for request_tool. Its calls are
+// handled by the agent loop (grantTool), which needs the run's approval state.
+type requestTool struct {
+	requestable []Tool
+}
+
+func (t *requestTool) Name() string { return RequestToolName }
+
+func (t *requestTool) Description() string {
+	var b strings.Builder
+	b.WriteString("Request a tool that is not offered for this diagnosis. Granted tools can be called from the next step. Requestable tools:")
+	for _, tool := range t.requestable {
+		fmt.Fprintf(&b, "\n- %s (%s): %s", tool.Name(), tool.SafetyLevel(), tool.Description())
+	}
+	return b.String()
+}
+
+func (t *requestTool) Schema() string {
+	return `{"type":"object","properties":{"tool_name":{"type":"string","description":"Name of the tool to request"},"reason":{"type":"string","description":"Why the diagnosis needs it"}},"required":["tool_name","reason"]}`
+}
+
+func (t *requestTool) SafetyLevel() SafetyLevel { return SafetyLevelReadOnly }
+
+func (t *requestTool) Execute(ctx context.Context, args string) (string, error) {
+	return "", fmt.Errorf("%s is handled by the agent", RequestToolName)
+}
+
+// WithToolRequests lets the agent request tools outside its skill's AllowedTools
+// through the built-in request_tool. A requested read-only tool named in autoGrant
+// is granted at once; any other request pauses the run with ErrWaitingForApproval
+// (ToolRequest set), and an approved request is granted to the resumed run through
+// WithGrantedTools. Forbidden tools, and write tools in advise mode, are never
+// granted. Nothing changes when the skill allows every tool.
+func (a *BaseAgent) WithToolRequests(autoGrant []string) *BaseAgent {
+	offered := make(map[string]bool, len(a.tools))
+	for _, t := range a.tools {
+		offered[t.Name()] = true
+	}
+	var requestable []Tool
+	for _, t := range a.allTools {
+		if offered[t.Name()] || t.SafetyLevel() == SafetyLevelForbidden {
+			continue
+		}
+		if a.skill.Mode != SkillModeAdvise || t.SafetyLevel() == SafetyLevelReadOnly {
+			requestable = append(requestable, t)
+		}
+	}
+	if len(requestable) == 0 {
+		return a
+	}
+	sort.Slice(requestable, func(i, j int) bool { return requestable[i].Name() < requestable[j].Name() })
+
+	a.autoGrant = make(map[string]bool, len(autoGrant))
+	for _, name := range autoGrant {
+		a.autoGrant[name] = true
+	}
+	a.tools = append(a.tools, &requestTool{requestable: requestable})
+	return a
+}
+
+// WithGrantedTools makes the named tools outside the skill available again, e.g.
+// tools granted through request_tool before the run paused or the controller
+// restarted. Granting a tool does not approve its calls: high-risk tools still
+// need approval per call. Unknown and forbidden tools, and write tools in advise
+// mode, are skipped.
+func (a *BaseAgent) WithGrantedTools(names []string) *BaseAgent {
+	for _, name := range names {
+		if a.findTool(name) != nil {
+			continue
+		}
+		tool := a.requestableTool(name)
+		if tool == nil || tool.SafetyLevel() == SafetyLevelForbidden ||
+			(tool.SafetyLevel() != SafetyLevelReadOnly && a.skill.Mode == SkillModeAdvise) {
+			continue
+		}
+		a.tools = append(a.tools, tool)
+		a.granted = append(a.granted, name)
+	}
+	return a
+}
+
+// GrantedTools returns the names of the tools granted outside the skill, for
+// persisting so a resumed run can be given them again through WithGrantedTools.
+func (a *BaseAgent) GrantedTools() []string {
+	return append([]string(nil), a.granted...)
+}
+
+// requestableTool returns the tool named name among all tools, or nil.
+func (a *BaseAgent) requestableTool(name string) Tool {
+	for _, t := range a.allTools {
+		if t.Name() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// grantTool handles a request_tool call and returns the output reported to the LLM.
+// It returns ErrWaitingForApproval when the request needs a human decision first;
+// a blanket approval of the run does not grant requests.
+func (a *BaseAgent) grantTool(args string) (string, error) {
+	var req requestToolArgs
+	if err := json.Unmarshal([]byte(args), &req); err != nil || req.ToolName == "" {
+		return fmt.Sprintf("Error: invalid %s arguments, expected {\"tool_name\": \"...\", \"reason\": \"...\"}", RequestToolName), nil
+	}
+	if a.findTool(req.ToolName) != nil {
+		return fmt.Sprintf("Tool %s is already available.", req.ToolName), nil
+	}
+
+	tool := a.requestableTool(req.ToolName)
+	switch {
+	case tool == nil:
+		return fmt.Sprintf("Error: Tool %s not found", req.ToolName), nil
+	case tool.SafetyLevel() == SafetyLevelForbidden:
+		return fmt.Sprintf("Error: Tool %s is forbidden by safety policy.", req.ToolName), nil
+	case tool.SafetyLevel() != SafetyLevelReadOnly && a.skill.Mode == SkillModeAdvise:
+		return fmt.Sprintf("Error: Tool %s is not available in advise mode. Only read-only tools can be used; put remediation actions in the runbook instead.", req.ToolName), nil
+	}
+
+	autoGranted := tool.SafetyLevel() == SafetyLevelReadOnly && a.autoGrant[tool.Name()]
+	if !autoGranted {
+		a.logger.Warn("Tool request requires approval", "tool", tool.Name(), "reason", req.Reason)
+		return "", &ErrWaitingForApproval{
+			ToolName:    tool.Name(),
+			Arguments:   args,
+			RiskLevel:   tool.SafetyLevel(),
+			ToolRequest: true,
+		}
+	}
+
+	a.logger.Info("Tool granted outside skill allowlist", "tool", tool.Name(), "reason", req.Reason)
+	a.tools = append(a.tools, tool)
+	a.granted = append(a.granted, tool.Name())
+	return fmt.Sprintf("Tool %s granted. You can call it from the next step.", tool.Name()), nil
+}
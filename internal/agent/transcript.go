@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Transcript is the recorded conversation of an agent run: the tools offered to the
+// model and the message history as last sent, including the assistant turns.
+// cmd/tools/replay-task re-sends it to another model.
+type Transcript struct {
+	Task     string           `json:"task,omitempty"`
+	Tools    []TranscriptTool `json:"tools,omitempty"`
+	Messages []Message        `json:"messages"`
+}
+
+// TranscriptTool is the model-facing definition of a tool offered during the run.
+type TranscriptTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schema      string `json:"schema"`
+}
+
+// Transcript returns the agent's conversation so far. History compaction and memory
+// limits apply, so it holds what the last LLM call was sent plus its answer.
+func (a *BaseAgent) Transcript() *Transcript {
+	t := &Transcript{Messages: a.memory.GetHistory()}
+	for _, tool := range a.tools {
+		t.Tools = append(t.Tools, TranscriptTool{Name: tool.Name(), Description: tool.Description(), Schema: tool.Schema()})
+	}
+	return t
+}
+
+// TranscriptStore persists the transcript of each task's latest run.
+type TranscriptStore interface {
+	// Save stores t as the transcript of the task, replacing any earlier one.
+	Save(ctx context.Context, namespace, name string, t *Transcript) error
+	// Load returns the stored transcript of the task.
+	Load(ctx context.Context, namespace, name string) (*Transcript, error)
+}
+
+// FileTranscriptStore implements TranscriptStore with one JSON file per task at
+// "{dir}/{namespace}/{name}.json".
+type FileTranscriptStore struct {
+	dir string
+}
+
+// NewFileTranscriptStore creates a store writing transcripts under dir.
+func NewFileTranscriptStore(dir string) *FileTranscriptStore {
+	return &FileTranscriptStore{dir: dir}
+}
+
+// Save implements TranscriptStore. The file is replaced atomically, so a concurrent
+// Load never reads a partial transcript.
+func (s *FileTranscriptStore) Save(_ context.Context, namespace, name string, t *Transcript) error {
+	path, err := s.path(namespace, name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("marshal transcript: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create transcript dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	return nil
+}
+
+// Load implements TranscriptStore.
+func (s *FileTranscriptStore) Load(_ context.Context, namespace, name string) (*Transcript, error) {
+	path, err := s.path(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return ReadTranscript(path)
+}
+
+// path returns the file of the task's transcript. namespace and name are Kubernetes
+// object names; anything that could leave dir is rejected.
+func (s *FileTranscriptStore) path(namespace, name string) (string, error) {
+	for _, part := range []string{namespace, name} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid task %s/%s", namespace, name)
+		}
+	}
+	return filepath.Join(s.dir, namespace, name+".json"), nil
+}
+
+// ReadTranscript reads a JSON transcript from path.
+func ReadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse transcript %s: %w", path, err)
+	}
+	if len(t.Messages) == 0 {
+		return nil, fmt.Errorf("transcript %s has no messages", path)
+	}
+	return &t, nil
+}
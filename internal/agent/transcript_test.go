@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileTranscriptStore_SavesRunTranscript(t *testing.T) {
+	mockLLM := NewMockLLMProvider()
+	mockLLM.Responses[0] = &Message{
+		Type:      MessageTypeAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "get_pod_logs", Arguments: "{}"}}},
+	}
+	mockLLM.Responses[1] = &Message{Type: MessageTypeAssistant, Content: "Root Cause: OOM\nSuggestion: raise the limit"}
+	tool := &MockTool{NameVal: "get_pod_logs", SafetyLevelVal: SafetyLevelReadOnly}
+	ag := NewAgent(mockLLM, []Tool{tool}, 5, nil, nil, Skill{})
+	if _, err := ag.Run(context.Background(), "Diagnose", false); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	ctx := context.Background()
+	store := NewFileTranscriptStore(t.TempDir())
+	transcript := ag.Transcript()
+	transcript.Task = "default/oom-task"
+	if err := store.Save(ctx, "default", "oom-task", transcript); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := store.Load(ctx, "default", "oom-task")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.Task != "default/oom-task" || len(got.Tools) != 1 || got.Tools[0].Name != "get_pod_logs" {
+		t.Errorf("transcript = %+v, want the task and its get_pod_logs tool", got)
+	}
+	var assistant, toolOutputs int
+	for _, msg := range got.Messages {
+		switch msg.Type {
+		case MessageTypeAssistant:
+			assistant++
+		case MessageTypeTool:
+			toolOutputs++
+		}
+	}
+	if assistant != 2 || toolOutputs != 1 {
+		t.Errorf("transcript has %d assistant turns and %d tool outputs, want 2 and 1", assistant, toolOutputs)
+	}
+}
+
+func TestFileTranscriptStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := NewFileTranscriptStore(dir)
+	if _, err := store.Load(ctx, "default", "missing"); err == nil {
+		t.Error("Load() of a missing transcript error = nil, want error")
+	}
+	if err := store.Save(ctx, "..", "task", &Transcript{}); err == nil {
+		t.Error("Save() with namespace .. error = nil, want error")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "empty.json"), []byte(`{"messages": []}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := ReadTranscript(filepath.Join(dir, "empty.json")); err == nil {
+		t.Error("ReadTranscript() of an empty transcript error = nil, want error")
+	}
+}
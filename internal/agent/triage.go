@@ -0,0 +1,42 @@
+package agent
+
+import "strings"
+
+// TriageSkillName is the name of the built-in quick-triage skill.
+const TriageSkillName = "triage"
+
+// TriageVerdictField is the output schema field holding the triage verdict.
+const TriageVerdictField = "verdict"
+
+// Triage verdicts. Anything other than TriageVerdictBenign escalates to a full diagnosis.
+const (
+	TriageVerdictSerious = "serious"
+	TriageVerdictBenign  = "benign"
+)
+
+// TriageSkill is the built-in "first responder" skill: one or two read-only steps
+// to decide whether an alert deserves a full diagnosis. A skills/triage.yaml with
+// the same name overrides it.
+var TriageSkill = Skill{
+	Name:        TriageSkillName,
+	Description: "Quick first-responder triage deciding whether an alert needs a full diagnosis",
+	SystemPrompt: `You are a Kubernetes on-call first responder triaging an alert during an alert storm.
+You have at most one or two tool calls. Do not try to find the full root cause.
+Decide only whether this alert is serious (user impact, data loss, or a spreading failure)
+or benign (transient, self-healing, or noise). When in doubt, answer serious.`,
+	AllowedTools: []string{"get_pod_events", "get_pod_spec"},
+	Mode:         SkillModeAdvise,
+	OutputSchema: map[string]string{
+		TriageVerdictField: "serious or benign",
+	},
+}
+
+// TriageSuppresses reports whether a triage result judged the alert benign, in which
+// case the full diagnosis is skipped. A missing or unrecognized verdict escalates.
+func TriageSuppresses(result *Result) bool {
+	if result == nil {
+		return false
+	}
+	verdict := strings.ToLower(strings.TrimSpace(result.Details[TriageVerdictField]))
+	return strings.HasPrefix(verdict, TriageVerdictBenign)
+}
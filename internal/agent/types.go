@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +12,16 @@ import (
 // ErrWaitingForApproval is returned when a tool execution is blocked pending user approval
 type ErrWaitingForApproval struct {
 	ToolName string
+	// Arguments is the raw JSON arguments of the blocked tool call.
+	Arguments string
+	// RiskLevel is the blocked tool's safety level.
+	RiskLevel SafetyLevel
+	// Plan lists, in order, the blocked call and every later high-risk call of the
+	// same LLM turn, so a human can approve them individually in one decision.
+	Plan []v1alpha1.PlannedAction
+	// ToolRequest is set when the agent asked for ToolName through request_tool:
+	// approving grants the tool (see WithGrantedTools) rather than approving a call.
+	ToolRequest bool
 }
 
 func (e *ErrWaitingForApproval) Error() string {
@@ -26,9 +37,68 @@ func (e *ErrToolForbidden) Error() string {
 	return fmt.Sprintf("tool %s is forbidden", e.ToolName)
 }
 
+// ErrorClass categorizes run failures that need handling beyond a generic error.
+type ErrorClass string
+
+const (
+	// ErrorClassContextTooLong means the request exceeded the model's context window.
+	// Resending the same conversation cannot succeed, so it is never retried as is.
+	ErrorClassContextTooLong ErrorClass = "ContextTooLong"
+	// ErrorClassLLMUnavailable means the LLM call failed transiently (outage, rate
+	// limit, network), so the whole run may be retried later. Providers tag it; other
+	// chat errors (auth, invalid request) stay unclassified and are not retried.
+	ErrorClassLLMUnavailable ErrorClass = "LLMUnavailable"
+	// ErrorClassLoopDetected means the agent kept repeating the same tool call.
+	ErrorClassLoopDetected ErrorClass = "LoopDetected"
+	// ErrorClassMaxSteps means the agent ran out of steps without concluding.
+	ErrorClassMaxSteps ErrorClass = "MaxSteps"
+)
+
+// Retryable reports whether a run that failed with this class may succeed if
+// started again from scratch.
+func (c ErrorClass) Retryable() bool {
+	return c == ErrorClassLLMUnavailable
+}
+
+// ErrLoopDetected is wrapped by the error of a run stopped by loop detection.
+var ErrLoopDetected = errors.New("agent loop detected")
+
+// ErrMaxStepsExceeded is wrapped by the error of a run that reached its step limit.
+var ErrMaxStepsExceeded = errors.New("agent exceeded maximum steps")
+
+// LLMError is an LLM provider error tagged with its class.
+type LLMError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *LLMError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *LLMError) Unwrap() error {
+	return e.Err
+}
+
+// ClassOf returns the class of the first LLMError in err's chain, the class of a
+// loop or step-limit failure, or "" when err is not classified.
+func ClassOf(err error) ErrorClass {
+	var llmErr *LLMError
+	switch {
+	case errors.As(err, &llmErr):
+		return llmErr.Class
+	case errors.Is(err, ErrLoopDetected):
+		return ErrorClassLoopDetected
+	case errors.Is(err, ErrMaxStepsExceeded):
+		return ErrorClassMaxSteps
+	}
+	return ""
+}
+
 // Agent defines the interface for the AI agent
 type Agent interface {
-	// Run executes the agent loop for a given goal
+	// Run executes the agent loop for a given goal. An error may come with a Result
+	// holding the ActionsTaken before the failure.
 	Run(ctx context.Context, goal string, approved bool) (*Result, error)
 	// Restore restores the agent's memory from a list of findings
 	Restore(findings []v1alpha1.Finding)
@@ -38,6 +108,20 @@ type Agent interface {
 type Result struct {
 	RootCause  string
 	Suggestion string
+	// Details holds skill-specific structured fields parsed from the conclusion
+	// (see Skill.OutputSchema and FindingExtractor). Nil when the skill has none.
+	Details map[string]string
+	// ActionsTaken lists the write (non read-only) tools executed during the run.
+	ActionsTaken []v1alpha1.RemediationAction
+	// Confidence is the agent's self-reported confidence (0-1) in the conclusion.
+	// Nil when it reported none.
+	Confidence *float64
+	// Inconclusive is set when the agent could not determine a root cause: it said
+	// so, or its confidence was below the configured threshold.
+	Inconclusive bool
+	// SupportingFindings are the steps of the findings the conclusion cited as
+	// supporting the root cause, sorted. Steps that produced no finding are dropped.
+	SupportingFindings []int
 }
 
 // Memory defines the interface for storing conversation history
@@ -70,6 +154,23 @@ type Message struct {
 	Content    string
 	ToolCalls  []ToolCall
 	ToolCallID string
+	// Usage is the token usage of the call that produced this message.
+	// Nil when the provider did not report it.
+	Usage *TokenUsage
+}
+
+// TokenUsage counts the tokens consumed by one or more LLM calls.
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// Add accumulates other into u.
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
 }
 
 // ToolCall represents a request to execute a tool
@@ -108,6 +209,16 @@ type Tool interface {
 	SafetyLevel() SafetyLevel
 }
 
+// ReversibleTool is a write tool whose changes can be undone by calling it again.
+// Before a call executes, the agent asks it for the arguments that restore the
+// current state and records them on the action for a later rollback.
+type ReversibleTool interface {
+	Tool
+	// RollbackArgs captures the state a call with args is about to change and returns
+	// the arguments that restore it.
+	RollbackArgs(ctx context.Context, args string) (string, error)
+}
+
 // ToolProvider defines the interface for providing tools
 type ToolProvider interface {
 	// ListTools returns a list of available tools
@@ -120,6 +231,64 @@ type LLMProvider interface {
 	Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error)
 }
 
+// StreamChunk is one increment of a streamed chat response.
+// Content carries newly generated text. The last chunk sent before the channel is
+// closed has either Message set to the complete response (tool calls and usage
+// included) or Err set when the stream failed.
+type StreamChunk struct {
+	Content string
+	Message *Message
+	Err     error
+}
+
+// StreamingLLMProvider is an LLMProvider that can also stream its response.
+// BaseAgent uses ChatStream when available to report the Think phase as it is generated.
+type StreamingLLMProvider interface {
+	LLMProvider
+	// ChatStream starts a chat request and returns a channel of incremental chunks.
+	// Errors before the stream starts are returned directly.
+	ChatStream(ctx context.Context, messages []Message, tools []Tool) (<-chan StreamChunk, error)
+}
+
+// ResponseFormat pins the format of an LLM response.
+type ResponseFormat string
+
+const (
+	// ResponseFormatJSON constrains the response to a single valid JSON object.
+	ResponseFormatJSON ResponseFormat = "json"
+)
+
+type responseFormatKey struct{}
+
+// WithResponseFormat returns a copy of ctx asking the LLM provider to constrain its
+// response to format. Providers without such a mode ignore it, so the prompt must
+// still ask for the format.
+func WithResponseFormat(ctx context.Context, format ResponseFormat) context.Context {
+	return context.WithValue(ctx, responseFormatKey{}, format)
+}
+
+// ResponseFormatFromContext returns the format set by WithResponseFormat, or "".
+func ResponseFormatFromContext(ctx context.Context) ResponseFormat {
+	format, _ := ctx.Value(responseFormatKey{}).(ResponseFormat)
+	return format
+}
+
+type finalStepKey struct{}
+
+// WithFinalStep returns a copy of ctx marking the call as the last step of a run,
+// whose response must be the conclusion since no tool call can follow it.
+// Providers whose response format mode excludes function calling may leave the
+// tools out of such a call.
+func WithFinalStep(ctx context.Context) context.Context {
+	return context.WithValue(ctx, finalStepKey{}, true)
+}
+
+// IsFinalStep reports whether ctx was marked by WithFinalStep.
+func IsFinalStep(ctx context.Context) bool {
+	final, _ := ctx.Value(finalStepKey{}).(bool)
+	return final
+}
+
 // AlertEvent represents a recent alert event stored in the L2 event stream.
 type AlertEvent struct {
 	AlertName string
@@ -136,8 +305,9 @@ type EventStore interface {
 	// AppendAlertEvent writes a new alert event to the event stream.
 	AppendAlertEvent(ctx context.Context, event AlertEvent) error
 	// GetRecentEvents retrieves the most recent events for the given namespace.
-	// If pod is non-empty, only events for that pod are returned.
-	GetRecentEvents(ctx context.Context, namespace, pod string, limit int) ([]AlertEvent, error)
+	// If pod is non-empty, only events for that pod are returned; if alertName is
+	// non-empty, only events of that alert.
+	GetRecentEvents(ctx context.Context, namespace, pod, alertName string, limit int) ([]AlertEvent, error)
 }
 
 // KnowledgeFinding represents a completed diagnosis stored in the L3 knowledge base.
@@ -150,18 +320,34 @@ type KnowledgeFinding struct {
 	CreatedAt  time.Time
 }
 
+// EvidenceVector is a supporting evidence snippet (e.g. a key tool finding) stored
+// alongside a diagnosis with its own embedding, so similarity search can match on
+// the evidence as well as on the root cause summary.
+type EvidenceVector struct {
+	Text      string
+	Embedding []float32
+}
+
 // KnowledgeBase is the L3 interface for storing and retrieving historical diagnoses.
 // Embeddings are owned by the caller to keep this interface storage-agnostic.
 type KnowledgeBase interface {
 	// InitSchema creates the required database schema if it does not already exist.
 	InitSchema(ctx context.Context) error
 	// SaveDiagnosis persists a completed diagnosis alongside its embedding vector.
-	SaveDiagnosis(ctx context.Context, finding KnowledgeFinding, embedding []float32) error
+	// Optional evidence vectors are linked to the same diagnosis.
+	SaveDiagnosis(ctx context.Context, finding KnowledgeFinding, embedding []float32, evidence ...EvidenceVector) error
 	// SearchSimilar returns the top-k historically similar diagnoses ordered by
-	// cosine similarity to queryEmbedding.
+	// cosine similarity to queryEmbedding, considering both the diagnosis vector
+	// and any linked evidence vectors.
 	SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int) ([]KnowledgeFinding, error)
+	// DeleteDiagnosis removes a diagnosis and its linked evidence by ID.
+	// Returns ErrKnowledgeNotFound when no diagnosis has that ID.
+	DeleteDiagnosis(ctx context.Context, id string) error
 }
 
+// ErrKnowledgeNotFound is returned by KnowledgeBase.DeleteDiagnosis for an unknown ID.
+var ErrKnowledgeNotFound = errors.New("knowledge entry not found")
+
 // EmbeddingProvider generates dense vector embeddings for text.
 // The interface lives here so the controller can reference it without importing
 // the llm package (which would create an import cycle: controller → llm → agent).
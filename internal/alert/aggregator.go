@@ -2,13 +2,18 @@ package alert
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"kubeminds/internal/admin"
 	"kubeminds/internal/agent"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 // Aggregator deduplicates and merges incoming alerts within a sliding time window,
@@ -23,9 +28,114 @@ type Aggregator struct {
 
 	// l2Store is an optional L2 event store. When non-nil, each flushed alert
 	// group is written as an AlertEvent so the Agent can query recent context.
-	l2Store agent.EventStore
+	// Appends go through l2 (started by Run), a bounded single-writer queue.
+	l2Store     agent.EventStore
+	l2QueueSize int
+	l2          *l2Writer
+
+	// pause is the optional global kill switch. While paused, flushed groups
+	// are dropped instead of becoming DiagnosisTasks.
+	pause *admin.PauseSwitch
+
+	// ingestBatchSize caps how many alerts IngestMany processes per lock
+	// acquisition. Zero means the whole batch is processed under one lock.
+	ingestBatchSize int
+
+	// groupBy overrides the label names used to build group keys (default:
+	// alertname/namespace/pod). minSeverity drops alerts below that severity.
+	groupBy     []string
+	minSeverity string
+
+	// cancelOnResolve drops a pending group when a resolved alert with its key
+	// arrives, so already-fixed problems are not diagnosed.
+	cancelOnResolve bool
+
+	// shutdownGrace bounds the final flush of pending groups when Run's context
+	// is cancelled. Zero disables the final flush.
+	shutdownGrace time.Duration
+
+	// groupStore optionally checkpoints the groups after every sweep and restores
+	// them when Run starts. groupsDirty (guarded by mu) records changes since the
+	// last checkpoint.
+	groupStore  GroupStore
+	groupsDirty bool
+
+	// cooldown is the minimum interval between tasks for the same alertname and
+	// target; cooldownByAlert overrides it per alertname. cooldowns tracks them.
+	cooldown        time.Duration
+	cooldownByAlert map[string]time.Duration
+	cooldowns       CooldownStore
+
+	// maxGroups caps how many groups may be pending at once; 0 means no limit.
+	maxGroups int
+
+	// reportedGroups (guarded by mu) is this aggregator's share of GroupsActive.
+	reportedGroups int
+
+	// onLock is called with mu held after every acquisition (tests only).
+	onLock func()
+}
+
+// ErrTooManyGroups is returned by Ingest and IngestMany when alerts would open new
+// groups beyond the limit set by WithMaxGroups.
+var ErrTooManyGroups = errors.New("alert aggregator is at its group limit")
+
+// GroupLimitError is returned by IngestMany when some alerts of the batch were
+// rejected at the group limit. It wraps ErrTooManyGroups.
+type GroupLimitError struct {
+	// Rejected and Ingested count the batch's alerts rejected and merged into groups.
+	Rejected int
+	Ingested int
+	Total    int
+}
+
+func (e *GroupLimitError) Error() string {
+	return fmt.Sprintf("%v: %d of %d alerts rejected", ErrTooManyGroups, e.Rejected, e.Total)
+}
+
+func (e *GroupLimitError) Unwrap() error { return ErrTooManyGroups }
+
+// AlertsRejected counts alerts rejected because the aggregator was at its group limit.
+var AlertsRejected = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_alert_rejected_total",
+	Help: "Alerts rejected because the alert aggregator held its maximum number of groups.",
+})
+
+// AlertsIngested counts alerts added to a group (new or existing).
+var AlertsIngested = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_alerts_ingested_total",
+	Help: "Alerts ingested into an alert aggregator group.",
+})
+
+// GroupsActive is the number of alert groups waiting for their window to expire,
+// summed over all aggregators.
+var GroupsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "kubeminds_alert_groups_active",
+	Help: "Alert groups pending in the alert aggregators.",
+})
+
+// DiagnosisTasksCreated counts DiagnosisTasks created for flushed alert groups.
+var DiagnosisTasksCreated = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_diagnosis_tasks_created_total",
+	Help: "DiagnosisTasks created by the alert aggregators.",
+})
+
+// FlushErrors counts alert groups whose DiagnosisTask could not be created.
+var FlushErrors = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_alert_flush_errors_total",
+	Help: "Alert groups that failed to flush into a DiagnosisTask.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(AlertsRejected, AlertsIngested, GroupsActive, DiagnosisTasksCreated, FlushErrors)
 }
 
+// DefaultShutdownGracePeriod is how long Run spends flushing pending groups on shutdown.
+const DefaultShutdownGracePeriod = 10 * time.Second
+
+// groupStoreTimeout bounds each checkpoint and restore of the group store.
+const groupStoreTimeout = 5 * time.Second
+
 // NewAggregator constructs an Aggregator. All dependencies are injected; no global state.
 func NewAggregator(
 	k8sClient client.Client,
@@ -40,6 +150,7 @@ func NewAggregator(
 		sweepInterval: sweepInterval,
 		creator:       NewDiagnosisTaskCreator(k8sClient, targetNamespace),
 		log:           log,
+		shutdownGrace: DefaultShutdownGracePeriod,
 	}
 }
 
@@ -50,12 +161,137 @@ func (a *Aggregator) WithL2Store(store agent.EventStore) *Aggregator {
 	return a
 }
 
-// Run starts the background sweep goroutine. It blocks until ctx is cancelled.
+// WithL2QueueSize sets how many alert events may wait for the L2 writer before the
+// oldest are dropped (default DefaultL2QueueSize). Call before Run().
+func (a *Aggregator) WithL2QueueSize(n int) *Aggregator {
+	a.l2QueueSize = n
+	return a
+}
+
+// WithPauseSwitch attaches the global kill switch. Call before Run().
+func (a *Aggregator) WithPauseSwitch(p *admin.PauseSwitch) *Aggregator {
+	a.pause = p
+	return a
+}
+
+// WithIngestBatchSize sets how many alerts IngestMany processes per lock acquisition.
+// Smaller batches let concurrent webhook requests interleave on very large payloads.
+// n <= 0 processes each IngestMany call under a single lock (default).
+func (a *Aggregator) WithIngestBatchSize(n int) *Aggregator {
+	a.ingestBatchSize = n
+	return a
+}
+
+// WithShutdownGracePeriod sets how long Run may spend flushing all pending groups
+// after its context is cancelled, so in-window alerts still become DiagnosisTasks
+// on SIGTERM. d <= 0 disables the final flush (pending groups are dropped).
+func (a *Aggregator) WithShutdownGracePeriod(d time.Duration) *Aggregator {
+	a.shutdownGrace = d
+	return a
+}
+
+// WithGroupStore checkpoints the in-window groups to store after every sweep and
+// restores them when Run starts, so alerts ingested before a restart still become
+// DiagnosisTasks. Call before Run(). Without a store groups live only in memory.
+func (a *Aggregator) WithGroupStore(store GroupStore) *Aggregator {
+	a.groupStore = store
+	return a
+}
+
+// WithGroupBy sets the label names used to group alerts, e.g. ["alertname", "namespace"].
+// Missing labels key as "_". A "pod", "deployment" or "node" label among them makes
+// that resource the task's target. Empty keeps the default alertname/namespace/pod grouping.
+func (a *Aggregator) WithGroupBy(labels []string) *Aggregator {
+	a.groupBy = labels
+	a.creator.WithGroupBy(labels)
+	return a
+}
+
+// WithAllowedTargetNamespaces creates the DiagnosisTasks of alerts from the given
+// namespaces in the alert's own namespace instead of the target namespace, so
+// tenant RBAC applies to them. Empty creates every task in the target namespace.
+func (a *Aggregator) WithAllowedTargetNamespaces(namespaces []string) *Aggregator {
+	a.creator.WithAllowedNamespaces(namespaces)
+	return a
+}
+
+// WithPropagateLabels copies the given alert label keys onto each created
+// DiagnosisTask's metadata.labels, for `kubectl get -l` filtering and ownership.
+func (a *Aggregator) WithPropagateLabels(keys []string) *Aggregator {
+	a.creator.WithPropagateLabels(keys)
+	return a
+}
+
+// WithCancelOnResolve makes CancelResolved drop the pending group of each resolved
+// alert before it flushes. Off by default: resolved alerts are ignored. Note that
+// with a coarse WithGroupBy one resolved alert cancels the whole group.
+func (a *Aggregator) WithCancelOnResolve(enabled bool) *Aggregator {
+	a.cancelOnResolve = enabled
+	return a
+}
+
+// WithCooldown suppresses a flushed group when a task was created for the same
+// alertname and target less than d ago, so a flapping alert is not diagnosed
+// again and again. byAlert overrides d per alertname (zero disables the cooldown
+// for that alert). Cooldowns are kept in memory unless WithCooldownStore is set.
+// d <= 0 without overrides disables it (default).
+func (a *Aggregator) WithCooldown(d time.Duration, byAlert map[string]time.Duration) *Aggregator {
+	a.cooldown = d
+	a.cooldownByAlert = byAlert
+	if a.cooldowns == nil {
+		a.cooldowns = NewMemoryCooldownStore()
+	}
+	return a
+}
+
+// WithCooldownStore keeps the cooldowns of WithCooldown in store, e.g. a
+// RedisCooldownStore shared by all replicas.
+func (a *Aggregator) WithCooldownStore(store CooldownStore) *Aggregator {
+	a.cooldowns = store
+	return a
+}
+
+// WithMaxGroups caps how many groups may be pending at once, bounding memory during
+// an alert storm. Alerts that would open a group beyond the cap are rejected with
+// ErrTooManyGroups (the webhook answers 429 so AlertManager retries later, unless
+// part of the payload was merged); alerts of existing groups are still merged. n <= 0 disables the cap (default).
+func (a *Aggregator) WithMaxGroups(n int) *Aggregator {
+	a.maxGroups = n
+	return a
+}
+
+// WithMinSeverity drops alerts whose "severity" label ranks below min
+// (info < warning < critical). Alerts without a known severity are dropped too.
+// Empty (default) accepts all alerts.
+func (a *Aggregator) WithMinSeverity(min string) *Aggregator {
+	a.minSeverity = min
+	return a
+}
+
+// Run starts the background sweep goroutine. It blocks until ctx is cancelled,
+// then flushes all pending groups (see WithShutdownGracePeriod) before returning.
 // The caller is responsible for managing the goroutine lifecycle (e.g. via errgroup).
 func (a *Aggregator) Run(ctx context.Context) {
 	ticker := time.NewTicker(a.sweepInterval)
 	defer ticker.Stop()
 
+	if a.l2Store != nil {
+		a.l2 = newL2Writer(a.l2Store, a.l2QueueSize, a.log)
+		writerCtx, stopWriter := context.WithCancel(context.Background())
+		writerDone := make(chan struct{})
+		go func() {
+			defer close(writerDone)
+			a.l2.run(writerCtx)
+		}()
+		// Deferred calls run after the shutdown drain below, so its events are written too.
+		defer func() {
+			stopWriter()
+			<-writerDone
+		}()
+	}
+
+	a.restoreGroups(ctx)
+
 	a.log.Info("alert aggregator started",
 		"windowSize", a.windowSize,
 		"sweepInterval", a.sweepInterval,
@@ -64,10 +300,15 @@ func (a *Aggregator) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			a.drain()
+			// Groups left after the drain (e.g. with the final flush disabled) are
+			// picked up by the next Run.
+			a.checkpoint(context.Background())
 			a.log.Info("alert aggregator stopped")
 			return
 		case <-ticker.C:
 			a.sweep(ctx)
+			a.checkpoint(ctx)
 		}
 	}
 }
@@ -75,14 +316,96 @@ func (a *Aggregator) Run(ctx context.Context) {
 // Ingest accepts a single AlertItem and adds it to the appropriate group.
 // It is thread-safe and performs no I/O.
 func (a *Aggregator) Ingest(item AlertItem) error {
-	key := buildGroupKey(item.Labels)
-	now := time.Now()
+	return a.IngestMany([]AlertItem{item})
+}
 
-	a.mu.Lock()
-	defer a.mu.Unlock()
+// IngestMany adds a batch of alerts, acquiring the lock once per ingest batch
+// (see WithIngestBatchSize) instead of once per alert. Alerts beyond the group cap
+// are skipped and reported by a *GroupLimitError; the rest of the batch is still
+// ingested.
+// It is thread-safe and performs no I/O.
+func (a *Aggregator) IngestMany(items []AlertItem) error {
+	rejected, ingested := 0, 0
+	batchSize := a.ingestBatchSize
+	if batchSize <= 0 {
+		batchSize = len(items)
+	}
+
+	for start := 0; start < len(items); start += batchSize {
+		end := min(start+batchSize, len(items))
+		now := time.Now()
+
+		a.lock()
+		for _, item := range items[start:end] {
+			if !meetsMinSeverity(item.Labels, a.minSeverity) {
+				a.log.V(1).Info("dropping alert below min severity",
+					"alertname", item.Labels["alertname"],
+					"severity", item.Labels["severity"],
+					"minSeverity", a.minSeverity,
+				)
+				continue
+			}
+			if !a.ingestLocked(item, now) {
+				rejected++
+				continue
+			}
+			ingested++
+			AlertsIngested.Inc()
+		}
+		a.syncGroupsGauge()
+		a.mu.Unlock()
+	}
+
+	if rejected > 0 {
+		AlertsRejected.Add(float64(rejected))
+		a.log.Info("alert group limit reached, rejecting alerts",
+			"rejected", rejected,
+			"maxGroups", a.maxGroups,
+		)
+		return &GroupLimitError{Rejected: rejected, Ingested: ingested, Total: len(items)}
+	}
+	return nil
+}
+
+// CancelResolved removes the pending groups that the resolved alerts in items belong
+// to, returning how many were removed. It does nothing unless WithCancelOnResolve
+// is enabled. It is thread-safe and performs no I/O.
+func (a *Aggregator) CancelResolved(items []AlertItem) int {
+	if !a.cancelOnResolve || len(items) == 0 {
+		return 0
+	}
+	cancelled := 0
+	a.lock()
+	for _, item := range items {
+		key := buildGroupKeyBy(item.Labels, a.groupBy)
+		group, ok := a.groups[key]
+		if !ok {
+			continue
+		}
+		delete(a.groups, key)
+		a.groupsDirty = true
+		cancelled++
+		a.log.Info("alert resolved within the window, cancelling its group",
+			"key", string(key),
+			"alertName", group.AlertName,
+			"count", group.Count,
+		)
+	}
+	a.syncGroupsGauge()
+	a.mu.Unlock()
+	return cancelled
+}
+
+// ingestLocked adds one alert to its group, reporting false when it would open a
+// group beyond maxGroups. The caller must hold a.mu.
+func (a *Aggregator) ingestLocked(item AlertItem, now time.Time) bool {
+	key := buildGroupKeyBy(item.Labels, a.groupBy)
 
 	group, exists := a.groups[key]
 	if !exists {
+		if a.maxGroups > 0 && len(a.groups) >= a.maxGroups {
+			return false
+		}
 		group = &AlertGroup{
 			Key:          key,
 			MergedLabels: make(map[string]string),
@@ -98,22 +421,43 @@ func (a *Aggregator) Ingest(item AlertItem) error {
 	for k, v := range item.Labels {
 		group.MergedLabels[k] = v
 	}
+	if len(item.Annotations) > 0 {
+		if group.MergedAnnotations == nil {
+			group.MergedAnnotations = make(map[string]string, len(item.Annotations))
+		}
+		maps.Copy(group.MergedAnnotations, item.Annotations)
+	}
 
 	// Update sliding window anchor and counter.
 	group.LastSeen = now
 	group.Count++
+	a.groupsDirty = true
 
 	a.log.V(1).Info("alert ingested",
 		"key", string(key),
 		"count", group.Count,
 	)
+	return true
+}
 
-	return nil
+// lock acquires a.mu and calls the onLock test hook, if set.
+func (a *Aggregator) lock() {
+	a.mu.Lock()
+	if a.onLock != nil {
+		a.onLock()
+	}
+}
+
+// syncGroupsGauge moves GroupsActive by the change in this aggregator's group count
+// since the last call, so several aggregators add up. The caller must hold a.mu.
+func (a *Aggregator) syncGroupsGauge() {
+	GroupsActive.Add(float64(len(a.groups) - a.reportedGroups))
+	a.reportedGroups = len(a.groups)
 }
 
 // GroupCount returns the number of active alert groups. Used for observability and tests.
 func (a *Aggregator) GroupCount() int {
-	a.mu.Lock()
+	a.lock()
 	defer a.mu.Unlock()
 	return len(a.groups)
 }
@@ -122,22 +466,60 @@ func (a *Aggregator) GroupCount() int {
 // K8s API calls happen outside the lock to avoid blocking Ingest.
 func (a *Aggregator) sweep(ctx context.Context) {
 	now := time.Now()
+	a.flushGroups(ctx, a.takeGroups(func(group *AlertGroup) bool {
+		return now.Sub(group.LastSeen) > a.windowSize
+	}))
+}
+
+// drain flushes every pending group, regardless of its window, within the
+// shutdown grace period. It runs after Run's context is cancelled, so it uses
+// its own bounded context; groups not flushed in time are dropped.
+func (a *Aggregator) drain() {
+	if a.shutdownGrace <= 0 {
+		return
+	}
+	pending := a.takeGroups(func(*AlertGroup) bool { return true })
+	if len(pending) == 0 {
+		return
+	}
 
-	// Collect expired groups and remove them from the map while holding the lock.
-	var expired []*AlertGroup
+	a.log.Info("flushing pending alert groups before shutdown",
+		"groups", len(pending),
+		"gracePeriod", a.shutdownGrace,
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownGrace)
+	defer cancel()
+	a.flushGroups(ctx, pending)
+}
 
-	a.mu.Lock()
+// takeGroups removes and returns the groups matching pred while holding the lock.
+func (a *Aggregator) takeGroups(pred func(*AlertGroup) bool) []*AlertGroup {
+	var taken []*AlertGroup
+
+	a.lock()
 	for key, group := range a.groups {
-		if now.Sub(group.LastSeen) > a.windowSize {
-			expired = append(expired, group)
+		if pred(group) {
+			taken = append(taken, group)
 			delete(a.groups, key)
+			a.groupsDirty = true
 		}
 	}
+	a.syncGroupsGauge()
 	a.mu.Unlock()
 
-	// Flush each expired group outside the lock.
-	for _, group := range expired {
+	return taken
+}
+
+// flushGroups flushes each group outside the lock, logging failures. It first
+// checkpoints the groups without the taken ones, so a crash mid-flush cannot
+// restore a group whose task was already created.
+func (a *Aggregator) flushGroups(ctx context.Context, groups []*AlertGroup) {
+	if len(groups) > 0 {
+		a.checkpoint(ctx)
+	}
+	for _, group := range groups {
 		if err := a.flush(ctx, group); err != nil {
+			FlushErrors.Inc()
 			a.log.Error(err, "failed to flush alert group",
 				"key", string(group.Key),
 				"alertName", group.AlertName,
@@ -157,31 +539,169 @@ func (a *Aggregator) flush(ctx context.Context, group *AlertGroup) error {
 		"lastSeen", group.LastSeen,
 	)
 
-	if err := a.creator.Create(ctx, group); err != nil {
-		return fmt.Errorf("flush alert group %s: %w", group.Key, err)
-	}
+	if a.pause.Paused() {
+		// AlertManager keeps re-sending firing alerts, so they will be picked up
+		// again after resume.
+		a.log.Info("automated diagnosis paused, not creating DiagnosisTask",
+			"key", string(group.Key),
+			"alertName", group.AlertName,
+		)
+	} else if !a.acquireCooldown(ctx, group) {
+		a.log.Info("target diagnosed within its cooldown, not creating DiagnosisTask",
+			"key", string(group.Key),
+			"alertName", group.AlertName,
+			"cooldown", a.cooldownFor(group),
+		)
+	} else {
+		if err := a.creator.Create(ctx, group); err != nil {
+			a.releaseCooldown(ctx, group)
+			return fmt.Errorf("flush alert group %s: %w", group.Key, err)
+		}
+		DiagnosisTasksCreated.Inc()
 
-	a.log.Info("DiagnosisTask created for alert group",
-		"key", string(group.Key),
-		"alertName", group.AlertName,
-	)
+		a.log.Info("DiagnosisTask created for alert group",
+			"key", string(group.Key),
+			"alertName", group.AlertName,
+		)
+	}
 
-	// Write to L2 event store asynchronously so K8s task creation is never blocked.
-	if a.l2Store != nil {
-		event := agent.AlertEvent{
+	// Queue the L2 write so K8s task creation is never blocked by a slow store.
+	if a.l2 != nil {
+		a.l2.enqueue(agent.AlertEvent{
 			AlertName: group.AlertName,
 			Namespace: group.Namespace,
 			Pod:       group.Pod,
 			Count:     group.Count,
 			FirstSeen: group.FirstSeen,
 			LastSeen:  group.LastSeen,
-		}
-		go func(ev agent.AlertEvent) {
-			if err := a.l2Store.AppendAlertEvent(context.Background(), ev); err != nil {
-				a.log.Error(err, "l2: failed to append alert event", "alertName", ev.AlertName)
-			}
-		}(event)
+		})
 	}
 
 	return nil
 }
+
+// cooldownFor returns the cooldown of group's alertname.
+func (a *Aggregator) cooldownFor(group *AlertGroup) time.Duration {
+	if d, ok := a.cooldownByAlert[group.AlertName]; ok {
+		return d
+	}
+	return a.cooldown
+}
+
+// cooldownKey identifies the alertname and target a cooldown applies to.
+func (a *Aggregator) cooldownKey(group *AlertGroup) string {
+	target := a.creator.buildTarget(group)
+	return fmt.Sprintf("%s/%s/%s/%s", group.AlertName, target.Kind, target.Namespace, target.Name)
+}
+
+// acquireCooldown starts group's cooldown, reporting false when its target is
+// still within an earlier one. Store errors let the task through: a duplicate
+// diagnosis is better than a missed one.
+func (a *Aggregator) acquireCooldown(ctx context.Context, group *AlertGroup) bool {
+	ttl := a.cooldownFor(group)
+	if ttl <= 0 || a.cooldowns == nil {
+		return true
+	}
+	ok, err := a.cooldowns.Acquire(ctx, a.cooldownKey(group), ttl)
+	if err != nil {
+		a.log.Error(err, "failed to check alert cooldown; creating DiagnosisTask",
+			"key", string(group.Key),
+			"alertName", group.AlertName,
+		)
+		return true
+	}
+	return ok
+}
+
+// releaseCooldown ends the cooldown acquired for group, whose task was not created.
+func (a *Aggregator) releaseCooldown(ctx context.Context, group *AlertGroup) {
+	if a.cooldownFor(group) <= 0 || a.cooldowns == nil {
+		return
+	}
+	if err := a.cooldowns.Release(ctx, a.cooldownKey(group)); err != nil {
+		a.log.Error(err, "failed to release alert cooldown", "key", string(group.Key))
+	}
+}
+
+// restoreGroups loads the groups checkpointed by a previous Run. A restored group
+// whose key was ingested again meanwhile is merged into the live one. Restored
+// groups keep their LastSeen, so those whose window has passed flush on the next sweep.
+func (a *Aggregator) restoreGroups(ctx context.Context) {
+	if a.groupStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, groupStoreTimeout)
+	defer cancel()
+	restored, err := a.groupStore.LoadGroups(ctx)
+	if err != nil {
+		a.log.Error(err, "failed to restore alert groups; starting empty")
+		return
+	}
+	if len(restored) == 0 {
+		return
+	}
+
+	a.lock()
+	for _, group := range restored {
+		if live, ok := a.groups[group.Key]; ok {
+			for k, v := range group.MergedLabels {
+				if _, set := live.MergedLabels[k]; !set {
+					live.MergedLabels[k] = v
+				}
+			}
+			for k, v := range group.MergedAnnotations {
+				if _, set := live.MergedAnnotations[k]; !set {
+					if live.MergedAnnotations == nil {
+						live.MergedAnnotations = make(map[string]string, len(group.MergedAnnotations))
+					}
+					live.MergedAnnotations[k] = v
+				}
+			}
+			live.Count += group.Count
+			if group.FirstSeen.Before(live.FirstSeen) {
+				live.FirstSeen = group.FirstSeen
+			}
+			continue
+		}
+		if group.MergedLabels == nil {
+			group.MergedLabels = make(map[string]string)
+		}
+		a.groups[group.Key] = &group
+	}
+	a.groupsDirty = true
+	a.syncGroupsGauge()
+	a.mu.Unlock()
+
+	a.log.Info("restored alert groups from checkpoint", "groups", len(restored))
+}
+
+// checkpoint saves the current groups to the group store when they changed
+// since the last successful checkpoint.
+func (a *Aggregator) checkpoint(ctx context.Context) {
+	if a.groupStore == nil {
+		return
+	}
+	a.lock()
+	if !a.groupsDirty {
+		a.mu.Unlock()
+		return
+	}
+	snapshot := make([]AlertGroup, 0, len(a.groups))
+	for _, group := range a.groups {
+		g := *group
+		g.MergedLabels = maps.Clone(group.MergedLabels)
+		g.MergedAnnotations = maps.Clone(group.MergedAnnotations)
+		snapshot = append(snapshot, g)
+	}
+	a.groupsDirty = false
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, groupStoreTimeout)
+	defer cancel()
+	if err := a.groupStore.SaveGroups(ctx, snapshot); err != nil {
+		a.log.Error(err, "failed to checkpoint alert groups", "groups", len(snapshot))
+		a.lock()
+		a.groupsDirty = true
+		a.mu.Unlock()
+	}
+}
@@ -2,13 +2,19 @@ package alert
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
 )
 
 func newTestAggregator(windowSize, sweepInterval time.Duration) (*Aggregator, *fake.ClientBuilder) {
@@ -275,6 +281,183 @@ func TestAggregator_ContextCancel_StopsSweep(t *testing.T) {
 	}
 }
 
+func TestAggregator_Shutdown_FlushesPendingGroups(t *testing.T) {
+	// Window far longer than the test: the group can only be flushed by shutdown.
+	agg, _ := newTestAggregator(time.Hour, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		agg.Run(ctx)
+		close(done)
+	}()
+
+	item := AlertItem{
+		Status: "firing",
+		Labels: map[string]string{"alertname": "OOM", "namespace": "default", "pod": "app-1"},
+	}
+	if err := agg.Ingest(item); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after context cancel within deadline")
+	}
+
+	// The task must exist as soon as Run returns, without waiting for the window.
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	if err := agg.creator.client.List(context.Background(), &list); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("expected 1 DiagnosisTask flushed on shutdown, got %d", len(list.Items))
+	}
+	if agg.GroupCount() != 0 {
+		t.Errorf("GroupCount() = %d, want 0 after shutdown flush", agg.GroupCount())
+	}
+}
+
+func TestAggregator_Shutdown_GraceDisabled_DropsPending(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, 10*time.Millisecond)
+	agg.WithShutdownGracePeriod(0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := agg.Ingest(AlertItem{
+		Status: "firing",
+		Labels: map[string]string{"alertname": "OOM", "namespace": "default", "pod": "app-1"},
+	}); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+	cancel()
+	agg.Run(ctx)
+
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	if err := agg.creator.client.List(context.Background(), &list); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("expected no DiagnosisTasks with the final flush disabled, got %d", len(list.Items))
+	}
+}
+
+func TestAggregator_Paused_CreatesNoTasks(t *testing.T) {
+	const window = 30 * time.Millisecond
+	const sweep = 10 * time.Millisecond
+
+	pause := admin.NewPauseSwitch(nil)
+	if err := pause.Pause(context.Background()); err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+
+	agg, _ := newTestAggregator(window, sweep)
+	agg.WithPauseSwitch(pause)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agg.Run(ctx)
+
+	item := AlertItem{
+		Status: "firing",
+		Labels: map[string]string{"alertname": "OOM", "namespace": "default", "pod": "app-1"},
+	}
+	if err := agg.Ingest(item); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+
+	// Wait past the window: the group is flushed but no task is created.
+	time.Sleep(150 * time.Millisecond)
+	if agg.GroupCount() != 0 {
+		t.Errorf("GroupCount() = %d, want 0 (group should still be flushed)", agg.GroupCount())
+	}
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	if err := agg.creator.client.List(ctx, &list); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("expected 0 DiagnosisTasks while paused, got %d", len(list.Items))
+	}
+
+	// After resume, a re-sent alert creates a task again.
+	if err := pause.Resume(context.Background()); err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if err := agg.Ingest(item); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+	waitForTasks(t, agg, 1, 300*time.Millisecond)
+}
+
+func TestAggregator_IngestMany_SingleLockAcquisition(t *testing.T) {
+	agg, _ := newTestAggregator(time.Minute, time.Minute)
+
+	items := largeAlertBatch(5000, 100)
+	var got int
+	agg.onLock = func() { got++ }
+	if err := agg.IngestMany(items); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+	agg.onLock = nil
+	if got != 1 {
+		t.Errorf("lock acquisitions = %d, want 1", got)
+	}
+	if agg.GroupCount() != 100 {
+		t.Errorf("GroupCount() = %d, want 100", agg.GroupCount())
+	}
+}
+
+func TestAggregator_IngestMany_BatchSize(t *testing.T) {
+	agg, _ := newTestAggregator(time.Minute, time.Minute)
+	agg.WithIngestBatchSize(1000)
+
+	var got int
+	agg.onLock = func() { got++ }
+	if err := agg.IngestMany(largeAlertBatch(4500, 10)); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+	agg.onLock = nil
+	if got != 5 {
+		t.Errorf("lock acquisitions = %d, want 5", got)
+	}
+
+	var total int
+	agg.mu.Lock()
+	for _, g := range agg.groups {
+		total += g.Count
+	}
+	agg.mu.Unlock()
+	if total != 4500 {
+		t.Errorf("total ingested count = %d, want 4500", total)
+	}
+}
+
+func BenchmarkAggregator_IngestMany(b *testing.B) {
+	agg, _ := newTestAggregator(time.Minute, time.Minute)
+	items := largeAlertBatch(5000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = agg.IngestMany(items)
+	}
+}
+
+// largeAlertBatch builds n firing alerts spread over the given number of pods.
+func largeAlertBatch(n, pods int) []AlertItem {
+	items := make([]AlertItem, n)
+	for i := range items {
+		items[i] = AlertItem{
+			Status: "firing",
+			Labels: map[string]string{
+				"alertname": "KubePodCrashLooping",
+				"namespace": "default",
+				"pod":       fmt.Sprintf("app-%d", i%pods),
+			},
+		}
+	}
+	return items
+}
+
 // copyMap is a test helper that shallow-copies a map[string]string.
 func copyMap(m map[string]string) map[string]string {
 	out := make(map[string]string, len(m))
@@ -283,3 +466,122 @@ func copyMap(m map[string]string) map[string]string {
 	}
 	return out
 }
+
+func TestAggregator_GroupBy_Deployment(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithGroupBy([]string{"alertname", "namespace", "deployment"})
+
+	alert := func(pod, deployment string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{
+			"alertname": "KubePodCrashLooping", "namespace": "shop", "pod": pod, "deployment": deployment,
+		}}
+	}
+	if err := agg.IngestMany([]AlertItem{
+		alert("cart-1", "cart"),
+		alert("cart-2", "cart"),
+		alert("checkout-1", "checkout"),
+		{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "shop"}},
+	}); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+	if got := agg.GroupCount(); got != 3 {
+		t.Fatalf("GroupCount() = %d, want 3 (cart, checkout, no deployment)", got)
+	}
+	if _, ok := agg.groups["KubePodCrashLooping/shop/_"]; !ok {
+		t.Errorf("missing deployment label should key as _, groups = %v", slices.Collect(maps.Keys(agg.groups)))
+	}
+
+	agg.drain()
+	tasks := waitForTasks(t, agg, 3, time.Second)
+	targets := make(map[string]int)
+	for _, task := range tasks {
+		targets[task.Spec.Target.Kind+"/"+task.Spec.Target.Name] = task.Spec.AlertContext.Count
+	}
+	if targets["Deployment/cart"] != 2 || targets["Deployment/checkout"] != 1 {
+		t.Errorf("task targets (with alert counts) = %v, want Deployment/cart x2 and Deployment/checkout x1", targets)
+	}
+}
+
+func TestAggregator_MaxGroups_RejectsNewGroups(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithMaxGroups(2)
+	before := testutil.ToFloat64(AlertsRejected)
+
+	pod := func(name string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": name}}
+	}
+	for _, name := range []string{"app-1", "app-2"} {
+		if err := agg.Ingest(pod(name)); err != nil {
+			t.Fatalf("Ingest(%s) error: %v", name, err)
+		}
+	}
+
+	if err := agg.Ingest(pod("app-3")); !errors.Is(err, ErrTooManyGroups) {
+		t.Fatalf("Ingest() over the cap error = %v, want ErrTooManyGroups", err)
+	}
+	// Alerts of existing groups still merge.
+	if err := agg.Ingest(pod("app-1")); err != nil {
+		t.Errorf("Ingest() into an existing group error = %v", err)
+	}
+	if got := agg.GroupCount(); got != 2 {
+		t.Errorf("GroupCount() = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(AlertsRejected) - before; got != 1 {
+		t.Errorf("AlertsRejected increased by %v, want 1", got)
+	}
+}
+
+func TestAggregator_Metrics(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	ingested := testutil.ToFloat64(AlertsIngested)
+	active := testutil.ToFloat64(GroupsActive)
+	created := testutil.ToFloat64(DiagnosisTasksCreated)
+
+	pod := func(name string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": name}}
+	}
+	if err := agg.IngestMany([]AlertItem{pod("app-1"), pod("app-1"), pod("app-2")}); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+	if got := testutil.ToFloat64(AlertsIngested) - ingested; got != 3 {
+		t.Errorf("AlertsIngested increased by %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(GroupsActive) - active; got != 2 {
+		t.Errorf("GroupsActive increased by %v, want 2", got)
+	}
+
+	agg.drain()
+	if got := testutil.ToFloat64(GroupsActive) - active; got != 0 {
+		t.Errorf("GroupsActive after flushing = %v above the start, want 0", got)
+	}
+	if got := testutil.ToFloat64(DiagnosisTasksCreated) - created; got != 2 {
+		t.Errorf("DiagnosisTasksCreated increased by %v, want 2", got)
+	}
+}
+
+func TestAggregator_AllowedTargetNamespaces(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithAllowedTargetNamespaces([]string{"team-a"})
+
+	alert := func(namespace string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{
+			"alertname": "KubePodCrashLooping", "namespace": namespace, "pod": "app-1",
+		}}
+	}
+	if err := agg.IngestMany([]AlertItem{alert("team-a"), alert("team-b")}); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+
+	agg.drain()
+	tasks := waitForTasks(t, agg, 2, time.Second)
+	namespaces := make(map[string]string)
+	for _, task := range tasks {
+		namespaces[task.Spec.Target.Namespace] = task.Namespace
+	}
+	if namespaces["team-a"] != "team-a" {
+		t.Errorf("task for allowed namespace team-a created in %q, want team-a", namespaces["team-a"])
+	}
+	if namespaces["team-b"] != "default" {
+		t.Errorf("task for disallowed namespace team-b created in %q, want the target namespace default", namespaces["team-b"])
+	}
+}
@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CooldownStore remembers which targets were diagnosed recently, so a flapping
+// alert does not diagnose the same target over and over.
+type CooldownStore interface {
+	// Acquire starts a cooldown of ttl for key and reports true, or reports false
+	// when key is still within an earlier cooldown.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release ends the cooldown of key, e.g. when its task could not be created.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryCooldownStore is a CooldownStore local to the process.
+type MemoryCooldownStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryCooldownStore returns an empty in-memory CooldownStore.
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{expires: make(map[string]time.Time)}
+}
+
+// Acquire implements CooldownStore. Expired entries are dropped as it goes.
+func (s *MemoryCooldownStore) Acquire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, exp := range s.expires {
+		if !now.Before(exp) {
+			delete(s.expires, k)
+		}
+	}
+	if _, active := s.expires[key]; active {
+		return false, nil
+	}
+	s.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements CooldownStore.
+func (s *MemoryCooldownStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expires, key)
+	return nil
+}
+
+// cooldownKeyPrefix prefixes the Redis keys of active cooldowns.
+const cooldownKeyPrefix = "kubeminds:alert-cooldown:"
+
+// RedisCooldownStore keeps each cooldown as a Redis key expiring with it, so the
+// cooldown is shared by all replicas and survives restarts.
+type RedisCooldownStore struct {
+	client *redis.Client
+}
+
+// NewRedisCooldownStore returns a CooldownStore backed by the provided redis.Client.
+func NewRedisCooldownStore(client *redis.Client) *RedisCooldownStore {
+	return &RedisCooldownStore{client: client}
+}
+
+// Acquire implements CooldownStore with SET NX, so concurrent callers cannot both
+// acquire the same key.
+func (s *RedisCooldownStore) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, cooldownKeyPrefix+key, time.Now().Format(time.RFC3339), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("acquire alert cooldown: %w", err)
+	}
+	return ok, nil
+}
+
+// Release implements CooldownStore.
+func (s *RedisCooldownStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, cooldownKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("release alert cooldown: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// flushAlert flushes a one-alert group with labels, as a sweep would, and returns
+// how many DiagnosisTasks exist afterwards.
+func flushAlert(t *testing.T, agg *Aggregator, labels map[string]string) int {
+	t.Helper()
+	ctx := context.Background()
+	group := &AlertGroup{
+		Key:          buildGroupKey(labels),
+		MergedLabels: labels,
+		AlertName:    labels["alertname"],
+		Namespace:    labels["namespace"],
+		Pod:          labels["pod"],
+		Count:        1,
+	}
+	if err := agg.flush(ctx, group); err != nil {
+		t.Fatalf("flush() error: %v", err)
+	}
+	// Task names carry the creation time in milliseconds; keep them distinct.
+	time.Sleep(2 * time.Millisecond)
+
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	if err := agg.creator.client.List(ctx, &list); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	return len(list.Items)
+}
+
+func TestAggregator_Cooldown_SuppressesRepeatDiagnosis(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithCooldown(cooldown, nil)
+
+	app1 := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "app-1"}
+	app2 := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "app-2"}
+
+	if n := flushAlert(t, agg, app1); n != 1 {
+		t.Fatalf("tasks after first flush = %d, want 1", n)
+	}
+	if n := flushAlert(t, agg, app1); n != 1 {
+		t.Errorf("tasks after repeat within cooldown = %d, want 1 (suppressed)", n)
+	}
+	if n := flushAlert(t, agg, app2); n != 2 {
+		t.Errorf("tasks after another target = %d, want 2", n)
+	}
+
+	time.Sleep(cooldown)
+	if n := flushAlert(t, agg, app1); n != 3 {
+		t.Errorf("tasks after the cooldown = %d, want 3", n)
+	}
+}
+
+func TestAggregator_Cooldown_PerAlertOverride(t *testing.T) {
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithCooldown(time.Hour, map[string]time.Duration{"NodeNotReady": 0})
+
+	node := map[string]string{"alertname": "NodeNotReady", "namespace": "default"}
+	flushAlert(t, agg, node)
+	if n := flushAlert(t, agg, node); n != 2 {
+		t.Errorf("tasks = %d, want 2 (cooldown disabled for NodeNotReady)", n)
+	}
+}
+
+func TestRedisCooldownStore_AcquireRelease(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisCooldownStore(client)
+
+	const key = "KubePodCrashLooping/Pod/default/app-1"
+	if ok, err := store.Acquire(ctx, key, time.Minute); err != nil || !ok {
+		t.Fatalf("first Acquire() = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.Acquire(ctx, key, time.Minute); err != nil || ok {
+		t.Fatalf("Acquire() within cooldown = %v, %v; want false", ok, err)
+	}
+
+	mr.FastForward(time.Minute)
+	if ok, err := store.Acquire(ctx, key, time.Minute); err != nil || !ok {
+		t.Fatalf("Acquire() after cooldown = %v, %v; want true", ok, err)
+	}
+
+	if err := store.Release(ctx, key); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if ok, err := store.Acquire(ctx, key, time.Minute); err != nil || !ok {
+		t.Errorf("Acquire() after Release() = %v, %v; want true", ok, err)
+	}
+}
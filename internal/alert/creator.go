@@ -3,10 +3,13 @@ package alert
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
@@ -16,6 +19,17 @@ import (
 type DiagnosisTaskCreator struct {
 	client    client.Client
 	namespace string // target namespace for created DiagnosisTasks
+
+	// propagateLabels lists alert label keys copied onto the task's metadata.labels.
+	propagateLabels []string
+
+	// groupBy is the aggregator's grouping labels; the resource label among them
+	// picks the target (see buildTarget). Empty means alertname/namespace/pod.
+	groupBy []string
+
+	// allowedNamespaces lists source namespaces whose tasks are created in the
+	// alert's own namespace instead of namespace (see taskNamespace).
+	allowedNamespaces []string
 }
 
 // NewDiagnosisTaskCreator creates a new DiagnosisTaskCreator.
@@ -26,6 +40,28 @@ func NewDiagnosisTaskCreator(k8sClient client.Client, namespace string) *Diagnos
 	}
 }
 
+// WithPropagateLabels copies the given alert label keys onto each created task's
+// metadata.labels (values sanitized to valid label values), e.g. ["team", "severity"].
+func (c *DiagnosisTaskCreator) WithPropagateLabels(keys []string) *DiagnosisTaskCreator {
+	c.propagateLabels = keys
+	return c
+}
+
+// WithGroupBy tells the creator which labels alerts are grouped by, so a group keyed
+// on e.g. "deployment" or "node" targets that resource.
+func (c *DiagnosisTaskCreator) WithGroupBy(labels []string) *DiagnosisTaskCreator {
+	c.groupBy = labels
+	return c
+}
+
+// WithAllowedNamespaces creates the tasks of alerts from the given namespaces in
+// that namespace, so tenant RBAC applies to them. Alerts from other namespaces, or
+// without one, keep the creator's target namespace.
+func (c *DiagnosisTaskCreator) WithAllowedNamespaces(namespaces []string) *DiagnosisTaskCreator {
+	c.allowedNamespaces = namespaces
+	return c
+}
+
 // Create converts an AlertGroup into a DiagnosisTask and creates it via the K8s API.
 // It is idempotent: an AlreadyExists error is treated as success.
 func (c *DiagnosisTaskCreator) Create(ctx context.Context, group *AlertGroup) error {
@@ -52,25 +88,96 @@ func (c *DiagnosisTaskCreator) buildTask(group *AlertGroup) *kubemindsv1alpha1.D
 	for k, v := range group.MergedLabels {
 		labelsCopy[k] = v
 	}
+	priority := severityPriority(group.MergedLabels)
 
 	return &kubemindsv1alpha1.DiagnosisTask{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: c.namespace,
+			Namespace: c.taskNamespace(group),
+			Labels:    c.buildObjectLabels(group),
 		},
 		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
 			Target: target,
 			AlertContext: &kubemindsv1alpha1.AlertContext{
-				Name:   group.AlertName,
-				Labels: labelsCopy,
+				Name:        group.AlertName,
+				Labels:      labelsCopy,
+				Annotations: maps.Clone(group.MergedAnnotations),
+				Count:       group.Count,
 			},
+			Priority: &priority,
 		},
 	}
 }
 
-// buildTarget derives the DiagnosisTarget from the AlertGroup.
-// If the group has a pod label, the target is pod-level; otherwise namespace-level.
+// taskNamespace returns the namespace the group's task is created in: the alert's
+// namespace when it is allowed, otherwise the creator's target namespace.
+func (c *DiagnosisTaskCreator) taskNamespace(group *AlertGroup) string {
+	if group.Namespace != "" && slices.Contains(c.allowedNamespaces, group.Namespace) {
+		return group.Namespace
+	}
+	return c.namespace
+}
+
+// buildObjectLabels returns the task's metadata.labels from the configured
+// propagateLabels. Keys that are not valid label names, or whose value sanitizes
+// to empty, are skipped. Returns nil when nothing is propagated.
+func (c *DiagnosisTaskCreator) buildObjectLabels(group *AlertGroup) map[string]string {
+	var labels map[string]string
+	for _, key := range c.propagateLabels {
+		if len(validation.IsQualifiedName(key)) > 0 {
+			continue
+		}
+		value := sanitizeLabelValue(group.MergedLabels[key])
+		if value == "" {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string, len(c.propagateLabels))
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// targetLabels maps the resource labels a group may be keyed on to the target
+// kind they identify, most specific first.
+var targetLabels = []struct {
+	label string
+	kind  string
+}{
+	{"pod", "Pod"},
+	{"deployment", "Deployment"},
+	{"node", "Node"},
+}
+
+// buildTarget derives the DiagnosisTarget from the AlertGroup. The first resource
+// label of targetLabels the alerts are grouped by, and that the group carries,
+// picks the target: a pod, deployment or (cluster-scoped) node. Without one, a
+// group with a pod label is pod-level; otherwise it is namespace-level.
 func (c *DiagnosisTaskCreator) buildTarget(group *AlertGroup) kubemindsv1alpha1.DiagnosisTarget {
+	ns := group.Namespace
+	if ns == "" {
+		ns = c.namespace
+	}
+
+	groupBy := c.groupBy
+	if len(groupBy) == 0 {
+		groupBy = defaultGroupBy
+	}
+	for _, t := range targetLabels {
+		name := group.MergedLabels[t.label]
+		if name == "" || !slices.Contains(groupBy, t.label) {
+			continue
+		}
+		switch t.kind {
+		case "Node":
+			return kubemindsv1alpha1.DiagnosisTarget{Name: name, Kind: t.kind}
+		case "Pod":
+			return kubemindsv1alpha1.DiagnosisTarget{Namespace: group.Namespace, Name: name, Kind: t.kind}
+		}
+		return kubemindsv1alpha1.DiagnosisTarget{Namespace: ns, Name: name, Kind: t.kind}
+	}
+
 	if group.Pod != "" {
 		return kubemindsv1alpha1.DiagnosisTarget{
 			Namespace: group.Namespace,
@@ -78,11 +185,6 @@ func (c *DiagnosisTaskCreator) buildTarget(group *AlertGroup) kubemindsv1alpha1.
 			Kind:      "Pod",
 		}
 	}
-
-	ns := group.Namespace
-	if ns == "" {
-		ns = c.namespace
-	}
 	return kubemindsv1alpha1.DiagnosisTarget{
 		Namespace: ns,
 		Name:      ns,
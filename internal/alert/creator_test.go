@@ -3,6 +3,7 @@ package alert
 import (
 	"context"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -198,3 +199,143 @@ func TestDiagnosisTaskCreator_AlreadyExists_Idempotent(t *testing.T) {
 		t.Errorf("Create() on already-existing task returned unexpected error: %v", err)
 	}
 }
+
+func TestDiagnosisTaskCreator_PropagateLabels(t *testing.T) {
+	now := time.Now()
+	fakeClient := fake.NewClientBuilder().WithScheme(newTestScheme()).Build()
+	creator := NewDiagnosisTaskCreator(fakeClient, "default").
+		WithPropagateLabels([]string{"team", "severity", "summary", "missing"})
+
+	group := &AlertGroup{
+		AlertName: "KubePodCrashLooping",
+		Namespace: "default",
+		Pod:       "nginx-abc",
+		MergedLabels: map[string]string{
+			"team":     "payments",
+			"severity": "critical",
+			"summary":  "Pod is crash looping!",
+			"reason":   "OOMKilled",
+		},
+		FirstSeen: now,
+		LastSeen:  now,
+		Count:     1,
+	}
+	if err := creator.Create(context.Background(), group); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	if err := fakeClient.List(context.Background(), &list); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("expected 1 DiagnosisTask, got %d", len(list.Items))
+	}
+
+	want := map[string]string{
+		"team":     "payments",
+		"severity": "critical",
+		"summary":  "Pod-is-crash-looping",
+	}
+	got := list.Items[0].Labels
+	if len(got) != len(want) {
+		t.Errorf("Labels = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Labels[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestSanitizeLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	tests := map[string]string{
+		"critical":           "critical",
+		"team/a b":           "team-a-b",
+		"-leading.trailing.": "leading.trailing",
+		long:                 long[:63],
+		"!!!":                "",
+	}
+	for in, want := range tests {
+		if got := sanitizeLabelValue(in); got != want {
+			t.Errorf("sanitizeLabelValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDiagnosisTaskCreator_PriorityFromSeverity(t *testing.T) {
+	c := NewDiagnosisTaskCreator(fake.NewClientBuilder().WithScheme(newTestScheme()).Build(), "default")
+
+	tests := []struct {
+		severity string
+		want     int32
+	}{
+		{"critical", kubemindsv1alpha1.PriorityHigh},
+		{"CRITICAL", kubemindsv1alpha1.PriorityHigh},
+		{"warning", kubemindsv1alpha1.PriorityNormal},
+		{"info", kubemindsv1alpha1.PriorityLow},
+		{"page", kubemindsv1alpha1.PriorityNormal},
+		{"", kubemindsv1alpha1.PriorityNormal},
+	}
+	for _, tt := range tests {
+		group := &AlertGroup{Key: "Alert/default/_", AlertName: "Alert", Namespace: "default", MergedLabels: map[string]string{}}
+		if tt.severity != "" {
+			group.MergedLabels["severity"] = tt.severity
+		}
+		task := c.buildTask(group)
+		if task.Spec.Priority == nil || *task.Spec.Priority != tt.want {
+			t.Errorf("severity %q: Priority = %v, want %d", tt.severity, task.Spec.Priority, tt.want)
+		}
+	}
+
+	if !(kubemindsv1alpha1.PriorityHigh > kubemindsv1alpha1.PriorityNormal && kubemindsv1alpha1.PriorityNormal > kubemindsv1alpha1.PriorityLow) {
+		t.Error("priorities must order critical > warning > info")
+	}
+}
+
+func TestDiagnosisTaskCreator_TargetFromGroupBy(t *testing.T) {
+	labels := map[string]string{
+		"alertname":  "KubeDeploymentReplicasMismatch",
+		"namespace":  "shop",
+		"pod":        "cart-7d9f-abc",
+		"deployment": "cart",
+		"node":       "node-1",
+	}
+	group := &AlertGroup{AlertName: labels["alertname"], Namespace: "shop", Pod: labels["pod"], MergedLabels: labels}
+
+	tests := []struct {
+		name    string
+		groupBy []string
+		want    kubemindsv1alpha1.DiagnosisTarget
+	}{
+		{"default grouping", nil, kubemindsv1alpha1.DiagnosisTarget{Namespace: "shop", Name: "cart-7d9f-abc", Kind: "Pod"}},
+		{"deployment", []string{"alertname", "namespace", "deployment"}, kubemindsv1alpha1.DiagnosisTarget{Namespace: "shop", Name: "cart", Kind: "Deployment"}},
+		{"node", []string{"alertname", "node"}, kubemindsv1alpha1.DiagnosisTarget{Name: "node-1", Kind: "Node"}},
+		{"no resource label keeps pod fallback", []string{"alertname", "namespace"}, kubemindsv1alpha1.DiagnosisTarget{Namespace: "shop", Name: "cart-7d9f-abc", Kind: "Pod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := NewDiagnosisTaskCreator(nil, "default").WithGroupBy(tt.groupBy)
+			if got := creator.buildTarget(group); got != tt.want {
+				t.Errorf("buildTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosisTaskCreator_AllowedNamespaces(t *testing.T) {
+	creator := NewDiagnosisTaskCreator(nil, "kubeminds").WithAllowedNamespaces([]string{"team-a", "team-b"})
+	tests := map[string]string{
+		"team-a":  "team-a",
+		"team-c":  "kubeminds",
+		"default": "kubeminds",
+		"":        "kubeminds",
+	}
+	for source, want := range tests {
+		group := &AlertGroup{AlertName: "KubePodCrashLooping", Namespace: source, Pod: "app-1"}
+		if got := creator.buildTask(group).Namespace; got != want {
+			t.Errorf("task namespace for alert namespace %q = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// GrafanaLegacyPayload is the webhook payload of Grafana legacy (dashboard) alerting.
+// Grafana unified alerting sends the AlertManager format and needs no conversion.
+// See: https://grafana.com/docs/grafana/v8.5/alerting/old-alerting/notifications/#webhook
+type GrafanaLegacyPayload struct {
+	Title       string             `json:"title"`
+	RuleID      int64              `json:"ruleId"`
+	RuleName    string             `json:"ruleName"`
+	RuleURL     string             `json:"ruleUrl"`
+	State       string             `json:"state"` // "alerting" | "ok" | "no_data" | "paused" | "pending"
+	Message     string             `json:"message"`
+	Tags        map[string]string  `json:"tags"`
+	EvalMatches []GrafanaEvalMatch `json:"evalMatches"`
+}
+
+// GrafanaEvalMatch is one series that matched a legacy Grafana alert rule.
+type GrafanaEvalMatch struct {
+	Value  *float64          `json:"value"`
+	Metric string            `json:"metric"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// grafanaStatuses maps legacy Grafana rule states to AlertManager alert statuses.
+// Other states (no_data, paused, pending) keep their name and are not ingested.
+var grafanaStatuses = map[string]string{
+	"alerting": "firing",
+	"ok":       "resolved",
+}
+
+// AlertItems converts the payload to one AlertItem per matched series, or a single
+// item when the rule reported no series. The rule name becomes the alertname and
+// the rule and series tags become the labels.
+func (p *GrafanaLegacyPayload) AlertItems() []AlertItem {
+	status, ok := grafanaStatuses[p.State]
+	if !ok {
+		status = p.State
+	}
+	annotations := map[string]string{}
+	if p.Title != "" {
+		annotations["summary"] = p.Title
+	}
+	if p.Message != "" {
+		annotations["description"] = p.Message
+	}
+
+	newItem := func(match *GrafanaEvalMatch) AlertItem {
+		labels := maps.Clone(p.Tags)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		itemAnnotations := maps.Clone(annotations)
+		if match != nil {
+			maps.Copy(labels, match.Tags)
+			if match.Value != nil {
+				itemAnnotations["value"] = fmt.Sprintf("%s=%g", match.Metric, *match.Value)
+			}
+		}
+		labels["alertname"] = p.RuleName
+		return AlertItem{
+			Status:       status,
+			Labels:       labels,
+			Annotations:  itemAnnotations,
+			GeneratorURL: p.RuleURL,
+		}
+	}
+
+	if len(p.EvalMatches) == 0 {
+		return []AlertItem{newItem(nil)}
+	}
+	items := make([]AlertItem, 0, len(p.EvalMatches))
+	for i := range p.EvalMatches {
+		items = append(items, newItem(&p.EvalMatches[i]))
+	}
+	return items
+}
+
+// decodeWebhookPayload decodes an AlertManager v4 payload, which Grafana unified
+// alerting also sends, or a legacy Grafana payload converted to the same shape.
+// A legacy payload is recognized by its rule name and state and lack of alerts.
+func decodeWebhookPayload(body []byte) (AlertManagerPayload, error) {
+	var shape struct {
+		Alerts   json.RawMessage `json:"alerts"`
+		RuleName string          `json:"ruleName"`
+		State    string          `json:"state"`
+	}
+	if err := json.Unmarshal(body, &shape); err != nil {
+		return AlertManagerPayload{}, err
+	}
+
+	if shape.Alerts == nil && shape.RuleName != "" && shape.State != "" {
+		var legacy GrafanaLegacyPayload
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return AlertManagerPayload{}, fmt.Errorf("decode Grafana payload: %w", err)
+		}
+		return AlertManagerPayload{
+			Status: grafanaStatuses[legacy.State],
+			Alerts: legacy.AlertItems(),
+		}, nil
+	}
+
+	var payload AlertManagerPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return AlertManagerPayload{}, err
+	}
+	return payload, nil
+}
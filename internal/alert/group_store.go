@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GroupStore checkpoints an aggregator's in-window alert groups, so the groups
+// survive a restart instead of being lost with the process.
+type GroupStore interface {
+	// SaveGroups replaces the stored groups with groups.
+	SaveGroups(ctx context.Context, groups []AlertGroup) error
+	// LoadGroups returns the stored groups; none when nothing was saved.
+	LoadGroups(ctx context.Context) ([]AlertGroup, error)
+}
+
+// groupStoreKeyPrefix prefixes the Redis key holding one aggregator's groups.
+const groupStoreKeyPrefix = "kubeminds:alert-groups:"
+
+// groupCheckpointTTL expires a checkpoint nobody refreshes, so groups are not
+// restored after a long outage when their alerts are long gone.
+const groupCheckpointTTL = time.Hour
+
+// RedisGroupStore keeps the groups of one aggregator as a JSON array in a single
+// Redis key, "kubeminds:alert-groups:{name}".
+type RedisGroupStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisGroupStore returns a GroupStore for the aggregator called name (e.g.
+// "default" or a receiver name), backed by the provided redis.Client.
+func NewRedisGroupStore(client *redis.Client, name string) *RedisGroupStore {
+	return &RedisGroupStore{client: client, key: groupStoreKeyPrefix + name}
+}
+
+// SaveGroups writes groups, deleting the key when there are none.
+func (s *RedisGroupStore) SaveGroups(ctx context.Context, groups []AlertGroup) error {
+	if len(groups) == 0 {
+		if err := s.client.Del(ctx, s.key).Err(); err != nil {
+			return fmt.Errorf("delete alert groups: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(groups)
+	if err != nil {
+		return fmt.Errorf("marshal alert groups: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key, data, groupCheckpointTTL).Err(); err != nil {
+		return fmt.Errorf("save alert groups: %w", err)
+	}
+	return nil
+}
+
+// LoadGroups reads the groups saved by SaveGroups.
+func (s *RedisGroupStore) LoadGroups(ctx context.Context) ([]AlertGroup, error) {
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load alert groups: %w", err)
+	}
+	var groups []AlertGroup
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("decode alert groups: %w", err)
+	}
+	return groups, nil
+}
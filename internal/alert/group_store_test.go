@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-logr/logr"
+	"github.com/redis/go-redis/v9"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// memoryGroupStore is a GroupStore shared by the aggregators of a simulated restart.
+type memoryGroupStore struct {
+	mu     sync.Mutex
+	groups []AlertGroup
+	saves  int
+}
+
+func (s *memoryGroupStore) SaveGroups(_ context.Context, groups []AlertGroup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups = groups
+	s.saves++
+	return nil
+}
+
+func (s *memoryGroupStore) LoadGroups(context.Context) ([]AlertGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.groups, nil
+}
+
+func (s *memoryGroupStore) saved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.groups)
+}
+
+func TestAggregator_GroupStore_RecoversGroupsAfterRestart(t *testing.T) {
+	const window = time.Hour // nothing expires before the restart
+	const sweep = 10 * time.Millisecond
+	store := &memoryGroupStore{}
+
+	// First process: ingest, let a sweep checkpoint the groups, then stop without
+	// the final flush, as if killed mid-window.
+	before, _ := newTestAggregator(window, sweep)
+	before.WithGroupStore(store).WithShutdownGracePeriod(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { before.Run(ctx); close(done) }()
+
+	crashLoop := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "app-1", "team": "payments"}
+	for range 3 {
+		if err := before.Ingest(AlertItem{Status: "firing", Labels: crashLoop}); err != nil {
+			t.Fatalf("Ingest() error: %v", err)
+		}
+	}
+	if err := before.Ingest(AlertItem{Status: "firing", Labels: map[string]string{"alertname": "NodeNotReady"}}); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for store.saved() != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if store.saved() != 2 {
+		t.Fatalf("checkpointed groups = %d, want 2", store.saved())
+	}
+
+	// Second process against the same store: the groups are back and still flush.
+	after, _ := newTestAggregator(20*time.Millisecond, sweep)
+	after.WithGroupStore(store)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	go after.Run(ctx2)
+	tasks := waitForTasks(t, after, 2, time.Second)
+	for _, task := range tasks {
+		if task.Spec.AlertContext.Name != "KubePodCrashLooping" {
+			continue
+		}
+		if task.Spec.AlertContext.Count != 3 || task.Spec.AlertContext.Labels["team"] != "payments" {
+			t.Errorf("recovered task alert context = %+v, want count 3 with merged labels", task.Spec.AlertContext)
+		}
+	}
+}
+
+func TestAggregator_GroupStore_MergesRestoredIntoLiveGroup(t *testing.T) {
+	labels := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "app-1"}
+	firstSeen := time.Now().Add(-time.Minute)
+	store := &memoryGroupStore{groups: []AlertGroup{{
+		Key:          buildGroupKey(labels),
+		MergedLabels: map[string]string{"alertname": "KubePodCrashLooping", "team": "payments"},
+		AlertName:    "KubePodCrashLooping",
+		FirstSeen:    firstSeen,
+		LastSeen:     firstSeen,
+		Count:        2,
+	}}}
+
+	agg, _ := newTestAggregator(time.Hour, time.Hour)
+	agg.WithGroupStore(store)
+	if err := agg.Ingest(AlertItem{Status: "firing", Labels: labels}); err != nil {
+		t.Fatalf("Ingest() error: %v", err)
+	}
+	agg.restoreGroups(context.Background())
+
+	group := agg.groups[buildGroupKey(labels)]
+	if group.Count != 3 || !group.FirstSeen.Equal(firstSeen) || group.MergedLabels["team"] != "payments" {
+		t.Errorf("merged group = %+v, want count 3, restored FirstSeen and labels", group)
+	}
+}
+
+func TestAggregator_GroupStore_RemovesGroupBeforeCreatingTask(t *testing.T) {
+	labels := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "app-1"}
+	lastSeen := time.Now().Add(-time.Minute)
+	store := &memoryGroupStore{groups: []AlertGroup{{
+		Key:          buildGroupKey(labels),
+		MergedLabels: labels,
+		AlertName:    "KubePodCrashLooping",
+		Namespace:    "default",
+		Pod:          "app-1",
+		FirstSeen:    lastSeen,
+		LastSeen:     lastSeen,
+		Count:        1,
+	}}}
+
+	// A crash right after Create must not leave the group in the store.
+	storedAtCreate := -1
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			storedAtCreate = store.saved()
+			return cl.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	agg := NewAggregator(c, "default", time.Second, time.Hour, logr.Discard())
+	agg.WithGroupStore(store)
+	agg.restoreGroups(context.Background())
+	agg.sweep(context.Background())
+
+	if storedAtCreate != 0 {
+		t.Errorf("groups in store when the task was created = %d, want 0", storedAtCreate)
+	}
+}
+
+func TestRedisGroupStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisGroupStore(client, "default")
+
+	if groups, err := store.LoadGroups(ctx); err != nil || len(groups) != 0 {
+		t.Fatalf("LoadGroups() on empty store = %v, %v", groups, err)
+	}
+
+	seen := time.Unix(1700000000, 0)
+	want := AlertGroup{Key: "KubePodCrashLooping/default/app-1", MergedLabels: map[string]string{"team": "payments"}, AlertName: "KubePodCrashLooping", FirstSeen: seen, LastSeen: seen, Count: 4}
+	if err := store.SaveGroups(ctx, []AlertGroup{want}); err != nil {
+		t.Fatalf("SaveGroups() error = %v", err)
+	}
+	if ttl := mr.TTL(groupStoreKeyPrefix + "default"); ttl != groupCheckpointTTL {
+		t.Errorf("checkpoint TTL = %v, want %v", ttl, groupCheckpointTTL)
+	}
+	got, err := store.LoadGroups(ctx)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("LoadGroups() = %v, %v", got, err)
+	}
+	if got[0].Key != want.Key || got[0].Count != 4 || !got[0].LastSeen.Equal(seen) || got[0].MergedLabels["team"] != "payments" {
+		t.Errorf("LoadGroups() = %+v, want %+v", got[0], want)
+	}
+
+	if err := store.SaveGroups(ctx, nil); err != nil {
+		t.Fatalf("SaveGroups(nil) error = %v", err)
+	}
+	if mr.Exists(groupStoreKeyPrefix + "default") {
+		t.Error("saving no groups should delete the checkpoint")
+	}
+}
@@ -1,60 +1,157 @@
 package alert
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+// AlertsTruncated counts alerts AlertManager dropped from webhook payloads
+// (truncatedAlerts), i.e. alerts that never reached KubeMinds.
+var AlertsTruncated = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_alert_truncated_total",
+	Help: "Alerts AlertManager reported as truncated from webhook payloads and never delivered.",
+})
+
+// PayloadsRejected counts webhook payloads rejected with 413 Request Entity Too
+// Large, by reason: "body_too_large" (over the body size cap) or "too_many_alerts"
+// (over the alert cap).
+var PayloadsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubeminds_alert_webhook_payloads_rejected_total",
+	Help: "Alert webhook payloads rejected as too large, by reason.",
+}, []string{"reason"})
+
+func init() {
+	metrics.Registry.MustRegister(AlertsTruncated, PayloadsRejected)
+}
+
+// defaultMaxBodyBytes bounds a webhook request body unless WithMaxBodyBytes overrides it.
+const defaultMaxBodyBytes = 10 << 20
+
 // Handler receives AlertManager webhook payloads and feeds them to the Aggregator.
 type Handler struct {
 	aggregator *Aggregator
-	log        logr.Logger
+	// maxAlerts caps the alerts accepted per payload; 0 means no limit.
+	maxAlerts int
+	// maxBodyBytes caps the size of a request body.
+	maxBodyBytes int64
+	log          logr.Logger
 }
 
 // NewHandler creates a new Handler.
 func NewHandler(aggregator *Aggregator, log logr.Logger) *Handler {
 	return &Handler{
-		aggregator: aggregator,
-		log:        log,
+		aggregator:   aggregator,
+		maxBodyBytes: defaultMaxBodyBytes,
+		log:          log,
 	}
 }
 
+// WithMaxAlerts caps how many alerts one payload may carry. Larger payloads are
+// rejected with 413 so no alert is silently dropped; 0 disables the cap.
+func (h *Handler) WithMaxAlerts(n int) *Handler {
+	h.maxAlerts = n
+	return h
+}
+
+// WithMaxBodyBytes caps the size of a request body (default 10 MiB). Larger bodies
+// are rejected with 413 before they are read into memory.
+func (h *Handler) WithMaxBodyBytes(n int64) *Handler {
+	h.maxBodyBytes = n
+	return h
+}
+
 // ServeWebhook handles POST /api/v1/alerts/webhook.
-// It decodes the AlertManager v4 payload, filters out resolved alerts,
-// and ingests each firing alert into the Aggregator.
-// It always responds asynchronously (202 Accepted) on success.
+// It decodes the AlertManager v4 payload (also sent by Grafana unified alerting)
+// or a legacy Grafana alerting payload, passes resolved alerts to
+// Aggregator.CancelResolved (a no-op unless cancel-on-resolve is enabled),
+// and ingests the firing alerts into the Aggregator as a single batch.
+// It always responds asynchronously (202 Accepted) on success, with
+// 413 Request Entity Too Large when the payload exceeds the body size or alert cap, and with
+// 429 Too Many Requests when the aggregator is at its group limit and ingested none of
+// the alerts. A payload partly ingested at the limit is answered 202 with the rejected
+// count, since a retry would merge the ingested alerts twice.
 func (h *Handler) ServeWebhook(w http.ResponseWriter, r *http.Request) {
-	var payload AlertManagerPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		h.log.Error(err, "failed to decode AlertManager payload")
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			PayloadsRejected.WithLabelValues("body_too_large").Inc()
+			h.log.Info("rejecting webhook payload over the body size cap", "maxBodyBytes", tooLarge.Limit)
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.log.Error(err, "failed to read webhook payload")
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	payload, err := decodeWebhookPayload(body)
+	if err != nil {
+		h.log.Error(err, "failed to decode webhook payload")
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	firing := 0
+	if payload.TruncatedAlerts > 0 {
+		AlertsTruncated.Add(float64(payload.TruncatedAlerts))
+		h.log.Info("AlertManager truncated the webhook payload; some alerts were not delivered",
+			"truncated", payload.TruncatedAlerts, "groupKey", payload.GroupKey)
+	}
+
+	if h.maxAlerts > 0 && len(payload.Alerts) > h.maxAlerts {
+		PayloadsRejected.WithLabelValues("too_many_alerts").Inc()
+		h.log.Info("rejecting webhook payload over the alert cap",
+			"alerts", len(payload.Alerts), "maxAlerts", h.maxAlerts)
+		http.Error(w, fmt.Sprintf("payload has %d alerts, the limit is %d; lower max_alerts in the AlertManager webhook_config",
+			len(payload.Alerts), h.maxAlerts), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	firing := make([]AlertItem, 0, len(payload.Alerts))
+	var resolved []AlertItem
 	for _, item := range payload.Alerts {
 		if item.Status != "firing" {
 			h.log.V(1).Info("skipping non-firing alert", "status", item.Status)
+			if item.Status == "resolved" {
+				resolved = append(resolved, item)
+			}
 			continue
 		}
+		firing = append(firing, item)
+	}
 
-		if err := h.aggregator.Ingest(item); err != nil {
-			h.log.Error(err, "failed to ingest alert",
-				"alertname", item.Labels["alertname"],
-				"namespace", item.Labels["namespace"],
-				"pod", item.Labels["pod"],
-			)
-			http.Error(w, "failed to ingest alert", http.StatusInternalServerError)
+	// Cancel before ingesting, so a group that still has firing alerts in this
+	// payload starts over instead of being dropped.
+	cancelled := h.aggregator.CancelResolved(resolved)
+
+	if err := h.aggregator.IngestMany(firing); err != nil {
+		var limitErr *GroupLimitError
+		if errors.As(err, &limitErr) {
+			if limitErr.Ingested == 0 {
+				// Nothing was merged, so AlertManager may safely retry the whole payload.
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			// A retry would merge the ingested alerts again and inflate their groups'
+			// counts, so accept the payload and report what was dropped.
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, "%d of %d alerts rejected: %v\n", limitErr.Rejected, limitErr.Total, ErrTooManyGroups)
 			return
 		}
-		firing++
+		h.log.Error(err, "failed to ingest alerts", "firing", len(firing))
+		http.Error(w, "failed to ingest alert", http.StatusInternalServerError)
+		return
 	}
 
 	h.log.Info("webhook received",
 		"total", len(payload.Alerts),
-		"firing", firing,
+		"firing", len(firing),
+		"cancelledGroups", cancelled,
 	)
 
 	w.WriteHeader(http.StatusAccepted)
@@ -2,14 +2,19 @@ package alert
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
 )
 
 func newTestHandler() (*Handler, *Aggregator) {
@@ -150,3 +155,348 @@ func TestHandler_EmptyAlerts_202(t *testing.T) {
 		t.Errorf("GroupCount() = %d, want 0", agg.GroupCount())
 	}
 }
+
+func TestHandler_TruncatedAlerts_Counted(t *testing.T) {
+	h, agg := newTestHandler()
+	before := testutil.ToFloat64(AlertsTruncated)
+
+	payload := AlertManagerPayload{
+		TruncatedAlerts: 3,
+		Alerts: []AlertItem{
+			{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "nginx-abc"}},
+		},
+	}
+
+	w := postWebhook(t, h, payload)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	if got := testutil.ToFloat64(AlertsTruncated) - before; got != 3 {
+		t.Errorf("AlertsTruncated increment = %v, want 3", got)
+	}
+	// The delivered alerts are still ingested.
+	if agg.GroupCount() != 1 {
+		t.Errorf("GroupCount() = %d, want 1", agg.GroupCount())
+	}
+}
+
+func TestHandler_MaxAlerts_413(t *testing.T) {
+	h, agg := newTestHandler()
+	h.WithMaxAlerts(2)
+	before := testutil.ToFloat64(PayloadsRejected.WithLabelValues("too_many_alerts"))
+
+	alertFor := func(pod string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": pod}}
+	}
+
+	w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{alertFor("a"), alertFor("b"), alertFor("c")}})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("over-cap status = %d, want 413", w.Code)
+	}
+	if got := testutil.ToFloat64(PayloadsRejected.WithLabelValues("too_many_alerts")) - before; got != 1 {
+		t.Errorf("PayloadsRejected{too_many_alerts} increment = %v, want 1", got)
+	}
+	if agg.GroupCount() != 0 {
+		t.Errorf("GroupCount() = %d, want 0 after a rejected payload", agg.GroupCount())
+	}
+
+	w = postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{alertFor("a"), alertFor("b")}})
+	if w.Code != http.StatusAccepted {
+		t.Errorf("at-cap status = %d, want 202", w.Code)
+	}
+	if agg.GroupCount() != 2 {
+		t.Errorf("GroupCount() = %d, want 2", agg.GroupCount())
+	}
+}
+
+func TestHandler_MaxBodyBytes_413(t *testing.T) {
+	h, agg := newTestHandler()
+	h.WithMaxBodyBytes(64)
+	before := testutil.ToFloat64(PayloadsRejected.WithLabelValues("body_too_large"))
+
+	w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{
+		{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "nginx-abc"}},
+	}})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", w.Code)
+	}
+	if got := testutil.ToFloat64(PayloadsRejected.WithLabelValues("body_too_large")) - before; got != 1 {
+		t.Errorf("PayloadsRejected{body_too_large} increment = %v, want 1", got)
+	}
+	if agg.GroupCount() != 0 {
+		t.Errorf("GroupCount() = %d, want 0 after a rejected payload", agg.GroupCount())
+	}
+}
+
+func TestReceiverRouter_RoutesToPerReceiverNamespace(t *testing.T) {
+	const window = 50 * time.Millisecond
+	const sweep = 10 * time.Millisecond
+
+	fakeClient := fake.NewClientBuilder().WithScheme(newTestScheme()).Build()
+	aggA := NewAggregator(fakeClient, "team-a", window, sweep, logr.Discard())
+	aggB := NewAggregator(fakeClient, "team-b", window, sweep, logr.Discard()).
+		WithGroupBy([]string{"alertname"}).
+		WithMinSeverity("warning")
+
+	router := NewReceiverRouter()
+	router.Register("team-a", NewHandler(aggA, logr.Discard()))
+	router.Register("team-b", NewHandler(aggB, logr.Discard()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go aggA.Run(ctx)
+	go aggB.Run(ctx)
+
+	post := func(receiver string, payload AlertManagerPayload) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("marshal payload: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/webhook/"+receiver, bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeReceiver(w, req, receiver)
+		return w
+	}
+
+	alertFor := func(pod, severity string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{
+			"alertname": "KubePodCrashLooping",
+			"namespace": "prod",
+			"pod":       pod,
+			"severity":  severity,
+		}}
+	}
+
+	if w := post("team-a", AlertManagerPayload{Alerts: []AlertItem{alertFor("api-1", "warning")}}); w.Code != http.StatusAccepted {
+		t.Fatalf("team-a status = %d, want 202", w.Code)
+	}
+	// team-b groups by alertname only, so both pods collapse into one group;
+	// the info-level alert is dropped by the severity filter.
+	payloadB := AlertManagerPayload{Alerts: []AlertItem{
+		alertFor("web-1", "critical"),
+		alertFor("web-2", "warning"),
+		{Status: "firing", Labels: map[string]string{"alertname": "Noise", "severity": "info"}},
+	}}
+	if w := post("team-b", payloadB); w.Code != http.StatusAccepted {
+		t.Fatalf("team-b status = %d, want 202", w.Code)
+	}
+	if got := aggB.GroupCount(); got != 1 {
+		t.Errorf("team-b GroupCount() = %d, want 1", got)
+	}
+
+	tasks := waitForTasks(t, aggA, 2, 2*time.Second)
+	byNamespace := map[string]int{}
+	for _, task := range tasks {
+		byNamespace[task.Namespace]++
+	}
+	if byNamespace["team-a"] != 1 || byNamespace["team-b"] != 1 {
+		t.Errorf("tasks per namespace = %v, want one each in team-a and team-b", byNamespace)
+	}
+}
+
+func TestReceiverRouter_UnknownReceiver_404(t *testing.T) {
+	h, _ := newTestHandler()
+	router := NewReceiverRouter()
+	router.Register("team-a", h)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/webhook/nope", bytes.NewReader([]byte(`{"alerts":[]}`)))
+	w := httptest.NewRecorder()
+	router.ServeReceiver(w, req, "nope")
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandler_CancelOnResolve_NoTaskForResolvedGroup(t *testing.T) {
+	agg, _ := newTestAggregator(50*time.Millisecond, 10*time.Millisecond)
+	agg.WithCancelOnResolve(true)
+	h := NewHandler(agg, logr.Discard())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agg.Run(ctx)
+
+	labels := func(pod string) map[string]string {
+		return map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": pod}
+	}
+	postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{
+		{Status: "firing", Labels: labels("nginx-abc")},
+		{Status: "firing", Labels: labels("nginx-def")},
+	}})
+	if w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{{Status: "resolved", Labels: labels("nginx-abc")}}}); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", w.Code)
+	}
+	if got := agg.GroupCount(); got != 1 {
+		t.Fatalf("GroupCount() after resolve = %d, want 1", got)
+	}
+
+	// Only the still-firing pod is diagnosed once the window passes.
+	waitForTasks(t, agg, 1, 500*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	var tasks kubemindsv1alpha1.DiagnosisTaskList
+	if err := agg.creator.client.List(context.Background(), &tasks); err != nil {
+		t.Fatalf("failed to list DiagnosisTasks: %v", err)
+	}
+	if len(tasks.Items) != 1 {
+		t.Fatalf("DiagnosisTasks = %d, want 1", len(tasks.Items))
+	}
+	if pod := tasks.Items[0].Spec.AlertContext.Labels["pod"]; pod != "nginx-def" {
+		t.Errorf("task created for pod %q, want nginx-def", pod)
+	}
+}
+
+func TestHandler_AnnotationsReachDiagnosisTask(t *testing.T) {
+	h, agg := newTestHandler()
+
+	labels := map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": "nginx-abc"}
+	postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{
+		{Status: "firing", Labels: labels, Annotations: map[string]string{
+			"description": "Pod nginx-abc restarted 3 times",
+			"runbook_url": "https://runbooks.example.com/KubePodCrashLooping",
+		}},
+		{Status: "firing", Labels: labels, Annotations: map[string]string{
+			"description": "Pod nginx-abc restarted 5 times",
+		}},
+	}})
+	agg.drain()
+
+	tasks := waitForTasks(t, agg, 1, time.Second)
+	annotations := tasks[0].Spec.AlertContext.Annotations
+	if annotations["description"] != "Pod nginx-abc restarted 5 times" {
+		t.Errorf("description = %q, want the latest alert's", annotations["description"])
+	}
+	if annotations["runbook_url"] != "https://runbooks.example.com/KubePodCrashLooping" {
+		t.Errorf("runbook_url = %q, want it merged from the first alert", annotations["runbook_url"])
+	}
+}
+
+func TestHandler_MaxGroups_429(t *testing.T) {
+	h, agg := newTestHandler()
+	agg.WithMaxGroups(1)
+
+	alert := func(pod string) AlertItem {
+		return AlertItem{Status: "firing", Labels: map[string]string{"alertname": "KubePodCrashLooping", "namespace": "default", "pod": pod}}
+	}
+	if w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{alert("nginx-abc")}}); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", w.Code)
+	}
+	if w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{alert("nginx-def")}}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status at the group limit = %d, want 429", w.Code)
+	}
+	if got := agg.GroupCount(); got != 1 {
+		t.Errorf("GroupCount() = %d, want 1", got)
+	}
+
+	// A payload merged in part is accepted, so AlertManager does not resend the merged alert.
+	w := postWebhook(t, h, AlertManagerPayload{Alerts: []AlertItem{alert("nginx-abc"), alert("nginx-def")}})
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status of a partly ingested payload = %d, want 202", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "1 of 2 alerts rejected") {
+		t.Errorf("body = %q, want the rejected count", w.Body.String())
+	}
+}
+
+func TestHandler_GrafanaUnifiedPayload_Ingested(t *testing.T) {
+	h, agg := newTestHandler()
+
+	// Grafana unified alerting extends the AlertManager format with extra fields.
+	payload := json.RawMessage(`{
+		"receiver": "kubeminds",
+		"status": "firing",
+		"orgId": 1,
+		"alerts": [{
+			"status": "firing",
+			"labels": {"alertname": "HighMemory", "grafana_folder": "k8s", "namespace": "shop", "pod": "cart-1"},
+			"annotations": {"summary": "cart-1 memory above 90%"},
+			"startsAt": "2024-05-01T10:00:00Z",
+			"endsAt": "0001-01-01T00:00:00Z",
+			"generatorURL": "https://grafana.example.com/alerting/grafana/abc/view",
+			"fingerprint": "5a7f0b6c1d2e3f40",
+			"silenceURL": "https://grafana.example.com/alerting/silence/new",
+			"dashboardURL": "",
+			"panelURL": "",
+			"values": {"B": 93.5},
+			"valueString": "[ var='B' labels={pod=cart-1} value=93.5 ]"
+		}],
+		"groupLabels": {"alertname": "HighMemory"},
+		"commonLabels": {"alertname": "HighMemory"},
+		"commonAnnotations": {},
+		"externalURL": "https://grafana.example.com/",
+		"version": "1",
+		"groupKey": "{}:{alertname=\"HighMemory\"}",
+		"truncatedAlerts": 0,
+		"title": "[FIRING:1] HighMemory",
+		"state": "alerting",
+		"message": "**Firing**"
+	}`)
+
+	if w := postWebhook(t, h, payload); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", w.Code)
+	}
+	agg.drain()
+
+	tasks := waitForTasks(t, agg, 1, time.Second)
+	if got := tasks[0].Spec.Target.Name; got != "cart-1" {
+		t.Errorf("target = %q, want cart-1", got)
+	}
+	if got := tasks[0].Spec.AlertContext.Annotations["summary"]; got != "cart-1 memory above 90%" {
+		t.Errorf("summary = %q, want the alert's summary", got)
+	}
+}
+
+func TestHandler_GrafanaLegacyPayload(t *testing.T) {
+	payload := func(state string) json.RawMessage {
+		return json.RawMessage(`{
+			"dashboardId": 1,
+			"evalMatches": [
+				{"value": 100, "metric": "restarts", "tags": {"namespace": "shop", "pod": "cart-1"}},
+				{"value": 42, "metric": "restarts", "tags": {"namespace": "shop", "pod": "cart-2"}}
+			],
+			"message": "Pods are restarting",
+			"orgId": 1,
+			"panelId": 2,
+			"ruleId": 3,
+			"ruleName": "PodRestarts",
+			"ruleUrl": "https://grafana.example.com/d/abc?panelId=2",
+			"state": "` + state + `",
+			"tags": {"severity": "critical"},
+			"title": "[Alerting] PodRestarts"
+		}`)
+	}
+
+	t.Run("alerting ingests each matched series", func(t *testing.T) {
+		h, agg := newTestHandler()
+		if w := postWebhook(t, h, payload("alerting")); w.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202", w.Code)
+		}
+		if got := agg.GroupCount(); got != 2 {
+			t.Fatalf("GroupCount() = %d, want 2", got)
+		}
+		agg.drain()
+
+		tasks := waitForTasks(t, agg, 2, time.Second)
+		for _, task := range tasks {
+			alert := task.Spec.AlertContext
+			if alert.Name != "PodRestarts" || alert.Labels["severity"] != "critical" || task.Spec.Target.Namespace != "shop" {
+				t.Errorf("task %s: alert = %+v, target = %+v; want PodRestarts with the rule tags in namespace shop",
+					task.Name, alert, task.Spec.Target)
+			}
+			if alert.Annotations["description"] != "Pods are restarting" {
+				t.Errorf("task %s: description = %q, want the rule message", task.Name, alert.Annotations["description"])
+			}
+		}
+	})
+
+	t.Run("ok is not ingested", func(t *testing.T) {
+		h, agg := newTestHandler()
+		if w := postWebhook(t, h, payload("ok")); w.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want 202", w.Code)
+		}
+		if got := agg.GroupCount(); got != 0 {
+			t.Errorf("GroupCount() = %d, want 0", got)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"kubeminds/internal/agent"
+)
+
+const (
+	// DefaultL2QueueSize is how many alert events may wait for the L2 writer
+	// before the oldest are dropped.
+	DefaultL2QueueSize = 256
+	// l2AppendTimeout bounds a single L2 append so a dead Redis cannot wedge the writer.
+	l2AppendTimeout = 5 * time.Second
+)
+
+// L2EventsDropped counts alert events dropped because the L2 queue was full.
+var L2EventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeminds_alert_l2_events_dropped_total",
+	Help: "Alert events dropped before reaching the L2 event store because the append queue was full.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(L2EventsDropped)
+}
+
+// l2Writer serializes L2 appends through a bounded queue drained by a single
+// goroutine. When the store is slow and the queue is full, the oldest queued
+// event is dropped so recent context wins and goroutines never pile up.
+type l2Writer struct {
+	store   agent.EventStore
+	queue   chan agent.AlertEvent
+	timeout time.Duration
+	dropped prometheus.Counter
+	log     logr.Logger
+}
+
+func newL2Writer(store agent.EventStore, size int, log logr.Logger) *l2Writer {
+	if size <= 0 {
+		size = DefaultL2QueueSize
+	}
+	return &l2Writer{
+		store:   store,
+		queue:   make(chan agent.AlertEvent, size),
+		timeout: l2AppendTimeout,
+		dropped: L2EventsDropped,
+		log:     log,
+	}
+}
+
+// enqueue adds an event without blocking, evicting the oldest queued event when full.
+func (w *l2Writer) enqueue(ev agent.AlertEvent) {
+	for {
+		select {
+		case w.queue <- ev:
+			return
+		default:
+		}
+		select {
+		case old := <-w.queue:
+			w.dropped.Inc()
+			w.log.V(1).Info("l2: queue full, dropping oldest alert event", "alertName", old.AlertName)
+		default:
+			// The writer drained the queue in the meantime; retry the send.
+		}
+	}
+}
+
+// run appends queued events until ctx is cancelled, then makes one bounded
+// best-effort pass over whatever is still queued.
+func (w *l2Writer) run(ctx context.Context) {
+	for {
+		select {
+		case ev := <-w.queue:
+			w.append(context.Background(), ev)
+		case <-ctx.Done():
+			w.drain()
+			return
+		}
+	}
+}
+
+func (w *l2Writer) drain() {
+	deadline, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	for {
+		select {
+		case ev := <-w.queue:
+			if deadline.Err() != nil {
+				w.dropped.Inc()
+				continue
+			}
+			w.append(deadline, ev)
+		default:
+			return
+		}
+	}
+}
+
+func (w *l2Writer) append(parent context.Context, ev agent.AlertEvent) {
+	ctx, cancel := context.WithTimeout(parent, w.timeout)
+	defer cancel()
+	if err := w.store.AppendAlertEvent(ctx, ev); err != nil {
+		w.log.Error(err, "l2: failed to append alert event", "alertName", ev.AlertName)
+	}
+}
@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"kubeminds/internal/agent"
+)
+
+// blockingEventStore blocks every append until release is closed.
+type blockingEventStore struct {
+	release  chan struct{}
+	started  atomic.Int64
+	appended atomic.Int64
+}
+
+func (s *blockingEventStore) AppendAlertEvent(ctx context.Context, _ agent.AlertEvent) error {
+	s.started.Add(1)
+	select {
+	case <-s.release:
+		s.appended.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *blockingEventStore) GetRecentEvents(context.Context, string, string, string, int) ([]agent.AlertEvent, error) {
+	return nil, nil
+}
+
+func droppedCount(t *testing.T) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := L2EventsDropped.Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestAggregator_L2_SlowStore_BoundedGoroutines(t *testing.T) {
+	const queueSize = 16
+	const groups = 200
+
+	store := &blockingEventStore{release: make(chan struct{})}
+	agg, _ := newTestAggregator(10*time.Millisecond, 10*time.Millisecond)
+	agg.WithL2Store(store).WithL2QueueSize(queueSize)
+
+	droppedBefore := droppedCount(t)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		agg.Run(ctx)
+		close(done)
+	}()
+
+	// A storm of distinct groups, all flushed while the store is stuck.
+	if err := agg.IngestMany(largeAlertBatch(groups, groups)); err != nil {
+		t.Fatalf("IngestMany() error: %v", err)
+	}
+	waitForTasks(t, agg, groups, 15*time.Second)
+
+	// Run + the single writer; a goroutine per flush would add ~200.
+	if got := runtime.NumGoroutine() - baseline; got > 5 {
+		t.Errorf("goroutines grew by %d during the flush storm, want a bounded number", got)
+	}
+	if got := store.started.Load(); got != 1 {
+		t.Errorf("concurrent appends in flight = %d, want 1 (single writer)", got)
+	}
+	// One event is blocked in the store and queueSize are queued; the rest were dropped.
+	if got := droppedCount(t) - droppedBefore; got != groups-queueSize-1 {
+		t.Errorf("dropped events = %v, want %d", got, groups-queueSize-1)
+	}
+
+	// Once the store recovers, the queued events are written and shutdown completes.
+	close(store.release)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not return after the store recovered")
+	}
+	if got := store.appended.Load(); got != queueSize+1 {
+		t.Errorf("appended events = %d, want %d", got, queueSize+1)
+	}
+}
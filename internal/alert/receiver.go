@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ReceiverRouter dispatches webhooks for named receivers
+// (POST /api/v1/alerts/webhook/{receiver}) to per-receiver Handlers, so different
+// alert sources can feed aggregators with their own target namespace, grouping
+// and severity filter.
+type ReceiverRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]*Handler
+}
+
+// NewReceiverRouter creates an empty ReceiverRouter.
+func NewReceiverRouter() *ReceiverRouter {
+	return &ReceiverRouter{handlers: make(map[string]*Handler)}
+}
+
+// Register adds (or replaces) the handler for a receiver name.
+func (r *ReceiverRouter) Register(name string, h *Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[name] = h
+}
+
+// Names returns the registered receiver names, sorted.
+func (r *ReceiverRouter) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeReceiver handles a webhook for the named receiver, responding 404 when
+// the receiver is not registered.
+func (r *ReceiverRouter) ServeReceiver(w http.ResponseWriter, req *http.Request, name string) {
+	r.mu.RLock()
+	h, ok := r.handlers[name]
+	r.mu.RUnlock()
+	if !ok {
+		http.Error(w, "unknown alert receiver", http.StatusNotFound)
+		return
+	}
+	h.ServeWebhook(w, req)
+}
@@ -3,6 +3,10 @@ package alert
 import (
 	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
 )
 
 // AlertManagerPayload is the AlertManager v4 webhook payload format.
@@ -40,14 +44,19 @@ type GroupKey string
 type AlertGroup struct {
 	Key          GroupKey
 	MergedLabels map[string]string // labels merge: later alerts overwrite earlier ones
-	AlertName    string
-	Namespace    string
-	Pod          string // empty for non-pod-level alerts
-	FirstSeen    time.Time
-	LastSeen     time.Time // used for last_seen sliding window expiry
-	Count        int
+	// MergedAnnotations merges the alerts' annotations the same way (nil when none had any).
+	MergedAnnotations map[string]string
+	AlertName         string
+	Namespace         string
+	Pod               string // empty for non-pod-level alerts
+	FirstSeen         time.Time
+	LastSeen          time.Time // used for last_seen sliding window expiry
+	Count             int
 }
 
+// defaultGroupBy is the labels alerts are grouped by unless configured otherwise.
+var defaultGroupBy = []string{"alertname", "namespace", "pod"}
+
 // buildGroupKey constructs a GroupKey from alert labels.
 // Uses alertname + namespace + pod as the three-tuple key.
 // Missing fields default to "_" to avoid ambiguity.
@@ -69,6 +78,52 @@ func buildGroupKey(labels map[string]string) GroupKey {
 	return GroupKey(alertname + "/" + namespace + "/" + pod)
 }
 
+// buildGroupKeyBy constructs a GroupKey from the given label names, in order.
+// An empty groupBy falls back to the default alertname/namespace/pod key.
+func buildGroupKeyBy(labels map[string]string, groupBy []string) GroupKey {
+	if len(groupBy) == 0 {
+		return buildGroupKey(labels)
+	}
+	parts := make([]string, len(groupBy))
+	for i, name := range groupBy {
+		v := labels[name]
+		if v == "" {
+			v = "_"
+		}
+		parts[i] = v
+	}
+	return GroupKey(strings.Join(parts, "/"))
+}
+
+// severityRank orders the conventional Prometheus "severity" label values.
+// Unknown or missing severities rank lowest.
+var severityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"critical": 3,
+}
+
+// meetsMinSeverity reports whether an alert's severity label is at least minSeverity.
+// An empty minSeverity accepts everything.
+func meetsMinSeverity(labels map[string]string, minSeverity string) bool {
+	if minSeverity == "" {
+		return true
+	}
+	return severityRank[strings.ToLower(labels["severity"])] >= severityRank[strings.ToLower(minSeverity)]
+}
+
+// severityPriority maps an alert's severity label to a DiagnosisTask priority:
+// critical > warning > info. Unknown or missing severities get the middle priority.
+func severityPriority(labels map[string]string) int32 {
+	switch severityRank[strings.ToLower(labels["severity"])] {
+	case severityRank["critical"]:
+		return kubemindsv1alpha1.PriorityHigh
+	case severityRank["info"]:
+		return kubemindsv1alpha1.PriorityLow
+	}
+	return kubemindsv1alpha1.PriorityNormal
+}
+
 // sanitizeName converts an arbitrary string into a valid K8s resource name segment.
 // Replaces non-alphanumeric characters with "-", lowercases, and truncates to maxLen.
 func sanitizeName(s string, maxLen int) string {
@@ -87,3 +142,23 @@ func sanitizeName(s string, maxLen int) string {
 	}
 	return result
 }
+
+// sanitizeLabelValue converts an arbitrary string into a valid K8s label value:
+// at most 63 characters of alphanumerics, "-", "_" and ".", starting and ending
+// with an alphanumeric. Other characters become "-".
+func sanitizeLabelValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	result := b.String()
+	if len(result) > validation.LabelValueMaxLength {
+		result = result[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(result, "-_.")
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+// listCache keeps the result of a list call for ttl, so bursts of dashboard
+// requests don't each rebuild it. Zero ttl disables it.
+type listCache[T any] struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	value     T
+	loaded    bool
+	expiresAt time.Time
+}
+
+// get returns the cached value while it is fresh and calls load otherwise.
+// Failed loads are not cached.
+func (c *listCache[T]) get(load func() (T, error)) (T, error) {
+	if c.ttl <= 0 {
+		return load()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && time.Now().Before(c.expiresAt) {
+		return c.value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.value, c.loaded, c.expiresAt = value, true, time.Now().Add(c.ttl)
+	return value, nil
+}
+
+// invalidate drops the cached value so the next get loads it again.
+func (c *listCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	c.value, c.loaded = zero, false
+}
+
+// WithListCacheTTL caches the skill list served by the API for d. Zero (default)
+// queries the skill manager on every request. The tool list is cached by the tool
+// router instead (see tools.Router.WithCacheTTL).
+func (s *Server) WithListCacheTTL(d time.Duration) *Server {
+	s.skillsCache.ttl = d
+	return s
+}
+
+// InvalidateListCache drops the cached skill list and the tool router's cached tool
+// list, e.g. after skills or tool providers were reloaded, so the next request sees
+// the current ones.
+func (s *Server) InvalidateListCache() {
+	s.skillsCache.invalidate()
+	if s.toolRouter != nil {
+		s.toolRouter.Invalidate()
+	}
+}
+
+// listSkillDefinitions returns the skills of the skill manager.
+func (s *Server) listSkillDefinitions() []agent.Skill {
+	skills, _ := s.skillsCache.get(func() ([]agent.Skill, error) {
+		return s.skillManager.ListSkills(), nil
+	})
+	return skills
+}
+
+// reloadLists drops the cached tool and skill lists.
+//
+// POST /api/v1/admin/reload
+func (s *Server) reloadLists(w http.ResponseWriter, r *http.Request) {
+	s.InvalidateListCache()
+	s.log.Info("tool and skill list caches invalidated")
+	w.WriteHeader(http.StatusNoContent)
+}
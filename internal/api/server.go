@@ -2,9 +2,17 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -15,6 +23,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
 	"kubeminds/internal/agent"
 	"kubeminds/internal/alert"
 	"kubeminds/internal/llm"
@@ -26,11 +35,28 @@ type Server struct {
 	client       client.Client
 	k8sClient    kubernetes.Interface
 	skillManager *agent.SkillManager
-	toolRouter   *tools.Router  // Unified tool router
-	alertHandler *alert.Handler // nil when alert webhook is not configured
-	llmRouter    *llm.Router    // nil when LLM is not configured (e.g. mock-only mode)
+	toolRouter   *tools.Router         // Unified tool router
+	alertHandler *alert.Handler        // nil when alert webhook is not configured
+	receivers    *alert.ReceiverRouter // nil when no named receivers are configured
+	llmRouter    *llm.Router           // nil when LLM is not configured (e.g. mock-only mode)
+	pause        *admin.PauseSwitch    // nil when the kill switch is not configured
+	knowledge    agent.KnowledgeBase   // nil when the L3 knowledge base is not configured
+	adminToken   string                // bearer token for admin-only endpoints; empty disables them
+	authToken    string                // bearer token for all /api/v1 routes; empty leaves them open
+	watcher      client.WithWatch      // nil falls back to client when it can watch (see WithTaskWatcher)
+	elected      <-chan struct{}       // closed once this replica leads; nil when leader election is off
 	port         int
 	log          logr.Logger
+
+	skillsCache listCache[[]agent.Skill] // see WithListCacheTTL
+
+	mu  sync.Mutex
+	srv *http.Server // set by Start, stopped by Shutdown
+
+	// stopping is closed when Shutdown begins, ending open task streams, which would
+	// otherwise hold the graceful shutdown until its deadline.
+	stopping     chan struct{}
+	stoppingOnce sync.Once
 }
 
 // NewServer creates a new API server
@@ -42,6 +68,7 @@ func NewServer(client client.Client, k8sClient kubernetes.Interface, skillManage
 		toolRouter:   toolRouter,
 		port:         port,
 		log:          log,
+		stopping:     make(chan struct{}),
 	}
 }
 
@@ -58,28 +85,117 @@ func (s *Server) WithAlertHandler(h *alert.Handler) *Server {
 	return s
 }
 
-// Start starts the API server
+// WithAlertReceiver registers a named alert webhook receiver.
+// When any receiver is set, POST /api/v1/alerts/webhook/{receiver} is registered as a route.
+func (s *Server) WithAlertReceiver(name string, h *alert.Handler) *Server {
+	if s.receivers == nil {
+		s.receivers = alert.NewReceiverRouter()
+	}
+	s.receivers.Register(name, h)
+	return s
+}
+
+// WithKnowledgeBase attaches the L3 knowledge base, enabling DELETE /api/v1/knowledge/{id}.
+func (s *Server) WithKnowledgeBase(kb agent.KnowledgeBase) *Server {
+	s.knowledge = kb
+	return s
+}
+
+// WithAdminToken sets the bearer token required by admin-only endpoints.
+// Those endpoints reject every request while the token is empty.
+func (s *Server) WithAdminToken(token string) *Server {
+	s.adminToken = token
+	return s
+}
+
+// WithAuthToken requires "Authorization: Bearer <token>" on every /api/v1 route;
+// /healthz stays open. The admin token is accepted too, since admin-only endpoints
+// are called with it. An empty token (default) leaves the API unauthenticated.
+func (s *Server) WithAuthToken(token string) *Server {
+	s.authToken = token
+	return s
+}
+
+// WithPauseSwitch attaches the global kill switch, enabling the /api/v1/admin endpoints.
+func (s *Server) WithPauseSwitch(p *admin.PauseSwitch) *Server {
+	s.pause = p
+	return s
+}
+
+// WithLeaderElection makes the routes that create tasks (POST /api/v1/tasks and the
+// alert webhooks) answer 503 until elected is closed, so with several replicas only
+// the leader creates tasks. Every replica keeps serving the other routes.
+func (s *Server) WithLeaderElection(elected <-chan struct{}) *Server {
+	s.elected = elected
+	return s
+}
+
+// Start starts the API server and blocks until it stops. After Shutdown it
+// returns http.ErrServerClosed.
 func (s *Server) Start() error {
+	addr := fmt.Sprintf(":%d", s.port)
+	s.log.Info("listening", "address", addr)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	srv.RegisterOnShutdown(func() {
+		s.stoppingOnce.Do(func() { close(s.stopping) })
+	})
+	s.mu.Lock()
+	s.srv = srv
+	s.mu.Unlock()
+	return srv.ListenAndServe()
+}
+
+// Shutdown stops the server gracefully: it stops accepting connections, ends open
+// task streams and waits for other in-flight requests to finish until ctx is done.
+// It is a no-op before Start.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.srv
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	s.log.Info("shutting down")
+	return srv.Shutdown(ctx)
+}
+
+// Handler builds the HTTP router with all API routes registered.
+func (s *Server) Handler() http.Handler {
 	r := mux.NewRouter()
 	r.Use(loggingMiddleware(s.log))
 
 	// API Routes
 	v1 := r.PathPrefix("/api/v1").Subrouter()
+	if s.authToken != "" {
+		v1.Use(s.requireAuthToken)
+	}
 
 	// Diagnosis Tasks
 	v1.HandleFunc("/tasks", s.listTasks).Methods("GET")
-	v1.HandleFunc("/tasks", s.createTask).Methods("POST")
+	v1.HandleFunc("/tasks", s.requireLeader(s.createTask)).Methods("POST")
 	v1.HandleFunc("/tasks/{namespace}/{name}", s.getTask).Methods("GET")
 	v1.HandleFunc("/tasks/{namespace}/{name}", s.deleteTask).Methods("DELETE")
 	v1.HandleFunc("/tasks/{namespace}/{name}/approve", s.approveTask).Methods("POST")
+	v1.HandleFunc("/tasks/{namespace}/{name}/approve-plan", s.approvePlan).Methods("POST")
+	v1.HandleFunc("/tasks/{namespace}/{name}/rollback", s.rollbackTask).Methods("POST")
+	v1.HandleFunc("/tasks/{namespace}/{name}/cancel", s.cancelTask).Methods("POST")
+	v1.HandleFunc("/tasks/{namespace}/{name}/stream", s.streamTask).Methods("GET")
 
 	// Alert Aggregator webhook
 	if s.alertHandler != nil {
-		v1.HandleFunc("/alerts/webhook", s.alertHandler.ServeWebhook).Methods("POST")
+		v1.HandleFunc("/alerts/webhook", s.requireLeader(s.alertHandler.ServeWebhook)).Methods("POST")
+	}
+	if s.receivers != nil {
+		v1.HandleFunc("/alerts/webhook/{receiver}", s.requireLeader(s.serveAlertReceiver)).Methods("POST")
 	}
 
 	// Skills (MVP: Mocked)
 	v1.HandleFunc("/skills", s.listSkills).Methods("GET")
+	v1.HandleFunc("/skills/match-preview", s.previewSkillMatch).Methods("POST")
 
 	// Config (MVP: Mocked)
 	v1.HandleFunc("/config/tools", s.getToolConfig).Methods("GET")
@@ -87,32 +203,73 @@ func (s *Server) Start() error {
 	// LLM connectivity test
 	v1.HandleFunc("/llm/ping", s.pingLLM).Methods("POST")
 
+	// One-shot analysis of pasted logs, without a cluster target
+	v1.HandleFunc("/analyze-logs", s.analyzeLogs).Methods("POST")
+
+	// Admin: global kill switch for automated diagnosis (admin token required)
+	v1.Handle("/admin/pause", s.requireAdminToken(http.HandlerFunc(s.getPauseState))).Methods("GET")
+	v1.Handle("/admin/pause", s.requireAdminToken(http.HandlerFunc(s.pauseDiagnosis))).Methods("POST")
+	v1.Handle("/admin/resume", s.requireAdminToken(http.HandlerFunc(s.resumeDiagnosis))).Methods("POST")
+
+	// Drop cached tool and skill lists after a reload (admin token required)
+	v1.Handle("/admin/reload", s.requireAdminToken(http.HandlerFunc(s.reloadLists))).Methods("POST")
+
+	// Knowledge base maintenance (admin token required)
+	v1.Handle("/knowledge/{id}", s.requireAdminToken(http.HandlerFunc(s.deleteKnowledge))).Methods("DELETE")
+
 	// Health check
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
 
-	addr := fmt.Sprintf(":%d", s.port)
-	s.log.Info("listening", "address", addr)
-	srv := &http.Server{
-		Addr:              addr,
-		Handler:           r,
-		ReadHeaderTimeout: 5 * time.Second,
-	}
-	return srv.ListenAndServe()
+	return r
 }
 
 // --- Handlers ---
 
-// List Tasks
+// Task list page sizes: the default when ?limit is unset, and the largest allowed.
+const (
+	defaultTaskListLimit = 50
+	maxTaskListLimit     = 500
+)
+
+// listTasks returns DiagnosisTasks ordered by namespace and name, one page at a time.
+// Phase lives in status and cannot be selected server-side, so filters are applied
+// in memory after the List call. The continue token is the opaque key of the last
+// returned task; pass it back to get the next page.
+//
+// GET /api/v1/tasks?namespace=default&phase=Running&kind=Pod&limit=50&continue=<token>
+//
+// Response:
+//
+//	{"items": [...], "total": 120, "continue": "ZGVmYXVsdC90YXNrLTQ5"}
 func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
-	var list kubemindsv1alpha1.DiagnosisTaskList
+	query := r.URL.Query()
+
+	limit := defaultTaskListLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxTaskListLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxTaskListLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var after string
+	if v := query.Get("continue"); v != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			http.Error(w, "invalid continue token", http.StatusBadRequest)
+			return
+		}
+		after = string(decoded)
+	}
 
-	// TODO: Support filtering by namespace and status via query params
+	var list kubemindsv1alpha1.DiagnosisTaskList
 	opts := []client.ListOption{}
-	if ns := r.URL.Query().Get("namespace"); ns != "" {
+	if ns := query.Get("namespace"); ns != "" {
 		opts = append(opts, client.InNamespace(ns))
 	}
 
@@ -121,12 +278,41 @@ func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	phase := kubemindsv1alpha1.DiagnosisPhase(query.Get("phase"))
+	kind := query.Get("kind")
+	items := make([]kubemindsv1alpha1.DiagnosisTask, 0, len(list.Items))
+	for _, task := range list.Items {
+		if phase != "" && task.Status.Phase != phase {
+			continue
+		}
+		if kind != "" && task.Spec.Target.Kind != kind {
+			continue
+		}
+		items = append(items, task)
+	}
+	sort.Slice(items, func(i, j int) bool { return taskListKey(&items[i]) < taskListKey(&items[j]) })
+	total := len(items)
+
+	start := sort.Search(len(items), func(i int) bool { return taskListKey(&items[i]) > after })
+	page := items[start:]
+	next := ""
+	if len(page) > limit {
+		page = page[:limit]
+		next = base64.RawURLEncoding.EncodeToString([]byte(taskListKey(&page[limit-1])))
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"items": list.Items,
-		"total": len(list.Items),
+		"items":    page,
+		"total":    total,
+		"continue": next,
 	})
 }
 
+// taskListKey is the unique key that orders listTasks pages and forms the continue token.
+func taskListKey(task *kubemindsv1alpha1.DiagnosisTask) string {
+	return task.Namespace + "/" + task.Name
+}
+
 // Create Task
 func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
@@ -199,6 +385,189 @@ func (s *Server) approveTask(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, task)
 }
 
+// approvePlanRequest is the body of approvePlan.
+type approvePlanRequest struct {
+	// Approved are the indexes of the planned actions to run; all others are rejected.
+	Approved []int `json:"approved"`
+}
+
+// approvePlan records a human's per-step decision on the remediation plan of a task
+// waiting for approval. The agent then runs only the approved steps, in plan order.
+// An empty list rejects the whole plan.
+//
+// POST /api/v1/tasks/{namespace}/{name}/approve-plan
+//
+// Request body:
+//
+//	{"approved": [0, 2]}
+func (s *Server) approvePlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	key := types.NamespacedName{Namespace: vars["namespace"], Name: vars["name"]}
+
+	var req approvePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var task kubemindsv1alpha1.DiagnosisTask
+	if err := s.client.Get(ctx, key, &task); err != nil {
+		if errors.IsNotFound(err) {
+			http.Error(w, "task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	plan := task.Status.PlannedActions
+	if task.Status.Phase != kubemindsv1alpha1.PhaseWaitingApproval || len(plan) == 0 {
+		http.Error(w, "task has no remediation plan waiting for approval", http.StatusConflict)
+		return
+	}
+	for _, i := range req.Approved {
+		if i < 0 || i >= len(plan) {
+			http.Error(w, fmt.Sprintf("step %d is not in the plan (0-%d)", i, len(plan)-1), http.StatusBadRequest)
+			return
+		}
+	}
+
+	task.Spec.PlanApproval = &kubemindsv1alpha1.PlanApproval{ApprovedSteps: req.Approved}
+	if err := s.client.Update(ctx, &task); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, task)
+}
+
+// Rollback outcomes reported per action by rollbackTask.
+const (
+	rollbackStatusRolledBack      = "rolled_back"
+	rollbackStatusAlreadyDone     = "already_rolled_back"
+	rollbackStatusNotRollbackable = "not_rollbackable"
+	rollbackStatusFailed          = "failed"
+)
+
+// rollbackResult is the outcome of rolling back one recorded action.
+type rollbackResult struct {
+	Tool   string `json:"tool"`
+	Target string `json:"target,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// rollbackTask reverses a finished task's recorded ActionsTaken, newest first, by
+// calling each reversible tool again with its captured RollbackArguments.
+// Actions without rollback arguments (e.g. delete_pod) are reported and skipped.
+// Rolling back is best-effort: a failed action does not stop the remaining ones.
+//
+// POST /api/v1/tasks/{namespace}/{name}/rollback
+//
+// Response:
+//
+//	{"actions":[{"tool":"scale_statefulset","target":"default/db","status":"rolled_back"},
+//	            {"tool":"delete_pod","target":"default/app-1","status":"not_rollbackable"}]}
+func (s *Server) rollbackTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	key := types.NamespacedName{Namespace: vars["namespace"], Name: vars["name"]}
+
+	if s.toolRouter == nil {
+		http.Error(w, "tool router not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var task kubemindsv1alpha1.DiagnosisTask
+	if err := s.client.Get(ctx, key, &task); err != nil {
+		if errors.IsNotFound(err) {
+			http.Error(w, "task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	// A running agent could act again concurrently, so only finished tasks are rolled back.
+	if !task.Status.Phase.IsTerminal() {
+		http.Error(w, fmt.Sprintf("task is %s; only finished tasks can be rolled back", task.Status.Phase), http.StatusConflict)
+		return
+	}
+
+	available, err := s.toolRouter.ListTools(ctx)
+	if err != nil {
+		s.log.Error(err, "failed to list tools")
+		http.Error(w, "failed to list tools", http.StatusInternalServerError)
+		return
+	}
+	toolsByName := make(map[string]agent.Tool, len(available))
+	for _, t := range available {
+		toolsByName[t.Name()] = t
+	}
+
+	results := []rollbackResult{}
+	if task.Status.Report != nil {
+		actions := task.Status.Report.ActionsTaken
+		for i := len(actions) - 1; i >= 0; i-- {
+			action := &actions[i]
+			res := rollbackResult{Tool: action.Tool, Target: action.Target}
+			switch tool, ok := toolsByName[action.Tool]; {
+			case action.RolledBackAt != "":
+				res.Status = rollbackStatusAlreadyDone
+			case action.RollbackArguments == "":
+				res.Status = rollbackStatusNotRollbackable
+			case !ok:
+				res.Status = rollbackStatusFailed
+				res.Error = fmt.Sprintf("tool %s is no longer available", action.Tool)
+			default:
+				if _, err := tool.Execute(ctx, action.RollbackArguments); err != nil {
+					res.Status = rollbackStatusFailed
+					res.Error = err.Error()
+				} else {
+					res.Status = rollbackStatusRolledBack
+					action.RolledBackAt = time.Now().Format(time.RFC3339)
+				}
+			}
+			results = append(results, res)
+		}
+		if err := s.client.Status().Update(ctx, &task); err != nil {
+			s.log.Error(err, "failed to record rollback on task", "task", key)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"actions": results})
+}
+
+// cancelTask asks the controller to stop a task's agent while keeping the task.
+// The controller observes spec.cancelled, cancels the running agent and moves the
+// task to the Cancelled phase. Cancelling a finished task has no effect.
+//
+// POST /api/v1/tasks/{namespace}/{name}/cancel
+func (s *Server) cancelTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	key := types.NamespacedName{Namespace: vars["namespace"], Name: vars["name"]}
+
+	var task kubemindsv1alpha1.DiagnosisTask
+	if err := s.client.Get(ctx, key, &task); err != nil {
+		if errors.IsNotFound(err) {
+			http.Error(w, "task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	task.Spec.Cancelled = true
+	if err := s.client.Update(ctx, &task); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, task)
+}
+
 // Delete Task
 func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
@@ -228,10 +597,80 @@ func (s *Server) listSkills(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, map[string]interface{}{"items": []interface{}{}})
 		return
 	}
-	skills := s.skillManager.ListSkills()
+	skills := s.listSkillDefinitions()
 	respondJSON(w, http.StatusOK, map[string]interface{}{"items": skills})
 }
 
+// matchPreviewRequest describes a hypothetical alert for POST /api/v1/skills/match-preview.
+type matchPreviewRequest struct {
+	AlertName string                            `json:"alertName"`
+	Labels    map[string]string                 `json:"labels"`
+	Target    kubemindsv1alpha1.DiagnosisTarget `json:"target"`
+}
+
+type matchPreviewTool struct {
+	Name        string            `json:"name"`
+	SafetyLevel agent.SafetyLevel `json:"safetyLevel"`
+}
+
+type matchPreviewResponse struct {
+	Skill  string             `json:"skill"`
+	Mode   agent.SkillMode    `json:"mode,omitempty"`
+	Reason string             `json:"reason"`
+	Tools  []matchPreviewTool `json:"tools"`
+}
+
+// previewSkillMatch is a dry run of skill selection: it reports which skill a
+// hypothetical alert would match and which tools the agent would be offered,
+// without creating a DiagnosisTask.
+//
+// POST /api/v1/skills/match-preview
+func (s *Server) previewSkillMatch(w http.ResponseWriter, r *http.Request) {
+	if s.skillManager == nil {
+		http.Error(w, "skill manager not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req matchPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	task := &kubemindsv1alpha1.DiagnosisTask{
+		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+			Target: req.Target,
+			AlertContext: &kubemindsv1alpha1.AlertContext{
+				Name:   req.AlertName,
+				Labels: req.Labels,
+			},
+		},
+	}
+	skill, reason := s.skillManager.MatchWithReason(task)
+
+	var allTools []agent.Tool
+	if s.toolRouter != nil {
+		var err error
+		allTools, err = s.toolRouter.ListTools(r.Context())
+		if err != nil {
+			s.log.Error(err, "failed to list tools")
+			http.Error(w, "failed to list tools", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp := matchPreviewResponse{
+		Skill:  skill.Name,
+		Mode:   skill.Mode,
+		Reason: reason,
+		Tools:  []matchPreviewTool{},
+	}
+	for _, t := range agent.ToolsForSkill(allTools, skill) {
+		resp.Tools = append(resp.Tools, matchPreviewTool{Name: t.Name(), SafetyLevel: t.SafetyLevel()})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
 // Get Tool Config
 func (s *Server) getToolConfig(w http.ResponseWriter, r *http.Request) {
 	// For MVP, we list available internal tools.
@@ -280,6 +719,8 @@ func (s *Server) getToolConfig(w http.ResponseWriter, r *http.Request) {
 //
 //	{"provider": "gemini"}   // omit to test the default provider
 //
+// An unknown provider name returns 400; connectivity failures return 200 with an error body.
+//
 // Response:
 //
 //	{"provider":"openai","model":"gpt-4o","status":"ok","latency_ms":342}
@@ -290,8 +731,21 @@ func (s *Server) pingLLM(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Optional body: {"provider": "..."} — ignored for now since we always use defaultProvider.
-	// Kept for future extensibility when per-provider ping is needed.
+	// Optional body: {"provider": "..."} selects a configured provider other than the default.
+	var req struct {
+		Provider string `json:"provider"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	provider := req.Provider
+	if provider == "" {
+		provider = s.llmRouter.DefaultProvider()
+	} else if !s.llmRouter.HasProvider(provider) {
+		http.Error(w, fmt.Sprintf("LLM provider %q is not configured", provider), http.StatusBadRequest)
+		return
+	}
 
 	// Send a minimal chat message that requires only a short response.
 	// Using a fixed timeout to avoid hanging the health check indefinitely.
@@ -299,7 +753,7 @@ func (s *Server) pingLLM(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	start := time.Now()
-	_, err := s.llmRouter.Chat(ctx, []agent.Message{
+	_, err := s.llmRouter.ChatWith(ctx, provider, []agent.Message{
 		{
 			Type:    agent.MessageTypeUser,
 			Content: "Reply with 'pong' only.",
@@ -315,7 +769,7 @@ func (s *Server) pingLLM(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := pingResponse{
-		Provider:  s.llmRouter.DefaultProvider(),
+		Provider:  provider,
 		LatencyMs: latencyMs,
 	}
 
@@ -330,6 +784,148 @@ func (s *Server) pingLLM(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// maxAnalyzeLogsBodyBytes bounds the /analyze-logs request body.
+const maxAnalyzeLogsBodyBytes = 1 << 20
+
+// analyzeLogsTimeout bounds the LLM call of /analyze-logs.
+const analyzeLogsTimeout = 2 * time.Minute
+
+type analyzeLogsRequest struct {
+	Logs    string `json:"logs"`
+	Context string `json:"context"`
+}
+
+type analyzeLogsResponse struct {
+	RootCause    string   `json:"root_cause"`
+	Suggestion   string   `json:"suggestion"`
+	Confidence   *float64 `json:"confidence,omitempty"`
+	Inconclusive bool     `json:"inconclusive,omitempty"`
+}
+
+// analyzeLogs asks the default LLM provider for the root cause of a pasted log
+// excerpt. Nothing is fetched from the cluster and no DiagnosisTask is created.
+//
+// POST /api/v1/analyze-logs
+func (s *Server) analyzeLogs(w http.ResponseWriter, r *http.Request) {
+	if s.llmRouter == nil {
+		http.Error(w, "LLM provider not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req analyzeLogsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnalyzeLogsBodyBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Logs) == "" {
+		http.Error(w, "logs is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), analyzeLogsTimeout)
+	defer cancel()
+	result, err := agent.AnalyzeLogs(ctx, s.llmRouter, req.Logs, req.Context)
+	if err != nil {
+		s.log.Error(err, "failed to analyze logs")
+		http.Error(w, "failed to analyze logs", http.StatusBadGateway)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, analyzeLogsResponse{
+		RootCause:    result.RootCause,
+		Suggestion:   result.Suggestion,
+		Confidence:   result.Confidence,
+		Inconclusive: result.Inconclusive,
+	})
+}
+
+// pauseStateResponse is returned by the /api/v1/admin pause endpoints.
+type pauseStateResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// getPauseState reports whether automated diagnosis is paused.
+//
+// GET /api/v1/admin/pause
+func (s *Server) getPauseState(w http.ResponseWriter, r *http.Request) {
+	if s.pause == nil {
+		http.Error(w, "pause switch not configured", http.StatusServiceUnavailable)
+		return
+	}
+	respondJSON(w, http.StatusOK, pauseStateResponse{Paused: s.pause.Paused()})
+}
+
+// pauseDiagnosis stops the aggregator from creating tasks and the controller from
+// starting agents. The flag is persisted so it survives restarts.
+//
+// POST /api/v1/admin/pause
+func (s *Server) pauseDiagnosis(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, true)
+}
+
+// resumeDiagnosis re-enables automated diagnosis.
+//
+// POST /api/v1/admin/resume
+func (s *Server) resumeDiagnosis(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, false)
+}
+
+func (s *Server) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if s.pause == nil {
+		http.Error(w, "pause switch not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var err error
+	if paused {
+		err = s.pause.Pause(r.Context())
+	} else {
+		err = s.pause.Resume(r.Context())
+	}
+	if err != nil {
+		s.log.Error(err, "failed to update pause state", "paused", paused)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.log.Info("automated diagnosis pause state changed", "paused", paused)
+	respondJSON(w, http.StatusOK, pauseStateResponse{Paused: paused})
+}
+
+// deleteKnowledge removes a diagnosis (and its evidence) from the L3 knowledge base,
+// e.g. when it is wrong or captured sensitive data.
+//
+// DELETE /api/v1/knowledge/{id}
+func (s *Server) deleteKnowledge(w http.ResponseWriter, r *http.Request) {
+	if s.knowledge == nil {
+		http.Error(w, "knowledge base not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if err := s.knowledge.DeleteDiagnosis(r.Context(), id); err != nil {
+		if stderrors.Is(err, agent.ErrKnowledgeNotFound) {
+			http.Error(w, "knowledge entry not found", http.StatusNotFound)
+			return
+		}
+		s.log.Error(err, "failed to delete knowledge entry", "id", id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.log.Info("knowledge entry deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveAlertReceiver(w http.ResponseWriter, r *http.Request) {
+	s.receivers.ServeReceiver(w, r, mux.Vars(r)["receiver"])
+}
+
 // --- Helpers ---
 
 func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -344,6 +940,63 @@ func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
 	_, _ = w.Write(response)
 }
 
+// requireAdminToken rejects requests that do not carry "Authorization: Bearer <adminToken>".
+func (s *Server) requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			http.Error(w, "admin token not configured", http.StatusForbidden)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !tokenMatches(token, s.adminToken) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// leaderRetryAfter is the Retry-After sent by non-leader replicas; a standby can
+// take over within a few seconds once the leader's Lease is released.
+const leaderRetryAfter = "5"
+
+// requireLeader rejects requests with 503 while this replica is not the leader
+// (see WithLeaderElection), so clients such as Alertmanager retry elsewhere.
+func (s *Server) requireLeader(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.elected != nil {
+			select {
+			case <-s.elected:
+			default:
+				w.Header().Set("Retry-After", leaderRetryAfter)
+				http.Error(w, "not the leader replica", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requireAuthToken rejects requests that carry neither "Authorization: Bearer <authToken>"
+// nor the admin token.
+func (s *Server) requireAuthToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !(tokenMatches(token, s.authToken) || tokenMatches(token, s.adminToken)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kubeminds"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tokenMatches compares a presented token with a configured one in constant time.
+// An empty configured token never matches.
+func tokenMatches(presented, configured string) bool {
+	return configured != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(configured)) == 1
+}
+
 func loggingMiddleware(log logr.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,23 +1,35 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
+	"kubeminds/internal/agent"
+	"kubeminds/internal/llm"
 	"kubeminds/internal/tools"
 )
 
@@ -41,7 +53,7 @@ var _ = Describe("API Server", func() {
 		k8sClient = fakeclient.NewClientBuilder().WithScheme(scheme).Build()
 		k8sClientset := fake.NewSimpleClientset()
 		toolRouter := tools.NewRouter(nil)
-		toolRouter.AddProvider(tools.NewInternalProvider(k8sClientset))
+		toolRouter.AddProvider(tools.NewInternalProvider(k8sClientset, nil))
 		server = NewServer(k8sClient, k8sClientset, nil, toolRouter, 8081, logr.Discard())
 	})
 
@@ -107,5 +119,810 @@ var _ = Describe("API Server", func() {
 			items := response["items"].([]interface{})
 			Expect(len(items)).To(Equal(1))
 		})
+
+		Context("with filters and pagination", func() {
+			BeforeEach(func() {
+				k8sClient = fakeclient.NewClientBuilder().WithScheme(scheme).
+					WithStatusSubresource(&kubemindsv1alpha1.DiagnosisTask{}).Build()
+				server = NewServer(k8sClient, fake.NewSimpleClientset(), nil, nil, 8081, logr.Discard())
+
+				seed := []struct {
+					name  string
+					kind  string
+					phase kubemindsv1alpha1.DiagnosisPhase
+				}{
+					{"task-a", "Pod", kubemindsv1alpha1.PhaseRunning},
+					{"task-b", "Pod", kubemindsv1alpha1.PhaseCompleted},
+					{"task-c", "Deployment", kubemindsv1alpha1.PhaseRunning},
+					{"task-d", "Pod", kubemindsv1alpha1.PhaseRunning},
+					{"task-e", "Node", kubemindsv1alpha1.PhaseFailed},
+				}
+				for _, s := range seed {
+					task := &kubemindsv1alpha1.DiagnosisTask{
+						ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: "default"},
+						Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+							Target: kubemindsv1alpha1.DiagnosisTarget{Kind: s.kind, Name: "x"},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+					task.Status.Phase = s.phase
+					Expect(k8sClient.Status().Update(context.Background(), task)).To(Succeed())
+				}
+			})
+
+			type listResponse struct {
+				Items    []kubemindsv1alpha1.DiagnosisTask `json:"items"`
+				Total    int                               `json:"total"`
+				Continue string                            `json:"continue"`
+			}
+
+			list := func(query string) (int, listResponse) {
+				req := httptest.NewRequest("GET", "/api/v1/tasks"+query, nil)
+				rr := httptest.NewRecorder()
+				server.Handler().ServeHTTP(rr, req)
+				var resp listResponse
+				if rr.Code == http.StatusOK {
+					Expect(json.Unmarshal(rr.Body.Bytes(), &resp)).To(Succeed())
+				}
+				return rr.Code, resp
+			}
+
+			names := func(items []kubemindsv1alpha1.DiagnosisTask) []string {
+				out := make([]string, len(items))
+				for i, task := range items {
+					out[i] = task.Name
+				}
+				return out
+			}
+
+			It("should filter by phase", func() {
+				code, resp := list("?phase=Running")
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(names(resp.Items)).To(Equal([]string{"task-a", "task-c", "task-d"}))
+				Expect(resp.Total).To(Equal(3))
+			})
+
+			It("should filter by phase and target kind together", func() {
+				_, resp := list("?phase=Running&kind=Pod")
+				Expect(names(resp.Items)).To(Equal([]string{"task-a", "task-d"}))
+			})
+
+			It("should page through results with the continue token", func() {
+				_, first := list("?limit=2")
+				Expect(names(first.Items)).To(Equal([]string{"task-a", "task-b"}))
+				Expect(first.Total).To(Equal(5))
+				Expect(first.Continue).NotTo(BeEmpty())
+
+				_, second := list("?limit=2&continue=" + first.Continue)
+				Expect(names(second.Items)).To(Equal([]string{"task-c", "task-d"}))
+
+				_, last := list("?limit=2&continue=" + second.Continue)
+				Expect(names(last.Items)).To(Equal([]string{"task-e"}))
+				Expect(last.Continue).To(BeEmpty())
+			})
+
+			It("should reject an invalid limit or continue token", func() {
+				code, _ := list("?limit=0")
+				Expect(code).To(Equal(http.StatusBadRequest))
+				code, _ = list("?continue=%25%25")
+				Expect(code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("API authentication", func() {
+		request := func(path, token string) int {
+			req := httptest.NewRequest("GET", path, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			return rr.Code
+		}
+
+		BeforeEach(func() {
+			server.WithAuthToken("s3cret").WithAdminToken("admin-token")
+		})
+
+		It("should reject requests without a token", func() {
+			Expect(request("/api/v1/tasks", "")).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject a wrong token", func() {
+			Expect(request("/api/v1/tasks", "wrong")).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should accept the configured token", func() {
+			Expect(request("/api/v1/tasks", "s3cret")).To(Equal(http.StatusOK))
+		})
+
+		It("should accept the admin token", func() {
+			Expect(request("/api/v1/tasks", "admin-token")).To(Equal(http.StatusOK))
+		})
+
+		It("should leave /healthz open", func() {
+			Expect(request("/healthz", "")).To(Equal(http.StatusOK))
+		})
+
+		It("should leave the API open when no token is configured", func() {
+			server.WithAuthToken("")
+			Expect(request("/api/v1/tasks", "")).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("Admin pause switch", func() {
+		It("should pause and resume automated diagnosis", func() {
+			pause := admin.NewPauseSwitch(nil)
+			server.WithPauseSwitch(pause)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(server.pauseDiagnosis).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/pause", nil))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(ContainSubstring(`"paused":true`))
+			Expect(pause.Paused()).To(BeTrue())
+
+			rr = httptest.NewRecorder()
+			http.HandlerFunc(server.resumeDiagnosis).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/resume", nil))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(pause.Paused()).To(BeFalse())
+		})
+
+		It("should require the admin token", func() {
+			pause := admin.NewPauseSwitch(nil)
+			server.WithPauseSwitch(pause).WithAdminToken("s3cret")
+
+			send := func(method, path, token string) int {
+				req := httptest.NewRequest(method, path, nil)
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				rr := httptest.NewRecorder()
+				server.Handler().ServeHTTP(rr, req)
+				return rr.Code
+			}
+			Expect(send("POST", "/api/v1/admin/pause", "")).To(Equal(http.StatusUnauthorized))
+			Expect(send("POST", "/api/v1/admin/pause", "wrong")).To(Equal(http.StatusUnauthorized))
+			Expect(send("GET", "/api/v1/admin/pause", "")).To(Equal(http.StatusUnauthorized))
+			Expect(send("POST", "/api/v1/admin/resume", "")).To(Equal(http.StatusUnauthorized))
+			Expect(pause.Paused()).To(BeFalse())
+
+			Expect(send("POST", "/api/v1/admin/pause", "s3cret")).To(Equal(http.StatusOK))
+			Expect(pause.Paused()).To(BeTrue())
+			Expect(send("POST", "/api/v1/admin/resume", "s3cret")).To(Equal(http.StatusOK))
+			Expect(pause.Paused()).To(BeFalse())
+		})
+
+		It("should return 503 when the pause switch is not configured", func() {
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(server.pauseDiagnosis).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/pause", nil))
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Context("Skill match preview", func() {
+		previewMatch := func(body string) (*httptest.ResponseRecorder, matchPreviewResponse) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v1/skills/match-preview", bytes.NewBufferString(body))
+			server.Handler().ServeHTTP(rr, req)
+			var resp matchPreviewResponse
+			if rr.Code == http.StatusOK {
+				Expect(json.Unmarshal(rr.Body.Bytes(), &resp)).To(Succeed())
+			}
+			return rr, resp
+		}
+
+		BeforeEach(func() {
+			sm, err := agent.NewSkillManager("../../skills", nil)
+			Expect(err).NotTo(HaveOccurred())
+			server.skillManager = sm
+		})
+
+		It("should report the matched skill and the tools it would expose", func() {
+			rr, resp := previewMatch(`{"alertName":"KubeContainerOOMKilled","labels":{"severity":"critical"},"target":{"kind":"Pod","name":"app-1","namespace":"prod"}}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(resp.Skill).To(Equal("oom_diagnosis"))
+			Expect(resp.Reason).To(ContainSubstring("KubeContainerOOMKilled"))
+
+			names := make([]string, 0, len(resp.Tools))
+			for _, t := range resp.Tools {
+				names = append(names, t.Name)
+			}
+			Expect(names).To(ConsistOf("get_pod_logs", "get_pod_events", "get_pod_spec"))
+
+			// Dry run: no task is created.
+			var list kubemindsv1alpha1.DiagnosisTaskList
+			Expect(k8sClient.List(context.Background(), &list)).To(Succeed())
+			Expect(list.Items).To(BeEmpty())
+		})
+
+		It("should fall back to the base skill with all tools", func() {
+			rr, resp := previewMatch(`{"alertName":"SomethingUnknown"}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(resp.Skill).To(Equal("base_skill"))
+			Expect(resp.Reason).To(ContainSubstring("base_skill"))
+			Expect(resp.Tools).NotTo(BeEmpty())
+		})
+
+		It("should reject an invalid body", func() {
+			rr, _ := previewMatch(`{not json`)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("Knowledge base", func() {
+		var kb *memoryKnowledgeBase
+
+		BeforeEach(func() {
+			kb = &memoryKnowledgeBase{findings: []agent.KnowledgeFinding{
+				{ID: "keep", AlertName: "OOMKilled", RootCause: "memory limit"},
+				{ID: "leak", AlertName: "CrashLoop", RootCause: "DB_PASSWORD=hunter2"},
+			}}
+			server.WithKnowledgeBase(kb).WithAdminToken("s3cret")
+		})
+
+		deleteKnowledge := func(id, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("DELETE", "/api/v1/knowledge/"+id, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			return rr
+		}
+
+		It("should delete an entry so it no longer appears in search", func() {
+			rr := deleteKnowledge("leak", "s3cret")
+			Expect(rr.Code).To(Equal(http.StatusNoContent))
+
+			results, err := kb.SearchSimilar(context.Background(), []float32{1}, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].ID).To(Equal("keep"))
+		})
+
+		It("should return 404 for an unknown entry", func() {
+			rr := deleteKnowledge("missing", "s3cret")
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should reject requests without the admin token", func() {
+			Expect(deleteKnowledge("leak", "").Code).To(Equal(http.StatusUnauthorized))
+			Expect(deleteKnowledge("leak", "wrong").Code).To(Equal(http.StatusUnauthorized))
+			Expect(kb.findings).To(HaveLen(2))
+		})
+
+		It("should reject all requests when no admin token is configured", func() {
+			server.WithAdminToken("")
+			Expect(deleteKnowledge("leak", "anything").Code).To(Equal(http.StatusForbidden))
+			Expect(kb.findings).To(HaveLen(2))
+		})
+	})
+
+	Context("Plan approval", func() {
+		BeforeEach(func() {
+			k8sClient = fakeclient.NewClientBuilder().WithScheme(scheme).
+				WithStatusSubresource(&kubemindsv1alpha1.DiagnosisTask{}).Build()
+			server = NewServer(k8sClient, fake.NewSimpleClientset(), nil, nil, 8081, logr.Discard())
+		})
+
+		createTask := func(phase kubemindsv1alpha1.DiagnosisPhase, plan ...kubemindsv1alpha1.PlannedAction) {
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "plan-task", Namespace: "default"},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+			task.Status.Phase = phase
+			task.Status.PlannedActions = plan
+			Expect(k8sClient.Status().Update(context.Background(), task)).To(Succeed())
+		}
+
+		approvePlan := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/v1/tasks/default/plan-task/approve-plan", bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			return rr
+		}
+
+		plan := []kubemindsv1alpha1.PlannedAction{
+			{Index: 0, ToolName: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`},
+			{Index: 1, ToolName: "scale_statefulset", Arguments: `{"namespace":"default","statefulset_name":"db","replicas":3}`},
+		}
+
+		It("should record the approved steps", func() {
+			createTask(kubemindsv1alpha1.PhaseWaitingApproval, plan...)
+			rr := approvePlan(`{"approved":[1]}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var task kubemindsv1alpha1.DiagnosisTask
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "plan-task"}, &task)).To(Succeed())
+			Expect(task.Spec.PlanApproval).NotTo(BeNil())
+			Expect(task.Spec.PlanApproval.ApprovedSteps).To(Equal([]int{1}))
+		})
+
+		It("should reject steps outside the plan", func() {
+			createTask(kubemindsv1alpha1.PhaseWaitingApproval, plan...)
+			Expect(approvePlan(`{"approved":[2]}`).Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return 409 when no plan is waiting", func() {
+			createTask(kubemindsv1alpha1.PhaseRunning)
+			Expect(approvePlan(`{"approved":[0]}`).Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Context("Task rollback", func() {
+		var scaledTo []int32
+
+		BeforeEach(func() {
+			scaledTo = nil
+			k8sClient = fakeclient.NewClientBuilder().WithScheme(scheme).
+				WithStatusSubresource(&kubemindsv1alpha1.DiagnosisTask{}).Build()
+			k8sClientset := fake.NewSimpleClientset()
+			// The fake clientset does not implement the scale subresource; record the writes instead.
+			k8sClientset.PrependReactor("update", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "scale" {
+					return false, nil, nil
+				}
+				scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+				scaledTo = append(scaledTo, scale.Spec.Replicas)
+				return true, scale, nil
+			})
+			toolRouter := tools.NewRouter(nil)
+			toolRouter.AddProvider(tools.NewInternalProvider(k8sClientset, nil))
+			server = NewServer(k8sClient, k8sClientset, nil, toolRouter, 8081, logr.Discard())
+		})
+
+		createTask := func(phase kubemindsv1alpha1.DiagnosisPhase, actions ...kubemindsv1alpha1.RemediationAction) {
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "rollback-task", Namespace: "default"},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+			task.Status.Phase = phase
+			task.Status.Report = &kubemindsv1alpha1.DiagnosisReport{ActionsTaken: actions}
+			Expect(k8sClient.Status().Update(context.Background(), task)).To(Succeed())
+		}
+
+		rollback := func() (*httptest.ResponseRecorder, []rollbackResult) {
+			req := httptest.NewRequest("POST", "/api/v1/tasks/default/rollback-task/rollback", nil)
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			var resp struct {
+				Actions []rollbackResult `json:"actions"`
+			}
+			_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+			return rr, resp.Actions
+		}
+
+		It("should restore a scaled statefulset and skip irreversible actions", func() {
+			createTask(kubemindsv1alpha1.PhaseCompleted,
+				kubemindsv1alpha1.RemediationAction{
+					Tool:              "scale_statefulset",
+					Target:            "default/db",
+					Arguments:         `{"namespace":"default","statefulset_name":"db","replicas":1}`,
+					RollbackArguments: `{"namespace":"default","statefulset_name":"db","replicas":3}`,
+				},
+				kubemindsv1alpha1.RemediationAction{
+					Tool:      "delete_pod",
+					Target:    "default/app-1",
+					Arguments: `{"namespace":"default","pod_name":"app-1"}`,
+				},
+			)
+
+			rr, results := rollback()
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(results).To(Equal([]rollbackResult{
+				{Tool: "delete_pod", Target: "default/app-1", Status: rollbackStatusNotRollbackable},
+				{Tool: "scale_statefulset", Target: "default/db", Status: rollbackStatusRolledBack},
+			}))
+			Expect(scaledTo).To(Equal([]int32{3}))
+
+			var task kubemindsv1alpha1.DiagnosisTask
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "rollback-task"}, &task)).To(Succeed())
+			Expect(task.Status.Report.ActionsTaken[0].RolledBackAt).NotTo(BeEmpty())
+			Expect(task.Status.Report.ActionsTaken[1].RolledBackAt).To(BeEmpty())
+
+			// A second rollback does not apply the same change again.
+			rr, results = rollback()
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(results[1].Status).To(Equal(rollbackStatusAlreadyDone))
+			Expect(scaledTo).To(HaveLen(1))
+		})
+
+		It("should reject tasks that are still running", func() {
+			createTask(kubemindsv1alpha1.PhaseRunning)
+			rr, _ := rollback()
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should return 404 for a missing task", func() {
+			rr, _ := rollback()
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("Task streaming", func() {
+		BeforeEach(func() {
+			k8sClient = fakeclient.NewClientBuilder().WithScheme(scheme).
+				WithStatusSubresource(&kubemindsv1alpha1.DiagnosisTask{}).Build()
+			server = NewServer(k8sClient, fake.NewSimpleClientset(), nil, nil, 8081, logr.Discard())
+		})
+
+		It("should replay progress and stream new history until the task finishes", func() {
+			ctx := context.Background()
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "stream-task", Namespace: "default"},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+			task.Status.History = []string{"[Think] checking logs"}
+			Expect(k8sClient.Status().Update(ctx, task)).To(Succeed())
+
+			ts := httptest.NewServer(server.Handler())
+			defer ts.Close()
+			resp, err := http.Get(ts.URL + "/api/v1/tasks/default/stream-task/stream")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			events := make(chan string)
+			go func() {
+				defer GinkgoRecover()
+				defer close(events)
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if line := scanner.Text(); line != "" {
+						events <- line
+					}
+				}
+			}()
+			next := func() string {
+				var line string
+				Eventually(events, 2*time.Second).Should(Receive(&line))
+				return line
+			}
+
+			Expect(next()).To(Equal("event: history"))
+			Expect(next()).To(Equal(`data: {"index":0,"entry":"[Think] checking logs"}`))
+			Expect(next()).To(Equal("event: phase"))
+			Expect(next()).To(Equal(`data: "Running"`))
+
+			task.Status.History = append(task.Status.History, "[Act] get_pod_logs")
+			task.Status.Events = []kubemindsv1alpha1.HistoryEvent{{Step: 1, Phase: kubemindsv1alpha1.HistoryEventAct, ToolName: "get_pod_logs"}}
+			task.Status.Checkpoint = []kubemindsv1alpha1.Finding{{Step: 1, ToolName: "get_pod_logs"}}
+			task.Status.Phase = kubemindsv1alpha1.PhaseCompleted
+			Expect(k8sClient.Status().Update(ctx, task)).To(Succeed())
+
+			Expect(next()).To(Equal("event: history"))
+			Expect(next()).To(Equal(`data: {"index":1,"entry":"[Act] get_pod_logs"}`))
+			Expect(next()).To(Equal("event: step"))
+			Expect(next()).To(Equal(`data: {"index":0,"event":{"step":1,"phase":"Act","toolName":"get_pod_logs"}}`))
+			Expect(next()).To(Equal("event: finding"))
+			Expect(next()).To(ContainSubstring(`"toolName":"get_pod_logs"`))
+			Expect(next()).To(Equal("event: phase"))
+			Expect(next()).To(Equal(`data: "Completed"`))
+			// A finished task closes the stream.
+			Eventually(events, 2*time.Second).Should(BeClosed())
+		})
+
+		It("should return 404 for a missing task", func() {
+			req := httptest.NewRequest("GET", "/api/v1/tasks/default/missing/stream", nil)
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("Task cancellation", func() {
+		cancel := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/v1/tasks/default/cancel-task/cancel", nil)
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			return rr
+		}
+
+		It("should flag the task as cancelled and keep it", func() {
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "cancel-task", Namespace: "default"},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+
+			rr := cancel()
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			var updated kubemindsv1alpha1.DiagnosisTask
+			Expect(json.Unmarshal(rr.Body.Bytes(), &updated)).To(Succeed())
+			Expect(updated.Spec.Cancelled).To(BeTrue())
+
+			var stored kubemindsv1alpha1.DiagnosisTask
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "cancel-task"}, &stored)).To(Succeed())
+			Expect(stored.Spec.Cancelled).To(BeTrue())
+		})
+
+		It("should return 404 for a missing task", func() {
+			Expect(cancel().Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("List caching", func() {
+		var provider *countingProvider
+
+		BeforeEach(func() {
+			provider = &countingProvider{}
+			router := tools.NewRouter(nil).WithCacheTTL(time.Minute)
+			router.AddProvider(provider)
+			server = NewServer(k8sClient, fake.NewSimpleClientset(), nil, router, 8081, logr.Discard()).
+				WithListCacheTTL(time.Minute).WithAdminToken("s3cret")
+		})
+
+		getToolConfig := func() {
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/config/tools", nil))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		}
+
+		It("should query the tool providers once for rapid calls", func() {
+			getToolConfig()
+			getToolConfig()
+			Expect(provider.calls.Load()).To(Equal(int32(1)))
+		})
+
+		It("should query the providers again after a reload", func() {
+			getToolConfig()
+			req := httptest.NewRequest("POST", "/api/v1/admin/reload", nil)
+			req.Header.Set("Authorization", "Bearer s3cret")
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusNoContent))
+
+			getToolConfig()
+			Expect(provider.calls.Load()).To(Equal(int32(2)))
+		})
+	})
+
+	Context("Leader election", func() {
+		var elected chan struct{}
+
+		createTask := func() *httptest.ResponseRecorder {
+			task := kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "leader-task", Namespace: "default"},
+				Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+					Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Pod", Name: "nginx"},
+				},
+			}
+			body, _ := json.Marshal(task)
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(body)))
+			return rr
+		}
+
+		BeforeEach(func() {
+			elected = make(chan struct{})
+			server.WithLeaderElection(elected)
+		})
+
+		It("should refuse to create tasks until elected", func() {
+			rr := createTask()
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rr.Header().Get("Retry-After")).To(Equal(leaderRetryAfter))
+
+			close(elected)
+			Expect(createTask().Code).To(Equal(http.StatusCreated))
+		})
+
+		It("should keep serving reads on a standby", func() {
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/tasks", nil))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("Graceful shutdown", func() {
+		// start runs the server on a free port until it is shut down, returning the
+		// port and the channel receiving Start's result.
+		start := func() (int, chan error) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			port := l.Addr().(*net.TCPAddr).Port
+			Expect(l.Close()).To(Succeed())
+
+			server = NewServer(k8sClient, fake.NewSimpleClientset(), nil, tools.NewRouter(nil), port, logr.Discard())
+			started := make(chan error, 1)
+			go func() { started <- server.Start() }()
+
+			url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/tasks", port)
+			Eventually(func() (int, error) {
+				resp, err := http.Get(url)
+				if err != nil {
+					return 0, err
+				}
+				defer resp.Body.Close()
+				return resp.StatusCode, nil
+			}, 5*time.Second, 20*time.Millisecond).Should(Equal(http.StatusOK))
+			return port, started
+		}
+
+		It("should serve requests and shut down cleanly", func() {
+			_, started := start()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			Expect(server.Shutdown(ctx)).To(Succeed())
+			Eventually(started).Should(Receive(MatchError(http.ErrServerClosed)))
+		})
+
+		It("should end open task streams", func() {
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "streamed", Namespace: "default"},
+				Status:     kubemindsv1alpha1.DiagnosisTaskStatus{Phase: kubemindsv1alpha1.PhaseRunning},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+			port, started := start()
+
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/v1/tasks/default/streamed/stream", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			ended := make(chan struct{})
+			go func() {
+				defer close(ended)
+				_, _ = io.Copy(io.Discard, resp.Body)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			Expect(server.Shutdown(ctx)).To(Succeed())
+			Eventually(started).Should(Receive(MatchError(http.ErrServerClosed)))
+			Eventually(ended).Should(BeClosed())
+		})
+
+		It("should be a no-op before Start", func() {
+			Expect(server.Shutdown(context.Background())).To(Succeed())
+		})
+	})
+
+	Context("LLM ping", func() {
+		BeforeEach(func() {
+			router, err := llm.NewRouter(map[string]agent.LLMProvider{
+				"openai":    pingProvider{},
+				"anthropic": pingProvider{err: stderrors.New("401 Unauthorized")},
+			}, "openai")
+			Expect(err).NotTo(HaveOccurred())
+			server.WithLLMRouter(router)
+		})
+
+		ping := func(body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+			req := httptest.NewRequest("POST", "/api/v1/llm/ping", bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			var resp map[string]interface{}
+			_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+			return rr, resp
+		}
+
+		It("should ping the default provider without a body", func() {
+			rr, resp := ping("")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(resp["provider"]).To(Equal("openai"))
+			Expect(resp["status"]).To(Equal("ok"))
+		})
+
+		It("should route the ping to the requested provider", func() {
+			rr, resp := ping(`{"provider":"anthropic"}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(resp["provider"]).To(Equal("anthropic"))
+			Expect(resp["status"]).To(Equal("error"))
+			Expect(resp["error"]).To(ContainSubstring("401"))
+		})
+
+		It("should return 400 for an unconfigured provider", func() {
+			rr, _ := ping(`{"provider":"gemini"}`)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("Analyze logs", func() {
+		var provider *analyzeProvider
+
+		BeforeEach(func() {
+			provider = &analyzeProvider{reply: "Confidence: 0.8\nRoot Cause: nil pointer dereference in the order handler\nSuggestion: Guard against a missing customer before reading its address"}
+			router, err := llm.NewRouter(map[string]agent.LLMProvider{"openai": provider}, "openai")
+			Expect(err).NotTo(HaveOccurred())
+			server.WithLLMRouter(router)
+		})
+
+		analyze := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/v1/analyze-logs", bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			return rr
+		}
+
+		It("should return the conclusion parsed from the LLM reply", func() {
+			logs := "panic: runtime error: invalid memory address or nil pointer dereference\n\tmain.(*OrderHandler).Ship(0x0)\n\t/app/order.go:42"
+			body, err := json.Marshal(map[string]string{"logs": logs, "context": "orders service, deployed an hour ago"})
+			Expect(err).NotTo(HaveOccurred())
+
+			rr := analyze(string(body))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			var resp map[string]interface{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &resp)).To(Succeed())
+			Expect(resp["root_cause"]).To(Equal("nil pointer dereference in the order handler"))
+			Expect(resp["suggestion"]).To(Equal("Guard against a missing customer before reading its address"))
+			Expect(resp["confidence"]).To(BeNumerically("~", 0.8))
+
+			Expect(provider.prompt).To(ContainSubstring("/app/order.go:42"))
+			Expect(provider.prompt).To(ContainSubstring("orders service, deployed an hour ago"))
+			Expect(provider.tools).To(BeEmpty())
+		})
+
+		It("should return 400 without logs", func() {
+			Expect(analyze(`{"context": "orders"}`).Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return 503 without an LLM", func() {
+			server.llmRouter = nil
+			Expect(analyze(`{"logs": "panic"}`).Code).To(Equal(http.StatusServiceUnavailable))
+		})
 	})
 })
+
+// analyzeProvider is an agent.LLMProvider that records the prompt and tools it
+// is sent and replies with reply.
+type analyzeProvider struct {
+	reply  string
+	prompt string
+	tools  []agent.Tool
+}
+
+func (p *analyzeProvider) Chat(_ context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	p.prompt = messages[len(messages)-1].Content
+	p.tools = tools
+	return &agent.Message{Type: agent.MessageTypeAssistant, Content: p.reply}, nil
+}
+
+// countingProvider is an agent.ToolProvider that counts its ListTools calls.
+type countingProvider struct{ calls atomic.Int32 }
+
+func (p *countingProvider) ListTools(context.Context) ([]agent.Tool, error) {
+	p.calls.Add(1)
+	return []agent.Tool{&agent.MockTool{NameVal: "get_pod_logs"}}, nil
+}
+
+// pingProvider is an agent.LLMProvider that replies "pong" or fails with err.
+type pingProvider struct{ err error }
+
+func (p pingProvider) Chat(context.Context, []agent.Message, []agent.Tool) (*agent.Message, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &agent.Message{Type: agent.MessageTypeAssistant, Content: "pong"}, nil
+}
+
+// memoryKnowledgeBase is an in-memory agent.KnowledgeBase for API tests.
+type memoryKnowledgeBase struct {
+	findings []agent.KnowledgeFinding
+}
+
+func (m *memoryKnowledgeBase) InitSchema(context.Context) error { return nil }
+
+func (m *memoryKnowledgeBase) SaveDiagnosis(_ context.Context, f agent.KnowledgeFinding, _ []float32, _ ...agent.EvidenceVector) error {
+	m.findings = append(m.findings, f)
+	return nil
+}
+
+func (m *memoryKnowledgeBase) SearchSimilar(_ context.Context, _ []float32, limit int) ([]agent.KnowledgeFinding, error) {
+	if len(m.findings) < limit {
+		limit = len(m.findings)
+	}
+	return m.findings[:limit], nil
+}
+
+func (m *memoryKnowledgeBase) DeleteDiagnosis(_ context.Context, id string) error {
+	for i, f := range m.findings {
+		if f.ID == id {
+			m.findings = append(m.findings[:i], m.findings[i+1:]...)
+			return nil
+		}
+	}
+	return agent.ErrKnowledgeNotFound
+}
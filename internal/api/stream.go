@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// Server-Sent Event names emitted by streamTask.
+const (
+	streamEventHistory = "history"
+	streamEventStep    = "step"
+	streamEventFinding = "finding"
+	streamEventPhase   = "phase"
+	streamEventDeleted = "deleted"
+)
+
+// streamHistoryEntry is the data of a history event. A streamed thought is rewritten
+// in place as it grows, so an entry may be sent again with the same index.
+type streamHistoryEntry struct {
+	Index int    `json:"index"`
+	Entry string `json:"entry"`
+}
+
+// streamStep is the data of a step event, one typed history event.
+type streamStep struct {
+	Index int                            `json:"index"`
+	Event kubemindsv1alpha1.HistoryEvent `json:"event"`
+}
+
+// streamFinding is the data of a finding event.
+type streamFinding struct {
+	Index   int                       `json:"index"`
+	Finding kubemindsv1alpha1.Finding `json:"finding"`
+}
+
+// WithTaskWatcher sets the client used to watch tasks for /tasks/{namespace}/{name}/stream.
+// The manager's cached client cannot watch; without a watcher the endpoint uses the
+// server's client when it supports watches and responds 503 otherwise.
+func (s *Server) WithTaskWatcher(c client.WithWatch) *Server {
+	s.watcher = c
+	return s
+}
+
+// streamTask streams a task's progress as Server-Sent Events until the task reaches a
+// terminal phase, is deleted, the client disconnects, or the server shuts down. The history and findings
+// recorded so far are replayed first, then each new entry is sent as it is appended.
+//
+// GET /api/v1/tasks/{namespace}/{name}/stream
+//
+// Events:
+//
+//	event: history
+//	data: {"index":0,"entry":"[Think] Checking pod logs"}
+//
+//	event: step
+//	data: {"index":0,"event":{"step":1,"phase":"Think","content":"Checking pod logs"}}
+//
+//	event: finding
+//	data: {"index":0,"finding":{"step":1,"toolName":"get_pod_logs","summary":"..."}}
+//
+//	event: phase
+//	data: "Completed"
+func (s *Server) streamTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	key := types.NamespacedName{Namespace: vars["namespace"], Name: vars["name"]}
+
+	watcher := s.watcher
+	if watcher == nil {
+		watcher, _ = s.client.(client.WithWatch)
+	}
+	if watcher == nil {
+		http.Error(w, "task streaming not configured", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var task kubemindsv1alpha1.DiagnosisTask
+	if err := s.client.Get(ctx, key, &task); err != nil {
+		if errors.IsNotFound(err) {
+			http.Error(w, "task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Open the watch before replaying so no update between the Get and the watch is lost.
+	var list kubemindsv1alpha1.DiagnosisTaskList
+	watchCh, err := watcher.Watch(ctx, &list, client.InNamespace(key.Namespace),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", key.Name)})
+	if err != nil {
+		s.log.Error(err, "failed to watch task", "task", key)
+		http.Error(w, "failed to watch task", http.StatusInternalServerError)
+		return
+	}
+	defer watchCh.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &taskStream{w: w, flusher: flusher}
+	if !stream.send(&task) || task.Status.Phase.IsTerminal() {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopping:
+			// The client reconnects to another replica or to this one once restarted.
+			return
+		case ev, ok := <-watchCh.ResultChan():
+			if !ok {
+				return
+			}
+			updated, isTask := ev.Object.(*kubemindsv1alpha1.DiagnosisTask)
+			if !isTask || updated.Name != key.Name {
+				continue
+			}
+			switch ev.Type {
+			case watch.Deleted:
+				stream.event(streamEventDeleted, key.String())
+				return
+			case watch.Added, watch.Modified:
+				if !stream.send(updated) || updated.Status.Phase.IsTerminal() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// taskStream tracks what has been sent on one stream so each update only emits
+// what changed.
+type taskStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+
+	history  []string
+	steps    int
+	findings int
+	phase    kubemindsv1alpha1.DiagnosisPhase
+}
+
+// send emits the history entries, typed events, findings and phase of task not sent yet.
+// It returns false once the client can no longer be written to.
+func (st *taskStream) send(task *kubemindsv1alpha1.DiagnosisTask) bool {
+	for i, entry := range task.Status.History {
+		if i < len(st.history) && st.history[i] == entry {
+			continue
+		}
+		if !st.event(streamEventHistory, streamHistoryEntry{Index: i, Entry: entry}) {
+			return false
+		}
+		if i < len(st.history) {
+			st.history[i] = entry
+		} else {
+			st.history = append(st.history, entry)
+		}
+	}
+	for ; st.steps < len(task.Status.Events); st.steps++ {
+		if !st.event(streamEventStep, streamStep{Index: st.steps, Event: task.Status.Events[st.steps]}) {
+			return false
+		}
+	}
+	for ; st.findings < len(task.Status.Checkpoint); st.findings++ {
+		if !st.event(streamEventFinding, streamFinding{Index: st.findings, Finding: task.Status.Checkpoint[st.findings]}) {
+			return false
+		}
+	}
+	if task.Status.Phase != st.phase {
+		st.phase = task.Status.Phase
+		return st.event(streamEventPhase, task.Status.Phase)
+	}
+	return true
+}
+
+// event writes one Server-Sent Event and flushes it to the client.
+func (st *taskStream) event(name string, data any) bool {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(st.w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return false
+	}
+	st.flusher.Flush()
+	return true
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kubeminds/internal/crypto"
+)
+
+// setTestMasterKey sets KUBEMINDS_MASTER_KEY for the test and returns the key.
+func setTestMasterKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	t.Setenv("KUBEMINDS_MASTER_KEY", hex.EncodeToString(key))
+	return key
+}
+
+func encrypt(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+	enc, err := crypto.Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	return enc
+}
+
+func loadConfigYAML(t *testing.T, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	return cfg
+}
+
+func TestLoadConfig_DecryptsRedisPassword(t *testing.T) {
+	key := setTestMasterKey(t)
+	cfg := loadConfigYAML(t, "redis:\n  addr: localhost:6379\n  password: \""+encrypt(t, key, "s3cret")+"\"\n")
+
+	if cfg.Redis.Password != "s3cret" {
+		t.Errorf("Redis.Password = %q, want the decrypted password", cfg.Redis.Password)
+	}
+}
+
+func TestLoadConfig_DecryptsPostgresDSN(t *testing.T) {
+	key := setTestMasterKey(t)
+	const password = "p@ss/w:rd 'x'"
+
+	t.Run("url password", func(t *testing.T) {
+		cfg := loadConfigYAML(t, "postgres:\n  dsn: \"postgres://kubeminds:"+encrypt(t, key, password)+"@db:5432/kubeminds?sslmode=disable\"\n")
+
+		u, err := url.Parse(cfg.PostgreSQL.DSN)
+		if err != nil {
+			t.Fatalf("url.Parse(%q) error = %v", cfg.PostgreSQL.DSN, err)
+		}
+		got, _ := u.User.Password()
+		if u.User.Username() != "kubeminds" || got != password || u.Host != "db:5432" || u.Path != "/kubeminds" {
+			t.Errorf("DSN = %q, want user kubeminds with the decrypted password at db:5432/kubeminds", cfg.PostgreSQL.DSN)
+		}
+	})
+
+	t.Run("key/value password", func(t *testing.T) {
+		cfg := loadConfigYAML(t, "postgres:\n  dsn: \"host=db user=kubeminds password="+encrypt(t, key, password)+" dbname=kubeminds\"\n")
+
+		want := `host=db user=kubeminds password='p@ss/w:rd \'x\'' dbname=kubeminds`
+		if cfg.PostgreSQL.DSN != want {
+			t.Errorf("DSN = %q, want %q", cfg.PostgreSQL.DSN, want)
+		}
+	})
+
+	t.Run("whole dsn", func(t *testing.T) {
+		const dsn = "postgres://kubeminds:plain@db:5432/kubeminds"
+		cfg := loadConfigYAML(t, "postgres:\n  dsn: \""+encrypt(t, key, dsn)+"\"\n")
+
+		if cfg.PostgreSQL.DSN != dsn {
+			t.Errorf("DSN = %q, want %q", cfg.PostgreSQL.DSN, dsn)
+		}
+	})
+
+	t.Run("plain dsn unchanged", func(t *testing.T) {
+		const dsn = "postgres://kubeminds:kubeminds@db:5432/kubeminds?sslmode=disable"
+		cfg := loadConfigYAML(t, "postgres:\n  dsn: \""+dsn+"\"\n")
+
+		if cfg.PostgreSQL.DSN != dsn {
+			t.Errorf("DSN = %q, want it unchanged", cfg.PostgreSQL.DSN)
+		}
+	})
+}
+
+func TestLoadConfig_EncryptedRedisPasswordWithoutMasterKey(t *testing.T) {
+	key := setTestMasterKey(t)
+	enc := encrypt(t, key, "s3cret")
+	t.Setenv("KUBEMINDS_MASTER_KEY", "")
+	t.Setenv("KUBEMINDS_MASTER_KEY_FILE", "")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("redis:\n  password: \""+enc+"\"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() error = nil, want a decryption error without the master key")
+	}
+}
+
+func TestLoadConfig_Validate(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown minSeverity":         "alertAggregator:\n  receivers:\n    - name: prod\n      minSeverity: high\n",
+		"invalid propagateLabels key": "alertAggregator:\n  propagateLabels: [team, \"owner team\"]\n",
+	} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("LoadConfig() with %s error = nil, want error", name)
+		}
+	}
+
+	cfg := loadConfigYAML(t, "alertAggregator:\n  propagateLabels: [team, app.kubernetes.io/part-of]\n  receivers:\n    - name: prod\n      minSeverity: Warning\n")
+	if got := cfg.AlertAggregator.Receivers[0].MinSeverity; got != "Warning" {
+		t.Errorf("MinSeverity = %q, want Warning", got)
+	}
+}
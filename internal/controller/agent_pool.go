@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// RunningAgents is the number of agents currently running in this controller.
+var RunningAgents = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "kubeminds_running_agents",
+	Help: "Diagnosis agents currently running in the controller.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(RunningAgents)
+}
+
+// agentPool caps the agents running at once across all tasks, so a burst of alerts
+// cannot launch more LLM-driven agents than the provider's rate limits and the
+// controller's memory allow. Slots are keyed by skill (see fairnessKey), so fair
+// scheduling shares the same limit. It also keeps RunningAgents up to date.
+type agentPool struct {
+	*slotSemaphore
+}
+
+// newAgentPool creates a pool of limit slots of which reservedFraction is kept from
+// any single skill (see perSkillAgentCap). A non-positive limit never blocks.
+func newAgentPool(limit int, reservedFraction float64) *agentPool {
+	return &agentPool{newSlotSemaphore(limit, perSkillAgentCap(limit, reservedFraction))}
+}
+
+// TryAcquire takes a slot for an agent running skill, reporting false when the
+// pool is full or skill already holds its share.
+func (p *agentPool) TryAcquire(skill string) bool {
+	if !p.slotSemaphore.TryAcquire(skill) {
+		return false
+	}
+	RunningAgents.Inc()
+	return true
+}
+
+// Release frees a slot taken by TryAcquire.
+func (p *agentPool) Release(skill string) {
+	if p.slotSemaphore.Release(skill) {
+		RunningAgents.Dec()
+	}
+}
+
+// agents returns the controller-wide agent pool, built on first use.
+func (r *DiagnosisTaskReconciler) agents() *agentPool {
+	r.agentPoolOnce.Do(func() {
+		r.agentPool = newAgentPool(r.MaxConcurrentAgents, r.FairReservedFraction)
+	})
+	return r.agentPool
+}
+
+// yieldToHigherPriority reports whether task should wait because at least as many
+// higher-priority Pending tasks that could start now are queued as the pool has free
+// slots, so those start first. It never holds tasks back when the pool is unlimited
+// or full.
+func (r *DiagnosisTaskReconciler) yieldToHigherPriority(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, pool *agentPool) (bool, error) {
+	free := pool.Free()
+	if free <= 0 {
+		return false, nil
+	}
+	var tasks kubemindsv1alpha1.DiagnosisTaskList
+	if err := r.List(ctx, &tasks); err != nil {
+		return false, fmt.Errorf("failed to list tasks for priority scheduling: %w", err)
+	}
+	priority := task.Spec.EffectivePriority()
+	higher := 0
+	for i := range tasks.Items {
+		other := &tasks.Items[i]
+		if other.Namespace == task.Namespace && other.Name == task.Name {
+			continue
+		}
+		if other.DeletionTimestamp != nil || other.Spec.Cancelled {
+			continue
+		}
+		if phase := other.Status.Phase; phase != "" && phase != kubemindsv1alpha1.PhasePending {
+			continue
+		}
+		if other.Spec.EffectivePriority() > priority && r.couldStart(other) {
+			higher++
+		}
+	}
+	return higher >= free, nil
+}
+
+// couldStart reports whether a Pending task is held back by nothing but the agent
+// pool's free slots. A task waiting for its RetryAt, or at its namespace cap or fair
+// share, would not take a free slot, so yielding to it would leave the slot idle.
+func (r *DiagnosisTaskReconciler) couldStart(task *kubemindsv1alpha1.DiagnosisTask) bool {
+	return retryWait(task) <= 0 &&
+		r.agentLimiter().Available(agentNamespace(task)) &&
+		r.agents().Available(r.fairnessKey(task))
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_MaxConcurrentAgentsRequeuesOverCap(t *testing.T) {
+	ctx := context.Background()
+	first, second := newPendingTask("first"), newPendingTask("second")
+	first.Spec.Target.Namespace = "a"
+	second.Spec.Target.Namespace = "b"
+
+	r := newTestReconciler(t, first, second)
+	llm := &blockingLLM{release: make(chan struct{})}
+	r.LLMProvider = llm
+	r.MaxConcurrentAgents = 1
+
+	keyOf := func(task *kubemindsv1alpha1.DiagnosisTask) types.NamespacedName {
+		return types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(first)}); err != nil {
+		t.Fatalf("Reconcile(first): %v", err)
+	}
+	waitForPhase(t, r, keyOf(first), kubemindsv1alpha1.PhaseRunning)
+
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(second)})
+	if err != nil {
+		t.Fatalf("Reconcile(second): %v", err)
+	}
+	if res.RequeueAfter != agentPoolRequeueInterval {
+		t.Errorf("over-cap RequeueAfter = %v, want %v", res.RequeueAfter, agentPoolRequeueInterval)
+	}
+	var held kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, keyOf(second), &held); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if held.Status.Phase != kubemindsv1alpha1.PhasePending {
+		t.Errorf("over-cap task phase = %s, want Pending", held.Status.Phase)
+	}
+
+	close(llm.release)
+	waitForPhase(t, r, keyOf(first), kubemindsv1alpha1.PhaseCompleted)
+}
+
+func TestAgentPool(t *testing.T) {
+	p := newAgentPool(1, 0)
+	before := testutil.ToFloat64(RunningAgents)
+	if !p.TryAcquire("") {
+		t.Fatal("first acquire should succeed")
+	}
+	if got := testutil.ToFloat64(RunningAgents) - before; got != 1 {
+		t.Errorf("RunningAgents delta = %v, want 1", got)
+	}
+	if p.TryAcquire("") {
+		t.Error("second acquire should hit the cap")
+	}
+	p.Release("")
+	if got := testutil.ToFloat64(RunningAgents) - before; got != 0 {
+		t.Errorf("RunningAgents delta after release = %v, want 0", got)
+	}
+	if !p.TryAcquire("") {
+		t.Error("acquire after release should succeed")
+	}
+	p.Release("")
+
+	unlimited := newAgentPool(0, 0)
+	for i := 0; i < 5; i++ {
+		if !unlimited.TryAcquire("") {
+			t.Fatalf("acquire %d without a limit should succeed", i)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		unlimited.Release("")
+	}
+}
+
+func TestReconcile_HigherPriorityTaskStartsFirst(t *testing.T) {
+	ctx := context.Background()
+	high, normal := kubemindsv1alpha1.PriorityHigh, kubemindsv1alpha1.PriorityNormal
+	warning, critical := newPendingTask("warning"), newPendingTask("critical")
+	warning.Spec.Priority = &normal
+	critical.Spec.Priority = &high
+
+	r := newTestReconciler(t, warning, critical)
+	llm := &blockingLLM{release: make(chan struct{})}
+	r.LLMProvider = llm
+	r.MaxConcurrentAgents = 1
+
+	keyOf := func(task *kubemindsv1alpha1.DiagnosisTask) types.NamespacedName {
+		return types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	}
+
+	// The warning task is reconciled first but yields the only slot.
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(warning)})
+	if err != nil {
+		t.Fatalf("Reconcile(warning): %v", err)
+	}
+	if res.RequeueAfter != agentPoolRequeueInterval {
+		t.Errorf("warning RequeueAfter = %v, want %v", res.RequeueAfter, agentPoolRequeueInterval)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(critical)}); err != nil {
+		t.Fatalf("Reconcile(critical): %v", err)
+	}
+	waitForPhase(t, r, keyOf(critical), kubemindsv1alpha1.PhaseRunning)
+
+	var held kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, keyOf(warning), &held); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if held.Status.Phase != kubemindsv1alpha1.PhasePending {
+		t.Errorf("warning task phase = %s, want Pending", held.Status.Phase)
+	}
+
+	// Once the critical task is done the warning task gets the slot.
+	close(llm.release)
+	waitForPhase(t, r, keyOf(critical), kubemindsv1alpha1.PhaseCompleted)
+	for i := 0; i < 200 && r.agents().Free() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(warning)}); err != nil {
+		t.Fatalf("Reconcile(warning): %v", err)
+	}
+	waitForPhase(t, r, keyOf(warning), kubemindsv1alpha1.PhaseCompleted)
+}
+
+func TestReconcile_DoesNotYieldToHeldTasks(t *testing.T) {
+	ctx := context.Background()
+	high, normal := kubemindsv1alpha1.PriorityHigh, kubemindsv1alpha1.PriorityNormal
+	busy, normalTask := newPendingTask("busy"), newPendingTask("normal")
+	retrying, capped := newPendingTask("retrying"), newPendingTask("capped")
+	busy.Spec.Target.Namespace, capped.Spec.Target.Namespace = "a", "a"
+	retrying.Spec.Target.Namespace, normalTask.Spec.Target.Namespace = "b", "c"
+	busy.Spec.Priority, normalTask.Spec.Priority = &normal, &normal
+	retrying.Spec.Priority, capped.Spec.Priority = &high, &high
+	// Both high-priority tasks are held by something other than the agent pool.
+	retrying.Status.Attempt = 1
+	retrying.Status.RetryAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	r := newTestReconciler(t, busy, normalTask, retrying, capped)
+	llm := &blockingLLM{release: make(chan struct{})}
+	r.LLMProvider = llm
+	r.MaxConcurrentAgents = 2
+	r.MaxAgentsPerNamespace = 1
+
+	keyOf := func(task *kubemindsv1alpha1.DiagnosisTask) types.NamespacedName {
+		return types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(busy)}); err != nil {
+		t.Fatalf("Reconcile(busy): %v", err)
+	}
+	waitForPhase(t, r, keyOf(busy), kubemindsv1alpha1.PhaseRunning)
+
+	// Namespace a is now at its cap, so the capped task could not take the free slot either.
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(normalTask)}); err != nil {
+		t.Fatalf("Reconcile(normal): %v", err)
+	}
+	waitForPhase(t, r, keyOf(normalTask), kubemindsv1alpha1.PhaseRunning)
+
+	close(llm.release)
+	waitForPhase(t, r, keyOf(busy), kubemindsv1alpha1.PhaseCompleted)
+	waitForPhase(t, r, keyOf(normalTask), kubemindsv1alpha1.PhaseCompleted)
+}
@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+	"kubeminds/internal/tools"
+)
+
+func TestReconcile_WaitingApprovalSetsPendingApproval(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("needs-approval")
+	r := newTestReconciler(t, task)
+	r.ToolRouter.AddProvider(tools.NewInternalProvider(k8sfake.NewClientset(), nil))
+
+	args := `{"namespace":"default","name":"app-1"}`
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	llm.Responses[0] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{
+			ID:       "call-1",
+			Function: agent.FunctionCall{Name: "delete_pod", Arguments: args},
+		}},
+	}
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseWaitingApproval)
+
+	var waiting kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &waiting); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	pa := waiting.Status.PendingApproval
+	if pa == nil {
+		t.Fatal("PendingApproval is nil, want the blocked tool call")
+	}
+	if pa.ToolName != "delete_pod" || pa.Arguments != args || pa.RiskLevel != string(agent.SafetyLevelHighRisk) {
+		t.Errorf("PendingApproval = %+v, want delete_pod %s HighRisk", pa, args)
+	}
+	if pa.RequestedAt == "" {
+		t.Error("PendingApproval.RequestedAt is empty")
+	}
+	if waiting.Status.Message != "Tool delete_pod requires approval." {
+		t.Errorf("Message = %q, want the compatibility message", waiting.Status.Message)
+	}
+
+	// Approving clears the typed request as the task goes back to Running.
+	waiting.Spec.Approved = true
+	if err := r.Update(ctx, &waiting); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() after approval: %v", err)
+	}
+	var running kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &running); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if running.Status.PendingApproval != nil {
+		t.Errorf("PendingApproval = %+v after approval, want nil", running.Status.PendingApproval)
+	}
+}
+
+func TestReconcile_ApprovedDeleteRecordedInReport(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("approved-delete")
+	task.Spec.Approved = true
+	r := newTestReconciler(t, task)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default"}}
+	r.ToolRouter.AddProvider(tools.NewInternalProvider(k8sfake.NewClientset(pod), nil))
+
+	args := `{"namespace":"default","pod_name":"app-1"}`
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	llm.Responses[0] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{
+			ID:       "call-1",
+			Function: agent.FunctionCall{Name: "delete_pod", Arguments: args},
+		}},
+	}
+	llm.Responses[1] = &agent.Message{
+		Type:    agent.MessageTypeAssistant,
+		Content: "Root Cause: stuck pod\nSuggestion: recreated it",
+	}
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	actions := done.Status.Report.ActionsTaken
+	if len(actions) != 1 {
+		t.Fatalf("ActionsTaken = %+v, want the delete_pod call", actions)
+	}
+	a := actions[0]
+	if a.Tool != "delete_pod" || a.Target != "default/app-1" || a.Arguments != args {
+		t.Errorf("action = %+v, want delete_pod on default/app-1", a)
+	}
+	if a.Result == "" || a.Timestamp == "" {
+		t.Errorf("action = %+v, want result and timestamp recorded", a)
+	}
+}
+
+func TestReconcile_FailedRunRecordsActionsTaken(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("failed-delete")
+	task.Spec.Approved = true
+	task.Spec.Policy.MaxSteps = 1
+	r := newTestReconciler(t, task)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default"}}
+	r.ToolRouter.AddProvider(tools.NewInternalProvider(k8sfake.NewClientset(pod), nil))
+
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	llm.Responses[0] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{
+			ID:       "call-1",
+			Function: agent.FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`},
+		}},
+	}
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseFailed)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	actions := done.Status.Report.ActionsTaken
+	if len(actions) != 1 || actions[0].Tool != "delete_pod" || actions[0].Target != "default/app-1" {
+		t.Errorf("ActionsTaken = %+v, want the delete_pod executed before the run failed", actions)
+	}
+}
+
+func TestReconcile_PartialPlanApproval(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("plan-approval")
+	r := newTestReconciler(t, task)
+	clientset := k8sfake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-2", Namespace: "default"}},
+	)
+	r.ToolRouter.AddProvider(tools.NewInternalProvider(clientset, nil))
+
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	llm.Responses[0] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{
+			{ID: "call-1", Function: agent.FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`}},
+			{ID: "call-2", Function: agent.FunctionCall{Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-2"}`}},
+		},
+	}
+	llm.Responses[1] = &agent.Message{
+		Type:    agent.MessageTypeAssistant,
+		Content: "Root Cause: stuck pod\nSuggestion: recreated app-2",
+	}
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseWaitingApproval)
+
+	var waiting kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &waiting); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if len(waiting.Status.PlannedActions) != 2 {
+		t.Fatalf("PlannedActions = %+v, want both delete_pod calls", waiting.Status.PlannedActions)
+	}
+
+	// Approve only the second step.
+	waiting.Spec.PlanApproval = &kubemindsv1alpha1.PlanApproval{ApprovedSteps: []int{1}}
+	if err := r.Update(ctx, &waiting); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() after plan approval: %v", err)
+	}
+	var running kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &running); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if running.Spec.PlanApproval != nil {
+		t.Errorf("PlanApproval = %+v, want it cleared once applied", running.Spec.PlanApproval)
+	}
+	if plan := running.Status.PlannedActions; len(plan) != 2 || plan[0].Approved || !plan[1].Approved {
+		t.Errorf("PlannedActions = %+v, want only step 1 approved", plan)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() to resume: %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	actions := done.Status.Report.ActionsTaken
+	if len(actions) != 1 || actions[0].Target != "default/app-2" {
+		t.Errorf("ActionsTaken = %+v, want only the approved delete of app-2", actions)
+	}
+	if len(done.Status.PlannedActions) != 0 {
+		t.Errorf("PlannedActions = %+v after completion, want none", done.Status.PlannedActions)
+	}
+	if _, err := clientset.CoreV1().Pods("default").Get(ctx, "app-1", metav1.GetOptions{}); err != nil {
+		t.Errorf("rejected step deleted app-1: %v", err)
+	}
+	if _, err := clientset.CoreV1().Pods("default").Get(ctx, "app-2", metav1.GetOptions{}); err == nil {
+		t.Error("approved step did not delete app-2")
+	}
+}
+
+func TestReconcile_ToolRequestApprovalGrantsOnlyTheTool(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("tool-request")
+	task.Spec.Approved = true
+	task.Status.Phase = kubemindsv1alpha1.PhaseWaitingApproval
+	task.Status.GrantedTools = []string{"get_events"}
+	task.Status.PendingApproval = &kubemindsv1alpha1.PendingApproval{
+		ToolName:    "get_pod_logs",
+		Arguments:   `{"tool_name":"get_pod_logs","reason":"check the crash"}`,
+		RiskLevel:   string(agent.SafetyLevelReadOnly),
+		ToolRequest: true,
+	}
+	r := newTestReconciler(t, task)
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+
+	var running kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &running); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if running.Status.Phase != kubemindsv1alpha1.PhaseRunning || running.Status.PendingApproval != nil {
+		t.Errorf("Phase = %s, PendingApproval = %+v, want Running without a pending request", running.Status.Phase, running.Status.PendingApproval)
+	}
+	if want := []string{"get_events", "get_pod_logs"}; !slices.Equal(running.Status.GrantedTools, want) {
+		t.Errorf("GrantedTools = %v, want %v", running.Status.GrantedTools, want)
+	}
+	// The approval covered the tool request only; later high-risk calls need their own.
+	if running.Spec.Approved {
+		t.Error("Spec.Approved is still set after granting the requested tool")
+	}
+}
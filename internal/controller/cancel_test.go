@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_CancelStopsRunningAgent(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("cancel-task")
+	r := newTestReconciler(t, task)
+	r.LLMProvider = &blockingLLM{release: make(chan struct{})}
+	// A cap of one lets waitForSlot observe the agent goroutine exiting.
+	r.MaxAgentsPerNamespace = 1
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	req := ctrl.Request{NamespacedName: key}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseRunning)
+
+	var running kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &running); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	running.Spec.Cancelled = true
+	if err := r.Update(ctx, &running); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() after cancel: %v", err)
+	}
+	if _, ok := r.ActiveAgents.Load(key.String()); ok {
+		t.Error("agent still registered after cancel")
+	}
+
+	// The stopped agent's final status update must not overwrite the cancellation.
+	waitForSlot(t, r, agentNamespace(task))
+	var cancelled kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &cancelled); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if cancelled.Status.Phase != kubemindsv1alpha1.PhaseCancelled {
+		t.Errorf("Phase = %s, want Cancelled", cancelled.Status.Phase)
+	}
+	if cancelled.Status.Report != nil {
+		t.Errorf("Report = %+v, want none for a cancelled task", cancelled.Status.Report)
+	}
+}
+
+func TestReconcile_CancelPendingTask(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("cancel-pending")
+	task.Spec.Cancelled = true
+	r := newTestReconciler(t, task)
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	if _, ok := r.ActiveAgents.Load(key.String()); ok {
+		t.Fatal("agent started for a cancelled task")
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCancelled)
+}
@@ -5,17 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
 	"kubeminds/internal/agent"
+	"kubeminds/internal/notify"
 	"kubeminds/internal/tools"
 )
 
@@ -29,8 +35,13 @@ type DiagnosisTaskReconciler struct {
 
 	// LLMProvider is the LLM backend used by every agent spawned by this controller.
 	// Inject llm.NewRouterFromConfig(cfg.LLM) at startup, or llm.NewMockProvider() for tests.
+	// Nil (no LLM configured) fails new tasks with a clear message instead of running them.
 	LLMProvider agent.LLMProvider
 
+	// Recorder records a Kubernetes Event at each phase transition, so
+	// `kubectl describe diagnosistask` shows the task's audit trail. Nil disables events.
+	Recorder record.EventRecorder
+
 	// ActiveAgents tracks running agents to prevent duplicate execution and enable cancellation
 	ActiveAgents sync.Map // map[string]context.CancelFunc
 
@@ -44,6 +55,10 @@ type DiagnosisTaskReconciler struct {
 	// the target namespace are injected into the agent's context before each run.
 	L2Store agent.EventStore
 
+	// L2SameAlertOnly narrows the injected recent events to those of the alert that
+	// triggered the task, instead of every alert in its namespace.
+	L2SameAlertOnly bool
+
 	// KnowledgeBase is an optional L3 knowledge base. When non-nil, similar historical
 	// diagnoses are retrieved and injected before each run, and completed diagnoses are
 	// saved asynchronously after each successful run.
@@ -52,11 +67,132 @@ type DiagnosisTaskReconciler struct {
 	// Embedder is required when KnowledgeBase is set. It generates the embedding vectors
 	// used for semantic search and storage.
 	Embedder agent.EmbeddingProvider
+
+	// KnowledgeEvidenceTopN is how many of the most recent tool findings are embedded
+	// and stored as evidence alongside each completed diagnosis. Zero (default) stores
+	// only the root cause + suggestion vector.
+	KnowledgeEvidenceTopN int
+
+	// TranscriptStore is optional. When non-nil, the conversation of each run is saved
+	// to it, replacing the task's previous transcript, for replay-task.
+	TranscriptStore agent.TranscriptStore
+
+	// SummaryMaxLen and ThoughtMaxLen set the agent's history truncation lengths.
+	// Zero keeps the agent defaults.
+	SummaryMaxLen int
+	ThoughtMaxLen int
+
+	// MinWriteConfidence is the agent's self-reported confidence required before a
+	// high-risk tool may run. Zero disables the check.
+	MinWriteConfidence float64
+
+	// InconclusiveConfidence finishes a task as Inconclusive when the agent's confidence
+	// is below it. Zero leaves it to the agent to declare a diagnosis inconclusive.
+	InconclusiveConfidence float64
+
+	// MinReadSteps is how many steps must gather read-only evidence before the agent
+	// may run a write tool. Zero disables the check.
+	MinReadSteps int
+
+	// MaxMemoryMessages and MaxMemoryBytes cap each agent's conversation history.
+	// Zero leaves a dimension unbounded.
+	MaxMemoryMessages int
+	MaxMemoryBytes    int
+
+	// HistoryTokenBudget and HistoryKeepRecentTurns configure the agent's history
+	// compaction. Zero budget disables it.
+	HistoryTokenBudget     int
+	HistoryKeepRecentTurns int
+
+	// StepDelay pauses each agent between steps to limit the LLM request rate.
+	// Zero runs steps back to back.
+	StepDelay time.Duration
+
+	// ToolTimeout limits each tool call. Zero keeps agent.DefaultToolTimeout.
+	ToolTimeout time.Duration
+
+	// ToolRequests lets agents request tools outside their skill's AllowedTools;
+	// read-only tools in ToolAutoGrant are granted without approval.
+	ToolRequests  bool
+	ToolAutoGrant []string
+
+	// TriageMinAlertCount enables quick triage for high-volume alerts: tasks whose
+	// AlertContext.Count reaches it first run the triage skill, and a full diagnosis
+	// only follows when triage judges the alert serious. Zero disables triage.
+	TriageMinAlertCount int
+
+	// TriageLLMProvider is the (usually smaller, cheaper) model used for triage.
+	// Nil falls back to LLMProvider.
+	TriageLLMProvider agent.LLMProvider
+
+	// TriageMaxSteps caps the triage agent's steps. Zero uses DefaultTriageMaxSteps.
+	TriageMaxSteps int
+
+	// Notifier optionally routes a notification for each finished or
+	// approval-pending task to its team's sink.
+	Notifier *notify.NotificationRouter
+
+	// GoalFormatter builds the agent goal from the task. Defaults to DefaultGoalFormatter.
+	GoalFormatter GoalFormatter
+
+	// Pause is the optional global kill switch. While paused, Pending and interrupted
+	// Running tasks are held and requeued instead of starting an agent.
+	Pause *admin.PauseSwitch
+
+	// MaxAgentsPerNamespace caps the agents running at once for tasks targeting the
+	// same namespace; tasks over the cap stay Pending and are requeued. Zero disables it.
+	MaxAgentsPerNamespace int
+
+	// MaxConcurrentAgents caps the agents running at once across all tasks; tasks over
+	// the cap stay Pending (or Running, when resuming) and are requeued. Zero disables it.
+	MaxConcurrentAgents int
+
+	// FairReservedFraction (0-1) of the MaxConcurrentAgents slots is kept from any single
+	// skill, so a storm of one alert type cannot starve rarer diagnoses. Tasks over their
+	// share stay Pending and are requeued. Zero, or no MaxConcurrentAgents, disables it.
+	FairReservedFraction float64
+
+	// TTLAfterFinished deletes finished tasks this long after they finish, unless a
+	// task sets Spec.TTLSecondsAfterFinished. Zero keeps them forever.
+	TTLAfterFinished time.Duration
+
+	// MaxRunRetries is how many times a run that failed on a transient LLM error is
+	// started again before the task fails. Loop and step-limit failures are never
+	// retried. Zero fails on the first error.
+	MaxRunRetries int
+
+	// RunRetryBackoff is the wait before the first retry, doubled for each later one.
+	// Zero uses defaultRunRetryBackoff.
+	RunRetryBackoff time.Duration
+
+	agentPoolOnce sync.Once
+	agentPool     *agentPool
+
+	namespaceLimiterOnce sync.Once
+	namespaceLimiter     *slotSemaphore
+
+	// approvedRuns holds the keys of tasks this controller moved from WaitingApproval
+	// to Running. Starting their agent continues the run; it is not a resume after
+	// an interruption.
+	approvedRuns sync.Map
 }
 
+// pausedRequeueInterval is how often held tasks are rechecked while diagnosis is paused.
+const pausedRequeueInterval = 30 * time.Second
+
+// agentPoolRequeueInterval is how often tasks held by MaxConcurrentAgents are rechecked.
+const agentPoolRequeueInterval = 10 * time.Second
+
+// namespaceCapRequeueInterval is how often tasks held by the per-namespace cap are rechecked.
+const namespaceCapRequeueInterval = 15 * time.Second
+
+// fairShareRequeueInterval is how often tasks held by fair skill scheduling are rechecked.
+const fairShareRequeueInterval = 15 * time.Second
+
 // +kubebuilder:rbac:groups=kubeminds.io,resources=diagnosistasks,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubeminds.io,resources=diagnosistasks/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubeminds.io,resources=diagnosistasks/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := slog.Default().With("diagnosistask", req.NamespacedName)
@@ -79,17 +215,23 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	// Handle deletion/cleanup
-	if !task.ObjectMeta.DeletionTimestamp.IsZero() ||
-		task.Status.Phase == kubemindsv1alpha1.PhaseCompleted ||
-		task.Status.Phase == kubemindsv1alpha1.PhaseFailed {
+	if !task.ObjectMeta.DeletionTimestamp.IsZero() || task.Status.Phase.IsTerminal() {
 		if cancel, ok := r.ActiveAgents.Load(req.NamespacedName.String()); ok {
 			log.Info("Stopping active agent")
 			cancel.(context.CancelFunc)()
 			r.ActiveAgents.Delete(req.NamespacedName.String())
 		}
+		r.approvedRuns.Delete(req.NamespacedName.String())
+		if task.ObjectMeta.DeletionTimestamp.IsZero() {
+			return r.expireFinished(ctx, &task, log)
+		}
 		return ctrl.Result{}, nil
 	}
 
+	if task.Spec.Cancelled {
+		return ctrl.Result{}, r.cancelTask(ctx, &task, log)
+	}
+
 	// If status phase is empty, set it to Pending
 	if task.Status.Phase == "" {
 		task.Status.Phase = kubemindsv1alpha1.PhasePending
@@ -107,15 +249,26 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	// Handle WaitingApproval: check if human has approved before resuming
 	if task.Status.Phase == kubemindsv1alpha1.PhaseWaitingApproval {
+		if task.Spec.Approved && task.Status.PendingApproval != nil && task.Status.PendingApproval.ToolRequest {
+			return r.applyToolGrant(ctx, &task, log)
+		}
 		if task.Spec.Approved {
 			log.Info("Task approved by human, transitioning to Running")
 			task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+			observeApprovalWait(&task)
+			task.Status.PendingApproval = nil
+			// Blanket approval lets the agent re-plan freely; drop the itemized plan.
+			task.Status.PlannedActions = nil
 			if err := r.Status().Update(ctx, &task); err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to update phase to Running after approval: %w", err)
 			}
+			r.approvedRuns.Store(req.NamespacedName.String(), true)
 			return ctrl.Result{Requeue: true}, nil
 		}
-		// Not yet approved; wait for spec.approved to be set
+		if task.Spec.PlanApproval != nil && len(task.Status.PlannedActions) > 0 {
+			return r.applyPlanApproval(ctx, &task, log)
+		}
+		// Not yet approved; wait for spec.approved or spec.planApproval to be set
 		return ctrl.Result{}, nil
 	}
 
@@ -129,10 +282,65 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		// It's Running in Status but not locally -> Resume!
 		shouldStart = true
 		isResume = true
-		log.Info("Resuming interrupted task")
+	}
+
+	if shouldStart && !isResume {
+		if wait := retryWait(&task); wait > 0 {
+			log.Info("Waiting to retry the run", "attempt", task.Status.Attempt, "retryAt", task.Status.RetryAt)
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	if shouldStart && r.Pause.Paused() {
+		log.Info("Automated diagnosis is paused, holding task", "phase", task.Status.Phase)
+		return ctrl.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+
+	if shouldStart && r.LLMProvider == nil {
+		log.Info("No LLM configured, failing task")
+		return ctrl.Result{}, r.failBeforeStart(ctx, &task, "No LLM configured", noLLMMessage, log)
+	}
+
+	// The task's model override, when set, replaces the injected LLM for this task only.
+	llmProvider := r.LLMProvider
+	if shouldStart && task.Spec.ModelOverride != nil {
+		p, err := r.modelOverrideLLM(task.Spec.ModelOverride)
+		if err != nil {
+			log.Info("Model override rejected, failing task", "error", err)
+			return ctrl.Result{}, r.failBeforeStart(ctx, &task, "Model override rejected", err.Error(), log)
+		}
+		llmProvider = p
 	}
 
 	if shouldStart {
+		pool := r.agents()
+		if !isResume {
+			wait, err := r.yieldToHigherPriority(ctx, &task, pool)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if wait {
+				log.Info("Higher-priority tasks are waiting for an agent, requeueing task", "priority", task.Spec.EffectivePriority())
+				return ctrl.Result{RequeueAfter: agentPoolRequeueInterval}, nil
+			}
+		}
+		fairnessKey := r.fairnessKey(&task)
+		if !pool.TryAcquire(fairnessKey) {
+			if pool.Free() > 0 {
+				log.Info("Skill is at its fair share of agents, requeueing task", "skill", fairnessKey, "limit", r.MaxConcurrentAgents)
+				return ctrl.Result{RequeueAfter: fairShareRequeueInterval}, nil
+			}
+			log.Info("Concurrent agent cap reached, requeueing task", "limit", r.MaxConcurrentAgents)
+			return ctrl.Result{RequeueAfter: agentPoolRequeueInterval}, nil
+		}
+		limiter := r.agentLimiter()
+		namespace := agentNamespace(&task)
+		if !limiter.TryAcquire(namespace) {
+			pool.Release(fairnessKey)
+			log.Info("Namespace is at its concurrent agent cap, requeueing task", "namespace", namespace, "limit", r.MaxAgentsPerNamespace)
+			return ctrl.Result{RequeueAfter: namespaceCapRequeueInterval}, nil
+		}
+
 		// Create context with timeout to prevent agent goroutine from hanging indefinitely
 		timeout := r.AgentTimeout
 		if timeout == 0 {
@@ -144,12 +352,22 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		// Update status to Running if needed
 		if !isResume {
 			task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+			task.Status.RetryAt = ""
 			if err := r.Status().Update(ctx, &task); err != nil {
 				log.Error("Failed to update status to Running", "error", err)
 				cancel()
 				r.ActiveAgents.Delete(req.NamespacedName.String())
+				pool.Release(fairnessKey)
+				limiter.Release(namespace)
 				return ctrl.Result{}, err
 			}
+			r.recordEvent(&task, corev1.EventTypeNormal, EventReasonAgentStarted, "Agent started diagnosing %s %s/%s",
+				task.Spec.Target.Kind, task.Spec.Target.Namespace, task.Spec.Target.Name)
+		} else if _, approved := r.approvedRuns.LoadAndDelete(req.NamespacedName.String()); approved {
+			log.Info("Continuing approved run", "checkpoints", len(task.Status.Checkpoint))
+		} else {
+			r.recordResume(ctx, &task, log)
+			r.recordEvent(&task, corev1.EventTypeNormal, EventReasonAgentResumed, "Agent resumed from %d checkpoints", len(task.Status.Checkpoint))
 		}
 
 		// Start agent using errgroup for structured lifecycle management (CLAUDE.md §3.2)
@@ -164,9 +382,6 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				return fmt.Errorf("failed to list tools: %w", err)
 			}
 
-			// Use the LLM provider injected at startup (Router or Mock).
-			llmProvider := r.LLMProvider
-
 			// Define Checkpoint Callback
 			onStepComplete := func(finding *kubemindsv1alpha1.Finding, historyEntry string) {
 				updateCtx := context.Background()
@@ -181,7 +396,12 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 					latestTask.Status.Checkpoint = append(latestTask.Status.Checkpoint, *finding)
 				}
 				if historyEntry != "" {
-					latestTask.Status.History = append(latestTask.Status.History, historyEntry)
+					// A streamed Think update supersedes the partial one before it.
+					if n := len(latestTask.Status.History); n > 0 && agent.IsPartialThought(latestTask.Status.History[n-1]) {
+						latestTask.Status.History[n-1] = historyEntry
+					} else {
+						latestTask.Status.History = append(latestTask.Status.History, historyEntry)
+					}
 				}
 
 				if err := r.Status().Update(updateCtx, &latestTask); err != nil {
@@ -189,6 +409,41 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				}
 			}
 
+			// Define typed event callback (Status.Events mirrors Status.History)
+			onEvent := func(event kubemindsv1alpha1.HistoryEvent) {
+				updateCtx := context.Background()
+
+				var latestTask kubemindsv1alpha1.DiagnosisTask
+				if err := r.Get(updateCtx, req.NamespacedName, &latestTask); err != nil {
+					log.Error("Failed to get task for event update", "error", err)
+					return
+				}
+
+				latestTask.Status.Events = append(latestTask.Status.Events, event)
+
+				if err := r.Status().Update(updateCtx, &latestTask); err != nil {
+					log.Error("Failed to update task events", "error", err)
+				}
+			}
+
+			// Formulate Goal
+			formatGoal := r.GoalFormatter
+			if formatGoal == nil {
+				formatGoal = DefaultGoalFormatter
+			}
+			goal := formatGoal(&task)
+
+			// Quick triage for high-volume alerts: a benign verdict completes the task
+			// without a full diagnosis.
+			var triageUsage agent.TokenUsage
+			if r.shouldTriage(&task) {
+				var verdict *agent.Result
+				verdict, triageUsage = r.runTriage(agentCtx, agentTools, goal, log)
+				if verdict != nil {
+					return r.completeSuppressed(req.NamespacedName, verdict, triageUsage, log)
+				}
+			}
+
 			// Match Skill
 			skill := r.SkillManager.Match(&task)
 			log.Info("Matched skill", "skill", skill.Name)
@@ -205,20 +460,49 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			}
 
 			// Create Agent with Skill
-			ag := agent.NewAgent(llmProvider, agentTools, task.Spec.Policy.MaxSteps, log, onStepComplete, skill)
+			ag := agent.NewAgent(llmProvider, agentTools, task.Spec.Policy.MaxSteps, log, onStepComplete, skill).
+				WithEventHandler(onEvent).
+				WithSummaryLimits(r.SummaryMaxLen, r.ThoughtMaxLen).
+				WithMinWriteConfidence(r.MinWriteConfidence).
+				WithInconclusiveBelow(r.InconclusiveConfidence).
+				WithMinReadSteps(r.MinReadSteps).
+				WithMemoryLimits(r.MaxMemoryMessages, r.MaxMemoryBytes).
+				WithHistoryCompaction(r.HistoryTokenBudget, r.HistoryKeepRecentTurns).
+				WithStepDelay(r.StepDelay)
+			if r.ToolTimeout > 0 {
+				ag.WithToolTimeout(r.ToolTimeout)
+			}
+			if r.ToolRequests {
+				ag.WithToolRequests(r.ToolAutoGrant)
+			}
+			if len(task.Status.GrantedTools) > 0 {
+				ag.WithGrantedTools(task.Status.GrantedTools)
+			}
 
 			// Restore from checkpoint if available
 			if len(task.Status.Checkpoint) > 0 {
 				ag.Restore(task.Status.Checkpoint)
 			}
 
-			// Formulate Goal
-			goal := fmt.Sprintf("Diagnose the issue with %s %s in namespace %s.",
-				task.Spec.Target.Kind, task.Spec.Target.Name, task.Spec.Target.Namespace)
+			// Replay a remediation plan a human has decided on
+			if len(task.Status.PlannedActions) > 0 {
+				ag.WithApprovedPlan(task.Status.PlannedActions)
+			}
+
+			// Inject the alert's annotations: its description and runbook, if any.
+			if task.Spec.AlertContext != nil {
+				if formatted := agent.FormatAlertAnnotations(task.Spec.AlertContext.Annotations); formatted != "" {
+					ag.InjectContext(formatted)
+				}
+			}
 
 			// Inject L2 context: recent alert events for the same namespace.
 			if r.L2Store != nil {
-				events, err := r.L2Store.GetRecentEvents(agentCtx, task.Spec.Target.Namespace, task.Spec.Target.Name, 10)
+				alertName := ""
+				if r.L2SameAlertOnly && task.Spec.AlertContext != nil {
+					alertName = task.Spec.AlertContext.Name
+				}
+				events, err := r.L2Store.GetRecentEvents(agentCtx, task.Spec.Target.Namespace, task.Spec.Target.Name, alertName, 10)
 				if err != nil {
 					log.Info("l2: failed to fetch recent events (non-fatal)", "error", err)
 				} else if formatted := agent.FormatAlertEvents(events); formatted != "" {
@@ -249,6 +533,14 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			// Run Agent
 			result, err := ag.Run(agentCtx, goal, task.Spec.Approved)
 
+			if r.TranscriptStore != nil {
+				transcript := ag.Transcript()
+				transcript.Task = req.NamespacedName.String()
+				if err := r.TranscriptStore.Save(context.Background(), task.Namespace, task.Name, transcript); err != nil {
+					log.Warn("Failed to save run transcript (non-fatal)", "error", err)
+				}
+			}
+
 			// Update CRD Status with result
 			updateCtx = context.Background()
 			var latestTask kubemindsv1alpha1.DiagnosisTask
@@ -257,6 +549,24 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				return fmt.Errorf("failed to get latest task for status update: %w", err)
 			}
 
+			// Accumulate LLM token usage across runs (resumes add to the total).
+			usage := ag.TokenUsage()
+			usage.Add(triageUsage)
+			latestTask.Status.TokensUsed += int64(usage.TotalTokens)
+			latestTask.Status.GrantedTools = ag.GrantedTools()
+
+			// A cancelled task keeps its phase; the run's outcome is just the cancellation.
+			if latestTask.Status.Phase == kubemindsv1alpha1.PhaseCancelled {
+				if err := r.Status().Update(updateCtx, &latestTask); err != nil {
+					log.Error("Failed to update token usage of cancelled task", "error", err)
+				}
+				return nil
+			}
+
+			// The decided plan has been replayed; a new approval request sets a new one.
+			latestTask.Status.PlannedActions = nil
+			latestTask.Status.ErrorClass = ""
+
 			if err != nil {
 				// Check for WaitingForApproval
 				var waitingErr *agent.ErrWaitingForApproval
@@ -264,23 +574,56 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 					log.Info("Agent requested approval", "tool", waitingErr.ToolName)
 					latestTask.Status.Phase = kubemindsv1alpha1.PhaseWaitingApproval
 					latestTask.Status.Message = fmt.Sprintf("Tool %s requires approval.", waitingErr.ToolName)
+					latestTask.Status.PendingApproval = &kubemindsv1alpha1.PendingApproval{
+						ToolName:    waitingErr.ToolName,
+						Arguments:   waitingErr.Arguments,
+						RiskLevel:   string(waitingErr.RiskLevel),
+						RequestedAt: time.Now().Format(time.RFC3339),
+						ToolRequest: waitingErr.ToolRequest,
+					}
+					if waitingErr.ToolRequest {
+						latestTask.Status.Message = fmt.Sprintf("The agent requested tool %s, which its skill does not allow.", waitingErr.ToolName)
+					}
+					latestTask.Status.PlannedActions = waitingErr.Plan
+					if len(waitingErr.Plan) > 1 {
+						latestTask.Status.Message = fmt.Sprintf("Remediation plan of %d steps requires approval.", len(waitingErr.Plan))
+					}
+				} else if r.scheduleRetry(&latestTask, err) {
+					log.Info("Run failed with a transient error, retry scheduled", "attempt", latestTask.Status.Attempt, "retryAt", latestTask.Status.RetryAt, "error", err)
+					// Unregister now so the reconcile triggered by the Pending update is not
+					// mistaken for this agent still running.
+					r.ActiveAgents.Delete(req.NamespacedName.String())
 				} else {
 					latestTask.Status.Phase = kubemindsv1alpha1.PhaseFailed
 					latestTask.Status.Report = &kubemindsv1alpha1.DiagnosisReport{
 						RootCause:  "Agent execution failed",
 						Suggestion: err.Error(),
 					}
+					// Write tools executed before the failure stay auditable and reversible.
+					if result != nil {
+						latestTask.Status.Report.ActionsTaken = result.ActionsTaken
+					}
+					latestTask.Status.ErrorClass = string(agent.ClassOf(err))
 				}
 			} else {
 				latestTask.Status.Phase = kubemindsv1alpha1.PhaseCompleted
+				if result.Inconclusive {
+					latestTask.Status.Phase = kubemindsv1alpha1.PhaseInconclusive
+					latestTask.Status.Message = "The agent could not determine a root cause."
+				}
 				latestTask.Status.Report = &kubemindsv1alpha1.DiagnosisReport{
-					RootCause:  result.RootCause,
-					Suggestion: result.Suggestion,
+					RootCause:          result.RootCause,
+					Suggestion:         result.Suggestion,
+					Details:            result.Details,
+					ActionsTaken:       result.ActionsTaken,
+					ConfidencePercent:  confidencePercent(result.Confidence),
+					SupportingFindings: result.SupportingFindings,
 				}
 
 				// Save diagnosis to L3 knowledge base asynchronously.
 				// This must not block the reconcile path or status update.
-				if r.KnowledgeBase != nil && r.Embedder != nil {
+				// Inconclusive diagnoses would only mislead future runs, so they are not saved.
+				if r.KnowledgeBase != nil && r.Embedder != nil && !result.Inconclusive {
 					alertName := ""
 					if latestTask.Spec.AlertContext != nil {
 						alertName = latestTask.Spec.AlertContext.Name
@@ -291,7 +634,8 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 						RootCause:  result.RootCause,
 						Suggestion: result.Suggestion,
 					}
-					go func(f agent.KnowledgeFinding) {
+					evidenceTexts := selectEvidence(latestTask.Status.Checkpoint, r.KnowledgeEvidenceTopN)
+					go func(f agent.KnowledgeFinding, evidenceTexts []string) {
 						saveCtx := context.Background()
 						text := f.RootCause + " " + f.Suggestion
 						emb, err := r.Embedder.Embed(saveCtx, text)
@@ -299,15 +643,31 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 							log.Error("l3: failed to generate embedding for completed diagnosis", "error", err)
 							return
 						}
-						if err := r.KnowledgeBase.SaveDiagnosis(saveCtx, f, emb); err != nil {
+						var evidence []agent.EvidenceVector
+						for _, et := range evidenceTexts {
+							evEmb, err := r.Embedder.Embed(saveCtx, et)
+							if err != nil {
+								log.Warn("l3: failed to generate embedding for evidence, skipping", "error", err)
+								continue
+							}
+							evidence = append(evidence, agent.EvidenceVector{Text: et, Embedding: evEmb})
+						}
+						if err := r.KnowledgeBase.SaveDiagnosis(saveCtx, f, emb, evidence...); err != nil {
 							log.Error("l3: failed to save diagnosis to knowledge base", "error", err)
 						}
-					}(finding)
+					}(finding, evidenceTexts)
 				}
 			}
 
+			markFinished(&latestTask)
 			if err := r.Status().Update(updateCtx, &latestTask); err != nil {
 				log.Error("Failed to update status with result", "error", err)
+			} else {
+				r.recordOutcome(&latestTask)
+				// A scheduled retry is not an outcome yet.
+				if latestTask.Status.Phase != kubemindsv1alpha1.PhasePending {
+					r.sendNotification(&latestTask, log)
+				}
 			}
 			return nil
 		})
@@ -316,6 +676,8 @@ func (r *DiagnosisTaskReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		// This outer goroutine is intentionally minimal: it only waits and logs.
 		go func() {
 			defer cancel()
+			defer limiter.Release(namespace)
+			defer pool.Release(fairnessKey)
 			if err := eg.Wait(); err != nil {
 				log.Error("Agent errgroup exited with error", "error", err)
 			}
@@ -331,3 +693,155 @@ func (r *DiagnosisTaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&kubemindsv1alpha1.DiagnosisTask{}).
 		Complete(r)
 }
+
+// noLLMMessage explains why a task failed when the controller runs without an LLM.
+const noLLMMessage = "No LLM is configured: set llm.defaultProvider and llm.providers (or llm.demoMode) to run diagnoses."
+
+// failBeforeStart fails a task that cannot run at all, e.g. because no LLM provider
+// is configured (alert-only deployments), instead of starting an agent that cannot chat.
+func (r *DiagnosisTaskReconciler) failBeforeStart(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, rootCause, message string, log *slog.Logger) error {
+	task.Status.Phase = kubemindsv1alpha1.PhaseFailed
+	task.Status.Message = message
+	task.Status.Report = &kubemindsv1alpha1.DiagnosisReport{
+		RootCause:  rootCause,
+		Suggestion: message,
+	}
+	markFinished(task)
+	if err := r.Status().Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to mark task failed before start: %w", err)
+	}
+	r.recordOutcome(task)
+	r.sendNotification(task, log)
+	return nil
+}
+
+// cancelTask moves the task to PhaseCancelled and stops its running agent, if any.
+// The phase is written first so the agent's final status update sees it and keeps it.
+func (r *DiagnosisTaskReconciler) cancelTask(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) error {
+	task.Status.Phase = kubemindsv1alpha1.PhaseCancelled
+	task.Status.Message = "Task cancelled."
+	task.Status.PendingApproval = nil
+	task.Status.PlannedActions = nil
+	markFinished(task)
+	if err := r.Status().Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to update phase to Cancelled: %w", err)
+	}
+	r.recordEvent(task, corev1.EventTypeNormal, EventReasonCancelled, "Task cancelled")
+
+	key := client.ObjectKeyFromObject(task).String()
+	if cancel, ok := r.ActiveAgents.Load(key); ok {
+		log.Info("Cancelling active agent")
+		cancel.(context.CancelFunc)()
+		r.ActiveAgents.Delete(key)
+	}
+	return nil
+}
+
+// agentLimiter returns the per-namespace agent limiter, built on first use.
+func (r *DiagnosisTaskReconciler) agentLimiter() *slotSemaphore {
+	r.namespaceLimiterOnce.Do(func() {
+		r.namespaceLimiter = newNamespaceLimiter(r.MaxAgentsPerNamespace)
+	})
+	return r.namespaceLimiter
+}
+
+// applyPlanApproval marks the planned actions selected by spec.planApproval as
+// approved, moves the task back to Running so the agent replays the plan, and
+// clears spec.planApproval so the decision is not applied to a later plan.
+func (r *DiagnosisTaskReconciler) applyPlanApproval(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) (ctrl.Result, error) {
+	approved := make(map[int]bool, len(task.Spec.PlanApproval.ApprovedSteps))
+	for _, i := range task.Spec.PlanApproval.ApprovedSteps {
+		approved[i] = true
+	}
+	approvedCount := 0
+	for i := range task.Status.PlannedActions {
+		step := &task.Status.PlannedActions[i]
+		step.Approved = approved[step.Index]
+		if step.Approved {
+			approvedCount++
+		}
+	}
+	log.Info("Remediation plan decided, transitioning to Running",
+		"approved", approvedCount, "planned", len(task.Status.PlannedActions))
+
+	task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+	observeApprovalWait(task)
+	task.Status.PendingApproval = nil
+	task.Status.Message = fmt.Sprintf("Plan approved: %d of %d steps.", approvedCount, len(task.Status.PlannedActions))
+	if err := r.Status().Update(ctx, task); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update phase to Running after plan approval: %w", err)
+	}
+	r.approvedRuns.Store(client.ObjectKeyFromObject(task).String(), true)
+
+	task.Spec.PlanApproval = nil
+	if err := r.Update(ctx, task); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to clear plan approval: %w", err)
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// applyToolGrant grants the tool the agent requested through request_tool, moves
+// the task back to Running, and clears spec.approved: the approval covers the
+// requested tool only, so later high-risk calls still need their own approval.
+func (r *DiagnosisTaskReconciler) applyToolGrant(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) (ctrl.Result, error) {
+	tool := task.Status.PendingApproval.ToolName
+	log.Info("Tool request approved, transitioning to Running", "tool", tool)
+
+	if !slices.Contains(task.Status.GrantedTools, tool) {
+		task.Status.GrantedTools = append(task.Status.GrantedTools, tool)
+	}
+	task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+	observeApprovalWait(task)
+	task.Status.PendingApproval = nil
+	task.Status.Message = fmt.Sprintf("Tool %s granted.", tool)
+	if err := r.Status().Update(ctx, task); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update phase to Running after tool grant: %w", err)
+	}
+	r.approvedRuns.Store(client.ObjectKeyFromObject(task).String(), true)
+
+	task.Spec.Approved = false
+	if err := r.Update(ctx, task); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to clear approval after tool grant: %w", err)
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// sendNotification asynchronously notifies the task's routed sink, if any.
+// Delivery failures are logged and never affect the task.
+func (r *DiagnosisTaskReconciler) sendNotification(task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) {
+	if r.Notifier == nil {
+		return
+	}
+	task = task.DeepCopy()
+	go func() {
+		if err := r.Notifier.Notify(context.Background(), task); err != nil {
+			log.Error("Failed to send task notification", "error", err)
+		}
+	}()
+}
+
+// selectEvidence returns up to n of the most recent findings formatted as
+// "tool: summary" for embedding as L3 evidence. Returns nil when n <= 0.
+func selectEvidence(findings []kubemindsv1alpha1.Finding, n int) []string {
+	if n <= 0 || len(findings) == 0 {
+		return nil
+	}
+	start := max(len(findings)-n, 0)
+	texts := make([]string, 0, len(findings)-start)
+	for _, f := range findings[start:] {
+		if f.Summary == "" {
+			continue
+		}
+		texts = append(texts, f.ToolName+": "+f.Summary)
+	}
+	return texts
+}
+
+// confidencePercent converts the agent's 0-1 confidence to the report's percentage.
+func confidencePercent(confidence *float64) *int {
+	if confidence == nil {
+		return nil
+	}
+	pct := int(math.Round(*confidence * 100))
+	return &pct
+}
@@ -8,6 +8,8 @@ import (
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
 )
@@ -47,5 +49,70 @@ var _ = Describe("DiagnosisTask Controller", func() {
 				return string(t.Status.Phase)
 			}, time.Second*10, time.Millisecond*500).Should(Or(Equal(string(kubemindsv1alpha1.PhaseRunning)), Equal(string(kubemindsv1alpha1.PhaseCompleted))))
 		})
+
+		It("should delete a finished task once its TTL elapses", func() {
+			ttl := int32(1)
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ttl-task",
+					Namespace: "default",
+				},
+				Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+					Target: kubemindsv1alpha1.DiagnosisTarget{
+						Namespace: "default",
+						Name:      "test-pod",
+						Kind:      "Pod",
+					},
+					Policy: kubemindsv1alpha1.DiagnosisPolicy{
+						MaxSteps: 5,
+					},
+					TTLSecondsAfterFinished: &ttl,
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+
+			Eventually(func() bool {
+				var t kubemindsv1alpha1.DiagnosisTask
+				err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(task), &t)
+				return apierrors.IsNotFound(err)
+			}, time.Second*20, time.Millisecond*500).Should(BeTrue())
+		})
+
+		It("should record Kubernetes Events for phase transitions", func() {
+			task := &kubemindsv1alpha1.DiagnosisTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "events-task",
+					Namespace: "default",
+				},
+				Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+					Target: kubemindsv1alpha1.DiagnosisTarget{
+						Namespace: "default",
+						Name:      "test-pod",
+						Kind:      "Pod",
+					},
+					Policy: kubemindsv1alpha1.DiagnosisPolicy{
+						MaxSteps: 5,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), task)).To(Succeed())
+
+			reasons := func() []string {
+				var events corev1.EventList
+				if err := k8sClient.List(context.Background(), &events, client.InNamespace("default")); err != nil {
+					return nil
+				}
+				var found []string
+				for _, e := range events.Items {
+					if e.InvolvedObject.Kind == "DiagnosisTask" && e.InvolvedObject.Name == task.Name {
+						found = append(found, e.Reason)
+					}
+				}
+				return found
+			}
+			Eventually(reasons, time.Second*10, time.Millisecond*500).Should(ContainElement(EventReasonAgentStarted))
+			Eventually(reasons, time.Second*10, time.Millisecond*500).Should(ContainElement(
+				Or(Equal(EventReasonCompleted), Equal(EventReasonFailed), Equal(EventReasonApprovalRequired))))
+		})
 	})
 })
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// Reasons of the Kubernetes Events recorded on DiagnosisTasks.
+const (
+	EventReasonAgentStarted     = "AgentStarted"
+	EventReasonAgentResumed     = "AgentResumed"
+	EventReasonApprovalRequired = "ApprovalRequired"
+	EventReasonCompleted        = "Completed"
+	EventReasonInconclusive     = "Inconclusive"
+	EventReasonFailed           = "Failed"
+	EventReasonRetryScheduled   = "RetryScheduled"
+	EventReasonCancelled        = "Cancelled"
+)
+
+// eventMessageMaxLen truncates root causes and errors quoted in event messages.
+const eventMessageMaxLen = 200
+
+// recordEvent records a Kubernetes Event on task. It is a no-op without a Recorder.
+func (r *DiagnosisTaskReconciler) recordEvent(task *kubemindsv1alpha1.DiagnosisTask, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(task, eventType, reason, messageFmt, args...)
+}
+
+// recordOutcome records the event for the phase a run left task in.
+func (r *DiagnosisTaskReconciler) recordOutcome(task *kubemindsv1alpha1.DiagnosisTask) {
+	var report kubemindsv1alpha1.DiagnosisReport
+	if task.Status.Report != nil {
+		report = *task.Status.Report
+	}
+	switch task.Status.Phase {
+	case kubemindsv1alpha1.PhaseWaitingApproval:
+		tool := ""
+		if task.Status.PendingApproval != nil {
+			tool = task.Status.PendingApproval.ToolName
+		}
+		r.recordEvent(task, corev1.EventTypeNormal, EventReasonApprovalRequired, "Tool %s requires approval", tool)
+	case kubemindsv1alpha1.PhaseCompleted:
+		r.recordEvent(task, corev1.EventTypeNormal, EventReasonCompleted, "Root cause: %s", truncateEventMessage(report.RootCause))
+		observeTimeToDiagnosis(task)
+	case kubemindsv1alpha1.PhaseInconclusive:
+		r.recordEvent(task, corev1.EventTypeWarning, EventReasonInconclusive, "%s", task.Status.Message)
+	case kubemindsv1alpha1.PhasePending:
+		// Only a failed run moves a task back to Pending.
+		r.recordEvent(task, corev1.EventTypeWarning, EventReasonRetryScheduled, "%s", task.Status.Message)
+	case kubemindsv1alpha1.PhaseFailed:
+		// A failed run's report carries the error as its suggestion.
+		r.recordEvent(task, corev1.EventTypeWarning, EventReasonFailed, "Diagnosis failed: %s", truncateEventMessage(report.Suggestion))
+	}
+}
+
+func truncateEventMessage(s string) string {
+	if len(s) > eventMessageMaxLen {
+		return s[:eventMessageMaxLen] + "..."
+	}
+	return s
+}
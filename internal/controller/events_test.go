@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+	"kubeminds/internal/tools"
+)
+
+func TestReconcile_RecordsPhaseEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     error
+		want    []string
+	}{
+		{
+			name:    "completed",
+			content: "Root Cause: memory limit too low\nSuggestion: raise it",
+			want:    []string{"Normal AgentStarted", "Normal Completed Root cause: memory limit too low"},
+		},
+		{
+			name: "failed",
+			err:  context.DeadlineExceeded,
+			want: []string{"Normal AgentStarted", "Warning Failed Diagnosis failed: failed to chat with LLM"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := newPendingTask("events")
+			r := newTestReconciler(t, task)
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+			llm := r.LLMProvider.(*agent.MockLLMProvider)
+			if tt.err != nil {
+				llm.SetError(0, tt.err)
+			} else {
+				llm.Responses[0].Content = tt.content
+			}
+
+			key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile(): %v", err)
+			}
+
+			for _, want := range tt.want {
+				select {
+				case got := <-recorder.Events:
+					if !strings.HasPrefix(got, want) {
+						t.Errorf("event = %q, want prefix %q", got, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for event %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcile_RecordsApprovalEvent(t *testing.T) {
+	task := newPendingTask("events-approval")
+	r := newTestReconciler(t, task)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	r.ToolRouter.AddProvider(tools.NewInternalProvider(k8sfake.NewClientset(), nil))
+	r.LLMProvider.(*agent.MockLLMProvider).SetResponse(0, &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{ID: "call-1", Function: agent.FunctionCall{
+			Name: "delete_pod", Arguments: `{"namespace":"default","name":"app-1"}`,
+		}}},
+	})
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseWaitingApproval)
+
+	var events []string
+	for len(events) < 2 {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", events)
+		}
+	}
+	if events[1] != "Normal ApprovalRequired Tool delete_pod requires approval" {
+		t.Errorf("events = %v, want an ApprovalRequired event for delete_pod", events)
+	}
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"math"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// perSkillAgentCap is how many of limit agent slots a single skill may take when
+// reservedFraction (0-1) of them is kept from any single skill, so during a storm
+// dominated by one alert type, rarer diagnoses still find a free agent. At least
+// one slot stays usable per skill. It returns 0 (no cap) when fairness is off.
+func perSkillAgentCap(limit int, reservedFraction float64) int {
+	if limit <= 0 || reservedFraction <= 0 {
+		return 0
+	}
+	reserved := int(math.Ceil(float64(limit) * math.Min(reservedFraction, 1)))
+	return max(limit-reserved, 1)
+}
+
+// fairnessKey is the skill a task's agent counts against in the agent pool, or ""
+// when fair scheduling is off.
+func (r *DiagnosisTaskReconciler) fairnessKey(task *kubemindsv1alpha1.DiagnosisTask) string {
+	if perSkillAgentCap(r.MaxConcurrentAgents, r.FairReservedFraction) == 0 || r.SkillManager == nil {
+		return ""
+	}
+	return r.SkillManager.Match(task).Name
+}
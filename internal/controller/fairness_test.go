@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_FairSchedulingKeepsSlotForRareSkill(t *testing.T) {
+	ctx := context.Background()
+	var oom []*kubemindsv1alpha1.DiagnosisTask
+	for i := 0; i < 6; i++ {
+		task := newPendingTask(fmt.Sprintf("oom-%d", i))
+		task.Spec.AlertContext = &kubemindsv1alpha1.AlertContext{
+			Name:   "KubeContainerOOMKilled",
+			Labels: map[string]string{"reason": "OOMKilled"},
+		}
+		oom = append(oom, task)
+	}
+	network := newPendingTask("network-1")
+	network.Spec.AlertContext = &kubemindsv1alpha1.AlertContext{Name: "KubeServiceUnreachable"}
+
+	r := newTestReconciler(t, append(oom, network)...)
+	llm := &blockingLLM{release: make(chan struct{})}
+	defer close(llm.release)
+	r.LLMProvider = llm
+	r.MaxConcurrentAgents = 4
+	r.FairReservedFraction = 0.25
+
+	keyOf := func(task *kubemindsv1alpha1.DiagnosisTask) types.NamespacedName {
+		return types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	}
+	reconcile := func(task *kubemindsv1alpha1.DiagnosisTask) ctrl.Result {
+		t.Helper()
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(task)})
+		if err != nil {
+			t.Fatalf("Reconcile(%s): %v", task.Name, err)
+		}
+		return res
+	}
+
+	// The OOM flood arrives first; it may take 3 of the 4 slots.
+	held := 0
+	for _, task := range oom {
+		if reconcile(task).RequeueAfter == fairShareRequeueInterval {
+			held++
+		}
+	}
+	if held != 3 {
+		t.Errorf("held %d OOM tasks, want 3 (one slot reserved)", held)
+	}
+	for _, task := range oom[:3] {
+		waitForPhase(t, r, keyOf(task), kubemindsv1alpha1.PhaseRunning)
+	}
+
+	// The networking diagnosis still gets the reserved slot.
+	if res := reconcile(network); res.RequeueAfter != 0 {
+		t.Fatalf("networking task requeued after %v, want it started", res.RequeueAfter)
+	}
+	waitForPhase(t, r, keyOf(network), kubemindsv1alpha1.PhaseRunning)
+}
+
+func TestAgentPool_FairShare(t *testing.T) {
+	p := newAgentPool(4, 0.5)
+	for i := 0; i < 2; i++ {
+		if !p.TryAcquire("oom") {
+			t.Fatalf("acquire %d for oom refused", i)
+		}
+	}
+	if p.TryAcquire("oom") {
+		t.Error("oom took more than its share")
+	}
+	if !p.TryAcquire("network") || !p.TryAcquire("disk") {
+		t.Error("other skills refused while slots are free")
+	}
+	if p.TryAcquire("dns") {
+		t.Error("acquired beyond capacity")
+	}
+	p.Release("oom")
+	if !p.TryAcquire("dns") {
+		t.Error("released slot not reusable")
+	}
+	for _, skill := range []string{"oom", "network", "disk", "dns"} {
+		p.Release(skill)
+	}
+
+	off := newAgentPool(0, 0.5)
+	for i := 0; i < 10; i++ {
+		if !off.TryAcquire("oom") {
+			t.Fatal("pool without a limit refused a slot")
+		}
+	}
+	for i := 0; i < 10; i++ {
+		off.Release("oom")
+	}
+}
+
+func TestPerSkillAgentCap(t *testing.T) {
+	for _, tc := range []struct {
+		limit    int
+		fraction float64
+		want     int
+	}{
+		{limit: 0, fraction: 0.25, want: 0},
+		{limit: 4, fraction: 0, want: 0},
+		{limit: 4, fraction: 0.25, want: 3},
+		{limit: 10, fraction: 0.25, want: 7},
+		{limit: 2, fraction: 1.5, want: 1},
+	} {
+		if got := perSkillAgentCap(tc.limit, tc.fraction); got != tc.want {
+			t.Errorf("perSkillAgentCap(%d, %v) = %d, want %d", tc.limit, tc.fraction, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"fmt"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// GoalFormatter turns a DiagnosisTask into the natural-language goal handed to the agent.
+type GoalFormatter func(task *kubemindsv1alpha1.DiagnosisTask) string
+
+// goalFormatters maps a target kind to its goal phrasing. Kinds not listed here
+// fall back to formatResourceGoal.
+var goalFormatters = map[string]GoalFormatter{
+	"Pod":       formatResourceGoal,
+	"Namespace": formatNamespaceGoal,
+	"Node":      formatNodeGoal,
+}
+
+// DefaultGoalFormatter selects a goal phrasing based on Spec.Target.Kind.
+func DefaultGoalFormatter(task *kubemindsv1alpha1.DiagnosisTask) string {
+	if f, ok := goalFormatters[task.Spec.Target.Kind]; ok {
+		return f(task)
+	}
+	return formatResourceGoal(task)
+}
+
+// formatResourceGoal is used for namespaced workload resources such as Pods.
+func formatResourceGoal(task *kubemindsv1alpha1.DiagnosisTask) string {
+	t := task.Spec.Target
+	return fmt.Sprintf("Diagnose the issue with %s %s in namespace %s.", t.Kind, t.Name, t.Namespace)
+}
+
+// formatNamespaceGoal is used for alerts without a pod label, where the target
+// Name is the namespace itself. Repeating it as "Namespace X in namespace X"
+// confuses the model, so the alert name is used as the subject instead.
+func formatNamespaceGoal(task *kubemindsv1alpha1.DiagnosisTask) string {
+	ns := task.Spec.Target.Namespace
+	if ns == "" {
+		ns = task.Spec.Target.Name
+	}
+	if task.Spec.AlertContext != nil && task.Spec.AlertContext.Name != "" {
+		return fmt.Sprintf("Investigate the cluster/namespace-level alert %s affecting namespace %s.",
+			task.Spec.AlertContext.Name, ns)
+	}
+	return fmt.Sprintf("Investigate the cluster/namespace-level issue affecting namespace %s.", ns)
+}
+
+// formatNodeGoal is used for cluster-scoped Node targets, which have no namespace.
+func formatNodeGoal(task *kubemindsv1alpha1.DiagnosisTask) string {
+	if task.Spec.AlertContext != nil && task.Spec.AlertContext.Name != "" {
+		return fmt.Sprintf("Investigate the node-level alert %s affecting node %s.",
+			task.Spec.AlertContext.Name, task.Spec.Target.Name)
+	}
+	return fmt.Sprintf("Diagnose the issue with node %s.", task.Spec.Target.Name)
+}
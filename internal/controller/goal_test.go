@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestDefaultGoalFormatter(t *testing.T) {
+	alert := &kubemindsv1alpha1.AlertContext{Name: "KubeQuotaExceeded"}
+
+	tests := []struct {
+		name string
+		task kubemindsv1alpha1.DiagnosisTask
+		want string
+	}{
+		{
+			name: "pod",
+			task: kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+				Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Pod", Name: "nginx-abc", Namespace: "default"},
+			}},
+			want: "Diagnose the issue with Pod nginx-abc in namespace default.",
+		},
+		{
+			name: "namespace_with_alert",
+			task: kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+				Target:       kubemindsv1alpha1.DiagnosisTarget{Kind: "Namespace", Name: "prod", Namespace: "prod"},
+				AlertContext: alert,
+			}},
+			want: "Investigate the cluster/namespace-level alert KubeQuotaExceeded affecting namespace prod.",
+		},
+		{
+			name: "namespace_without_alert",
+			task: kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+				Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Namespace", Name: "prod", Namespace: "prod"},
+			}},
+			want: "Investigate the cluster/namespace-level issue affecting namespace prod.",
+		},
+		{
+			name: "node",
+			task: kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+				Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Node", Name: "worker-1"},
+			}},
+			want: "Diagnose the issue with node worker-1.",
+		},
+		{
+			name: "unknown_kind_falls_back",
+			task: kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+				Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Deployment", Name: "api", Namespace: "prod"},
+			}},
+			want: "Diagnose the issue with Deployment api in namespace prod.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultGoalFormatter(&tt.task); got != tt.want {
+				t.Errorf("DefaultGoalFormatter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultGoalFormatter_NamespaceNotRepeated(t *testing.T) {
+	task := kubemindsv1alpha1.DiagnosisTask{Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+		Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Namespace", Name: "prod", Namespace: "prod"},
+	}}
+	goal := DefaultGoalFormatter(&task)
+	if strings.Count(goal, "prod") != 1 {
+		t.Errorf("namespace goal should mention the namespace once, got %q", goal)
+	}
+}
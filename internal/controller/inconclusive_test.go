@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+func TestReconcile_InconclusiveDiagnosis(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		threshold float64
+		want      kubemindsv1alpha1.DiagnosisPhase
+	}{
+		{
+			name:    "confident conclusion completes",
+			content: "Root Cause: test\nSuggestion: none\nConfidence: 0.9",
+			want:    kubemindsv1alpha1.PhaseCompleted,
+		},
+		{
+			name:    "agent declares it inconclusive",
+			content: "Root Cause: Inconclusive\nSuggestion: collect node logs",
+			want:    kubemindsv1alpha1.PhaseInconclusive,
+		},
+		{
+			name:      "low confidence",
+			content:   "Root Cause: test\nSuggestion: none\nConfidence: 0.2",
+			threshold: 0.5,
+			want:      kubemindsv1alpha1.PhaseInconclusive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			task := newPendingTask("inconclusive")
+			r := newTestReconciler(t, task)
+			r.LLMProvider.(*agent.MockLLMProvider).Responses[0].Content = tt.content
+			r.InconclusiveConfidence = tt.threshold
+
+			key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile(): %v", err)
+			}
+			waitForPhase(t, r, key, tt.want)
+
+			var done kubemindsv1alpha1.DiagnosisTask
+			if err := r.Get(ctx, key, &done); err != nil {
+				t.Fatalf("Get(): %v", err)
+			}
+			if done.Status.Report == nil {
+				t.Fatal("Report not recorded")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"fmt"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// ModelSelector is implemented by LLM providers that can serve a task with a model
+// other than the configured one (llm.Router). The controller cannot import the llm
+// package, so it depends on this interface instead.
+type ModelSelector interface {
+	// ForModel returns a provider pinned to provider/model, or an error when the
+	// pair is not allowlisted. An empty provider selects the default one.
+	ForModel(provider, model string) (agent.LLMProvider, error)
+}
+
+// modelOverrideLLM resolves a task's spec.modelOverride against the injected LLM.
+func (r *DiagnosisTaskReconciler) modelOverrideLLM(override *kubemindsv1alpha1.ModelOverride) (agent.LLMProvider, error) {
+	selector, ok := r.LLMProvider.(ModelSelector)
+	if !ok {
+		return nil, fmt.Errorf("the configured LLM does not support model overrides (requested model %q)", override.Model)
+	}
+	return selector.ForModel(override.Provider, override.Model)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// selectorLLM is a default LLM that hands out a separate mock per allowlisted model.
+type selectorLLM struct {
+	*agent.MockLLMProvider
+	allowed  map[string]bool
+	selected []string
+}
+
+func (s *selectorLLM) ForModel(provider, model string) (agent.LLMProvider, error) {
+	if !s.allowed[model] {
+		return nil, fmt.Errorf("model %q is not allowed", model)
+	}
+	s.selected = append(s.selected, provider+"/"+model)
+	override := agent.NewMockLLMProvider()
+	override.Responses[0] = &agent.Message{Type: agent.MessageTypeAssistant, Content: "Root Cause: from " + model + "\nSuggestion: none"}
+	return override, nil
+}
+
+func TestReconcile_ModelOverride(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("override-task")
+	task.Spec.ModelOverride = &kubemindsv1alpha1.ModelOverride{Model: "gpt-4o-mini"}
+	r := newTestReconciler(t, task)
+	defaultLLM := &selectorLLM{MockLLMProvider: r.LLMProvider.(*agent.MockLLMProvider), allowed: map[string]bool{"gpt-4o-mini": true}}
+	r.LLMProvider = defaultLLM
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status.Report == nil || got.Status.Report.RootCause != "from gpt-4o-mini" {
+		t.Errorf("report = %+v, want the override model's diagnosis", got.Status.Report)
+	}
+	if len(defaultLLM.selected) != 1 || defaultLLM.selected[0] != "/gpt-4o-mini" {
+		t.Errorf("selected models = %v, want [/gpt-4o-mini]", defaultLLM.selected)
+	}
+	if defaultLLM.CallCount != 0 {
+		t.Errorf("default LLM called %d times, want 0", defaultLLM.CallCount)
+	}
+}
+
+func TestReconcile_ModelOverrideRejected(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("rejected-override-task")
+	task.Spec.ModelOverride = &kubemindsv1alpha1.ModelOverride{Provider: "openai", Model: "gpt-5"}
+	r := newTestReconciler(t, task)
+	r.LLMProvider = &selectorLLM{MockLLMProvider: agent.NewMockLLMProvider(), allowed: map[string]bool{}}
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status.Phase != kubemindsv1alpha1.PhaseFailed {
+		t.Fatalf("phase = %s, want Failed", got.Status.Phase)
+	}
+	if got.Status.Report == nil || got.Status.Report.RootCause != "Model override rejected" {
+		t.Errorf("report = %+v, want a rejected override", got.Status.Report)
+	}
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// newNamespaceLimiter returns a semaphore allowing limit agents per namespace. It
+// keeps an alert storm in one namespace from occupying every agent slot while other
+// namespaces wait. A non-positive limit never blocks.
+func newNamespaceLimiter(limit int) *slotSemaphore {
+	return newSlotSemaphore(0, limit)
+}
+
+// agentNamespace is the namespace a task's agent counts against: the diagnosed
+// resource's namespace, falling back to the task's own.
+func agentNamespace(task *kubemindsv1alpha1.DiagnosisTask) string {
+	if task.Spec.Target.Namespace != "" {
+		return task.Spec.Target.Namespace
+	}
+	return task.Namespace
+}
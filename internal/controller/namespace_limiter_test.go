@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// blockingLLM holds every Chat call until release is closed, keeping agents running.
+type blockingLLM struct {
+	release chan struct{}
+}
+
+func (b *blockingLLM) Chat(ctx context.Context, _ []agent.Message, _ []agent.Tool) (*agent.Message, error) {
+	select {
+	case <-b.release:
+		return &agent.Message{Type: agent.MessageTypeAssistant, Content: "Root Cause: test\nSuggestion: none"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestReconcile_NamespaceCapDoesNotStarveOtherNamespaces(t *testing.T) {
+	ctx := context.Background()
+	noisy := []*kubemindsv1alpha1.DiagnosisTask{newPendingTask("noisy-1"), newPendingTask("noisy-2"), newPendingTask("noisy-3")}
+	for _, task := range noisy {
+		task.Spec.Target.Namespace = "noisy"
+	}
+	quiet := newPendingTask("quiet-1")
+	quiet.Spec.Target.Namespace = "quiet"
+
+	r := newTestReconciler(t, append(noisy, quiet)...)
+	llm := &blockingLLM{release: make(chan struct{})}
+	r.LLMProvider = llm
+	r.MaxAgentsPerNamespace = 2
+
+	keyOf := func(task *kubemindsv1alpha1.DiagnosisTask) types.NamespacedName {
+		return types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	}
+	reconcile := func(task *kubemindsv1alpha1.DiagnosisTask) ctrl.Result {
+		t.Helper()
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: keyOf(task)})
+		if err != nil {
+			t.Fatalf("Reconcile(%s): %v", task.Name, err)
+		}
+		return res
+	}
+
+	// Flood the noisy namespace: only the first two tasks start.
+	for _, task := range noisy {
+		reconcile(task)
+	}
+	waitForPhase(t, r, keyOf(noisy[0]), kubemindsv1alpha1.PhaseRunning)
+	waitForPhase(t, r, keyOf(noisy[1]), kubemindsv1alpha1.PhaseRunning)
+
+	res := reconcile(noisy[2])
+	if res.RequeueAfter != namespaceCapRequeueInterval {
+		t.Errorf("over-cap RequeueAfter = %v, want %v", res.RequeueAfter, namespaceCapRequeueInterval)
+	}
+	var held kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, keyOf(noisy[2]), &held); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if held.Status.Phase != kubemindsv1alpha1.PhasePending {
+		t.Errorf("over-cap task phase = %s, want Pending", held.Status.Phase)
+	}
+
+	// Another namespace still gets an agent.
+	reconcile(quiet)
+	waitForPhase(t, r, keyOf(quiet), kubemindsv1alpha1.PhaseRunning)
+
+	// Once the running agents finish, the held task can start.
+	close(llm.release)
+	waitForPhase(t, r, keyOf(noisy[0]), kubemindsv1alpha1.PhaseCompleted)
+	waitForPhase(t, r, keyOf(noisy[1]), kubemindsv1alpha1.PhaseCompleted)
+	waitForSlot(t, r, "noisy")
+	reconcile(noisy[2])
+	waitForPhase(t, r, keyOf(noisy[2]), kubemindsv1alpha1.PhaseCompleted)
+}
+
+// waitForSlot waits until namespace is below its agent cap again; slots are released
+// just after the agent goroutine writes its final status.
+func waitForSlot(t *testing.T, r *DiagnosisTaskReconciler, namespace string) {
+	t.Helper()
+	limiter := r.agentLimiter()
+	for i := 0; i < 200; i++ {
+		if limiter.TryAcquire(namespace) {
+			limiter.Release(namespace)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("namespace %s never got a free agent slot", namespace)
+}
+
+func TestNamespaceLimiter(t *testing.T) {
+	l := newNamespaceLimiter(1)
+	if !l.TryAcquire("a") {
+		t.Fatal("first acquire in a should succeed")
+	}
+	if l.TryAcquire("a") {
+		t.Error("second acquire in a should hit the cap")
+	}
+	if !l.TryAcquire("b") {
+		t.Error("acquire in b should not be limited by a")
+	}
+	l.Release("a")
+	if !l.TryAcquire("a") {
+		t.Error("acquire after release should succeed")
+	}
+
+	unlimited := newNamespaceLimiter(0)
+	for i := 0; i < 5; i++ {
+		if !unlimited.TryAcquire("a") {
+			t.Fatal("zero limit should never block")
+		}
+	}
+}
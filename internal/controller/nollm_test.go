@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_NoLLMFailsTask(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("no-llm-task")
+	r := newTestReconciler(t, task)
+	r.LLMProvider = nil
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status.Phase != kubemindsv1alpha1.PhaseFailed {
+		t.Fatalf("phase = %s, want Failed", got.Status.Phase)
+	}
+	if got.Status.Message != noLLMMessage {
+		t.Errorf("message = %q, want %q", got.Status.Message, noLLMMessage)
+	}
+	if got.Status.Report == nil || got.Status.Report.RootCause != "No LLM configured" {
+		t.Errorf("report = %+v, want a no-LLM root cause", got.Status.Report)
+	}
+	if _, running := r.ActiveAgents.Load(key.String()); running {
+		t.Error("no agent should be started without an LLM")
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/admin"
+	"kubeminds/internal/agent"
+	"kubeminds/internal/tools"
+)
+
+// newTestReconciler builds a reconciler backed by a fake client holding the given tasks.
+// The mock LLM concludes on its first call so started agents finish immediately.
+func newTestReconciler(t *testing.T, objs ...*kubemindsv1alpha1.DiagnosisTask) *DiagnosisTaskReconciler {
+	t.Helper()
+	s := runtime.NewScheme()
+	if err := kubemindsv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme: %v", err)
+	}
+	b := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&kubemindsv1alpha1.DiagnosisTask{})
+	for _, o := range objs {
+		b = b.WithObjects(o)
+	}
+
+	sm, err := agent.NewSkillManager(filepath.Join(t.TempDir(), "missing"), nil)
+	if err != nil {
+		t.Fatalf("NewSkillManager: %v", err)
+	}
+
+	llm := agent.NewMockLLMProvider()
+	llm.Responses[0] = &agent.Message{
+		Type:    agent.MessageTypeAssistant,
+		Content: "Root Cause: test\nSuggestion: none",
+	}
+
+	return &DiagnosisTaskReconciler{
+		Client:       b.Build(),
+		Scheme:       s,
+		SkillManager: sm,
+		ToolRouter:   tools.NewRouter(nil),
+		LLMProvider:  llm,
+	}
+}
+
+func newPendingTask(name string) *kubemindsv1alpha1.DiagnosisTask {
+	return &kubemindsv1alpha1.DiagnosisTask{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+			Target: kubemindsv1alpha1.DiagnosisTarget{Kind: "Pod", Name: "app-1", Namespace: "default"},
+			Policy: kubemindsv1alpha1.DiagnosisPolicy{MaxSteps: 5},
+		},
+		Status: kubemindsv1alpha1.DiagnosisTaskStatus{Phase: kubemindsv1alpha1.PhasePending},
+	}
+}
+
+// waitForPhase polls until the task reaches phase or the deadline passes.
+func waitForPhase(t *testing.T, r *DiagnosisTaskReconciler, key types.NamespacedName, phase kubemindsv1alpha1.DiagnosisPhase) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var task kubemindsv1alpha1.DiagnosisTask
+		if err := r.Get(context.Background(), key, &task); err == nil && task.Status.Phase == phase {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task %s did not reach phase %s", key, phase)
+}
+
+func TestReconcile_PausedHoldsTasks(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("paused-task")
+	r := newTestReconciler(t, task)
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	req := ctrl.Request{NamespacedName: key}
+
+	r.Pause = admin.NewPauseSwitch(nil)
+	if err := r.Pause.Pause(ctx); err != nil {
+		t.Fatalf("Pause(): %v", err)
+	}
+
+	res, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() while paused: %v", err)
+	}
+	if res.RequeueAfter != pausedRequeueInterval {
+		t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, pausedRequeueInterval)
+	}
+	if _, ok := r.ActiveAgents.Load(key.String()); ok {
+		t.Fatal("agent started while paused")
+	}
+
+	var held kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &held); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if held.Status.Phase != kubemindsv1alpha1.PhasePending {
+		t.Errorf("Phase = %s, want Pending while paused", held.Status.Phase)
+	}
+
+	// Resume re-enables agents on the next reconcile.
+	if err := r.Pause.Resume(ctx); err != nil {
+		t.Fatalf("Resume(): %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() after resume: %v", err)
+	}
+	if _, ok := r.ActiveAgents.Load(key.String()); !ok {
+		t.Fatal("expected agent to start after resume")
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// TaskResumes counts interrupted tasks resumed by the controller, by restore mode.
+var TaskResumes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubeminds_task_resumes_total",
+	Help: "Interrupted DiagnosisTasks resumed by the controller, by restore mode (Lossy or None).",
+}, []string{"mode"})
+
+// TaskRestoredFindings observes how many checkpoint findings each resume restored.
+var TaskRestoredFindings = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "kubeminds_task_restored_findings",
+	Help:    "Checkpoint findings restored into the agent when a DiagnosisTask is resumed.",
+	Buckets: []float64{0, 1, 2, 5, 10, 20, 50},
+})
+
+func init() {
+	metrics.Registry.MustRegister(TaskResumes, TaskRestoredFindings)
+}
+
+// recordResume records on the task, in the logs and in metrics how much context a
+// resumed agent gets back, to explain why a resumed diagnosis may behave differently.
+// A failed status update is logged only: the resume itself goes ahead.
+func (r *DiagnosisTaskReconciler) recordResume(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) {
+	restored := len(task.Status.Checkpoint)
+	mode := kubemindsv1alpha1.RestoreModeLossy
+	if restored == 0 {
+		mode = kubemindsv1alpha1.RestoreModeNone
+	}
+	log.Info("Resuming interrupted task", "restoredFindings", restored, "restoreMode", mode)
+	TaskResumes.WithLabelValues(string(mode)).Inc()
+	TaskRestoredFindings.Observe(float64(restored))
+
+	task.Status.ResumedAt = time.Now().Format(time.RFC3339)
+	task.Status.RestoredFindings = restored
+	task.Status.RestoreMode = mode
+	if err := r.Status().Update(ctx, task); err != nil {
+		log.Error("Failed to record resume metadata", "error", err)
+	}
+}
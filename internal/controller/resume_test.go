@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_ResumeRecordsRestoreMetadata(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint []kubemindsv1alpha1.Finding
+		wantMode   kubemindsv1alpha1.RestoreMode
+	}{
+		{
+			name: "lossy restore from checkpoint",
+			checkpoint: []kubemindsv1alpha1.Finding{
+				{Step: 1, ToolName: "get_pod_logs", Summary: "OOMKilled"},
+				{Step: 2, ToolName: "get_pod_events", Summary: "Back-off restarting"},
+			},
+			wantMode: kubemindsv1alpha1.RestoreModeLossy,
+		},
+		{name: "nothing to restore", wantMode: kubemindsv1alpha1.RestoreModeNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			task := newPendingTask("resumed-task")
+			task.Status.Phase = kubemindsv1alpha1.PhaseRunning
+			task.Status.Checkpoint = tt.checkpoint
+			r := newTestReconciler(t, task)
+			key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+			resumesBefore := testutil.ToFloat64(TaskResumes.WithLabelValues(string(tt.wantMode)))
+
+			before := time.Now().Add(-time.Second)
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+			var got kubemindsv1alpha1.DiagnosisTask
+			if err := r.Get(ctx, key, &got); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			resumedAt, err := time.Parse(time.RFC3339, got.Status.ResumedAt)
+			if err != nil || resumedAt.Before(before) {
+				t.Errorf("ResumedAt = %q, want the resume time", got.Status.ResumedAt)
+			}
+			if got.Status.RestoredFindings != len(tt.checkpoint) {
+				t.Errorf("RestoredFindings = %d, want %d", got.Status.RestoredFindings, len(tt.checkpoint))
+			}
+			if got.Status.RestoreMode != tt.wantMode {
+				t.Errorf("RestoreMode = %q, want %q", got.Status.RestoreMode, tt.wantMode)
+			}
+			if resumes := testutil.ToFloat64(TaskResumes.WithLabelValues(string(tt.wantMode))); resumes != resumesBefore+1 {
+				t.Errorf("resumes_total{mode=%s} = %v, want %v", tt.wantMode, resumes, resumesBefore+1)
+			}
+		})
+	}
+}
+
+func TestReconcile_ApprovalIsNotAResume(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("approved-task")
+	task.Spec.Approved = true
+	task.Status.Phase = kubemindsv1alpha1.PhaseWaitingApproval
+	task.Status.PendingApproval = &kubemindsv1alpha1.PendingApproval{ToolName: "delete_pod", RiskLevel: "high"}
+	task.Status.Checkpoint = []kubemindsv1alpha1.Finding{{Step: 1, ToolName: "get_pod_logs", Summary: "OOMKilled"}}
+	r := newTestReconciler(t, task)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	resumesBefore := testutil.ToFloat64(TaskResumes.WithLabelValues(string(kubemindsv1alpha1.RestoreModeLossy)))
+
+	// The first reconcile applies the approval, the second starts the agent again.
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status.ResumedAt != "" || got.Status.RestoreMode != "" {
+		t.Errorf("ResumedAt/RestoreMode = %q/%q, want them unset after an approval", got.Status.ResumedAt, got.Status.RestoreMode)
+	}
+	if resumes := testutil.ToFloat64(TaskResumes.WithLabelValues(string(kubemindsv1alpha1.RestoreModeLossy))); resumes != resumesBefore {
+		t.Errorf("resumes_total{mode=Lossy} = %v, want %v", resumes, resumesBefore)
+	}
+	for {
+		select {
+		case e := <-recorder.Events:
+			if strings.Contains(e, EventReasonAgentResumed) {
+				t.Errorf("recorded %q after an approval", e)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func TestReconcile_FreshStartHasNoResumeMetadata(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("fresh-task")
+	r := newTestReconciler(t, task)
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status.ResumedAt != "" || got.Status.RestoreMode != "" {
+		t.Errorf("fresh task has resume metadata: resumedAt=%q mode=%q", got.Status.ResumedAt, got.Status.RestoreMode)
+	}
+}
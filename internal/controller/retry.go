@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// defaultRunRetryBackoff is the wait before the first retry when RunRetryBackoff is zero.
+const defaultRunRetryBackoff = 30 * time.Second
+
+// maxRunRetryBackoff caps the doubling wait between retries.
+const maxRunRetryBackoff = 10 * time.Minute
+
+// runRetryBackoff returns the wait before retry number attempt (1-based): the
+// configured backoff, doubled for each earlier retry.
+func (r *DiagnosisTaskReconciler) runRetryBackoff(attempt int32) time.Duration {
+	backoff := r.RunRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRunRetryBackoff
+	}
+	for i := int32(1); i < attempt && backoff < maxRunRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxRunRetryBackoff)
+}
+
+// scheduleRetry moves a task whose run failed with err back to Pending for another
+// run after a backoff, when err is transient and retries remain. It reports
+// whether a retry was scheduled; otherwise the caller fails the task.
+func (r *DiagnosisTaskReconciler) scheduleRetry(task *kubemindsv1alpha1.DiagnosisTask, err error) bool {
+	class := agent.ClassOf(err)
+	if !class.Retryable() || int(task.Status.Attempt) >= r.MaxRunRetries {
+		return false
+	}
+	task.Status.Attempt++
+	backoff := r.runRetryBackoff(task.Status.Attempt)
+	task.Status.Phase = kubemindsv1alpha1.PhasePending
+	task.Status.RetryAt = time.Now().Add(backoff).Format(time.RFC3339)
+	task.Status.ErrorClass = string(class)
+	task.Status.Message = fmt.Sprintf("Run failed with a transient error, retry %d of %d in %s: %s",
+		task.Status.Attempt, r.MaxRunRetries, backoff, truncateEventMessage(err.Error()))
+	return true
+}
+
+// retryWait returns how long a Pending task must still wait for its scheduled
+// retry, or zero when it may start now.
+func retryWait(task *kubemindsv1alpha1.DiagnosisTask) time.Duration {
+	if task.Status.RetryAt == "" {
+		return 0
+	}
+	retryAt, err := time.Parse(time.RFC3339, task.Status.RetryAt)
+	if err != nil {
+		return 0
+	}
+	return max(time.Until(retryAt), 0)
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// waitForAttempt polls until the task has been scheduled for retry attempt.
+func waitForAttempt(t *testing.T, r *DiagnosisTaskReconciler, key types.NamespacedName, attempt int32) kubemindsv1alpha1.DiagnosisTask {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var task kubemindsv1alpha1.DiagnosisTask
+		if err := r.Get(context.Background(), key, &task); err == nil &&
+			task.Status.Attempt == attempt && task.Status.Phase == kubemindsv1alpha1.PhasePending {
+			return task
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task %s was not scheduled for retry %d", key, attempt)
+	return kubemindsv1alpha1.DiagnosisTask{}
+}
+
+func TestReconcile_RetriesTransientLLMFailure(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("retry-transient")
+	r := newTestReconciler(t, task)
+	r.MaxRunRetries = 2
+	r.RunRetryBackoff = time.Millisecond
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	// The mock bypasses the providers, which tag transient errors like this.
+	llm.SetError(0, &agent.LLMError{Class: agent.ErrorClassLLMUnavailable, Err: errors.New("503 service unavailable")})
+	llm.SetResponse(1, &agent.Message{Type: agent.MessageTypeAssistant, Content: "Root Cause: test\nSuggestion: none"})
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	retried := waitForAttempt(t, r, key, 1)
+	if retried.Status.ErrorClass != string(agent.ErrorClassLLMUnavailable) {
+		t.Errorf("ErrorClass = %q, want %q", retried.Status.ErrorClass, agent.ErrorClassLLMUnavailable)
+	}
+	if retried.Status.RetryAt == "" {
+		t.Error("RetryAt is empty, want the time of the retry")
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() retry: %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if got.Status.Attempt != 1 {
+		t.Errorf("Attempt = %d, want 1", got.Status.Attempt)
+	}
+	if got.Status.ErrorClass != "" {
+		t.Errorf("ErrorClass = %q, want it cleared by the successful run", got.Status.ErrorClass)
+	}
+	if calls := llm.Calls(); calls != 2 {
+		t.Errorf("LLM calls = %d, want 2", calls)
+	}
+}
+
+func TestReconcile_DoesNotRetryPermanentFailure(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("retry-permanent")
+	task.Spec.Policy.MaxSteps = 1
+	r := newTestReconciler(t, task)
+	r.MaxRunRetries = 2
+	r.RunRetryBackoff = time.Millisecond
+	// The agent spends its single step on a tool call and never concludes.
+	r.LLMProvider.(*agent.MockLLMProvider).SetResponse(0, &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{ID: "call-1", Function: agent.FunctionCall{
+			Name: "get_pod_logs", Arguments: `{"namespace":"default","name":"app-1"}`,
+		}}},
+	})
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseFailed)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if got.Status.Attempt != 0 {
+		t.Errorf("Attempt = %d, want 0 (step-limit failures are not retried)", got.Status.Attempt)
+	}
+	if got.Status.ErrorClass != string(agent.ErrorClassMaxSteps) {
+		t.Errorf("ErrorClass = %q, want %q", got.Status.ErrorClass, agent.ErrorClassMaxSteps)
+	}
+}
+
+func TestReconcile_DoesNotRetryUnclassifiedLLMError(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("retry-unauthorized")
+	r := newTestReconciler(t, task)
+	r.MaxRunRetries = 2
+	r.RunRetryBackoff = time.Millisecond
+	llm := r.LLMProvider.(*agent.MockLLMProvider)
+	llm.SetError(0, errors.New("401 unauthorized: invalid api key"))
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseFailed)
+
+	var got kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if got.Status.Attempt != 0 || got.Status.RetryAt != "" {
+		t.Errorf("Attempt/RetryAt = %d/%q, want 0/\"\" (auth errors are not retried)", got.Status.Attempt, got.Status.RetryAt)
+	}
+	if calls := llm.Calls(); calls != 1 {
+		t.Errorf("LLM calls = %d, want 1", calls)
+	}
+}
+
+func TestReconcile_FailsWhenRetriesExhausted(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("retry-exhausted")
+	task.Status.Attempt = 1
+	r := newTestReconciler(t, task)
+	r.MaxRunRetries = 1
+	r.LLMProvider.(*agent.MockLLMProvider).SetError(0, &agent.LLMError{Class: agent.ErrorClassLLMUnavailable, Err: errors.New("503 service unavailable")})
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseFailed)
+}
+
+func TestReconcile_WaitsForRetryAt(t *testing.T) {
+	task := newPendingTask("retry-wait")
+	task.Status.Attempt = 1
+	task.Status.RetryAt = time.Now().Add(time.Minute).Format(time.RFC3339)
+	r := newTestReconciler(t, task)
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	if res.RequeueAfter <= 0 || res.RequeueAfter > time.Minute {
+		t.Errorf("RequeueAfter = %v, want the time left until RetryAt", res.RequeueAfter)
+	}
+	if calls := r.LLMProvider.(*agent.MockLLMProvider).Calls(); calls != 0 {
+		t.Errorf("LLM calls = %d, want 0 before RetryAt", calls)
+	}
+}
+
+func TestRunRetryBackoff(t *testing.T) {
+	r := &DiagnosisTaskReconciler{RunRetryBackoff: time.Minute}
+	for attempt, want := range map[int32]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 10: maxRunRetryBackoff} {
+		if got := r.runRetryBackoff(attempt); got != want {
+			t.Errorf("runRetryBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import "sync"
+
+// slotSemaphore is a counting semaphore with an overall limit and a limit per key.
+// The agent pool keys its slots by skill and the namespace limiter by namespace.
+// A non-positive limit does not apply.
+type slotSemaphore struct {
+	mu       sync.Mutex
+	limit    int
+	keyLimit int
+	total    int
+	active   map[string]int
+}
+
+func newSlotSemaphore(limit, keyLimit int) *slotSemaphore {
+	return &slotSemaphore{limit: limit, keyLimit: keyLimit, active: make(map[string]int)}
+}
+
+// TryAcquire takes a slot for key, reporting false when the semaphore or key is
+// already at its limit.
+func (s *slotSemaphore) TryAcquire(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.availableLocked(key) {
+		return false
+	}
+	s.active[key]++
+	s.total++
+	return true
+}
+
+// Available reports whether TryAcquire(key) would succeed now.
+func (s *slotSemaphore) Available(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.availableLocked(key)
+}
+
+func (s *slotSemaphore) availableLocked(key string) bool {
+	return (s.limit <= 0 || s.total < s.limit) && (s.keyLimit <= 0 || s.active[key] < s.keyLimit)
+}
+
+// Free returns how many slots are free overall, or -1 when there is no overall limit.
+func (s *slotSemaphore) Free() int {
+	if s.limit <= 0 {
+		return -1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max(s.limit-s.total, 0)
+}
+
+// Release frees a slot taken by TryAcquire(key), reporting false when key held none.
+func (s *slotSemaphore) Release(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[key] == 0 {
+		return false
+	}
+	s.total--
+	if s.active[key] == 1 {
+		delete(s.active, key)
+	} else {
+		s.active[key]--
+	}
+	return true
+}
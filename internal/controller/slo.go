@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// TimeToDiagnosis observes how long completed tasks took from creation to a root
+// cause, by matched skill and alert severity. It backs the time-to-diagnosis SLO.
+var TimeToDiagnosis = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "kubeminds_time_to_diagnosis_seconds",
+	Help:    "Time from DiagnosisTask creation to the Completed phase, by skill and alert severity.",
+	Buckets: []float64{30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+}, []string{"skill", "severity"})
+
+// ApprovalWait observes how long tasks waited for a human to decide on an approval
+// request, by matched skill.
+var ApprovalWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "kubeminds_approval_wait_seconds",
+	Help:    "Time DiagnosisTasks spent in WaitingApproval before a human decided, by skill.",
+	Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400},
+}, []string{"skill"})
+
+func init() {
+	metrics.Registry.MustRegister(TimeToDiagnosis, ApprovalWait)
+}
+
+// observeTimeToDiagnosis records the age of a task that just completed.
+func observeTimeToDiagnosis(task *kubemindsv1alpha1.DiagnosisTask) {
+	if task.CreationTimestamp.IsZero() {
+		return
+	}
+	severity := ""
+	if task.Spec.AlertContext != nil {
+		severity = task.Spec.AlertContext.Labels["severity"]
+	}
+	TimeToDiagnosis.WithLabelValues(task.Status.MatchedSkill, severity).
+		Observe(time.Since(task.CreationTimestamp.Time).Seconds())
+}
+
+// observeApprovalWait records how long the pending approval of a task that was
+// just decided on had been waiting. Call it before clearing Status.PendingApproval.
+func observeApprovalWait(task *kubemindsv1alpha1.DiagnosisTask) {
+	if task.Status.PendingApproval == nil {
+		return
+	}
+	requestedAt, err := time.Parse(time.RFC3339, task.Status.PendingApproval.RequestedAt)
+	if err != nil {
+		return
+	}
+	ApprovalWait.WithLabelValues(task.Status.MatchedSkill).Observe(time.Since(requestedAt).Seconds())
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// histogramSamples returns how many observations h has recorded.
+func histogramSamples(t *testing.T, h prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestReconcile_ObservesTimeToDiagnosis(t *testing.T) {
+	task := newPendingTask("slo")
+	task.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+	task.Spec.AlertContext = &kubemindsv1alpha1.AlertContext{
+		Name:   "KubePodCrashLooping",
+		Labels: map[string]string{"severity": "slo-test"},
+	}
+	r := newTestReconciler(t, task)
+	observer := TimeToDiagnosis.WithLabelValues("base_skill", "slo-test")
+	before := histogramSamples(t, observer)
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	// The sample is recorded right after the Completed status is written.
+	deadline := time.Now().Add(2 * time.Second)
+	for histogramSamples(t, observer) == before {
+		if time.Now().After(deadline) {
+			t.Fatal("TimeToDiagnosis recorded no sample for the completed task")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := histogramSamples(t, observer) - before; got != 1 {
+		t.Errorf("TimeToDiagnosis samples increased by %d, want 1", got)
+	}
+}
+
+func TestObserveApprovalWait(t *testing.T) {
+	task := newPendingTask("slo-approval")
+	task.Status.MatchedSkill = "slo-approval-skill"
+	task.Status.PendingApproval = &kubemindsv1alpha1.PendingApproval{
+		ToolName:    "delete_pod",
+		RequestedAt: time.Now().Add(-5 * time.Minute).Format(time.RFC3339),
+	}
+	observer := ApprovalWait.WithLabelValues("slo-approval-skill")
+	before := histogramSamples(t, observer)
+
+	observeApprovalWait(task)
+	if got := histogramSamples(t, observer) - before; got != 1 {
+		t.Errorf("ApprovalWait samples increased by %d, want 1", got)
+	}
+}
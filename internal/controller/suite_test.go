@@ -79,7 +79,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	toolRouter := tools.NewRouter(nil)
-	toolRouter.AddProvider(tools.NewInternalProvider(k8sClientSet))
+	toolRouter.AddProvider(tools.NewInternalProvider(k8sClientSet, nil))
 
 	err = (&DiagnosisTaskReconciler{
 		Client:      k8sManager.GetClient(),
@@ -87,6 +87,7 @@ var _ = BeforeSuite(func() {
 		K8sClient:   k8sClientSet,
 		LLMProvider: llm.NewMockProvider(),
 		ToolRouter:  toolRouter,
+		Recorder:    k8sManager.GetEventRecorderFor("diagnosistask-controller"),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+func TestReconcile_SavesRunTranscript(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("transcript")
+	r := newTestReconciler(t, task)
+	store := agent.NewFileTranscriptStore(t.TempDir())
+	r.TranscriptStore = store
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	transcript, err := store.Load(ctx, task.Namespace, task.Name)
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	if transcript.Task != key.String() {
+		t.Errorf("Task = %q, want %q", transcript.Task, key.String())
+	}
+	if last := transcript.Messages[len(transcript.Messages)-1]; last.Type != agent.MessageTypeAssistant {
+		t.Errorf("last message = %+v, want the concluding assistant turn", last)
+	}
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+// DefaultTriageMaxSteps is the triage agent's step budget when TriageMaxSteps is unset.
+const DefaultTriageMaxSteps = 2
+
+// shouldTriage reports whether task is a high-volume alert that gets a quick triage
+// before a full diagnosis. Resumed tasks that already have findings skip triage.
+func (r *DiagnosisTaskReconciler) shouldTriage(task *kubemindsv1alpha1.DiagnosisTask) bool {
+	if r.TriageMinAlertCount <= 0 || task.Spec.AlertContext == nil {
+		return false
+	}
+	return task.Spec.AlertContext.Count >= r.TriageMinAlertCount && len(task.Status.Checkpoint) == 0
+}
+
+// runTriage runs the triage skill and returns its result when the alert was judged
+// benign. The result is nil when the alert should get a full diagnosis, including
+// when triage itself fails. The tokens triage consumed are returned either way.
+func (r *DiagnosisTaskReconciler) runTriage(ctx context.Context, agentTools []agent.Tool, goal string, log *slog.Logger) (*agent.Result, agent.TokenUsage) {
+	skill, ok := r.SkillManager.GetSkillByName(agent.TriageSkillName)
+	if !ok {
+		skill = agent.TriageSkill
+	}
+	provider := r.TriageLLMProvider
+	if provider == nil {
+		provider = r.LLMProvider
+	}
+	maxSteps := r.TriageMaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultTriageMaxSteps
+	}
+
+	ag := agent.NewAgent(provider, agentTools, maxSteps, log, nil, skill).
+		WithSummaryLimits(r.SummaryMaxLen, r.ThoughtMaxLen)
+	result, err := ag.Run(ctx, goal, false)
+	if err != nil {
+		log.Info("triage failed, escalating to full diagnosis", "error", err)
+		return nil, ag.TokenUsage()
+	}
+	if !agent.TriageSuppresses(result) {
+		log.Info("triage escalated alert to full diagnosis", "verdict", result.Details[agent.TriageVerdictField])
+		return nil, ag.TokenUsage()
+	}
+	log.Info("triage judged alert benign, skipping full diagnosis")
+	return result, ag.TokenUsage()
+}
+
+// completeSuppressed marks a task Completed with the triage result as its report.
+func (r *DiagnosisTaskReconciler) completeSuppressed(key types.NamespacedName, result *agent.Result, usage agent.TokenUsage, log *slog.Logger) error {
+	updateCtx := context.Background()
+	var latestTask kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(updateCtx, key, &latestTask); err != nil {
+		log.Error("Failed to get latest task for triage update", "error", err)
+		return fmt.Errorf("failed to get latest task for triage status update: %w", err)
+	}
+
+	latestTask.Status.Phase = kubemindsv1alpha1.PhaseCompleted
+	latestTask.Status.TokensUsed += int64(usage.TotalTokens)
+	latestTask.Status.MatchedSkill = agent.TriageSkillName
+	latestTask.Status.Message = "Suppressed by triage: alert judged benign, no full diagnosis run."
+	latestTask.Status.Report = &kubemindsv1alpha1.DiagnosisReport{
+		RootCause:         result.RootCause,
+		Suggestion:        result.Suggestion,
+		Details:           result.Details,
+		ConfidencePercent: confidencePercent(result.Confidence),
+	}
+	markFinished(&latestTask)
+	if err := r.Status().Update(updateCtx, &latestTask); err != nil {
+		log.Error("Failed to update status with triage result", "error", err)
+	} else {
+		r.recordOutcome(&latestTask)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+func TestReconcile_TriageEscalatesOrSuppresses(t *testing.T) {
+	tests := []struct {
+		name         string
+		severity     string
+		verdict      string
+		wantSkill    string
+		wantFullRuns int
+	}{
+		{name: "critical alert escalates", severity: "critical", verdict: "serious", wantSkill: "base_skill", wantFullRuns: 1},
+		{name: "benign alert suppressed", severity: "info", verdict: "benign", wantSkill: agent.TriageSkillName, wantFullRuns: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			task := newPendingTask("storm-" + tt.severity)
+			task.Spec.AlertContext = &kubemindsv1alpha1.AlertContext{
+				Name:   "KubePodNotReady",
+				Labels: map[string]string{"severity": tt.severity},
+				Count:  50,
+			}
+			r := newTestReconciler(t, task)
+			r.TriageMinAlertCount = 10
+
+			triageLLM := agent.NewMockLLMProvider()
+			triageLLM.Responses[0] = &agent.Message{
+				Type:    agent.MessageTypeAssistant,
+				Content: "Root Cause: triaged\nSuggestion: none\nverdict: " + tt.verdict,
+			}
+			r.TriageLLMProvider = triageLLM
+			fullLLM := r.LLMProvider.(*agent.MockLLMProvider)
+
+			key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile(): %v", err)
+			}
+			waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+			var done kubemindsv1alpha1.DiagnosisTask
+			if err := r.Get(ctx, key, &done); err != nil {
+				t.Fatalf("Get(): %v", err)
+			}
+			if triageLLM.Calls() != 1 {
+				t.Errorf("triage LLM calls = %d, want 1", triageLLM.Calls())
+			}
+			if fullLLM.Calls() != tt.wantFullRuns {
+				t.Errorf("full diagnosis LLM calls = %d, want %d", fullLLM.Calls(), tt.wantFullRuns)
+			}
+			if done.Status.MatchedSkill != tt.wantSkill {
+				t.Errorf("MatchedSkill = %q, want %q", done.Status.MatchedSkill, tt.wantSkill)
+			}
+		})
+	}
+}
+
+func TestReconcile_TriageSkippedBelowMinAlertCount(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("quiet")
+	task.Spec.AlertContext = &kubemindsv1alpha1.AlertContext{Name: "KubePodNotReady", Count: 2}
+	r := newTestReconciler(t, task)
+	r.TriageMinAlertCount = 10
+	triageLLM := agent.NewMockLLMProvider()
+	r.TriageLLMProvider = triageLLM
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+	if triageLLM.Calls() != 0 {
+		t.Errorf("triage LLM calls = %d, want 0 below minAlertCount", triageLLM.Calls())
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// markFinished stamps Status.CompletionTime on a task that has reached a terminal phase.
+func markFinished(task *kubemindsv1alpha1.DiagnosisTask) {
+	if task.Status.Phase.IsTerminal() && task.Status.CompletionTime == "" {
+		task.Status.CompletionTime = time.Now().Format(time.RFC3339)
+	}
+}
+
+// ttlAfterFinished returns how long task is kept once finished: its own
+// Spec.TTLSecondsAfterFinished, else TTLAfterFinished. ok is false when finished
+// tasks are kept forever.
+func (r *DiagnosisTaskReconciler) ttlAfterFinished(task *kubemindsv1alpha1.DiagnosisTask) (ttl time.Duration, ok bool) {
+	if task.Spec.TTLSecondsAfterFinished != nil {
+		return time.Duration(*task.Spec.TTLSecondsAfterFinished) * time.Second, true
+	}
+	return r.TTLAfterFinished, r.TTLAfterFinished > 0
+}
+
+// expireFinished deletes a finished task once its TTL has elapsed after
+// Status.CompletionTime, and otherwise requeues it for when it will have.
+// Tasks finished before CompletionTime was recorded are stamped now.
+func (r *DiagnosisTaskReconciler) expireFinished(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask, log *slog.Logger) (ctrl.Result, error) {
+	ttl, ok := r.ttlAfterFinished(task)
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	if task.Status.CompletionTime == "" {
+		markFinished(task)
+		if err := r.Status().Update(ctx, task); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to record completion time: %w", err)
+		}
+	}
+	completedAt, err := time.Parse(time.RFC3339, task.Status.CompletionTime)
+	if err != nil {
+		log.Warn("Invalid completion time, keeping task", "completionTime", task.Status.CompletionTime, "error", err)
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(completedAt.Add(ttl)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("Deleting finished task after its TTL", "phase", task.Status.Phase, "ttl", ttl)
+	if err := r.Delete(ctx, task); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, nil
+}
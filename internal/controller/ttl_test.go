@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+func TestReconcile_DeletesFinishedTaskAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("short-ttl")
+	ttl := int32(0)
+	task.Spec.TTLSecondsAfterFinished = &ttl
+	r := newTestReconciler(t, task)
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	req := ctrl.Request{NamespacedName: key}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if done.Status.CompletionTime == "" {
+		t.Fatal("CompletionTime not recorded")
+	}
+
+	// The status update of the finished task triggers the reconcile that expires it.
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	if err := r.Get(ctx, key, &done); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() after TTL = %v, want NotFound", err)
+	}
+}
+
+func TestReconcile_FinishedTaskTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		specTTL     *int32
+		defaultTTL  time.Duration
+		finishedAgo time.Duration
+		wantDeleted bool
+		wantRequeue bool
+	}{
+		{name: "no TTL keeps the task", finishedAgo: time.Hour},
+		{name: "controller default elapsed", defaultTTL: time.Minute, finishedAgo: time.Hour, wantDeleted: true},
+		{name: "controller default pending", defaultTTL: time.Hour, finishedAgo: time.Minute, wantRequeue: true},
+		{name: "spec overrides default", specTTL: ptrInt32(7200), defaultTTL: time.Minute, finishedAgo: time.Hour, wantRequeue: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			task := newPendingTask("finished")
+			task.Spec.TTLSecondsAfterFinished = tt.specTTL
+			task.Status.Phase = kubemindsv1alpha1.PhaseFailed
+			task.Status.CompletionTime = time.Now().Add(-tt.finishedAgo).Format(time.RFC3339)
+			r := newTestReconciler(t, task)
+			r.TTLAfterFinished = tt.defaultTTL
+			key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatalf("Reconcile(): %v", err)
+			}
+			var got kubemindsv1alpha1.DiagnosisTask
+			deleted := apierrors.IsNotFound(r.Get(ctx, key, &got))
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if (res.RequeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("RequeueAfter = %v, want requeue %v", res.RequeueAfter, tt.wantRequeue)
+			}
+		})
+	}
+}
+
+func ptrInt32(v int32) *int32 { return &v }
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/agent"
+)
+
+func TestReconcile_RecordsTokensUsed(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("tokens")
+	task.Status.TokensUsed = 1000 // from an earlier, interrupted run
+	r := newTestReconciler(t, task)
+	r.LLMProvider.(*agent.MockLLMProvider).Responses[0].Usage = &agent.TokenUsage{
+		PromptTokens: 200, CompletionTokens: 50, TotalTokens: 250,
+	}
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if done.Status.TokensUsed != 1250 {
+		t.Errorf("TokensUsed = %d, want 1250", done.Status.TokensUsed)
+	}
+}
+
+func TestReconcile_RecordsConfidence(t *testing.T) {
+	ctx := context.Background()
+	task := newPendingTask("confidence")
+	r := newTestReconciler(t, task)
+	r.LLMProvider.(*agent.MockLLMProvider).Responses[0].Content = "Root Cause: test\nSuggestion: none\nConfidence: 0.42"
+
+	key := types.NamespacedName{Namespace: task.Namespace, Name: task.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile(): %v", err)
+	}
+	waitForPhase(t, r, key, kubemindsv1alpha1.PhaseCompleted)
+
+	var done kubemindsv1alpha1.DiagnosisTask
+	if err := r.Get(ctx, key, &done); err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if got := done.Status.Report.ConfidencePercent; got == nil || *got != 42 {
+		t.Errorf("ConfidencePercent = %v, want 42", got)
+	}
+}
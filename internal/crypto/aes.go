@@ -2,7 +2,8 @@
 //
 // Usage pattern:
 //  1. Generate a 32-byte master key (e.g. `openssl rand -hex 32`) and store it in
-//     the KUBEMINDS_MASTER_KEY environment variable.
+//     the KUBEMINDS_MASTER_KEY environment variable, or in a file (e.g. a mounted
+//     Secret) whose path is in KUBEMINDS_MASTER_KEY_FILE.
 //  2. Encrypt your API key with `make encrypt-key KEY=sk-xxx` → outputs an "enc:aes256:..." string.
 //  3. Paste the encrypted string into config.yaml. The config loader decrypts it automatically at startup.
 //
@@ -18,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -105,37 +107,71 @@ func IsEncrypted(value string) bool {
 	return strings.HasPrefix(value, encPrefix)
 }
 
+// MasterKeyEnv and MasterKeyFileEnv name the environment variables holding the master
+// key itself, or the path of a file containing it (e.g. a mounted Kubernetes Secret).
+const (
+	MasterKeyEnv     = "KUBEMINDS_MASTER_KEY"
+	MasterKeyFileEnv = "KUBEMINDS_MASTER_KEY_FILE"
+)
+
 // MasterKeyFromEnv reads the 32-byte master key from the KUBEMINDS_MASTER_KEY environment variable.
 // The env var must be a 64-character lowercase hex string (e.g. from `openssl rand -hex 32`).
 // Returns an error if the variable is missing or malformed.
 func MasterKeyFromEnv() ([]byte, error) {
-	hexKey := os.Getenv("KUBEMINDS_MASTER_KEY")
+	hexKey := os.Getenv(MasterKeyEnv)
 	if hexKey == "" {
 		return nil, fmt.Errorf("crypto: KUBEMINDS_MASTER_KEY environment variable is not set; " +
 			"generate one with: openssl rand -hex 32")
 	}
+	return parseMasterKey(hexKey, "KUBEMINDS_MASTER_KEY")
+}
+
+// MasterKeyFromFile reads the 32-byte master key from the file at path, which must
+// hold a 64-character hex string. Trailing whitespace and newlines are ignored.
+func MasterKeyFromFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to read master key file: %w", err)
+	}
+	return parseMasterKey(strings.TrimRight(string(data), " \t\r\n"), "master key file "+path)
+}
+
+// MasterKey resolves the master key: KUBEMINDS_MASTER_KEY when set, otherwise the
+// file named by KUBEMINDS_MASTER_KEY_FILE.
+func MasterKey() ([]byte, error) {
+	if os.Getenv(MasterKeyEnv) != "" {
+		return MasterKeyFromEnv()
+	}
+	if path := os.Getenv(MasterKeyFileEnv); path != "" {
+		return MasterKeyFromFile(path)
+	}
+	return nil, fmt.Errorf("crypto: neither KUBEMINDS_MASTER_KEY nor KUBEMINDS_MASTER_KEY_FILE is set; " +
+		"generate a key with: openssl rand -hex 32")
+}
 
+// parseMasterKey decodes a hex master key read from source.
+func parseMasterKey(hexKey, source string) ([]byte, error) {
 	key, err := hex.DecodeString(hexKey)
 	if err != nil {
-		return nil, fmt.Errorf("crypto: KUBEMINDS_MASTER_KEY is not valid hex: %w", err)
+		return nil, fmt.Errorf("crypto: %s is not valid hex: %w", source, err)
 	}
 
 	if len(key) != 32 {
-		return nil, fmt.Errorf("crypto: KUBEMINDS_MASTER_KEY must be 64 hex chars (32 bytes), got %d bytes", len(key))
+		return nil, fmt.Errorf("crypto: %s must be 64 hex chars (32 bytes), got %d bytes", source, len(key))
 	}
 
 	return key, nil
 }
 
-// DecryptValue decrypts a single config value using the master key from KUBEMINDS_MASTER_KEY.
+// DecryptValue decrypts a single config value using the master key resolved by MasterKey.
 // If the value does not have the "enc:aes256:" prefix, it is returned unchanged.
-// If the value is encrypted but the env var is missing or the key is wrong, an error is returned.
+// If the value is encrypted but no key is configured or the key is wrong, an error is returned.
 func DecryptValue(value string) (string, error) {
 	if !IsEncrypted(value) {
 		return value, nil
 	}
 
-	key, err := MasterKeyFromEnv()
+	key, err := MasterKey()
 	if err != nil {
 		return "", fmt.Errorf("crypto: cannot decrypt config value: %w", err)
 	}
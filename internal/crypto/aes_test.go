@@ -1,6 +1,10 @@
 package crypto
 
 import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -115,3 +119,88 @@ func TestEncrypt_InvalidKeySize(t *testing.T) {
 		t.Error("Encrypt() with short key should return an error")
 	}
 }
+
+// writeKeyFile writes content to a key file in a temp dir and returns its path.
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestMasterKeyFromFile(t *testing.T) {
+	want := generateTestKey()
+
+	t.Run("valid key with trailing newline", func(t *testing.T) {
+		key, err := MasterKeyFromFile(writeKeyFile(t, hex.EncodeToString(want)+"\n"))
+		if err != nil {
+			t.Fatalf("MasterKeyFromFile() error = %v", err)
+		}
+		if !bytes.Equal(key, want) {
+			t.Errorf("key = %x, want %x", key, want)
+		}
+	})
+
+	t.Run("malformed key", func(t *testing.T) {
+		for _, content := range []string{"not-hex\n", hex.EncodeToString(want[:16]) + "\n"} {
+			if _, err := MasterKeyFromFile(writeKeyFile(t, content)); err == nil {
+				t.Errorf("MasterKeyFromFile(%q) error = nil, want an error", content)
+			}
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := MasterKeyFromFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("MasterKeyFromFile() error = nil, want an error")
+		}
+	})
+}
+
+func TestMasterKey_Precedence(t *testing.T) {
+	envKey := generateTestKey()
+	fileKey := bytes.Repeat([]byte{0xab}, 32)
+	keyFile := writeKeyFile(t, hex.EncodeToString(fileKey))
+
+	t.Run("env var wins over the file", func(t *testing.T) {
+		t.Setenv(MasterKeyEnv, hex.EncodeToString(envKey))
+		t.Setenv(MasterKeyFileEnv, keyFile)
+		key, err := MasterKey()
+		if err != nil || !bytes.Equal(key, envKey) {
+			t.Errorf("MasterKey() = %x, %v; want the env var key", key, err)
+		}
+	})
+
+	t.Run("file when the env var is unset", func(t *testing.T) {
+		t.Setenv(MasterKeyEnv, "")
+		t.Setenv(MasterKeyFileEnv, keyFile)
+		key, err := MasterKey()
+		if err != nil || !bytes.Equal(key, fileKey) {
+			t.Errorf("MasterKey() = %x, %v; want the file key", key, err)
+		}
+	})
+
+	t.Run("neither set", func(t *testing.T) {
+		t.Setenv(MasterKeyEnv, "")
+		t.Setenv(MasterKeyFileEnv, "")
+		if _, err := MasterKey(); err == nil {
+			t.Error("MasterKey() error = nil, want an error")
+		}
+	})
+}
+
+func TestDecryptValue_MasterKeyFile(t *testing.T) {
+	key := generateTestKey()
+	enc, err := Encrypt(key, "sk-from-file")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	t.Setenv(MasterKeyEnv, "")
+	t.Setenv(MasterKeyFileEnv, writeKeyFile(t, hex.EncodeToString(key)+"\n"))
+
+	got, err := DecryptValue(enc)
+	if err != nil || got != "sk-from-file" {
+		t.Errorf("DecryptValue() = %q, %v; want the plaintext", got, err)
+	}
+}
@@ -13,26 +13,41 @@ package llm
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"time"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/anthropics/anthropic-sdk-go/packages/param"
+	"github.com/anthropics/anthropic-sdk-go/packages/ssestream"
 
 	"kubeminds/internal/agent"
 )
 
-// defaultMaxTokens is the default max_tokens sent to Anthropic.
+// anthropicStatusOverloaded is the non-standard HTTP status Anthropic returns
+// when the API is temporarily overloaded.
+const anthropicStatusOverloaded = 529
+
+// defaultMaxTokens is the max_tokens sent to Anthropic when none is configured.
 // Anthropic requires this field; 4096 is a safe default for diagnostic tasks.
 const defaultMaxTokens int64 = 4096
 
 // AnthropicProvider implements agent.LLMProvider using the Anthropic SDK.
 type AnthropicProvider struct {
 	client *anthropic.Client
-	model  string
+	// opts are the client options, kept to rebuild the client in WithTLSConfig.
+	opts  []option.RequestOption
+	model string
+
+	// temperature is sent with each request when non-nil.
+	temperature *float32
+	// maxTokens overrides defaultMaxTokens when non-nil.
+	maxTokens *int
 }
 
 // NewAnthropicProvider creates a new AnthropicProvider.
@@ -51,18 +66,144 @@ func NewAnthropicProvider(apiKey, model, baseURL string) *AnthropicProvider {
 	c := anthropic.NewClient(opts...)
 	return &AnthropicProvider{
 		client: &c,
+		opts:   opts,
 		model:  model,
 	}
 }
 
+// WithTLSConfig makes the provider's HTTPS client use tlsCfg, e.g. to trust a
+// private CA. A nil tlsCfg keeps the default.
+func (p *AnthropicProvider) WithTLSConfig(tlsCfg *tls.Config) *AnthropicProvider {
+	if tlsCfg != nil {
+		p.opts = append(p.opts, option.WithHTTPClient(&http.Client{Transport: tlsTransport(tlsCfg)}))
+		c := anthropic.NewClient(p.opts...)
+		p.client = &c
+	}
+	return p
+}
+
+// WithGenerationParams sets the sampling temperature and max_tokens sent with each
+// request. A nil temperature leaves the API default; a nil maxTokens uses defaultMaxTokens,
+// since Anthropic requires max_tokens on every request.
+func (p *AnthropicProvider) WithGenerationParams(temperature *float32, maxTokens *int) *AnthropicProvider {
+	p.temperature = temperature
+	p.maxTokens = maxTokens
+	return p
+}
+
 // Chat sends messages to Anthropic Claude and returns the response.
 // It converts from our internal OpenAI-style format to Anthropic's format,
 // makes the API call with exponential-backoff retry, and converts the response back.
 func (p *AnthropicProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	reqParams, err := p.buildParams(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Call API with exponential-backoff retry ---
+	resp, err := p.callWithRetry(ctx, reqParams)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic api error: %w", classifyAnthropicError(ctx, err))
+	}
+
+	// --- Convert response back to our internal format ---
+	return convertResponse(resp)
+}
+
+// ChatStream implements agent.StreamingLLMProvider. text_delta events are sent as they
+// arrive and the events are accumulated into the final message, tool_use blocks included.
+// Only opening the stream is retried (see openStream), since a broken stream cannot be resumed.
+func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []agent.Message, tools []agent.Tool) (<-chan agent.StreamChunk, error) {
+	reqParams, err := p.buildParams(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.openStream(ctx, reqParams)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic api error: %w", classifyAnthropicError(ctx, err))
+	}
+	chunks := make(chan agent.StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		var acc anthropic.Message
+		// openStream has already read the first event.
+		for ok := true; ok; ok = stream.Next() {
+			event := stream.Current()
+			if err := acc.Accumulate(event); err != nil {
+				sendChunk(ctx, chunks, agent.StreamChunk{Err: fmt.Errorf("anthropic stream error: %w", err)})
+				return
+			}
+			if event.Type == "content_block_delta" && event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				if !sendChunk(ctx, chunks, agent.StreamChunk{Content: event.Delta.Text}) {
+					return
+				}
+			}
+		}
+		if err := stream.Err(); err != nil {
+			sendChunk(ctx, chunks, agent.StreamChunk{Err: fmt.Errorf("anthropic api error: %w", classifyAnthropicError(ctx, err))})
+			return
+		}
+
+		msg, err := convertResponse(&acc)
+		if err != nil {
+			sendChunk(ctx, chunks, agent.StreamChunk{Err: err})
+			return
+		}
+		sendChunk(ctx, chunks, agent.StreamChunk{Message: msg})
+	}()
+	return chunks, nil
+}
+
+// openStream opens a Messages stream and reads its first event. NewStreaming defers
+// HTTP errors into the stream, so waiting for the first event surfaces 429/529 and
+// other failures here, where they are retried like callWithRetry and returned to
+// the caller instead of the stream. On success the stream is positioned on its
+// first event.
+func (p *AnthropicProvider) openStream(ctx context.Context, params anthropic.MessageNewParams) (*ssestream.Stream[anthropic.MessageStreamEventUnion], error) {
+	const maxRetries = 3
+	baseDelay := time.Second
+
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		stream := p.client.Messages.NewStreaming(ctx, params)
+		if stream.Next() {
+			return stream, nil
+		}
+		err = stream.Err()
+		_ = stream.Close()
+		if err == nil {
+			return nil, errors.New("stream ended before its first event")
+		}
+
+		if attempt < maxRetries-1 && isRetryableAnthropicError(err) {
+			delay := time.Duration(math.Min(
+				float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))),
+				10000,
+			)) * time.Millisecond
+
+			select {
+			case <-time.After(delay):
+				// continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		} else {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// buildParams converts internal messages and tools to Messages API request params.
+func (p *AnthropicProvider) buildParams(messages []agent.Message, tools []agent.Tool) (anthropic.MessageNewParams, error) {
 	// --- Convert tools ---
 	anthropicTools, err := convertTools(tools)
 	if err != nil {
-		return nil, fmt.Errorf("anthropic: failed to convert tools: %w", err)
+		return anthropic.MessageNewParams{}, fmt.Errorf("anthropic: failed to convert tools: %w", err)
 	}
 
 	// --- Split system prompt from the rest of the messages ---
@@ -117,28 +258,27 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []agent.Message,
 	}
 
 	// --- Build request params ---
+	maxTokens := defaultMaxTokens
+	if p.maxTokens != nil {
+		maxTokens = int64(*p.maxTokens)
+	}
 	reqParams := anthropic.MessageNewParams{
 		Model:     anthropic.Model(p.model),
-		MaxTokens: defaultMaxTokens,
+		MaxTokens: maxTokens,
 		Messages:  chatMessages,
 		System:    systemBlocks,
 	}
+	if p.temperature != nil {
+		reqParams.Temperature = param.NewOpt(float64(*p.temperature))
+	}
 	if len(anthropicTools) > 0 {
 		reqParams.Tools = anthropicTools
 	}
-
-	// --- Call API with exponential-backoff retry ---
-	resp, err := p.callWithRetry(ctx, reqParams)
-	if err != nil {
-		return nil, fmt.Errorf("anthropic api error: %w", err)
-	}
-
-	// --- Convert response back to our internal format ---
-	return convertResponse(resp)
+	return reqParams, nil
 }
 
 // callWithRetry calls the Anthropic Messages API with exponential backoff.
-// Max 3 attempts; delays: 1s, 2s (capped at 10s). Only network/5xx/429 errors are retried.
+// Max 3 attempts; delays: 1s, 2s (capped at 10s). Only network/5xx/429/529 errors are retried.
 func (p *AnthropicProvider) callWithRetry(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
 	const maxRetries = 3
 	baseDelay := time.Second
@@ -152,7 +292,7 @@ func (p *AnthropicProvider) callWithRetry(ctx context.Context, params anthropic.
 			return resp, nil
 		}
 
-		if attempt < maxRetries-1 && isRetryableError(err) {
+		if attempt < maxRetries-1 && isRetryableAnthropicError(err) {
 			delay := time.Duration(math.Min(
 				float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))),
 				10000,
@@ -172,6 +312,78 @@ func (p *AnthropicProvider) callWithRetry(ctx context.Context, params anthropic.
 	return nil, err
 }
 
+// isRetryableAnthropicError classifies errors returned by the Anthropic SDK.
+// API errors are typed (*anthropic.Error), so we inspect the HTTP status and the
+// error "type" from the response body instead of matching strings:
+//   - overloaded_error / 529, rate_limit_error / 429, api_error / 5xx are retried.
+//   - Everything else (400 invalid_request_error, 401 authentication_error, ...) is not.
+//
+// Non-API errors (network failures, timeouts) fall back to isRetryableError.
+func isRetryableAnthropicError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		return isRetryableError(err)
+	}
+
+	switch anthropicErrorType(apiErr) {
+	case "overloaded_error", "rate_limit_error", "api_error", "timeout_error":
+		return true
+	}
+
+	switch {
+	case apiErr.StatusCode == anthropicStatusOverloaded,
+		apiErr.StatusCode == 429,
+		apiErr.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// anthropicErrorType extracts error.type from the raw API error body, e.g.
+// {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}.
+// Returns "" when the body is missing or not in the expected shape.
+func anthropicErrorType(apiErr *anthropic.Error) string {
+	errType, _ := anthropicErrorBody(apiErr)
+	return errType
+}
+
+// anthropicErrorBody extracts error.type and error.message from the raw API error body.
+func anthropicErrorBody(apiErr *anthropic.Error) (errType, message string) {
+	raw := apiErr.RawJSON()
+	if raw == "" {
+		return "", ""
+	}
+	var body struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(raw), &body); err != nil {
+		return "", ""
+	}
+	return body.Error.Type, body.Error.Message
+}
+
+// classifyAnthropicError tags an invalid_request_error reporting that the prompt
+// exceeds the context window (e.g. "prompt is too long: 210000 tokens > 200000
+// maximum") as agent.ErrorClassContextTooLong and transient errors as
+// agent.ErrorClassLLMUnavailable; other errors are returned unchanged.
+func classifyAnthropicError(ctx context.Context, err error) error {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		if errType, message := anthropicErrorBody(apiErr); errType == "invalid_request_error" && isContextLengthMessage(message) {
+			return &agent.LLMError{Class: agent.ErrorClassContextTooLong, Err: err}
+		}
+	}
+	return classifyTransientError(ctx, err, isRetryableAnthropicError)
+}
+
 // convertTools converts our internal agent.Tool slice to Anthropic's ToolParam slice.
 func convertTools(tools []agent.Tool) ([]anthropic.ToolUnionParam, error) {
 	if len(tools) == 0 {
@@ -205,8 +417,16 @@ func convertTools(tools []agent.Tool) ([]anthropic.ToolUnionParam, error) {
 // convertResponse converts an Anthropic Message response to our internal agent.Message.
 // It extracts text content and any tool_use blocks into the appropriate fields.
 func convertResponse(resp *anthropic.Message) (*agent.Message, error) {
+	// Cached input tokens are billed separately but still count towards the prompt.
+	prompt := int(resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens)
+	completion := int(resp.Usage.OutputTokens)
 	result := &agent.Message{
 		Type: agent.MessageTypeAssistant,
+		Usage: &agent.TokenUsage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
 	}
 
 	for _, block := range resp.Content {
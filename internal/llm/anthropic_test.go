@@ -3,6 +3,12 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
@@ -145,8 +151,57 @@ func TestConvertResponse_MixedContent(t *testing.T) {
 	}
 }
 
+// TestIsRetryableAnthropicError verifies that typed Anthropic API errors are
+// classified by status code and error type rather than by message text.
+func TestIsRetryableAnthropicError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"nil", nil, false},
+		{"overloaded_529", newAnthropicAPIError(t, 529, "overloaded_error"), true},
+		{"overloaded_type_only", newAnthropicAPIError(t, 0, "overloaded_error"), true},
+		{"rate_limited_429", newAnthropicAPIError(t, 429, "rate_limit_error"), true},
+		{"api_error_500", newAnthropicAPIError(t, 500, "api_error"), true},
+		{"bad_gateway_no_body", newAnthropicAPIError(t, 502, ""), true},
+		{"invalid_request_400", newAnthropicAPIError(t, 400, "invalid_request_error"), false},
+		{"unauthorized_401", newAnthropicAPIError(t, 401, "authentication_error"), false},
+		{"wrapped_overloaded", fmt.Errorf("call failed: %w", newAnthropicAPIError(t, 529, "overloaded_error")), true},
+		{"wrapped_unauthorized", fmt.Errorf("call failed: %w", newAnthropicAPIError(t, 401, "authentication_error")), false},
+		{"network_error_fallback", errors.New("dial tcp: connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableAnthropicError(tt.err); got != tt.expect {
+				t.Errorf("isRetryableAnthropicError() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
 // --- helpers ---
 
+// newAnthropicAPIError builds an *anthropic.Error the way the SDK does for a
+// non-2xx response: status code, request/response, and the raw JSON error body.
+func newAnthropicAPIError(t *testing.T, status int, errType string) *anthropic.Error {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	apiErr := &anthropic.Error{
+		StatusCode: status,
+		Request:    req,
+		Response:   &http.Response{StatusCode: status, Request: req},
+	}
+	if errType != "" {
+		body := fmt.Sprintf(`{"type":"error","error":{"type":%q,"message":"test"}}`, errType)
+		if err := apiErr.UnmarshalJSON([]byte(body)); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %v", err)
+		}
+	}
+	return apiErr
+}
+
 // fakeToolForAnthropicTest implements agent.Tool for use in tests only.
 type fakeToolForAnthropicTest struct {
 	name        string
@@ -161,3 +216,133 @@ func (f *fakeToolForAnthropicTest) Execute(_ context.Context, _ string) (string,
 	return "", nil
 }
 func (f *fakeToolForAnthropicTest) SafetyLevel() agent.SafetyLevel { return agent.SafetyLevelReadOnly }
+
+func TestAnthropicProvider_ChatStream(t *testing.T) {
+	events := []struct{ name, data string }{
+		{"message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[],"usage":{"input_tokens":20,"output_tokens":1}}}`},
+		{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"logs"}}`},
+		{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_pod_logs","input":{}}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"podName\":\"app-1\"}"}}`},
+		{"content_block_stop", `{"type":"content_block_stop","index":1}`},
+		{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`},
+		{"message_stop", `{"type":"message_stop"}`},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewAnthropicProvider("k", "claude-test", srv.URL)
+	chunks, err := p.ChatStream(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var text string
+	var final *agent.Message
+	for c := range chunks {
+		if c.Err != nil {
+			t.Fatalf("stream error = %v", c.Err)
+		}
+		text += c.Content
+		if c.Message != nil {
+			final = c.Message
+		}
+	}
+
+	if text != "Checking logs" {
+		t.Errorf("streamed text = %q, want %q", text, "Checking logs")
+	}
+	if final == nil || len(final.ToolCalls) != 1 {
+		t.Fatalf("final = %+v, want one tool call", final)
+	}
+	var args map[string]string
+	if err := json.Unmarshal([]byte(final.ToolCalls[0].Function.Arguments), &args); err != nil || args["podName"] != "app-1" {
+		t.Errorf("Arguments = %q, want podName app-1", final.ToolCalls[0].Function.Arguments)
+	}
+	if final.Usage == nil || final.Usage.PromptTokens != 20 || final.Usage.CompletionTokens != 9 {
+		t.Errorf("Usage = %+v, want 20 prompt / 9 completion tokens", final.Usage)
+	}
+}
+
+func TestAnthropicProvider_ChatStream_ReturnsHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+	}))
+	defer srv.Close()
+
+	p := NewAnthropicProvider("bad", "claude-test", srv.URL)
+	chunks, err := p.ChatStream(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err == nil {
+		for range chunks {
+		}
+		t.Fatal("ChatStream() error = nil, want the 401 returned before streaming")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("ChatStream() error = %v, want the 401 status", err)
+	}
+}
+
+func TestAnthropicProvider_GenerationParams(t *testing.T) {
+	msgs := []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}
+
+	params, err := NewAnthropicProvider("k", "claude-test", "").buildParams(msgs, nil)
+	if err != nil {
+		t.Fatalf("buildParams() error = %v", err)
+	}
+	if params.MaxTokens != defaultMaxTokens || params.Temperature.Valid() {
+		t.Errorf("defaults: max_tokens = %d, temperature set = %v; want %d and unset",
+			params.MaxTokens, params.Temperature.Valid(), defaultMaxTokens)
+	}
+
+	maxTokens := 8192
+	params, err = NewAnthropicProvider("k", "claude-test", "").
+		WithGenerationParams(new(float32), &maxTokens).
+		buildParams(msgs, nil)
+	if err != nil {
+		t.Fatalf("buildParams() error = %v", err)
+	}
+	if params.MaxTokens != 8192 {
+		t.Errorf("max_tokens = %d, want 8192", params.MaxTokens)
+	}
+	if !params.Temperature.Valid() || params.Temperature.Value != 0 {
+		t.Errorf("temperature = %+v, want an explicit 0", params.Temperature)
+	}
+}
+
+func TestAnthropicProvider_ContextTooLong(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`)
+	}))
+	defer srv.Close()
+
+	p := NewAnthropicProvider("k", "claude-test", srv.URL)
+	_, err := p.Chat(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if got := agent.ClassOf(err); got != agent.ErrorClassContextTooLong {
+		t.Fatalf("ClassOf(%v) = %q, want %q", err, got, agent.ErrorClassContextTooLong)
+	}
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		t.Errorf("error %v does not wrap the API error", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1 (not retried)", n)
+	}
+
+	other := newAnthropicAPIError(t, 400, "invalid_request_error")
+	if got := agent.ClassOf(classifyAnthropicError(context.Background(), other)); got != "" {
+		t.Errorf("unrelated invalid_request_error classified as %q", got)
+	}
+}
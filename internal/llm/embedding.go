@@ -2,7 +2,12 @@ package llm
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"kubeminds/internal/agent"
@@ -15,6 +20,7 @@ var _ agent.EmbeddingProvider = (*OpenAIEmbedder)(nil)
 // It is compatible with any OpenAI-compatible endpoint (e.g. local proxies).
 type OpenAIEmbedder struct {
 	client *openai.Client
+	config openai.ClientConfig
 	model  openai.EmbeddingModel
 }
 
@@ -28,10 +34,21 @@ func NewOpenAIEmbedder(apiKey, baseURL string) *OpenAIEmbedder {
 	}
 	return &OpenAIEmbedder{
 		client: openai.NewClientWithConfig(cfg),
+		config: cfg,
 		model:  openai.SmallEmbedding3, // text-embedding-3-small, 1536 dims
 	}
 }
 
+// WithTLSConfig makes the embedder's HTTPS client use tlsCfg, e.g. to trust a
+// private CA. A nil tlsCfg keeps the default.
+func (e *OpenAIEmbedder) WithTLSConfig(tlsCfg *tls.Config) *OpenAIEmbedder {
+	if tlsCfg != nil {
+		e.config.HTTPClient = &http.Client{Transport: tlsTransport(tlsCfg)}
+		e.client = openai.NewClientWithConfig(e.config)
+	}
+	return e
+}
+
 // Embed calls the OpenAI Embeddings API and returns the first embedding vector.
 func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	if text == "" {
@@ -51,3 +68,67 @@ func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 
 	return resp.Data[0].Embedding, nil
 }
+
+// Compile-time check: OllamaEmbedder must satisfy agent.EmbeddingProvider.
+var _ agent.EmbeddingProvider = (*OllamaEmbedder)(nil)
+
+// ollamaDefaultEmbedModel is the embedding model used when none is configured.
+// It produces 768-dimensional vectors, so postgres.embedDim must be set to match.
+const ollamaDefaultEmbedModel = "nomic-embed-text"
+
+// OllamaEmbedder implements agent.EmbeddingProvider using a local Ollama server's
+// /api/embed endpoint, so the L3 knowledge base works without a hosted API.
+type OllamaEmbedder struct {
+	httpClient *http.Client
+	model      string
+	baseURL    string
+}
+
+// NewOllamaEmbedder creates an OllamaEmbedder.
+// baseURL follows the same semantics as NewOllamaProvider.
+// model defaults to nomic-embed-text when empty.
+func NewOllamaEmbedder(model, baseURL string) *OllamaEmbedder {
+	if model == "" {
+		model = ollamaDefaultEmbedModel
+	}
+	if baseURL == "" {
+		baseURL = ollamaDefaultBaseURL
+	}
+	return &OllamaEmbedder{
+		httpClient: &http.Client{Timeout: time.Minute},
+		model:      model,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// WithTLSConfig makes the embedder's HTTP client use tlsCfg for an HTTPS server,
+// e.g. one behind a private CA. A nil tlsCfg keeps the default.
+func (e *OllamaEmbedder) WithTLSConfig(tlsCfg *tls.Config) *OllamaEmbedder {
+	if tlsCfg != nil {
+		e.httpClient.Transport = tlsTransport(tlsCfg)
+	}
+	return e
+}
+
+// Embed calls the Ollama embed API and returns the first embedding vector.
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if text == "" {
+		return nil, fmt.Errorf("embedding: input text must not be empty")
+	}
+
+	body, err := json.Marshal(map[string]any{"model": e.model, "input": text})
+	if err != nil {
+		return nil, fmt.Errorf("embedding: failed to marshal request: %w", err)
+	}
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := postOllama(ctx, e.httpClient, e.baseURL+"/api/embed", body, &resp); err != nil {
+		return nil, fmt.Errorf("embedding: ollama api error: %w", err)
+	}
+	if len(resp.Embeddings) == 0 {
+		return nil, fmt.Errorf("embedding: no embeddings returned by api")
+	}
+
+	return resp.Embeddings[0], nil
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+// Compile-time check: LimitingEmbeddingProvider must satisfy agent.EmbeddingProvider.
+var _ agent.EmbeddingProvider = (*LimitingEmbeddingProvider)(nil)
+
+const (
+	// embedMaxAttempts and embedBaseDelay mirror the chat providers' retry policy:
+	// up to 3 attempts with exponential backoff from 1s, capped at embedMaxDelay.
+	embedMaxAttempts = 3
+	embedBaseDelay   = time.Second
+	embedMaxDelay    = 10 * time.Second
+)
+
+// LimitingEmbeddingProvider decorates an EmbeddingProvider so that at most a fixed
+// number of Embed calls reach the endpoint at once, and transient failures (rate
+// limits, 5xx, network errors) are retried with exponential backoff. It protects
+// the embedding endpoint's rate limit from knowledge base backfills and from many
+// diagnoses finishing together.
+type LimitingEmbeddingProvider struct {
+	inner agent.EmbeddingProvider
+	// slots holds one token per call in flight; nil means no limit.
+	slots     chan struct{}
+	baseDelay time.Duration
+}
+
+// NewLimitingEmbeddingProvider wraps inner, allowing at most maxConcurrent Embed
+// calls at once. maxConcurrent <= 0 only adds retries.
+func NewLimitingEmbeddingProvider(inner agent.EmbeddingProvider, maxConcurrent int) *LimitingEmbeddingProvider {
+	p := &LimitingEmbeddingProvider{inner: inner, baseDelay: embedBaseDelay}
+	if maxConcurrent > 0 {
+		p.slots = make(chan struct{}, maxConcurrent)
+	}
+	return p
+}
+
+// Embed waits for a free slot and calls the wrapped provider, retrying retryable
+// errors. The slot is released while waiting to retry.
+func (p *LimitingEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	var err error
+	for attempt := 0; attempt < embedMaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := min(p.baseDelay<<(attempt-1), embedMaxDelay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		}
+
+		var embedding []float32
+		embedding, err = p.embedOnce(ctx, text)
+		if err == nil {
+			return embedding, nil
+		}
+		if !isRetryableError(err) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// embedOnce makes one call to the wrapped provider within a slot.
+func (p *LimitingEmbeddingProvider) embedOnce(ctx context.Context, text string) ([]float32, error) {
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+			defer func() { <-p.slots }()
+		case <-ctx.Done():
+			return nil, fmt.Errorf("embedding: waiting for a free slot: %w", ctx.Err())
+		}
+	}
+	return p.inner.Embed(ctx, text)
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"kubeminds/internal/agent"
 )
@@ -106,3 +108,72 @@ func TestMockEmbeddingProvider(t *testing.T) {
 		t.Errorf("expected 4 dims, got %d", len(vec))
 	}
 }
+
+// concurrencyEmbedder records the highest number of Embed calls in flight at once.
+type concurrencyEmbedder struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	// failures makes the first calls fail with a retryable error.
+	failures int
+	calls    int
+}
+
+func (e *concurrencyEmbedder) Embed(_ context.Context, _ string) ([]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	if e.calls <= e.failures {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("429 Too Many Requests")
+	}
+	e.inFlight++
+	e.peak = max(e.peak, e.inFlight)
+	e.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	e.mu.Lock()
+	e.inFlight--
+	e.mu.Unlock()
+	return []float32{0.1}, nil
+}
+
+func TestLimitingEmbeddingProvider_BoundsConcurrency(t *testing.T) {
+	inner := &concurrencyEmbedder{}
+	embedder := NewLimitingEmbeddingProvider(inner, 2)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := embedder.Embed(context.Background(), "container OOM killed"); err != nil {
+				t.Errorf("Embed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inner.peak != 2 {
+		t.Errorf("peak concurrent Embed calls = %d, want 2", inner.peak)
+	}
+}
+
+func TestLimitingEmbeddingProvider_RetriesTransientErrors(t *testing.T) {
+	inner := &concurrencyEmbedder{failures: 2}
+	embedder := NewLimitingEmbeddingProvider(inner, 1)
+	embedder.baseDelay = time.Millisecond
+
+	if _, err := embedder.Embed(context.Background(), "container OOM killed"); err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("calls = %d, want 3 (two retries)", inner.calls)
+	}
+
+	// A client error is not retried.
+	client := &mockEmbeddingProviderLLM{err: fmt.Errorf("401 Unauthorized")}
+	if _, err := NewLimitingEmbeddingProvider(client, 1).Embed(context.Background(), "x"); err == nil {
+		t.Error("Embed error = nil, want the client error")
+	}
+}
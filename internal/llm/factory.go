@@ -16,9 +16,10 @@ import (
 
 // NewRouterFromConfig builds a Router from the LLM configuration block.
 // It creates a concrete provider for each entry in cfg.Providers and wraps them
-// in a Router that selects the one named by cfg.DefaultProvider.
+// in a Router that selects the one named by cfg.DefaultProvider, falling back to
+// cfg.Fallbacks in order.
 //
-// Supported provider names: "openai", "gemini", "anthropic".
+// Supported provider names: "openai", "gemini", "anthropic", "ollama".
 // Unknown names return an error so misconfiguration is caught at startup.
 func NewRouterFromConfig(cfg config.LLMConfig) (*Router, error) {
 	if cfg.DefaultProvider == "" {
@@ -38,28 +39,114 @@ func NewRouterFromConfig(cfg config.LLMConfig) (*Router, error) {
 		providers[name] = p
 	}
 
-	return NewRouter(providers, cfg.DefaultProvider)
+	router, err := NewRouter(providers, cfg.DefaultProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string][]string, len(cfg.Providers))
+	for name, pcfg := range cfg.Providers {
+		allowed[name] = pcfg.AllowedModels
+	}
+	router.WithModelOverrides(allowed, func(name, model string) (agent.LLMProvider, error) {
+		// pcfg is a copy, so the override never changes the configured model.
+		pcfg := cfg.Providers[name]
+		pcfg.Model = model
+		return buildProvider(name, pcfg)
+	})
+	return router.WithFallbacks(cfg.Fallbacks...)
 }
 
 // buildProvider instantiates a single provider from its ProviderConfig.
 func buildProvider(name string, cfg config.ProviderConfig) (agent.LLMProvider, error) {
+	tlsCfg, err := cfg.TLS.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("llm factory: provider %q: %w", name, err)
+	}
+
 	switch name {
 	case "openai":
 		// OpenAIProvider handles OpenAI-compatible endpoints.
 		// If baseUrl is empty, the library default (https://api.openai.com/v1) is used.
-		return NewOpenAIProvider(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
+		return NewOpenAIProvider(cfg.APIKey, cfg.Model, cfg.BaseURL).
+			WithGenerationParams(cfg.Temperature, cfg.MaxTokens).
+			WithTLSConfig(tlsCfg), nil
 
 	case "gemini":
-		// GeminiProvider wraps OpenAIProvider with Google's compat endpoint.
-		// If baseUrl is set in config, it overrides the built-in default.
-		return NewGeminiProvider(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
+		// GeminiProvider calls the native generateContent API, which needs the model
+		// in the request path. If baseUrl is set in config, it overrides the default.
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("gemini provider requires llm.providers.gemini.model to be set")
+		}
+		return NewGeminiProvider(cfg.APIKey, cfg.Model, cfg.BaseURL).
+			WithGenerationParams(cfg.Temperature, cfg.MaxTokens).
+			WithTLSConfig(tlsCfg), nil
 
 	case "anthropic":
 		// AnthropicProvider uses the native Anthropic SDK.
 		// If baseUrl is set in config, it overrides https://api.anthropic.com.
-		return NewAnthropicProvider(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
+		// max_tokens is mandatory there, so an unset maxTokens falls back to 4096.
+		return NewAnthropicProvider(cfg.APIKey, cfg.Model, cfg.BaseURL).
+			WithGenerationParams(cfg.Temperature, cfg.MaxTokens).
+			WithTLSConfig(tlsCfg), nil
+
+	case "ollama":
+		// OllamaProvider calls a local Ollama server's /api/chat; apiKey is unused.
+		// If baseUrl is empty, http://localhost:11434 is used.
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("ollama provider requires llm.providers.ollama.model to be set")
+		}
+		return NewOllamaProvider(cfg.Model, cfg.BaseURL).
+			WithGenerationParams(cfg.Temperature, cfg.MaxTokens).
+			WithTLSConfig(tlsCfg), nil
 
 	default:
-		return nil, fmt.Errorf("unknown provider name %q; supported: openai, gemini, anthropic", name)
+		return nil, fmt.Errorf("unknown provider name %q; supported: openai, gemini, anthropic, ollama", name)
+	}
+}
+
+// NewEmbedderFromConfig builds the L3 embedding provider selected by
+// pgCfg.EmbeddingProvider. Connection settings are reused from the matching entry in
+// llmCfg.Providers: "openai" (the default) takes its apiKey and baseUrl, "ollama" its baseUrl.
+func NewEmbedderFromConfig(llmCfg config.LLMConfig, pgCfg config.PostgreSQLConfig) (agent.EmbeddingProvider, error) {
+	switch pgCfg.EmbeddingProvider {
+	case "", "openai":
+		openaiCfg := llmCfg.Providers["openai"]
+		tlsCfg, err := openaiCfg.TLS.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("llm factory: embedding provider openai: %w", err)
+		}
+		return NewOpenAIEmbedder(openaiCfg.APIKey, openaiCfg.BaseURL).WithTLSConfig(tlsCfg), nil
+	case "ollama":
+		ollamaCfg := llmCfg.Providers["ollama"]
+		tlsCfg, err := ollamaCfg.TLS.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("llm factory: embedding provider ollama: %w", err)
+		}
+		return NewOllamaEmbedder(pgCfg.EmbeddingModel, ollamaCfg.BaseURL).WithTLSConfig(tlsCfg), nil
+	default:
+		return nil, fmt.Errorf("llm factory: unknown embedding provider %q; supported: openai, ollama", pgCfg.EmbeddingProvider)
+	}
+}
+
+// NewModelRouterFromConfig builds a single-provider Router for the named provider in
+// cfg.Providers, overriding its model when model is non-empty. It is used for
+// secondary agents such as triage that run on a smaller model than the default.
+// An empty providerName selects cfg.DefaultProvider.
+func NewModelRouterFromConfig(cfg config.LLMConfig, providerName, model string) (*Router, error) {
+	if providerName == "" {
+		providerName = cfg.DefaultProvider
+	}
+	pcfg, ok := cfg.Providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("llm factory: provider %q is not configured under llm.providers", providerName)
+	}
+	if model != "" {
+		pcfg.Model = model
+	}
+	p, err := buildProvider(providerName, pcfg)
+	if err != nil {
+		return nil, fmt.Errorf("llm factory: failed to build provider %q: %w", providerName, err)
 	}
+	return NewRouter(map[string]agent.LLMProvider{providerName: p}, providerName)
 }
@@ -1,23 +1,366 @@
 package llm
 
-// GeminiProvider wraps OpenAIProvider using Google's official OpenAI-compatible endpoint.
+// GeminiProvider implements agent.LLMProvider against Google's native Gemini API
+// (models/{model}:generateContent).
 //
-// Google Gemini exposes an OpenAI-compatible API at a well-known URL, so all
-// message formatting, tool-call handling, and retry logic from OpenAIProvider are
-// reused without modification. Only the base URL differs.
+// Gemini's content format differs from our internal OpenAI-style messages:
+//   - The system prompt is a top-level systemInstruction, not a message.
+//   - Assistant turns use the "model" role; tool calls are functionCall parts.
+//   - Tool results are functionResponse parts in a user turn, keyed by function
+//     name rather than by call ID, so the name is recovered from the preceding call.
 //
-// Reference: https://ai.google.dev/gemini-api/docs/openai
-const geminiCompatBaseURL = "https://generativelanguage.googleapis.com/v1beta/openai/"
+// Reference: https://ai.google.dev/api/generate-content
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+// geminiDefaultBaseURL is the Gemini API root used when no baseURL is configured.
+const geminiDefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiProvider implements agent.LLMProvider using the Gemini REST API.
+type GeminiProvider struct {
+	httpClient *http.Client
+	apiKey     string
+	model      string
+	baseURL    string
+
+	// temperature and maxTokens are sent in generationConfig when non-nil.
+	temperature *float32
+	maxTokens   *int
+}
 
 // NewGeminiProvider creates a Gemini LLM provider.
 //
 // apiKey is your Google AI Studio API key (https://aistudio.google.com/app/apikey).
 // model is the Gemini model name (e.g. "gemini-2.0-flash", "gemini-1.5-pro").
-// baseURL overrides the default Gemini OpenAI-compat endpoint; leave empty to use
-// the default (geminiCompatBaseURL above).
-func NewGeminiProvider(apiKey, model, baseURL string) *OpenAIProvider {
+// baseURL overrides the default API root (geminiDefaultBaseURL); leave empty to use it.
+func NewGeminiProvider(apiKey, model, baseURL string) *GeminiProvider {
 	if baseURL == "" {
-		baseURL = geminiCompatBaseURL
+		baseURL = geminiDefaultBaseURL
+	}
+	return &GeminiProvider{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		apiKey:     apiKey,
+		model:      model,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// WithTLSConfig makes the provider's HTTPS client use tlsCfg, e.g. to trust a
+// private CA. A nil tlsCfg keeps the default.
+func (p *GeminiProvider) WithTLSConfig(tlsCfg *tls.Config) *GeminiProvider {
+	if tlsCfg != nil {
+		p.httpClient.Transport = tlsTransport(tlsCfg)
+	}
+	return p
+}
+
+// WithGenerationParams sets the sampling temperature and maxOutputTokens sent with
+// each request. Nil values leave the API defaults in place.
+func (p *GeminiProvider) WithGenerationParams(temperature *float32, maxTokens *int) *GeminiProvider {
+	p.temperature = temperature
+	p.maxTokens = maxTokens
+	return p
+}
+
+// --- Gemini wire format ---
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent         `json:"contents"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      *float32 `json:"temperature,omitempty"`
+	MaxOutputTokens  *int     `json:"maxOutputTokens,omitempty"`
+	ResponseMIMEType string   `json:"responseMimeType,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata,omitempty"`
+}
+
+// Chat sends messages to Gemini and returns the response.
+// It converts our internal format to generateContent contents, calls the API with
+// exponential-backoff retry, and converts the first candidate back.
+func (p *GeminiProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	req, err := buildGeminiRequest(messages, tools)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	if p.temperature != nil || p.maxTokens != nil {
+		req.GenerationConfig = &geminiGenerationConfig{Temperature: p.temperature, MaxOutputTokens: p.maxTokens}
+	}
+	applyGeminiResponseFormat(ctx, req)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: failed to marshal request: %w", err)
+	}
+
+	// Exponential backoff retry: max 3 attempts, 1s-10s intervals
+	var resp *geminiResponse
+	maxRetries := 3
+	baseDelay := time.Second
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		resp, err = p.generateContent(ctx, body)
+		if err == nil {
+			break
+		}
+
+		if attempt < maxRetries-1 && isRetryableError(err) {
+			delay := time.Duration(math.Min(float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))), 10000)) * time.Millisecond
+			select {
+			case <-time.After(delay):
+				// Continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		} else {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("gemini api error: %w", classifyTransientError(ctx, err, isRetryableError))
+	}
+	if len(resp.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates returned from gemini")
+	}
+
+	msg := convertGeminiContent(resp.Candidates[0].Content)
+	if u := resp.UsageMetadata; u != nil {
+		msg.Usage = &agent.TokenUsage{
+			PromptTokens:     u.PromptTokenCount,
+			CompletionTokens: u.CandidatesTokenCount,
+			TotalTokens:      u.TotalTokenCount,
+		}
+	}
+	return msg, nil
+}
+
+// applyGeminiResponseFormat enables JSON mode (responseMimeType application/json)
+// on req when ctx asks for a JSON response (see agent.WithResponseFormat). Gemini
+// rejects JSON mode combined with function calling, so the tools are dropped on a
+// run's final step (see agent.WithFinalStep), which has to conclude anyway; earlier
+// steps keep them and rely on the prompt's format instructions.
+func applyGeminiResponseFormat(ctx context.Context, req *geminiRequest) {
+	if agent.ResponseFormatFromContext(ctx) != agent.ResponseFormatJSON {
+		return
+	}
+	if agent.IsFinalStep(ctx) {
+		req.Tools = nil
+	}
+	if len(req.Tools) > 0 {
+		return
+	}
+	if req.GenerationConfig == nil {
+		req.GenerationConfig = &geminiGenerationConfig{}
+	}
+	req.GenerationConfig.ResponseMIMEType = "application/json"
+}
+
+// generateContent performs a single generateContent call.
+func (p *GeminiProvider) generateContent(ctx context.Context, body []byte) (*geminiResponse, error) {
+	url := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, p.model)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode/100 != 2 {
+		// The status code is kept in the message so isRetryableError can classify it.
+		return nil, fmt.Errorf("status %d: %s", httpResp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var resp geminiResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// buildGeminiRequest converts internal messages and tools to a generateContent request.
+func buildGeminiRequest(messages []agent.Message, tools []agent.Tool) (*geminiRequest, error) {
+	req := &geminiRequest{}
+
+	// Gemini keys function responses by name, so remember each call's function name.
+	callNames := make(map[string]string)
+
+	for _, msg := range messages {
+		switch msg.Type {
+		case agent.MessageTypeSystem:
+			if req.SystemInstruction == nil {
+				req.SystemInstruction = &geminiContent{}
+			}
+			req.SystemInstruction.Parts = append(req.SystemInstruction.Parts, geminiPart{Text: msg.Content})
+
+		case agent.MessageTypeUser:
+			req.Contents = append(req.Contents, geminiContent{
+				Role:  "user",
+				Parts: []geminiPart{{Text: msg.Content}},
+			})
+
+		case agent.MessageTypeAssistant:
+			content := geminiContent{Role: "model"}
+			if msg.Content != "" {
+				content.Parts = append(content.Parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage(`{}`)
+				}
+				content.Parts = append(content.Parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					ID:   tc.ID,
+					Name: tc.Function.Name,
+					Args: args,
+				}})
+			}
+			if len(content.Parts) == 0 {
+				content.Parts = []geminiPart{{Text: ""}}
+			}
+			req.Contents = append(req.Contents, content)
+
+		case agent.MessageTypeTool:
+			part := geminiPart{FunctionResponse: &geminiFunctionResponse{
+				ID:       msg.ToolCallID,
+				Name:     callNames[msg.ToolCallID],
+				Response: map[string]any{"content": msg.Content},
+			}}
+			// Responses to parallel calls belong in a single user turn.
+			if n := len(req.Contents); n > 0 && isFunctionResponseTurn(req.Contents[n-1]) {
+				req.Contents[n-1].Parts = append(req.Contents[n-1].Parts, part)
+			} else {
+				req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+			}
+		}
+	}
+
+	if len(tools) > 0 {
+		decls := make([]geminiFunctionDeclaration, len(tools))
+		for i, tool := range tools {
+			schema := json.RawMessage(tool.Schema())
+			if !json.Valid(schema) {
+				return nil, fmt.Errorf("invalid tool schema for %s", tool.Name())
+			}
+			decls[i] = geminiFunctionDeclaration{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  schema,
+			}
+		}
+		req.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+
+	return req, nil
+}
+
+// isFunctionResponseTurn reports whether c is a user turn holding only function responses.
+func isFunctionResponseTurn(c geminiContent) bool {
+	if c.Role != "user" || len(c.Parts) == 0 {
+		return false
+	}
+	for _, part := range c.Parts {
+		if part.FunctionResponse == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// convertGeminiContent converts a response candidate to an internal assistant message.
+// Gemini may omit call IDs, so positional IDs are generated for those calls.
+func convertGeminiContent(content geminiContent) *agent.Message {
+	result := &agent.Message{Type: agent.MessageTypeAssistant}
+	var texts []string
+	for _, part := range content.Parts {
+		if part.FunctionCall != nil {
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", len(result.ToolCalls))
+			}
+			args := string(part.FunctionCall.Args)
+			if args == "" {
+				args = "{}"
+			}
+			result.ToolCalls = append(result.ToolCalls, agent.ToolCall{
+				ID:       id,
+				Function: agent.FunctionCall{Name: part.FunctionCall.Name, Arguments: args},
+			})
+			continue
+		}
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
 	}
-	return NewOpenAIProvider(apiKey, model, baseURL)
+	result.Content = strings.Join(texts, "")
+	return result
 }
@@ -0,0 +1,262 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"kubeminds/internal/agent"
+	"kubeminds/internal/config"
+)
+
+// TestGeminiProvider_ToolCallRoundTrip verifies that a functionCall response becomes an
+// agent.ToolCall, and that feeding its result back produces a matching functionResponse.
+func TestGeminiProvider_ToolCallRoundTrip(t *testing.T) {
+	var requests []geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" {
+			t.Errorf("path = %s, want /models/gemini-test:generateContent", r.URL.Path)
+		}
+		if got := r.Header.Get("x-goog-api-key"); got != "test-key" {
+			t.Errorf("x-goog-api-key = %q, want test-key", got)
+		}
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+				{"functionCall":{"name":"get_pod_logs","args":{"namespace":"default","podName":"app-1"}}}
+			]}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+			{"text":"Root Cause: OOM\n"},{"text":"Suggestion: raise limit"}
+		]}}],"usageMetadata":{"promptTokenCount":40,"candidatesTokenCount":8,"totalTokenCount":48}}`))
+	}))
+	defer srv.Close()
+
+	p := NewGeminiProvider("test-key", "gemini-test", srv.URL+"/")
+	tools := []agent.Tool{&fakeToolForAnthropicTest{
+		name:        "get_pod_logs",
+		description: "Retrieve logs from a pod",
+		schema:      `{"type":"object","properties":{"namespace":{"type":"string"},"podName":{"type":"string"}}}`,
+	}}
+	messages := []agent.Message{
+		{Type: agent.MessageTypeSystem, Content: "You are a Kubernetes expert."},
+		{Type: agent.MessageTypeUser, Content: "Diagnose app-1"},
+	}
+
+	first, err := p.Chat(context.Background(), messages, tools)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(first.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %d, want 1", len(first.ToolCalls))
+	}
+	tc := first.ToolCalls[0]
+	if tc.Function.Name != "get_pod_logs" || tc.ID == "" {
+		t.Errorf("ToolCall = %+v, want get_pod_logs with an ID", tc)
+	}
+	var args map[string]string
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil || args["podName"] != "app-1" {
+		t.Errorf("Arguments = %q, want podName app-1", tc.Function.Arguments)
+	}
+
+	req := requests[0]
+	if req.SystemInstruction == nil || req.SystemInstruction.Parts[0].Text != "You are a Kubernetes expert." {
+		t.Errorf("SystemInstruction = %+v, want the system prompt", req.SystemInstruction)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].FunctionDeclarations[0].Name != "get_pod_logs" {
+		t.Errorf("Tools = %+v, want get_pod_logs declaration", req.Tools)
+	}
+
+	messages = append(messages, *first, agent.Message{
+		Type:       agent.MessageTypeTool,
+		ToolCallID: tc.ID,
+		Content:    "killed: out of memory",
+	})
+	second, err := p.Chat(context.Background(), messages, tools)
+	if err != nil {
+		t.Fatalf("Chat() second call error = %v", err)
+	}
+	if second.Content != "Root Cause: OOM\nSuggestion: raise limit" || len(second.ToolCalls) != 0 {
+		t.Errorf("second response = %+v, want joined text without tool calls", second)
+	}
+	if second.Usage == nil || second.Usage.TotalTokens != 48 || second.Usage.PromptTokens != 40 {
+		t.Errorf("Usage = %+v, want 40 prompt / 48 total tokens", second.Usage)
+	}
+
+	contents := requests[1].Contents
+	if len(contents) != 3 {
+		t.Fatalf("contents = %d, want user, model, function response", len(contents))
+	}
+	call := contents[1]
+	if call.Role != "model" || call.Parts[0].FunctionCall == nil || call.Parts[0].FunctionCall.Name != "get_pod_logs" {
+		t.Errorf("contents[1] = %+v, want model functionCall", call)
+	}
+	resp := contents[2]
+	if resp.Role != "user" || resp.Parts[0].FunctionResponse == nil {
+		t.Fatalf("contents[2] = %+v, want user functionResponse", resp)
+	}
+	fr := resp.Parts[0].FunctionResponse
+	if fr.Name != "get_pod_logs" || fr.Response["content"] != "killed: out of memory" {
+		t.Errorf("functionResponse = %+v, want get_pod_logs with tool output", fr)
+	}
+}
+
+func TestGeminiProvider_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, `{"error":{"code":503,"status":"UNAVAILABLE"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	p := NewGeminiProvider("k", "gemini-test", srv.URL)
+	msg, err := p.Chat(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if msg.Content != "ok" || calls.Load() != 2 {
+		t.Errorf("Content = %q after %d calls, want ok after 2", msg.Content, calls.Load())
+	}
+}
+
+func TestGeminiProvider_ClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error":{"code":400,"status":"INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	p := NewGeminiProvider("k", "gemini-test", srv.URL)
+	_, err := p.Chat(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("Chat() error = %v, want status 400", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1 (4xx is not retried)", calls.Load())
+	}
+}
+
+func TestGeminiProvider_GenerationConfig(t *testing.T) {
+	var req geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	maxTokens := 1024
+	p := NewGeminiProvider("k", "gemini-test", srv.URL).WithGenerationParams(new(float32), &maxTokens)
+	if _, err := p.Chat(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	gc := req.GenerationConfig
+	if gc == nil || gc.Temperature == nil || *gc.Temperature != 0 || gc.MaxOutputTokens == nil || *gc.MaxOutputTokens != 1024 {
+		t.Errorf("generationConfig = %+v, want temperature 0 and maxOutputTokens 1024", gc)
+	}
+}
+
+func TestGeminiProvider_ResponseFormat(t *testing.T) {
+	var mimeTypes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mimeType := ""
+		if req.GenerationConfig != nil {
+			mimeType = req.GenerationConfig.ResponseMIMEType
+		}
+		mimeTypes = append(mimeTypes, mimeType)
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"{\"root_cause\":\"oom\"}"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	p := NewGeminiProvider("k", "gemini-test", srv.URL)
+	msgs := []agent.Message{{Type: agent.MessageTypeUser, Content: "reply in JSON"}}
+	jsonCtx := agent.WithResponseFormat(context.Background(), agent.ResponseFormatJSON)
+	tools := []agent.Tool{&fakeToolForAnthropicTest{name: "get_pod_logs", schema: `{"type":"object"}`}}
+	if _, err := p.Chat(context.Background(), msgs, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if _, err := p.Chat(jsonCtx, msgs, nil); err != nil {
+		t.Fatalf("Chat() with JSON format error = %v", err)
+	}
+	if _, err := p.Chat(jsonCtx, msgs, tools); err != nil {
+		t.Fatalf("Chat() with JSON format and tools error = %v", err)
+	}
+
+	if want := []string{"", "application/json", ""}; !slices.Equal(mimeTypes, want) {
+		t.Errorf("responseMimeType per request = %q, want %q (not combined with tools)", mimeTypes, want)
+	}
+}
+
+func TestGeminiProvider_ResponseFormat_AgentFinalStep(t *testing.T) {
+	type sent struct {
+		tools    bool
+		mimeType string
+	}
+	var requests []sent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		s := sent{tools: len(req.Tools) > 0}
+		if req.GenerationConfig != nil {
+			s.mimeType = req.GenerationConfig.ResponseMIMEType
+		}
+		requests = append(requests, s)
+		if len(requests) == 1 {
+			_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_pod_logs","args":{"podName":"app-1"}}}]}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"{\"root_cause\":\"oom\",\"suggestion\":\"raise the memory limit\",\"confidence\":0.9}"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	p := NewGeminiProvider("k", "gemini-test", srv.URL)
+	tools := []agent.Tool{&fakeToolForAnthropicTest{name: "get_pod_logs", schema: `{"type":"object"}`}}
+	skill := agent.Skill{Name: "oom_diagnosis", OutputFormat: agent.SkillOutputFormatJSON}
+	a := agent.NewAgent(p, tools, 2, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, skill)
+	result, err := a.Run(context.Background(), "diagnose app-1", false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.RootCause != "oom" {
+		t.Errorf("RootCause = %q, want %q", result.RootCause, "oom")
+	}
+
+	// Only the final step trades the tools for JSON mode.
+	if want := []sent{{tools: true}, {mimeType: "application/json"}}; !slices.Equal(requests, want) {
+		t.Errorf("requests = %+v, want %+v", requests, want)
+	}
+}
+
+func TestNewRouterFromConfig_GeminiRequiresModel(t *testing.T) {
+	_, err := NewRouterFromConfig(config.LLMConfig{
+		DefaultProvider: "gemini",
+		Providers:       map[string]config.ProviderConfig{"gemini": {APIKey: "k"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "model") {
+		t.Fatalf("NewRouterFromConfig() error = %v, want missing model error", err)
+	}
+}
@@ -0,0 +1,16 @@
+package llm
+
+import "kubeminds/internal/metrics"
+
+// LLM request results used as the "result" label value.
+const (
+	llmResultSuccess = "success"
+	llmResultError   = "error"
+)
+
+// LLMRequestDuration measures Chat calls routed through Router, by provider and result.
+var LLMRequestDuration = metrics.NewLatencyHistogramVec(
+	"kubeminds_llm_request_duration_seconds",
+	"Duration of LLM chat requests, by provider and result.",
+	[]string{"provider", "result"},
+)
@@ -2,12 +2,15 @@ package llm
 
 import (
 	"context"
+	"sync"
 
 	"kubeminds/internal/agent"
 )
 
-// MockProvider implements LLMProvider for testing without real API calls
+// MockProvider implements LLMProvider for testing without real API calls.
+// It is safe for concurrent use, e.g. by several agents in demo mode.
 type MockProvider struct {
+	mu        sync.Mutex
 	responses map[string]string
 	callCount int
 }
@@ -29,6 +32,8 @@ func NewMockProvider() *MockProvider {
 // Chat returns a mock response based on the agent's goal
 // In a real scenario, you would analyze the messages and tools to determine response
 func (m *MockProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.callCount++
 
 	// Infer response type from message content (simple heuristic for testing)
@@ -55,11 +60,15 @@ func (m *MockProvider) Chat(ctx context.Context, messages []agent.Message, tools
 
 // SetResponse allows tests to customize responses
 func (m *MockProvider) SetResponse(key string, response string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.responses[key] = response
 }
 
 // GetCallCount returns how many times Chat was called
 func (m *MockProvider) GetCallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.callCount
 }
 
@@ -0,0 +1,284 @@
+package llm
+
+// OllamaProvider implements agent.LLMProvider against a local Ollama server's
+// /api/chat endpoint, for clusters that cannot reach a hosted LLM.
+//
+// Ollama's chat format is close to OpenAI's, with two differences:
+//   - Tool call arguments are JSON objects rather than JSON-encoded strings.
+//   - Tool calls carry no IDs; tool results are matched by tool_name instead, so
+//     positional IDs are generated and the name is recovered from the preceding call.
+//
+// Reference: https://github.com/ollama/ollama/blob/main/docs/api.md#generate-a-chat-completion
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+// ollamaDefaultBaseURL is the Ollama server used when no baseURL is configured.
+const ollamaDefaultBaseURL = "http://localhost:11434"
+
+// OllamaProvider implements agent.LLMProvider using the Ollama REST API.
+type OllamaProvider struct {
+	httpClient *http.Client
+	model      string
+	baseURL    string
+
+	// temperature and maxTokens are sent in options when non-nil.
+	temperature *float32
+	maxTokens   *int
+}
+
+// NewOllamaProvider creates an Ollama LLM provider.
+//
+// model is a model pulled on the server (e.g. "llama3.1", "qwen2.5:14b"); it must
+// support tool calling. baseURL overrides ollamaDefaultBaseURL; leave empty to use it.
+func NewOllamaProvider(model, baseURL string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = ollamaDefaultBaseURL
+	}
+	return &OllamaProvider{
+		// Local models can be slow on CPU-only nodes, so allow more time than hosted APIs.
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		model:      model,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// WithTLSConfig makes the provider's HTTP client use tlsCfg for an HTTPS server,
+// e.g. one behind a private CA. A nil tlsCfg keeps the default.
+func (p *OllamaProvider) WithTLSConfig(tlsCfg *tls.Config) *OllamaProvider {
+	if tlsCfg != nil {
+		p.httpClient.Transport = tlsTransport(tlsCfg)
+	}
+	return p
+}
+
+// WithGenerationParams sets the sampling temperature and num_predict sent with each
+// request. Nil values leave the model defaults in place.
+func (p *OllamaProvider) WithGenerationParams(temperature *float32, maxTokens *int) *OllamaProvider {
+	p.temperature = temperature
+	p.maxTokens = maxTokens
+	return p
+}
+
+// --- Ollama wire format ---
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function ollamaFunctionCall `json:"function"`
+}
+
+type ollamaFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+type ollamaToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// Chat sends messages to Ollama and returns the response.
+// It converts our internal format to /api/chat messages, calls the server with
+// exponential-backoff retry, and converts the reply back.
+func (p *OllamaProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	req, err := buildOllamaRequest(p.model, messages, tools)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	if p.temperature != nil || p.maxTokens != nil {
+		req.Options = &ollamaOptions{Temperature: p.temperature, NumPredict: p.maxTokens}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to marshal request: %w", err)
+	}
+
+	// Exponential backoff retry: max 3 attempts, 1s-10s intervals
+	var resp *ollamaChatResponse
+	maxRetries := 3
+	baseDelay := time.Second
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		resp, err = p.chat(ctx, body)
+		if err == nil {
+			break
+		}
+
+		if attempt < maxRetries-1 && isRetryableError(err) {
+			delay := time.Duration(math.Min(float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))), 10000)) * time.Millisecond
+			select {
+			case <-time.After(delay):
+				// Continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		} else {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("ollama api error: %w", classifyTransientError(ctx, err, isRetryableError))
+	}
+
+	msg := convertOllamaMessage(resp.Message)
+	msg.Usage = &agent.TokenUsage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+	return msg, nil
+}
+
+// chat performs a single /api/chat call.
+func (p *OllamaProvider) chat(ctx context.Context, body []byte) (*ollamaChatResponse, error) {
+	var resp ollamaChatResponse
+	if err := postOllama(ctx, p.httpClient, p.baseURL+"/api/chat", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// postOllama POSTs a JSON body to an Ollama endpoint and decodes the JSON reply into out.
+func postOllama(ctx context.Context, client *http.Client, url string, body []byte, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode/100 != 2 {
+		// The status code is kept in the message so isRetryableError can classify it.
+		return fmt.Errorf("status %d: %s", httpResp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// buildOllamaRequest converts internal messages and tools to a non-streaming chat request.
+func buildOllamaRequest(model string, messages []agent.Message, tools []agent.Tool) (*ollamaChatRequest, error) {
+	req := &ollamaChatRequest{Model: model}
+
+	// Ollama matches tool results by name, so remember each call's function name.
+	callNames := make(map[string]string)
+
+	for _, msg := range messages {
+		switch msg.Type {
+		case agent.MessageTypeSystem:
+			req.Messages = append(req.Messages, ollamaMessage{Role: "system", Content: msg.Content})
+
+		case agent.MessageTypeUser:
+			req.Messages = append(req.Messages, ollamaMessage{Role: "user", Content: msg.Content})
+
+		case agent.MessageTypeAssistant:
+			out := ollamaMessage{Role: "assistant", Content: msg.Content}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage(`{}`)
+				}
+				out.ToolCalls = append(out.ToolCalls, ollamaToolCall{
+					Function: ollamaFunctionCall{Name: tc.Function.Name, Arguments: args},
+				})
+			}
+			req.Messages = append(req.Messages, out)
+
+		case agent.MessageTypeTool:
+			req.Messages = append(req.Messages, ollamaMessage{
+				Role:     "tool",
+				Content:  msg.Content,
+				ToolName: callNames[msg.ToolCallID],
+			})
+		}
+	}
+
+	for _, tool := range tools {
+		schema := json.RawMessage(tool.Schema())
+		if !json.Valid(schema) {
+			return nil, fmt.Errorf("invalid tool schema for %s", tool.Name())
+		}
+		req.Tools = append(req.Tools, ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  schema,
+			},
+		})
+	}
+
+	return req, nil
+}
+
+// convertOllamaMessage converts a reply to an internal assistant message, assigning
+// positional IDs to its tool calls.
+func convertOllamaMessage(msg ollamaMessage) *agent.Message {
+	result := &agent.Message{Type: agent.MessageTypeAssistant, Content: msg.Content}
+	for i, tc := range msg.ToolCalls {
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		result.ToolCalls = append(result.ToolCalls, agent.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Function: agent.FunctionCall{Name: tc.Function.Name, Arguments: args},
+		})
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"kubeminds/internal/agent"
+	"kubeminds/internal/config"
+)
+
+func TestOllamaProvider_ToolCallRoundTrip(t *testing.T) {
+	var requests []ollamaChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			_, _ = w.Write([]byte(`{"model":"llama3.1","message":{"role":"assistant","content":"",
+				"tool_calls":[{"function":{"name":"get_pod_logs","arguments":{"namespace":"default","podName":"app-1"}}}]},
+				"done":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.1","message":{"role":"assistant","content":"Root Cause: OOM\nSuggestion: raise limit"},
+			"done":true,"prompt_eval_count":30,"eval_count":6}`))
+	}))
+	defer srv.Close()
+
+	p := NewOllamaProvider("llama3.1", srv.URL+"/")
+	tools := []agent.Tool{&fakeToolForAnthropicTest{
+		name:        "get_pod_logs",
+		description: "Retrieve logs from a pod",
+		schema:      `{"type":"object","properties":{"namespace":{"type":"string"},"podName":{"type":"string"}}}`,
+	}}
+	messages := []agent.Message{
+		{Type: agent.MessageTypeSystem, Content: "You are a Kubernetes expert."},
+		{Type: agent.MessageTypeUser, Content: "Diagnose app-1"},
+	}
+
+	first, err := p.Chat(context.Background(), messages, tools)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(first.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %d, want 1", len(first.ToolCalls))
+	}
+	tc := first.ToolCalls[0]
+	if tc.Function.Name != "get_pod_logs" || tc.ID == "" {
+		t.Errorf("ToolCall = %+v, want get_pod_logs with an ID", tc)
+	}
+	var args map[string]string
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil || args["podName"] != "app-1" {
+		t.Errorf("Arguments = %q, want podName app-1", tc.Function.Arguments)
+	}
+
+	req := requests[0]
+	if req.Model != "llama3.1" || req.Stream {
+		t.Errorf("model/stream = %q/%v, want llama3.1 non-streaming", req.Model, req.Stream)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "get_pod_logs" {
+		t.Errorf("Tools = %+v, want get_pod_logs function", req.Tools)
+	}
+	if req.Messages[0].Role != "system" {
+		t.Errorf("Messages[0].Role = %q, want system", req.Messages[0].Role)
+	}
+
+	messages = append(messages, *first, agent.Message{
+		Type:       agent.MessageTypeTool,
+		ToolCallID: tc.ID,
+		Content:    "killed: out of memory",
+	})
+	second, err := p.Chat(context.Background(), messages, tools)
+	if err != nil {
+		t.Fatalf("Chat() second call error = %v", err)
+	}
+	if second.Content != "Root Cause: OOM\nSuggestion: raise limit" || len(second.ToolCalls) != 0 {
+		t.Errorf("second response = %+v, want final text without tool calls", second)
+	}
+	if second.Usage == nil || second.Usage.TotalTokens != 36 {
+		t.Errorf("Usage = %+v, want 36 total tokens", second.Usage)
+	}
+
+	msgs := requests[1].Messages
+	if len(msgs) != 4 {
+		t.Fatalf("messages = %d, want system, user, assistant, tool", len(msgs))
+	}
+	call := msgs[2]
+	if call.Role != "assistant" || len(call.ToolCalls) != 1 || !strings.Contains(string(call.ToolCalls[0].Function.Arguments), `"podName":"app-1"`) {
+		t.Errorf("messages[2] = %+v, want assistant tool call with object arguments", call)
+	}
+	if result := msgs[3]; result.Role != "tool" || result.ToolName != "get_pod_logs" || result.Content != "killed: out of memory" {
+		t.Errorf("messages[3] = %+v, want tool result for get_pod_logs", result)
+	}
+}
+
+func TestOllamaProvider_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, `{"error":"model is loading"}`, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer srv.Close()
+
+	msg, err := NewOllamaProvider("llama3.1", srv.URL).Chat(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if msg.Content != "ok" || calls.Load() != 2 {
+		t.Errorf("Content = %q after %d calls, want ok after 2", msg.Content, calls.Load())
+	}
+}
+
+func TestOllamaEmbedder_Embed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if r.URL.Path != "/api/embed" || req.Model != ollamaDefaultEmbedModel || req.Input != "container OOM killed" {
+			t.Errorf("request = %s %+v, want /api/embed with default model", r.URL.Path, req)
+		}
+		_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	vec, err := NewOllamaEmbedder("", srv.URL).Embed(context.Background(), "container OOM killed")
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vec) != 3 || vec[1] != 0.2 {
+		t.Errorf("vector = %v, want [0.1 0.2 0.3]", vec)
+	}
+}
+
+func TestNewRouterFromConfig_Ollama(t *testing.T) {
+	router, err := NewRouterFromConfig(config.LLMConfig{
+		DefaultProvider: "ollama",
+		Providers:       map[string]config.ProviderConfig{"ollama": {Model: "llama3.1"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRouterFromConfig() error = %v", err)
+	}
+	p, ok := router.providers["ollama"].(*OllamaProvider)
+	if !ok || p.baseURL != ollamaDefaultBaseURL {
+		t.Errorf("provider = %#v, want OllamaProvider on %s", router.providers["ollama"], ollamaDefaultBaseURL)
+	}
+
+	embedder, err := NewEmbedderFromConfig(config.LLMConfig{}, config.PostgreSQLConfig{EmbeddingProvider: "ollama"})
+	if err != nil {
+		t.Fatalf("NewEmbedderFromConfig() error = %v", err)
+	}
+	if _, ok := embedder.(*OllamaEmbedder); !ok {
+		t.Errorf("embedder = %T, want *OllamaEmbedder", embedder)
+	}
+}
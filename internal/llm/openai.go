@@ -2,9 +2,13 @@ package llm
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
 	"strings"
 	"time"
 
@@ -15,7 +19,12 @@ import (
 // OpenAIProvider implements the LLMProvider interface for OpenAI
 type OpenAIProvider struct {
 	client *openai.Client
+	config openai.ClientConfig
 	model  string
+
+	// temperature and maxTokens are sent with each request when non-nil.
+	temperature *float32
+	maxTokens   *int
 }
 
 // NewOpenAIProvider creates a new OpenAIProvider
@@ -27,12 +36,213 @@ func NewOpenAIProvider(apiKey string, model string, baseURL string) *OpenAIProvi
 
 	return &OpenAIProvider{
 		client: openai.NewClientWithConfig(config),
+		config: config,
 		model:  model,
 	}
 }
 
+// WithTLSConfig makes the provider's HTTPS client use tlsCfg, e.g. to trust a
+// private CA. A nil tlsCfg keeps the default.
+func (p *OpenAIProvider) WithTLSConfig(tlsCfg *tls.Config) *OpenAIProvider {
+	if tlsCfg != nil {
+		p.config.HTTPClient = &http.Client{Transport: tlsTransport(tlsCfg)}
+		p.client = openai.NewClientWithConfig(p.config)
+	}
+	return p
+}
+
+// WithGenerationParams sets the sampling temperature and response token cap sent
+// with each request. Nil values leave the API defaults in place.
+func (p *OpenAIProvider) WithGenerationParams(temperature *float32, maxTokens *int) *OpenAIProvider {
+	p.temperature = temperature
+	p.maxTokens = maxTokens
+	return p
+}
+
 // Chat sends a chat request to the LLM and returns the response
 func (p *OpenAIProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	req, err := p.buildRequest(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	applyResponseFormat(ctx, &req)
+
+	// Exponential backoff retry: max 3 attempts, 1s-10s intervals
+	var resp openai.ChatCompletionResponse
+	maxRetries := 3
+	baseDelay := time.Second
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		resp, err = p.client.CreateChatCompletion(ctx, req)
+		if err == nil {
+			break
+		}
+
+		// Check if error is retryable (network error or 5xx)
+		if attempt < maxRetries-1 && isRetryableError(err) {
+			delay := time.Duration(math.Min(float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))), 10000)) * time.Millisecond
+			select {
+			case <-time.After(delay):
+				// Continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		} else {
+			// Non-retryable error, break immediately
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("openai api error: %w", classifyOpenAIError(ctx, err))
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from openai")
+	}
+
+	choice := resp.Choices[0]
+	result := &agent.Message{
+		Type:    agent.MessageTypeAssistant,
+		Content: choice.Message.Content,
+		Usage: &agent.TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+
+	if len(choice.Message.ToolCalls) > 0 {
+		result.ToolCalls = make([]agent.ToolCall, len(choice.Message.ToolCalls))
+		for i, tc := range choice.Message.ToolCalls {
+			result.ToolCalls[i] = agent.ToolCall{
+				ID: tc.ID,
+				Function: agent.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ChatStream implements agent.StreamingLLMProvider. Content deltas are sent as they
+// arrive; tool call fragments are assembled by index into the final message.
+// Only opening the stream is retried, since a broken stream cannot be resumed.
+func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []agent.Message, tools []agent.Tool) (<-chan agent.StreamChunk, error) {
+	req, err := p.buildRequest(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	applyResponseFormat(ctx, &req)
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	// Exponential backoff retry: max 3 attempts, 1s-10s intervals
+	var stream *openai.ChatCompletionStream
+	maxRetries := 3
+	baseDelay := time.Second
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		stream, err = p.client.CreateChatCompletionStream(ctx, req)
+		if err == nil {
+			break
+		}
+
+		if attempt < maxRetries-1 && isRetryableError(err) {
+			delay := time.Duration(math.Min(float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))), 10000)) * time.Millisecond
+			select {
+			case <-time.After(delay):
+				// Continue to next attempt
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		} else {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("openai api error: %w", classifyOpenAIError(ctx, err))
+	}
+
+	chunks := make(chan agent.StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		result := &agent.Message{Type: agent.MessageTypeAssistant}
+		var content strings.Builder
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				sendChunk(ctx, chunks, agent.StreamChunk{Err: fmt.Errorf("openai stream error: %w", err)})
+				return
+			}
+			if resp.Usage != nil {
+				result.Usage = &agent.TokenUsage{
+					PromptTokens:     resp.Usage.PromptTokens,
+					CompletionTokens: resp.Usage.CompletionTokens,
+					TotalTokens:      resp.Usage.TotalTokens,
+				}
+			}
+			if len(resp.Choices) == 0 {
+				continue
+			}
+			delta := resp.Choices[0].Delta
+			for _, tc := range delta.ToolCalls {
+				// Some OpenAI-compatible servers omit the index; their deltas
+				// continue the last call until one carries a new ID.
+				i := len(result.ToolCalls) - 1
+				if tc.Index != nil {
+					i = *tc.Index
+				} else if i < 0 || (tc.ID != "" && tc.ID != result.ToolCalls[i].ID) {
+					i++
+				}
+				for len(result.ToolCalls) <= i {
+					result.ToolCalls = append(result.ToolCalls, agent.ToolCall{})
+				}
+				call := &result.ToolCalls[i]
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				if tc.Function.Name != "" {
+					call.Function.Name = tc.Function.Name
+				}
+				call.Function.Arguments += tc.Function.Arguments
+			}
+			if delta.Content != "" {
+				content.WriteString(delta.Content)
+				if !sendChunk(ctx, chunks, agent.StreamChunk{Content: delta.Content}) {
+					return
+				}
+			}
+		}
+
+		result.Content = content.String()
+		sendChunk(ctx, chunks, agent.StreamChunk{Message: result})
+	}()
+	return chunks, nil
+}
+
+// sendChunk delivers a chunk unless ctx is cancelled first, in which case it
+// reports false so the stream goroutine can stop.
+func sendChunk(ctx context.Context, chunks chan<- agent.StreamChunk, chunk agent.StreamChunk) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// buildRequest converts internal messages and tools to a chat completion request.
+func (p *OpenAIProvider) buildRequest(messages []agent.Message, tools []agent.Tool) (openai.ChatCompletionRequest, error) {
 	openaiMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
 
 	for _, msg := range messages {
@@ -72,7 +282,7 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []agent.Message, too
 	for i, tool := range tools {
 		var params json.RawMessage
 		if err := json.Unmarshal([]byte(tool.Schema()), &params); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal tool schema for %s: %w", tool.Name(), err)
+			return openai.ChatCompletionRequest{}, fmt.Errorf("failed to unmarshal tool schema for %s: %w", tool.Name(), err)
 		}
 
 		openaiTools[i] = openai.Tool{
@@ -90,62 +300,26 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []agent.Message, too
 		Messages: openaiMessages,
 		Tools:    openaiTools,
 	}
-
-	// Exponential backoff retry: max 3 attempts, 1s-10s intervals
-	var resp openai.ChatCompletionResponse
-	var err error
-	maxRetries := 3
-	baseDelay := time.Second
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, err = p.client.CreateChatCompletion(ctx, req)
-		if err == nil {
-			break
-		}
-
-		// Check if error is retryable (network error or 5xx)
-		if attempt < maxRetries-1 && isRetryableError(err) {
-			delay := time.Duration(math.Min(float64(baseDelay.Milliseconds()*int64(math.Pow(2, float64(attempt)))), 10000)) * time.Millisecond
-			select {
-			case <-time.After(delay):
-				// Continue to next attempt
-			case <-ctx.Done():
-				return nil, fmt.Errorf("context cancelled during retry: %w", ctx.Err())
-			}
-		} else {
-			// Non-retryable error, break immediately
-			break
+	if p.temperature != nil {
+		req.Temperature = *p.temperature
+		// go-openai omits a zero temperature, which the API reads as its default of 1.
+		// The smallest non-zero float is sent instead and is equivalent to 0.
+		if req.Temperature == 0 {
+			req.Temperature = math.SmallestNonzeroFloat32
 		}
 	}
-
-	if err != nil {
-		return nil, fmt.Errorf("openai api error: %w", err)
+	if p.maxTokens != nil {
+		req.MaxTokens = *p.maxTokens
 	}
+	return req, nil
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices returned from openai")
+// applyResponseFormat enables JSON mode on req when ctx asks for a JSON response
+// (see agent.WithResponseFormat), so the reply is guaranteed to parse.
+func applyResponseFormat(ctx context.Context, req *openai.ChatCompletionRequest) {
+	if agent.ResponseFormatFromContext(ctx) == agent.ResponseFormatJSON {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
 	}
-
-	choice := resp.Choices[0]
-	result := &agent.Message{
-		Type:    agent.MessageTypeAssistant,
-		Content: choice.Message.Content,
-	}
-
-	if len(choice.Message.ToolCalls) > 0 {
-		result.ToolCalls = make([]agent.ToolCall, len(choice.Message.ToolCalls))
-		for i, tc := range choice.Message.ToolCalls {
-			result.ToolCalls[i] = agent.ToolCall{
-				ID: tc.ID,
-				Function: agent.FunctionCall{
-					Name:      tc.Function.Name,
-					Arguments: tc.Function.Arguments,
-				},
-			}
-		}
-	}
-
-	return result, nil
 }
 
 // isRetryableError determines if an error should trigger a retry
@@ -158,6 +332,11 @@ func isRetryableError(err error) bool {
 
 	errStr := err.Error()
 
+	// A context-window overflow fails the same way on every attempt.
+	if isContextLengthMessage(errStr) {
+		return false
+	}
+
 	// Check for timeout/context errors (network issues)
 	if errStr == "context deadline exceeded" || errStr == "context cancelled" {
 		return true
@@ -180,6 +359,48 @@ func isRetryableError(err error) bool {
 	return false
 }
 
+// contextLengthMarkers are substrings of the error messages providers return when
+// a request exceeds the model's context window.
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context window",
+	"prompt is too long",
+}
+
+// isContextLengthMessage reports whether msg describes a context-window overflow.
+func isContextLengthMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, marker := range contextLengthMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyOpenAIError tags a context_length_exceeded API error as
+// agent.ErrorClassContextTooLong and transient errors as agent.ErrorClassLLMUnavailable
+// (see classifyTransientError); other errors are returned unchanged.
+func classifyOpenAIError(ctx context.Context, err error) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && (apiErr.Code == "context_length_exceeded" || isContextLengthMessage(apiErr.Message)) {
+		return &agent.LLMError{Class: agent.ErrorClassContextTooLong, Err: err}
+	}
+	return classifyTransientError(ctx, err, isRetryableError)
+}
+
+// classifyTransientError tags err as agent.ErrorClassLLMUnavailable when retryable
+// reports it as transient (outage, rate limit, network), so the controller may retry
+// the whole run later. Anything else (401, 403, 400, ...) is returned unchanged and
+// fails the run on its first attempt, as does a call cut short by ctx itself.
+func classifyTransientError(ctx context.Context, err error, retryable func(error) bool) error {
+	if ctx.Err() != nil || !retryable(err) {
+		return err
+	}
+	return &agent.LLMError{Class: agent.ErrorClassLLMUnavailable, Err: err}
+}
+
 // stringContains is a simple helper to check if a string contains a substring
 func stringContains(s, substr string) bool {
 	return strings.Contains(s, substr)
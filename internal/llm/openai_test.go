@@ -0,0 +1,229 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+
+	"kubeminds/internal/agent"
+)
+
+// sseHandler replies with each event as a server-sent "data:" line.
+func sseHandler(events ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+	}
+}
+
+func TestOpenAIProvider_ChatStream(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Checking "}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"logs"}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_pod_logs","arguments":"{\"pod"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"Name\":\"app-1\"}"}}]}}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`,
+		`[DONE]`,
+	))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("k", "gpt-test", srv.URL)
+	chunks, err := p.ChatStream(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var text strings.Builder
+	var final *agent.Message
+	for c := range chunks {
+		if c.Err != nil {
+			t.Fatalf("stream error = %v", c.Err)
+		}
+		text.WriteString(c.Content)
+		if c.Message != nil {
+			final = c.Message
+		}
+	}
+
+	if text.String() != "Checking logs" {
+		t.Errorf("streamed text = %q, want %q", text.String(), "Checking logs")
+	}
+	if final == nil {
+		t.Fatal("no final message")
+	}
+	if final.Content != "Checking logs" || len(final.ToolCalls) != 1 {
+		t.Fatalf("final = %+v, want content and one tool call", final)
+	}
+	tc := final.ToolCalls[0]
+	if tc.ID != "call_1" || tc.Function.Name != "get_pod_logs" || tc.Function.Arguments != `{"podName":"app-1"}` {
+		t.Errorf("ToolCall = %+v, want assembled get_pod_logs call", tc)
+	}
+	if final.Usage == nil || final.Usage.TotalTokens != 17 {
+		t.Errorf("Usage = %+v, want 17 total tokens", final.Usage)
+	}
+}
+
+func TestOpenAIProvider_ChatStream_ToolCallsWithoutIndex(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_pod_logs","arguments":"{\"pod"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"function":{"arguments":"Name\":\"app-1\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"id":"call_2","type":"function","function":{"name":"get_pod_events","arguments":"{}"}}]}}]}`,
+		`[DONE]`,
+	))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("k", "gpt-test", srv.URL)
+	chunks, err := p.ChatStream(context.Background(), []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var final *agent.Message
+	for c := range chunks {
+		if c.Err != nil {
+			t.Fatalf("stream error = %v", c.Err)
+		}
+		if c.Message != nil {
+			final = c.Message
+		}
+	}
+
+	if final == nil || len(final.ToolCalls) != 2 {
+		t.Fatalf("final = %+v, want two tool calls", final)
+	}
+	if tc := final.ToolCalls[0]; tc.ID != "call_1" || tc.Function.Arguments != `{"podName":"app-1"}` {
+		t.Errorf("ToolCalls[0] = %+v, want assembled get_pod_logs call", tc)
+	}
+	if tc := final.ToolCalls[1]; tc.ID != "call_2" || tc.Function.Name != "get_pod_events" {
+		t.Errorf("ToolCalls[1] = %+v, want get_pod_events call", tc)
+	}
+}
+
+func TestOpenAIProvider_GenerationParams(t *testing.T) {
+	maxTokens := 2048
+	temperature := float32(0.3)
+
+	tests := []struct {
+		name        string
+		temperature *float32
+		maxTokens   *int
+		wantTemp    float32
+		wantMax     int
+	}{
+		{name: "unset keeps API defaults"},
+		{name: "configured values", temperature: &temperature, maxTokens: &maxTokens, wantTemp: 0.3, wantMax: 2048},
+		{name: "zero temperature is still sent", temperature: new(float32), wantTemp: math.SmallestNonzeroFloat32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewOpenAIProvider("k", "gpt-test", "").WithGenerationParams(tt.temperature, tt.maxTokens)
+			req, err := p.buildRequest([]agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}, nil)
+			if err != nil {
+				t.Fatalf("buildRequest() error = %v", err)
+			}
+			if req.Temperature != tt.wantTemp || req.MaxTokens != tt.wantMax {
+				t.Errorf("temperature/max_tokens = %v/%d, want %v/%d", req.Temperature, req.MaxTokens, tt.wantTemp, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_ContextTooLong(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		// The token counts contain "500", which must not make the error look retryable.
+		fmt.Fprint(w, `{"error":{"message":"This model's maximum context length is 128000 tokens. However, your messages resulted in 150000 tokens.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("k", "gpt-test", srv.URL)
+	msgs := []agent.Message{{Type: agent.MessageTypeUser, Content: "hi"}}
+	_, err := p.Chat(context.Background(), msgs, nil)
+	if got := agent.ClassOf(err); got != agent.ErrorClassContextTooLong {
+		t.Fatalf("Chat: ClassOf(%v) = %q, want %q", err, got, agent.ErrorClassContextTooLong)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1 (not retried)", n)
+	}
+
+	_, err = p.ChatStream(context.Background(), msgs, nil)
+	if got := agent.ClassOf(err); got != agent.ErrorClassContextTooLong {
+		t.Errorf("ChatStream: ClassOf(%v) = %q, want %q", err, got, agent.ErrorClassContextTooLong)
+	}
+}
+
+func TestClassifyOpenAIError_Transient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want agent.ErrorClass
+	}{
+		{"server error", &openai.APIError{HTTPStatusCode: 503, Message: "service unavailable"}, agent.ErrorClassLLMUnavailable},
+		{"rate limit", &openai.APIError{HTTPStatusCode: 429, Message: "rate limit reached"}, agent.ErrorClassLLMUnavailable},
+		{"network", errors.New("dial tcp: connection refused"), agent.ErrorClassLLMUnavailable},
+		{"unauthorized", &openai.APIError{HTTPStatusCode: 401, Message: "invalid api key"}, ""},
+		{"forbidden", &openai.APIError{HTTPStatusCode: 403, Message: "model not allowed"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := agent.ClassOf(classifyOpenAIError(context.Background(), tt.err)); got != tt.want {
+				t.Errorf("ClassOf(classifyOpenAIError(%v)) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := agent.ClassOf(classifyOpenAIError(ctx, errors.New("context deadline exceeded"))); got != "" {
+		t.Errorf("error of a cancelled call classified as %q, want unclassified", got)
+	}
+}
+
+func TestOpenAIProvider_ResponseFormat(t *testing.T) {
+	var formats []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat *struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		format := ""
+		if body.ResponseFormat != nil {
+			format = body.ResponseFormat.Type
+		}
+		formats = append(formats, format)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"root_cause\":\"oom\"}"}}]}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("k", "gpt-test", srv.URL)
+	msgs := []agent.Message{{Type: agent.MessageTypeUser, Content: "reply in JSON"}}
+	if _, err := p.Chat(context.Background(), msgs, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if _, err := p.Chat(agent.WithResponseFormat(context.Background(), agent.ResponseFormatJSON), msgs, nil); err != nil {
+		t.Fatalf("Chat() with JSON format error = %v", err)
+	}
+
+	if len(formats) != 2 || formats[0] != "" || formats[1] != "json_object" {
+		t.Errorf("response_format per request = %q, want [\"\" \"json_object\"]", formats)
+	}
+}
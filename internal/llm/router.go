@@ -2,16 +2,21 @@ package llm
 
 // Router selects an LLM provider by name and delegates all Chat calls to it.
 //
-// For Phase 2, routing is intentionally simple: one default provider is used for
-// all requests. There is no runtime failover — if you need a different provider,
-// change defaultProvider in config.yaml and restart.
+// One default provider serves all requests. An optional ordered fallback list is
+// tried in turn when the default fails after its own retries (e.g. 429 for the whole
+// retry window), so a single provider outage does not fail every DiagnosisTask.
 //
 // This design keeps the Agent loop unaware of which underlying provider is active,
 // which makes swapping providers trivially safe.
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
 
 	"kubeminds/internal/agent"
 )
@@ -24,6 +29,18 @@ type Router struct {
 	// defaultProvider is the name of the provider that Chat calls are routed to.
 	// It must match a key in providers.
 	defaultProvider string
+
+	// fallbacks are provider names tried in order when defaultProvider fails.
+	fallbacks []string
+
+	// allowedModels lists, per provider, the models a task may select with a model
+	// override; buildModel builds a provider for one of them.
+	allowedModels map[string][]string
+	buildModel    func(provider, model string) (agent.LLMProvider, error)
+
+	// modelProviders caches the providers built for overrides, keyed by provider/model.
+	mu             sync.Mutex
+	modelProviders map[string]agent.LLMProvider
 }
 
 // NewRouter creates a Router from a pre-built provider map.
@@ -39,14 +56,232 @@ func NewRouter(providers map[string]agent.LLMProvider, defaultProvider string) (
 	}, nil
 }
 
-// Chat implements agent.LLMProvider by forwarding the call to the default provider.
+// WithFallbacks sets the providers tried, in order, when the default provider fails.
+// Every name must be configured in the router.
+func (r *Router) WithFallbacks(names ...string) (*Router, error) {
+	for _, name := range names {
+		if _, ok := r.providers[name]; !ok {
+			return nil, fmt.Errorf("llm router: fallback provider %q is not configured in providers %v",
+				name, providerNames(r.providers))
+		}
+	}
+	r.fallbacks = names
+	return r, nil
+}
+
+// WithModelOverrides enables per-task model overrides (see ForModel). allowed maps a
+// provider name to the models that may be selected for it, and build creates the
+// provider for an allowed model without changing the configured one.
+func (r *Router) WithModelOverrides(allowed map[string][]string, build func(provider, model string) (agent.LLMProvider, error)) *Router {
+	r.allowedModels = allowed
+	r.buildModel = build
+	r.modelProviders = make(map[string]agent.LLMProvider)
+	return r
+}
+
+// Chat implements agent.LLMProvider by forwarding the call to the default provider,
+// then to each fallback in order until one answers. Context cancellation and
+// deadline errors stop the chain, since no other provider can succeed in time.
 func (r *Router) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
-	p, ok := r.providers[r.defaultProvider]
+	var errs []error
+	for i, provider := range r.chain() {
+		resp, err := r.ChatWith(ctx, provider, messages, tools)
+		if err == nil {
+			if i > 0 {
+				slog.Default().Info("LLM fallback provider answered", "provider", provider, "primary", r.defaultProvider)
+			}
+			return resp, nil
+		}
+		if len(r.fallbacks) == 0 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider, err))
+		if isContextError(ctx, err) {
+			break
+		}
+		slog.Default().Warn("LLM provider failed, trying next fallback", "provider", provider, "error", err)
+	}
+	return nil, fmt.Errorf("llm router: all providers failed: %w", errors.Join(errs...))
+}
+
+// chain returns the default provider followed by the fallbacks.
+func (r *Router) chain() []string {
+	return append([]string{r.defaultProvider}, r.fallbacks...)
+}
+
+// isContextError reports whether err is due to ctx being cancelled or timing out.
+func isContextError(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// ChatWith forwards the call to the named provider instead of the default one,
+// e.g. to health-check a failover provider. Unknown names return an error.
+func (r *Router) ChatWith(ctx context.Context, provider string, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	p, ok := r.providers[provider]
 	if !ok {
-		// Defensive: should not happen after NewRouter validates, but guard anyway.
-		return nil, fmt.Errorf("llm router: provider %q not found", r.defaultProvider)
+		return nil, fmt.Errorf("llm router: provider %q not found", provider)
 	}
-	return p.Chat(ctx, messages, tools)
+	return observeChat(ctx, provider, p, messages, tools)
+}
+
+// ChatWithModel forwards the call to the named provider running the given model
+// instead of its configured one. The model must be allowlisted for the provider;
+// an empty provider selects the default one. Fallbacks are not tried.
+func (r *Router) ChatWithModel(ctx context.Context, provider, model string, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	if provider == "" {
+		provider = r.defaultProvider
+	}
+	p, err := r.modelProvider(provider, model)
+	if err != nil {
+		return nil, err
+	}
+	return observeChat(ctx, provider, p, messages, tools)
+}
+
+// ForModel returns an agent.LLMProvider that sends every Chat call to provider
+// running model (see ChatWithModel). It fails fast when the override is not allowed.
+func (r *Router) ForModel(provider, model string) (agent.LLMProvider, error) {
+	if provider == "" {
+		provider = r.defaultProvider
+	}
+	if _, err := r.modelProvider(provider, model); err != nil {
+		return nil, err
+	}
+	return &modelOverrideProvider{router: r, provider: provider, model: model}, nil
+}
+
+// modelProvider returns the cached provider for an allowlisted provider/model pair,
+// building it on first use.
+func (r *Router) modelProvider(provider, model string) (agent.LLMProvider, error) {
+	if _, ok := r.providers[provider]; !ok {
+		return nil, fmt.Errorf("llm router: provider %q not found", provider)
+	}
+	if r.buildModel == nil || !slices.Contains(r.allowedModels[provider], model) {
+		return nil, fmt.Errorf("llm router: model %q is not in the allowedModels of provider %q", model, provider)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := provider + "/" + model
+	if p, ok := r.modelProviders[key]; ok {
+		return p, nil
+	}
+	p, err := r.buildModel(provider, model)
+	if err != nil {
+		return nil, fmt.Errorf("llm router: failed to build model %q for provider %q: %w", model, provider, err)
+	}
+	r.modelProviders[key] = p
+	return p, nil
+}
+
+// modelOverrideProvider pins Chat calls to one provider/model pair of a Router.
+type modelOverrideProvider struct {
+	router   *Router
+	provider string
+	model    string
+}
+
+func (m *modelOverrideProvider) Chat(ctx context.Context, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	return m.router.ChatWithModel(ctx, m.provider, m.model, messages, tools)
+}
+
+// observeChat calls p and records the request duration under the provider name.
+func observeChat(ctx context.Context, provider string, p agent.LLMProvider, messages []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	start := time.Now()
+	resp, err := p.Chat(ctx, messages, tools)
+	result := llmResultSuccess
+	if err != nil {
+		result = llmResultError
+	}
+	LLMRequestDuration.WithLabelValues(provider, result).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// ChatStream implements agent.StreamingLLMProvider for the default provider.
+// Providers that cannot stream are called through Chat, and their response is
+// delivered as a single final chunk. Fallbacks are tried when a stream cannot be
+// opened or fails before its first chunk; a stream that breaks midway, after
+// content was relayed, is not retried elsewhere.
+func (r *Router) ChatStream(ctx context.Context, messages []agent.Message, tools []agent.Tool) (<-chan agent.StreamChunk, error) {
+	var errs []error
+	for i, provider := range r.chain() {
+		chunks, err := r.chatStreamWith(ctx, provider, messages, tools)
+		if err == nil {
+			if i > 0 {
+				slog.Default().Info("LLM fallback provider answered", "provider", provider, "primary", r.defaultProvider)
+			}
+			return chunks, nil
+		}
+		if len(r.fallbacks) == 0 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider, err))
+		if isContextError(ctx, err) {
+			break
+		}
+		slog.Default().Warn("LLM provider failed, trying next fallback", "provider", provider, "error", err)
+	}
+	return nil, fmt.Errorf("llm router: all providers failed: %w", errors.Join(errs...))
+}
+
+// chatStreamWith opens a stream on the named provider.
+func (r *Router) chatStreamWith(ctx context.Context, provider string, messages []agent.Message, tools []agent.Tool) (<-chan agent.StreamChunk, error) {
+	p := r.providers[provider]
+
+	streamer, ok := p.(agent.StreamingLLMProvider)
+	if !ok {
+		resp, err := r.ChatWith(ctx, provider, messages, tools)
+		if err != nil {
+			return nil, err
+		}
+		chunks := make(chan agent.StreamChunk, 1)
+		chunks <- agent.StreamChunk{Message: resp}
+		close(chunks)
+		return chunks, nil
+	}
+
+	start := time.Now()
+	upstream, err := streamer.ChatStream(ctx, messages, tools)
+	var first agent.StreamChunk
+	if err == nil {
+		// A stream failing before its first chunk has sent nothing to the caller yet,
+		// so it is reported as a failure to open it and the next fallback can answer.
+		var ok bool
+		if first, ok = <-upstream; !ok {
+			err = errors.New("llm router: stream closed before its first chunk")
+		} else if first.Err != nil {
+			err = first.Err
+		}
+	}
+	if err != nil {
+		LLMRequestDuration.WithLabelValues(provider, llmResultError).Observe(time.Since(start).Seconds())
+		return nil, err
+	}
+
+	// Relay the chunks so the request duration covers the whole stream.
+	chunks := make(chan agent.StreamChunk)
+	go func() {
+		defer close(chunks)
+		result := llmResultError
+		defer func() {
+			LLMRequestDuration.WithLabelValues(provider, result).Observe(time.Since(start).Seconds())
+		}()
+		relay := func(chunk agent.StreamChunk) bool {
+			if chunk.Message != nil {
+				result = llmResultSuccess
+			}
+			return sendChunk(ctx, chunks, chunk)
+		}
+		if !relay(first) {
+			return
+		}
+		for chunk := range upstream {
+			if !relay(chunk) {
+				return
+			}
+		}
+	}()
+	return chunks, nil
 }
 
 // DefaultProvider returns the name of the currently active provider.
@@ -54,6 +289,12 @@ func (r *Router) DefaultProvider() string {
 	return r.defaultProvider
 }
 
+// HasProvider reports whether a provider with the given name is configured.
+func (r *Router) HasProvider(name string) bool {
+	_, ok := r.providers[name]
+	return ok
+}
+
 // providerNames extracts map keys as a slice for use in error messages.
 func providerNames(m map[string]agent.LLMProvider) []string {
 	names := make([]string, 0, len(m))
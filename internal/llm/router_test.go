@@ -2,10 +2,15 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"kubeminds/internal/agent"
+	"kubeminds/internal/config"
 )
 
 // stubProvider is a minimal agent.LLMProvider for testing.
@@ -78,3 +83,224 @@ func TestRouter_Chat_PropagatesError(t *testing.T) {
 		t.Errorf("Chat() error = %v, want %v", err, wantErr)
 	}
 }
+
+func TestRouter_ChatStream_WrapsNonStreamingProvider(t *testing.T) {
+	router, _ := NewRouter(map[string]agent.LLMProvider{"openai": &stubProvider{name: "openai"}}, "openai")
+
+	chunks, err := router.ChatStream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() unexpected error: %v", err)
+	}
+	var got []agent.StreamChunk
+	for c := range chunks {
+		got = append(got, c)
+	}
+	if len(got) != 1 || got[0].Message == nil || got[0].Message.Content != "response from openai" {
+		t.Errorf("chunks = %+v, want a single final chunk with the Chat response", got)
+	}
+}
+
+// streamingStubProvider is a streaming stubProvider whose streams fail before their
+// first chunk when streamErr is set.
+type streamingStubProvider struct {
+	stubProvider
+	streamErr error
+}
+
+func (s *streamingStubProvider) ChatStream(_ context.Context, _ []agent.Message, _ []agent.Tool) (<-chan agent.StreamChunk, error) {
+	chunks := make(chan agent.StreamChunk, 2)
+	if s.streamErr != nil {
+		chunks <- agent.StreamChunk{Err: s.streamErr}
+	} else {
+		chunks <- agent.StreamChunk{Content: "streamed by " + s.name}
+		chunks <- agent.StreamChunk{Message: &agent.Message{Type: agent.MessageTypeAssistant, Content: "streamed by " + s.name}}
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestRouter_ChatStream_FallsBack(t *testing.T) {
+	router, _ := NewRouter(map[string]agent.LLMProvider{
+		"anthropic": &streamingStubProvider{stubProvider: stubProvider{name: "anthropic"}, streamErr: errors.New("529 overloaded")},
+		"openai":    &streamingStubProvider{stubProvider: stubProvider{name: "openai"}},
+	}, "anthropic")
+	if _, err := router.WithFallbacks("openai"); err != nil {
+		t.Fatalf("WithFallbacks() error: %v", err)
+	}
+
+	chunks, err := router.ChatStream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() unexpected error: %v", err)
+	}
+	var content string
+	var final *agent.Message
+	for c := range chunks {
+		if c.Err != nil {
+			t.Fatalf("stream error = %v, want the fallback's stream", c.Err)
+		}
+		content += c.Content
+		if c.Message != nil {
+			final = c.Message
+		}
+	}
+	if content != "streamed by openai" || final == nil || final.Content != "streamed by openai" {
+		t.Errorf("content = %q, final = %+v, want the openai fallback's stream", content, final)
+	}
+
+	// Without fallbacks the early stream error is returned by ChatStream itself.
+	single, _ := NewRouter(map[string]agent.LLMProvider{
+		"anthropic": &streamingStubProvider{stubProvider: stubProvider{name: "anthropic"}, streamErr: errors.New("529 overloaded")},
+	}, "anthropic")
+	if _, err := single.ChatStream(context.Background(), nil, nil); err == nil {
+		t.Error("ChatStream() error = nil, want the stream's first error")
+	}
+}
+
+// countingProvider wraps stubProvider and counts Chat calls.
+type countingProvider struct {
+	stubProvider
+	calls int
+}
+
+func (c *countingProvider) Chat(ctx context.Context, msgs []agent.Message, tools []agent.Tool) (*agent.Message, error) {
+	c.calls++
+	return c.stubProvider.Chat(ctx, msgs, tools)
+}
+
+func TestRouter_Chat_FallsBackInOrder(t *testing.T) {
+	primary := &countingProvider{stubProvider: stubProvider{name: "openai", callErr: errors.New("status 429: rate limited")}}
+	secondary := &countingProvider{stubProvider: stubProvider{name: "anthropic"}}
+	tertiary := &countingProvider{stubProvider: stubProvider{name: "gemini"}}
+
+	router, _ := NewRouter(map[string]agent.LLMProvider{
+		"openai": primary, "anthropic": secondary, "gemini": tertiary,
+	}, "openai")
+	if _, err := router.WithFallbacks("anthropic", "gemini"); err != nil {
+		t.Fatalf("WithFallbacks() error: %v", err)
+	}
+
+	resp, err := router.Chat(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Chat() unexpected error: %v", err)
+	}
+	if resp.Content != "response from anthropic" {
+		t.Errorf("Chat() content = %q, want response from anthropic", resp.Content)
+	}
+	if primary.calls != 1 || secondary.calls != 1 || tertiary.calls != 0 {
+		t.Errorf("calls = %d/%d/%d, want 1/1/0", primary.calls, secondary.calls, tertiary.calls)
+	}
+}
+
+func TestRouter_Chat_AllFallbacksFail(t *testing.T) {
+	primaryErr := errors.New("primary down")
+	secondaryErr := errors.New("secondary down")
+	router, _ := NewRouter(map[string]agent.LLMProvider{
+		"openai":    &stubProvider{name: "openai", callErr: primaryErr},
+		"anthropic": &stubProvider{name: "anthropic", callErr: secondaryErr},
+	}, "openai")
+	_, _ = router.WithFallbacks("anthropic")
+
+	_, err := router.Chat(context.Background(), nil, nil)
+	if !errors.Is(err, primaryErr) || !errors.Is(err, secondaryErr) {
+		t.Errorf("Chat() error = %v, want both provider errors", err)
+	}
+}
+
+func TestRouter_Chat_ContextErrorStopsFallback(t *testing.T) {
+	secondary := &countingProvider{stubProvider: stubProvider{name: "anthropic"}}
+	router, _ := NewRouter(map[string]agent.LLMProvider{
+		"openai":    &stubProvider{name: "openai", callErr: context.DeadlineExceeded},
+		"anthropic": secondary,
+	}, "openai")
+	_, _ = router.WithFallbacks("anthropic")
+
+	if _, err := router.Chat(context.Background(), nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Chat() error = %v, want deadline exceeded", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("fallback calls = %d, want 0 after a context error", secondary.calls)
+	}
+}
+
+func TestRouter_WithFallbacks_UnknownProvider(t *testing.T) {
+	router, _ := NewRouter(map[string]agent.LLMProvider{"openai": &stubProvider{name: "openai"}}, "openai")
+	if _, err := router.WithFallbacks("gemini"); err == nil {
+		t.Error("WithFallbacks() should reject a provider that is not configured")
+	}
+}
+
+func TestRouter_ForModel_UsesOverrideModel(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		models = append(models, req.Model)
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.LLMConfig{
+		DefaultProvider: "ollama",
+		Providers: map[string]config.ProviderConfig{
+			"ollama": {Model: "llama3.1", BaseURL: srv.URL, AllowedModels: []string{"qwen2.5"}},
+		},
+	}
+	router, err := NewRouterFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewRouterFromConfig() error = %v", err)
+	}
+
+	override, err := router.ForModel("", "qwen2.5")
+	if err != nil {
+		t.Fatalf("ForModel() error = %v", err)
+	}
+	messages := []agent.Message{{Type: agent.MessageTypeUser, Content: "Diagnose app-1"}}
+	if _, err := override.Chat(context.Background(), messages, nil); err != nil {
+		t.Fatalf("override Chat() error = %v", err)
+	}
+	if _, err := router.Chat(context.Background(), messages, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if want := []string{"qwen2.5", "llama3.1"}; strings.Join(models, ",") != strings.Join(want, ",") {
+		t.Errorf("request models = %v, want %v", models, want)
+	}
+	if got := cfg.Providers["ollama"].Model; got != "llama3.1" {
+		t.Errorf("configured model changed to %q", got)
+	}
+}
+
+func TestRouter_ForModel_RejectsUnlistedModel(t *testing.T) {
+	router, err := NewRouter(map[string]agent.LLMProvider{"openai": &stubProvider{name: "openai"}}, "openai")
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	built := 0
+	router.WithModelOverrides(map[string][]string{"openai": {"gpt-4o-mini"}}, func(provider, model string) (agent.LLMProvider, error) {
+		built++
+		return &stubProvider{name: provider + "/" + model}, nil
+	})
+
+	if _, err := router.ForModel("openai", "gpt-5"); err == nil {
+		t.Error("ForModel() with an unlisted model: expected error")
+	}
+	if _, err := router.ForModel("anthropic", "gpt-4o-mini"); err == nil {
+		t.Error("ForModel() with an unknown provider: expected error")
+	}
+
+	for i := 0; i < 2; i++ {
+		p, err := router.ForModel("openai", "gpt-4o-mini")
+		if err != nil {
+			t.Fatalf("ForModel() error = %v", err)
+		}
+		resp, err := p.Chat(context.Background(), nil, nil)
+		if err != nil || resp.Content != "response from openai/gpt-4o-mini" {
+			t.Errorf("Chat() = %v, %v; want the override provider's response", resp, err)
+		}
+	}
+	if built != 1 {
+		t.Errorf("override provider built %d times, want 1 (cached)", built)
+	}
+}
@@ -0,0 +1,14 @@
+package llm
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// tlsTransport returns a copy of http.DefaultTransport that uses tlsCfg, e.g. to
+// trust an LLM gateway signed by a private CA.
+func tlsTransport(tlsCfg *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsCfg
+	return t
+}
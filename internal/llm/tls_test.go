@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubeminds/internal/config"
+)
+
+// writeCABundle writes the TLS test server's self-signed certificate as a PEM bundle.
+func writeCABundle(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, bundle, 0o600); err != nil {
+		t.Fatalf("write CA bundle: %v", err)
+	}
+	return path
+}
+
+func TestNewEmbedderFromConfig_CustomCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2,0.3]]}`))
+	}))
+	defer srv.Close()
+
+	newEmbedder := func(tlsCfg config.TLSConfig) *OllamaEmbedder {
+		t.Helper()
+		embedder, err := NewEmbedderFromConfig(config.LLMConfig{
+			Providers: map[string]config.ProviderConfig{"ollama": {BaseURL: srv.URL, TLS: tlsCfg}},
+		}, config.PostgreSQLConfig{EmbeddingProvider: "ollama"})
+		if err != nil {
+			t.Fatalf("NewEmbedderFromConfig() error = %v", err)
+		}
+		return embedder.(*OllamaEmbedder)
+	}
+
+	t.Run("trusts a server signed by the bundle", func(t *testing.T) {
+		embedder := newEmbedder(config.TLSConfig{CABundlePath: writeCABundle(t, srv)})
+		if _, err := embedder.Embed(context.Background(), "container OOM killed"); err != nil {
+			t.Fatalf("Embed: %v", err)
+		}
+	})
+
+	t.Run("rejects the server without the bundle", func(t *testing.T) {
+		embedder := newEmbedder(config.TLSConfig{})
+		_, err := embedder.Embed(context.Background(), "container OOM killed")
+		if err == nil || !strings.Contains(err.Error(), "certificate") {
+			t.Fatalf("Embed error = %v, want a certificate verification error", err)
+		}
+	})
+}
+
+func TestBuildProvider_InvalidCABundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := buildProvider("openai", config.ProviderConfig{Model: "gpt-4o", TLS: config.TLSConfig{CABundlePath: path}})
+	if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Errorf("buildProvider() error = %v, want an invalid bundle error", err)
+	}
+}
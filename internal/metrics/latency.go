@@ -0,0 +1,48 @@
+// Package metrics holds shared Prometheus metric helpers. Metrics are registered on
+// the controller-runtime registry, which is served by the manager's metrics server.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// nativeBucketFactor is the growth factor between native histogram buckets;
+	// 1.1 keeps the relative error of any quantile (e.g. p99) under ~5%.
+	nativeBucketFactor = 1.1
+	// nativeMaxBuckets caps memory per series; beyond it resolution is reduced.
+	nativeMaxBuckets = 160
+	// nativeMinResetDuration is how long a histogram runs before it may be reset
+	// when it exceeds nativeMaxBuckets.
+	nativeMinResetDuration = time.Hour
+)
+
+// latencyBuckets are the classic buckets exposed alongside the native histogram
+// for scrapers that do not negotiate native histograms (text exposition format).
+// They span 5ms to ~80s to cover both Kubernetes API calls and slow LLM responses.
+var latencyBuckets = prometheus.ExponentialBuckets(0.005, 2, 15)
+
+// LatencyHistogramOpts returns histogram options for a latency metric in seconds.
+// The histogram is exposed as a native (exponential) histogram where the scraper
+// supports it, and as classic buckets otherwise.
+func LatencyHistogramOpts(name, help string) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Name:                            name,
+		Help:                            help,
+		Buckets:                         latencyBuckets,
+		NativeHistogramBucketFactor:     nativeBucketFactor,
+		NativeHistogramMaxBucketNumber:  nativeMaxBuckets,
+		NativeHistogramMinResetDuration: nativeMinResetDuration,
+	}
+}
+
+// NewLatencyHistogramVec creates a latency HistogramVec (see LatencyHistogramOpts)
+// and registers it on the controller-runtime metrics registry.
+func NewLatencyHistogramVec(name, help string, labels []string) *prometheus.HistogramVec {
+	vec := prometheus.NewHistogramVec(LatencyHistogramOpts(name, help), labels)
+	crmetrics.Registry.MustRegister(vec)
+	return vec
+}
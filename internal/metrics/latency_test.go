@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLatencyHistogram_RecordsNativeSamples(t *testing.T) {
+	h := prometheus.NewHistogram(LatencyHistogramOpts("test_latency_seconds", "test"))
+	for _, v := range []float64{0.003, 0.25, 0.26, 42} {
+		h.Observe(v)
+	}
+
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	hist := m.GetHistogram()
+
+	if hist.Schema == nil {
+		t.Fatal("expected a native histogram schema to be set")
+	}
+	if hist.GetSampleCount() != 4 {
+		t.Errorf("samples = %d, want 4", hist.GetSampleCount())
+	}
+	// Four values spread over four orders of magnitude populate several sparse buckets.
+	if len(hist.GetPositiveSpan()) == 0 || len(hist.GetPositiveDelta()) < 3 {
+		t.Errorf("native buckets: spans = %d, deltas = %d; want populated native buckets",
+			len(hist.GetPositiveSpan()), len(hist.GetPositiveDelta()))
+	}
+
+	// Classic buckets are still exposed for scrapers without native histogram support.
+	if len(hist.GetBucket()) != len(latencyBuckets) {
+		t.Errorf("classic buckets = %d, want %d", len(hist.GetBucket()), len(latencyBuckets))
+	}
+}
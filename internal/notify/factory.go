@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"fmt"
+
+	"kubeminds/internal/config"
+)
+
+// NewRouterFromConfig builds a NotificationRouter from the notifications config block.
+// It returns nil (notifications disabled) when no sinks are configured. Unknown sink
+// types, routes naming undeclared sinks and ticket sinks used outside tickets are
+// errors so misconfiguration is caught at startup.
+func NewRouterFromConfig(cfg config.NotificationsConfig) (*NotificationRouter, error) {
+	if len(cfg.Sinks) == 0 {
+		return nil, nil
+	}
+
+	sinks := make(map[string]NotificationSink, len(cfg.Sinks))
+	for name, sc := range cfg.Sinks {
+		sink, err := buildSink(name, sc)
+		if err != nil {
+			return nil, err
+		}
+		sinks[name] = sink
+	}
+
+	// Ticket sinks file every completed diagnosis through tickets; routing one would
+	// replace the team's Slack or PagerDuty sink instead.
+	routable := func(name string) error {
+		if cfg.Sinks[name].Type == "ticket" {
+			return fmt.Errorf("notify: ticket sink %q must be listed under notifications.tickets, not routed", name)
+		}
+		return nil
+	}
+
+	var defaultSink NotificationSink
+	if cfg.DefaultSink != "" {
+		s, ok := sinks[cfg.DefaultSink]
+		if !ok {
+			return nil, fmt.Errorf("notify: defaultSink %q is not declared under notifications.sinks", cfg.DefaultSink)
+		}
+		if err := routable(cfg.DefaultSink); err != nil {
+			return nil, err
+		}
+		defaultSink = s
+	}
+
+	router := NewNotificationRouter(defaultSink)
+	for i, rc := range cfg.Routes {
+		s, ok := sinks[rc.Sink]
+		if !ok {
+			return nil, fmt.Errorf("notify: route %d names undeclared sink %q", i, rc.Sink)
+		}
+		if err := routable(rc.Sink); err != nil {
+			return nil, err
+		}
+		router.AddRoute(Route{Team: rc.Team, Namespace: rc.Namespace, Sink: s})
+	}
+	for _, name := range cfg.Tickets {
+		s, ok := sinks[name]
+		if !ok {
+			return nil, fmt.Errorf("notify: tickets names undeclared sink %q", name)
+		}
+		if cfg.Sinks[name].Type != "ticket" {
+			return nil, fmt.Errorf("notify: tickets names sink %q of type %q, want ticket", name, cfg.Sinks[name].Type)
+		}
+		router.AddTicketSink(s)
+	}
+	return router, nil
+}
+
+// buildSink instantiates a single sink from its config.
+func buildSink(name string, cfg config.NotificationSinkConfig) (NotificationSink, error) {
+	switch cfg.Type {
+	case "slack":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("notify: slack sink %q requires url", name)
+		}
+		return NewSlackSink(name, cfg.URL), nil
+	case "pagerduty":
+		if cfg.RoutingKey == "" {
+			return nil, fmt.Errorf("notify: pagerduty sink %q requires routingKey", name)
+		}
+		return NewPagerDutySink(name, cfg.RoutingKey, cfg.URL), nil
+	case "ticket":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("notify: ticket sink %q requires url", name)
+		}
+		tmpl, err := NewTicketTemplate(cfg.Ticket)
+		if err != nil {
+			return nil, fmt.Errorf("notify: ticket sink %q: %w", name, err)
+		}
+		return NewTicketSink(name, cfg.URL, tmpl), nil
+	default:
+		return nil, fmt.Errorf("notify: sink %q has unknown type %q; supported: slack, pagerduty, ticket", name, cfg.Type)
+	}
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// Route sends notifications for tasks matching Team and/or Namespace to Sink.
+// Empty fields match anything; a route with both fields empty matches every task.
+type Route struct {
+	// Team matches the alert's "team" label.
+	Team string
+	// Namespace matches the alert's "namespace" label, or the target namespace.
+	Namespace string
+	// Sink receives notifications for matching tasks.
+	Sink NotificationSink
+}
+
+// NotificationRouter selects the NotificationSink for each task from an ordered
+// routing table. The first matching route wins; unmatched tasks go to the default sink.
+// Ticket sinks are notified of every task on top of the selected sink.
+type NotificationRouter struct {
+	routes      []Route
+	defaultSink NotificationSink
+	tickets     []NotificationSink
+}
+
+// NewNotificationRouter creates a router with the given fallback sink, which may be
+// nil to drop notifications that match no route.
+func NewNotificationRouter(defaultSink NotificationSink) *NotificationRouter {
+	return &NotificationRouter{defaultSink: defaultSink}
+}
+
+// AddRoute appends a route. Routes are evaluated in the order they were added.
+func (r *NotificationRouter) AddRoute(route Route) *NotificationRouter {
+	r.routes = append(r.routes, route)
+	return r
+}
+
+// AddTicketSink adds a sink notified of every task in addition to the routed sink.
+func (r *NotificationRouter) AddTicketSink(sink NotificationSink) *NotificationRouter {
+	r.tickets = append(r.tickets, sink)
+	return r
+}
+
+// Select returns the sink for task, or nil when no route matches and there is no default sink.
+func (r *NotificationRouter) Select(task *kubemindsv1alpha1.DiagnosisTask) NotificationSink {
+	team, namespace := routingKeys(task)
+	for _, route := range r.routes {
+		if route.Team != "" && route.Team != team {
+			continue
+		}
+		if route.Namespace != "" && route.Namespace != namespace {
+			continue
+		}
+		return route.Sink
+	}
+	return r.defaultSink
+}
+
+// Notify sends a notification for task to the selected sink and to every ticket
+// sink. A failing sink does not keep the others from being notified; their errors
+// are joined.
+func (r *NotificationRouter) Notify(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) error {
+	sinks := r.tickets
+	if sink := r.Select(task); sink != nil {
+		sinks = append([]NotificationSink{sink}, r.tickets...)
+	}
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Notify(ctx, task); err != nil {
+			errs = append(errs, fmt.Errorf("notify sink %s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// routingKeys returns the team and namespace used for routing. The namespace
+// comes from the alert's namespace label, falling back to the target namespace.
+func routingKeys(task *kubemindsv1alpha1.DiagnosisTask) (team, namespace string) {
+	if ac := task.Spec.AlertContext; ac != nil {
+		team = ac.Labels["team"]
+		namespace = ac.Labels["namespace"]
+	}
+	if namespace == "" {
+		namespace = task.Spec.Target.Namespace
+	}
+	return team, namespace
+}
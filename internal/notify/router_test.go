@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/config"
+)
+
+// recordingSink records the tasks it was asked to notify about.
+type recordingSink struct {
+	name  string
+	tasks []string
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Notify(_ context.Context, task *kubemindsv1alpha1.DiagnosisTask) error {
+	s.tasks = append(s.tasks, task.Name)
+	return nil
+}
+
+func newTask(name, namespace string, labels map[string]string) *kubemindsv1alpha1.DiagnosisTask {
+	return &kubemindsv1alpha1.DiagnosisTask{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: kubemindsv1alpha1.DiagnosisTaskSpec{
+			Target:       kubemindsv1alpha1.DiagnosisTarget{Kind: "Pod", Name: "app", Namespace: namespace},
+			AlertContext: &kubemindsv1alpha1.AlertContext{Name: "KubePodCrashLooping", Labels: labels},
+		},
+		Status: kubemindsv1alpha1.DiagnosisTaskStatus{Phase: kubemindsv1alpha1.PhaseCompleted},
+	}
+}
+
+func TestNotificationRouter_RoutesByTeamAndNamespace(t *testing.T) {
+	payments := &recordingSink{name: "payments"}
+	platform := &recordingSink{name: "platform"}
+	fallback := &recordingSink{name: "default"}
+
+	router := NewNotificationRouter(fallback).
+		AddRoute(Route{Team: "payments", Sink: payments}).
+		AddRoute(Route{Namespace: "kube-system", Sink: platform})
+
+	ctx := context.Background()
+	tasks := []*kubemindsv1alpha1.DiagnosisTask{
+		newTask("pay", "shop", map[string]string{"team": "payments"}),
+		newTask("dns", "kube-system", nil),
+		newTask("other", "shop", map[string]string{"team": "search"}),
+	}
+	for _, task := range tasks {
+		if err := router.Notify(ctx, task); err != nil {
+			t.Fatalf("Notify(%s) error = %v", task.Name, err)
+		}
+	}
+
+	for sink, want := range map[*recordingSink]string{payments: "pay", platform: "dns", fallback: "other"} {
+		if len(sink.tasks) != 1 || sink.tasks[0] != want {
+			t.Errorf("sink %s got %v, want [%s]", sink.name, sink.tasks, want)
+		}
+	}
+}
+
+func TestNotificationRouter_NoDefaultSink(t *testing.T) {
+	router := NewNotificationRouter(nil).AddRoute(Route{Team: "payments", Sink: &recordingSink{name: "payments"}})
+	task := newTask("other", "shop", nil)
+	if sink := router.Select(task); sink != nil {
+		t.Errorf("Select() = %s, want nil", sink.Name())
+	}
+	if err := router.Notify(context.Background(), task); err != nil {
+		t.Errorf("Notify() error = %v, want nil when no sink is selected", err)
+	}
+}
+
+func TestNotificationRouter_TicketSinks(t *testing.T) {
+	payments := &recordingSink{name: "payments"}
+	jira := &recordingSink{name: "jira"}
+	router := NewNotificationRouter(nil).
+		AddRoute(Route{Team: "payments", Sink: payments}).
+		AddTicketSink(jira)
+
+	ctx := context.Background()
+	for _, task := range []*kubemindsv1alpha1.DiagnosisTask{
+		newTask("pay", "shop", map[string]string{"team": "payments"}),
+		newTask("other", "shop", nil),
+	} {
+		if err := router.Notify(ctx, task); err != nil {
+			t.Fatalf("Notify(%s) error = %v", task.Name, err)
+		}
+	}
+	if len(payments.tasks) != 1 || payments.tasks[0] != "pay" {
+		t.Errorf("routed sink got %v, want [pay]", payments.tasks)
+	}
+	if len(jira.tasks) != 2 {
+		t.Errorf("ticket sink got %v, want both tasks", jira.tasks)
+	}
+}
+
+func TestNewRouterFromConfig_SlackSink(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	router, err := NewRouterFromConfig(config.NotificationsConfig{
+		Sinks: map[string]config.NotificationSinkConfig{
+			"payments-slack": {Type: "slack", URL: srv.URL},
+		},
+		Routes: []config.NotificationRouteConfig{{Team: "payments", Sink: "payments-slack"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRouterFromConfig() error = %v", err)
+	}
+
+	task := newTask("pay", "shop", map[string]string{"team": "payments"})
+	task.Status.Report = &kubemindsv1alpha1.DiagnosisReport{RootCause: "OOM", Suggestion: "raise limit"}
+	if err := router.Notify(context.Background(), task); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if !strings.Contains(got["text"], "default/pay") || !strings.Contains(got["text"], "OOM") {
+		t.Errorf("slack text = %q, want task name and root cause", got["text"])
+	}
+}
+
+func TestNewRouterFromConfig_Invalid(t *testing.T) {
+	tests := map[string]config.NotificationsConfig{
+		"unknown type": {Sinks: map[string]config.NotificationSinkConfig{"x": {Type: "email"}}},
+		"undeclared route sink": {
+			Sinks:  map[string]config.NotificationSinkConfig{"s": {Type: "slack", URL: "http://example"}},
+			Routes: []config.NotificationRouteConfig{{Team: "a", Sink: "missing"}},
+		},
+		"undeclared default": {
+			Sinks:       map[string]config.NotificationSinkConfig{"s": {Type: "slack", URL: "http://example"}},
+			DefaultSink: "missing",
+		},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRouterFromConfig(cfg); err == nil {
+				t.Error("NewRouterFromConfig() error = nil, want error")
+			}
+		})
+	}
+}
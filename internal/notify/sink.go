@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+)
+
+// NotificationSink delivers a notification about a DiagnosisTask to one target,
+// e.g. a Slack channel or a PagerDuty service.
+type NotificationSink interface {
+	// Name identifies the sink in logs and routing configuration.
+	Name() string
+	// Notify sends a notification describing the task's current status.
+	Notify(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) error
+}
+
+// defaultHTTPTimeout bounds each notification request.
+const defaultHTTPTimeout = 10 * time.Second
+
+// SlackSink posts a message to a Slack incoming webhook.
+type SlackSink struct {
+	name       string
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewSlackSink creates a sink posting to the given Slack incoming webhook URL.
+func NewSlackSink(name, webhookURL string) *SlackSink {
+	return &SlackSink{
+		name:       name,
+		webhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Name implements NotificationSink.
+func (s *SlackSink) Name() string { return s.name }
+
+// Notify implements NotificationSink.
+func (s *SlackSink) Notify(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) error {
+	return postJSON(ctx, s.httpClient, s.webhookURL, map[string]string{"text": Summary(task)})
+}
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutySink triggers an event on a PagerDuty service via the Events API v2.
+type PagerDutySink struct {
+	name       string
+	routingKey string
+	eventsURL  string
+	httpClient *http.Client
+}
+
+// NewPagerDutySink creates a sink for the PagerDuty service with the given
+// integration routing key. eventsURL overrides the Events API endpoint; leave
+// empty to use the default.
+func NewPagerDutySink(name, routingKey, eventsURL string) *PagerDutySink {
+	if eventsURL == "" {
+		eventsURL = pagerDutyEventsURL
+	}
+	return &PagerDutySink{
+		name:       name,
+		routingKey: routingKey,
+		eventsURL:  eventsURL,
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Name implements NotificationSink.
+func (s *PagerDutySink) Name() string { return s.name }
+
+// Notify implements NotificationSink. The task's namespace/name is the dedup key,
+// so repeated notifications for one task update a single incident.
+func (s *PagerDutySink) Notify(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) error {
+	severity := "warning"
+	if task.Spec.AlertContext != nil && task.Spec.AlertContext.Labels["severity"] == "critical" {
+		severity = "critical"
+	}
+	return postJSON(ctx, s.httpClient, s.eventsURL, map[string]any{
+		"routing_key":  s.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    task.Namespace + "/" + task.Name,
+		"payload": map[string]string{
+			"summary":  Summary(task),
+			"source":   "kubeminds",
+			"severity": severity,
+		},
+	})
+}
+
+// Summary renders a one-line, human-readable description of the task's status.
+func Summary(task *kubemindsv1alpha1.DiagnosisTask) string {
+	s := fmt.Sprintf("[%s] DiagnosisTask %s/%s", task.Status.Phase, task.Namespace, task.Name)
+	if task.Spec.AlertContext != nil && task.Spec.AlertContext.Name != "" {
+		s += " (" + task.Spec.AlertContext.Name + ")"
+	}
+	if r := task.Status.Report; r != nil {
+		s += fmt.Sprintf(": %s — %s", r.RootCause, r.Suggestion)
+	} else if task.Status.Message != "" {
+		s += ": " + task.Status.Message
+	}
+	return s
+}
+
+// postJSON POSTs body as JSON and treats any non-2xx response as an error.
+func postJSON(ctx context.Context, c *http.Client, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("send notification: status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/config"
+)
+
+// Ticket is the structured payload filed with a ticketing system (Jira, ServiceNow)
+// for a completed diagnosis.
+type Ticket struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// Built-in ticket templates, used for fields the sink config leaves empty.
+const (
+	defaultTicketTitle       = `[kubeminds] {{or .AlertName "Diagnosis"}} on {{.Target}}`
+	defaultTicketSeverity    = `{{or .Severity "warning"}}`
+	defaultTicketDescription = `DiagnosisTask {{.Namespace}}/{{.Name}} diagnosed {{.Target}}.
+{{with .Report}}
+Root cause: {{.RootCause}}
+
+Suggestion: {{.Suggestion}}
+{{- if .ConfidencePercent}}
+
+Confidence: {{deref .ConfidencePercent}}%
+{{- end}}
+{{- if .ActionsTaken}}
+
+Actions taken:
+{{- range .ActionsTaken}}
+- {{.Tool}}{{if .Target}} {{.Target}}{{end}}
+{{- end}}
+{{- end}}
+{{- end}}`
+)
+
+var defaultTicketLabels = []string{"kubeminds", "{{.AlertName}}", "{{.Skill}}"}
+
+// TicketData is the data ticket templates are executed with.
+type TicketData struct {
+	Name      string
+	Namespace string
+	// Target is the diagnosed resource as Kind namespace/name.
+	Target    string
+	AlertName string
+	// Severity is the alert's severity label.
+	Severity string
+	Skill    string
+	// Labels and Annotations are the alert's.
+	Labels      map[string]string
+	Annotations map[string]string
+	Report      *kubemindsv1alpha1.DiagnosisReport
+}
+
+// TicketTemplate renders diagnoses into Tickets.
+type TicketTemplate struct {
+	title       *template.Template
+	description *template.Template
+	severity    *template.Template
+	labels      []*template.Template
+}
+
+// ticketFuncs are available to ticket templates in addition to the text/template builtins.
+var ticketFuncs = template.FuncMap{
+	"deref": func(p *int) int { return *p },
+}
+
+// NewTicketTemplate parses the templates in cfg, falling back to the built-in
+// templates for empty fields.
+func NewTicketTemplate(cfg config.TicketTemplateConfig) (*TicketTemplate, error) {
+	parse := func(name, text, fallback string) (*template.Template, error) {
+		if text == "" {
+			text = fallback
+		}
+		tmpl, err := template.New(name).Funcs(ticketFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parse ticket %s template: %w", name, err)
+		}
+		return tmpl, nil
+	}
+
+	t := &TicketTemplate{}
+	var err error
+	if t.title, err = parse("title", cfg.Title, defaultTicketTitle); err != nil {
+		return nil, err
+	}
+	if t.description, err = parse("description", cfg.Description, defaultTicketDescription); err != nil {
+		return nil, err
+	}
+	if t.severity, err = parse("severity", cfg.Severity, defaultTicketSeverity); err != nil {
+		return nil, err
+	}
+	labels := cfg.Labels
+	if len(labels) == 0 {
+		labels = defaultTicketLabels
+	}
+	for i, text := range labels {
+		tmpl, err := parse(fmt.Sprintf("labels[%d]", i), text, "")
+		if err != nil {
+			return nil, err
+		}
+		t.labels = append(t.labels, tmpl)
+	}
+	return t, nil
+}
+
+// Render renders task into a Ticket. Labels that render empty are dropped; an
+// empty title is an error since ticketing systems require one.
+func (t *TicketTemplate) Render(task *kubemindsv1alpha1.DiagnosisTask) (*Ticket, error) {
+	data := newTicketData(task)
+	execute := func(tmpl *template.Template) (string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("render ticket %s: %w", tmpl.Name(), err)
+		}
+		return strings.TrimSpace(buf.String()), nil
+	}
+
+	ticket := &Ticket{}
+	var err error
+	if ticket.Title, err = execute(t.title); err != nil {
+		return nil, err
+	}
+	if ticket.Title == "" {
+		return nil, fmt.Errorf("render ticket: title is empty")
+	}
+	if ticket.Description, err = execute(t.description); err != nil {
+		return nil, err
+	}
+	if ticket.Severity, err = execute(t.severity); err != nil {
+		return nil, err
+	}
+	for _, tmpl := range t.labels {
+		label, err := execute(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if label != "" {
+			ticket.Labels = append(ticket.Labels, label)
+		}
+	}
+	return ticket, nil
+}
+
+// newTicketData collects the template data for task.
+func newTicketData(task *kubemindsv1alpha1.DiagnosisTask) TicketData {
+	target := task.Spec.Target
+	data := TicketData{
+		Name:      task.Name,
+		Namespace: task.Namespace,
+		Target:    strings.TrimSpace(target.Kind + " " + target.Namespace + "/" + target.Name),
+		Skill:     task.Status.MatchedSkill,
+		Report:    task.Status.Report,
+	}
+	if ac := task.Spec.AlertContext; ac != nil {
+		data.AlertName = ac.Name
+		data.Severity = ac.Labels["severity"]
+		data.Labels = ac.Labels
+		data.Annotations = ac.Annotations
+	}
+	return data
+}
+
+// TicketSink files a ticket for each completed diagnosis by POSTing the rendered
+// Ticket as JSON to a ticketing webhook. Other phases are not filed.
+type TicketSink struct {
+	name       string
+	webhookURL string
+	template   *TicketTemplate
+	httpClient *http.Client
+}
+
+// NewTicketSink creates a sink posting tickets rendered with tmpl to webhookURL.
+func NewTicketSink(name, webhookURL string, tmpl *TicketTemplate) *TicketSink {
+	return &TicketSink{
+		name:       name,
+		webhookURL: webhookURL,
+		template:   tmpl,
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Name implements NotificationSink.
+func (s *TicketSink) Name() string { return s.name }
+
+// Notify implements NotificationSink.
+func (s *TicketSink) Notify(ctx context.Context, task *kubemindsv1alpha1.DiagnosisTask) error {
+	if task.Status.Phase != kubemindsv1alpha1.PhaseCompleted {
+		return nil
+	}
+	ticket, err := s.template.Render(task)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.httpClient, s.webhookURL, ticket)
+}
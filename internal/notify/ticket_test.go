@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	kubemindsv1alpha1 "kubeminds/api/v1alpha1"
+	"kubeminds/internal/config"
+)
+
+func newCompletedTask() *kubemindsv1alpha1.DiagnosisTask {
+	confidence := 80
+	task := newTask("pay", "shop", map[string]string{"severity": "critical", "team": "payments"})
+	task.Status.MatchedSkill = "oom_skill"
+	task.Status.Report = &kubemindsv1alpha1.DiagnosisReport{
+		RootCause:         "container exceeded its 256Mi memory limit",
+		Suggestion:        "raise the memory limit to 512Mi",
+		ConfidencePercent: &confidence,
+		ActionsTaken:      []kubemindsv1alpha1.RemediationAction{{Tool: "delete_pod", Target: "shop/app"}},
+	}
+	return task
+}
+
+func TestTicketTemplate_RenderDefaults(t *testing.T) {
+	tmpl, err := NewTicketTemplate(config.TicketTemplateConfig{})
+	if err != nil {
+		t.Fatalf("NewTicketTemplate() error = %v", err)
+	}
+	ticket, err := tmpl.Render(newCompletedTask())
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if want := "[kubeminds] KubePodCrashLooping on Pod shop/app"; ticket.Title != want {
+		t.Errorf("Title = %q, want %q", ticket.Title, want)
+	}
+	if ticket.Severity != "critical" {
+		t.Errorf("Severity = %q, want critical", ticket.Severity)
+	}
+	for _, want := range []string{"default/pay", "256Mi memory limit", "raise the memory limit", "Confidence: 80%", "- delete_pod shop/app"} {
+		if !strings.Contains(ticket.Description, want) {
+			t.Errorf("Description = %q, want it to contain %q", ticket.Description, want)
+		}
+	}
+	if want := []string{"kubeminds", "KubePodCrashLooping", "oom_skill"}; !slices.Equal(ticket.Labels, want) {
+		t.Errorf("Labels = %v, want %v", ticket.Labels, want)
+	}
+}
+
+func TestTicketTemplate_RenderCustom(t *testing.T) {
+	tmpl, err := NewTicketTemplate(config.TicketTemplateConfig{
+		Title:    "{{.Labels.team}}: {{.Report.RootCause}}",
+		Severity: `{{if eq .Severity "critical"}}P1{{else}}P3{{end}}`,
+		Labels:   []string{"team-{{.Labels.team}}", "{{.Labels.missing}}"},
+	})
+	if err != nil {
+		t.Fatalf("NewTicketTemplate() error = %v", err)
+	}
+	ticket, err := tmpl.Render(newCompletedTask())
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "payments: container exceeded its 256Mi memory limit"; ticket.Title != want {
+		t.Errorf("Title = %q, want %q", ticket.Title, want)
+	}
+	if ticket.Severity != "P1" {
+		t.Errorf("Severity = %q, want P1", ticket.Severity)
+	}
+	if ticket.Description == "" {
+		t.Error("Description is empty, want the built-in description")
+	}
+	if want := []string{"team-payments"}; !slices.Equal(ticket.Labels, want) {
+		t.Errorf("Labels = %v, want %v (empty labels dropped)", ticket.Labels, want)
+	}
+}
+
+func TestTicketTemplate_Errors(t *testing.T) {
+	if _, err := NewTicketTemplate(config.TicketTemplateConfig{Title: "{{.Name"}); err == nil {
+		t.Error("NewTicketTemplate() with a malformed template error = nil, want error")
+	}
+
+	tmpl, err := NewTicketTemplate(config.TicketTemplateConfig{Title: "{{.Labels.missing}}"})
+	if err != nil {
+		t.Fatalf("NewTicketTemplate() error = %v", err)
+	}
+	if _, err := tmpl.Render(newCompletedTask()); err == nil {
+		t.Error("Render() with an empty title error = nil, want error")
+	}
+}
+
+func TestNewRouterFromConfig_TicketSink(t *testing.T) {
+	var tickets []Ticket
+	var slackPosts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slack" {
+			slackPosts++
+			return
+		}
+		var ticket Ticket
+		_ = json.NewDecoder(r.Body).Decode(&ticket)
+		tickets = append(tickets, ticket)
+	}))
+	defer srv.Close()
+
+	router, err := NewRouterFromConfig(config.NotificationsConfig{
+		Sinks: map[string]config.NotificationSinkConfig{
+			"slack": {Type: "slack", URL: srv.URL + "/slack"},
+			"jira":  {Type: "ticket", URL: srv.URL + "/jira"},
+		},
+		DefaultSink: "slack",
+		Tickets:     []string{"jira"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouterFromConfig() error = %v", err)
+	}
+
+	failed := newCompletedTask()
+	failed.Status.Phase = kubemindsv1alpha1.PhaseFailed
+	for _, task := range []*kubemindsv1alpha1.DiagnosisTask{newCompletedTask(), failed} {
+		if err := router.Notify(context.Background(), task); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if slackPosts != 2 {
+		t.Errorf("slack notifications = %d, want 2 (tickets do not replace the routed sink)", slackPosts)
+	}
+	if len(tickets) != 1 {
+		t.Fatalf("tickets filed = %d, want 1 (only the completed task)", len(tickets))
+	}
+	if tickets[0].Title == "" || tickets[0].Description == "" || tickets[0].Severity != "critical" {
+		t.Errorf("ticket = %+v, want title, description and critical severity", tickets[0])
+	}
+
+	for name, cfg := range map[string]config.NotificationsConfig{
+		"ticket sink without url": {
+			Sinks:   map[string]config.NotificationSinkConfig{"jira": {Type: "ticket"}},
+			Tickets: []string{"jira"},
+		},
+		"routed ticket sink": {
+			Sinks:       map[string]config.NotificationSinkConfig{"jira": {Type: "ticket", URL: srv.URL}},
+			DefaultSink: "jira",
+		},
+		"tickets naming a slack sink": {
+			Sinks:   map[string]config.NotificationSinkConfig{"slack": {Type: "slack", URL: srv.URL}},
+			Tickets: []string{"slack"},
+		},
+		"tickets naming an undeclared sink": {
+			Sinks:   map[string]config.NotificationSinkConfig{"slack": {Type: "slack", URL: srv.URL}},
+			Tickets: []string{"jira"},
+		},
+	} {
+		if _, err := NewRouterFromConfig(cfg); err == nil {
+			t.Errorf("NewRouterFromConfig() with %s error = nil, want error", name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"kubeminds/internal/agent"
+	"kubeminds/internal/metrics"
+)
+
+// Tool execution results used as the "result" label value.
+const (
+	toolResultSuccess = "success"
+	toolResultError   = "error"
+)
+
+// ToolExecutionDuration measures how long each tool's Execute takes. For the
+// built-in tools this is dominated by Kubernetes API latency, which makes it the
+// counterpart to LLM latency when working out where a slow diagnosis spent its time.
+var ToolExecutionDuration = metrics.NewLatencyHistogramVec(
+	"kubeminds_tool_execution_duration_seconds",
+	"Duration of tool executions (Kubernetes API latency for built-in tools), by tool and result.",
+	[]string{"tool", "result"},
+)
+
+// InstrumentedTool wraps a Tool and records the duration of every Execute call
+// in ToolExecutionDuration.
+type InstrumentedTool struct {
+	agent.Tool
+	observer prometheus.ObserverVec
+}
+
+// NewInstrumentedTool wraps tool so its executions are recorded in ToolExecutionDuration.
+func NewInstrumentedTool(tool agent.Tool) *InstrumentedTool {
+	return &InstrumentedTool{Tool: tool, observer: ToolExecutionDuration}
+}
+
+// Execute runs the wrapped tool and observes its latency labeled by tool name and result.
+func (t *InstrumentedTool) Execute(ctx context.Context, args string) (string, error) {
+	start := time.Now()
+	out, err := t.Tool.Execute(ctx, args)
+
+	result := toolResultSuccess
+	if err != nil {
+		result = toolResultError
+	}
+	t.observer.WithLabelValues(t.Name(), result).Observe(time.Since(start).Seconds())
+	return out, err
+}
+
+// reversibleInstrumentedTool is the InstrumentedTool of an agent.ReversibleTool.
+// The embedded agent.Tool hides RollbackArgs, so it is forwarded explicitly.
+type reversibleInstrumentedTool struct {
+	*InstrumentedTool
+	reversible agent.ReversibleTool
+}
+
+// RollbackArgs implements agent.ReversibleTool.
+func (t *reversibleInstrumentedTool) RollbackArgs(ctx context.Context, args string) (string, error) {
+	return t.reversible.RollbackArgs(ctx, args)
+}
+
+// instrumentTool wraps tool in an InstrumentedTool that keeps it reversible if it was.
+func instrumentTool(tool agent.Tool) agent.Tool {
+	instrumented := NewInstrumentedTool(tool)
+	if reversible, ok := tool.(agent.ReversibleTool); ok {
+		return &reversibleInstrumentedTool{InstrumentedTool: instrumented, reversible: reversible}
+	}
+	return instrumented
+}
+
+// instrumentTools wraps every tool in an InstrumentedTool.
+func instrumentTools(tools []agent.Tool) []agent.Tool {
+	wrapped := make([]agent.Tool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = instrumentTool(tool)
+	}
+	return wrapped
+}
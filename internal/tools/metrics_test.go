@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kubeminds/internal/agent"
+)
+
+// sampleCount returns how many observations the histogram for the given labels holds.
+func sampleCount(t *testing.T, vec *prometheus.HistogramVec, tool, result string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := vec.WithLabelValues(tool, result).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentedTool_ObservesLatency(t *testing.T) {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_tool_duration_seconds"}, []string{"tool", "result"})
+
+	ok := &InstrumentedTool{Tool: &agent.MockTool{NameVal: "ok_tool"}, observer: vec}
+	if _, err := ok.Execute(context.Background(), "{}"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	failing := &InstrumentedTool{Tool: &agent.MockTool{NameVal: "bad_tool", ExecuteFunc: func(context.Context, string) (string, error) { return "", errors.New("boom") }}, observer: vec}
+	if _, err := failing.Execute(context.Background(), "{}"); err == nil {
+		t.Fatal("Execute: expected error from wrapped tool")
+	}
+
+	if got := sampleCount(t, vec, "ok_tool", toolResultSuccess); got != 1 {
+		t.Errorf("ok_tool success samples = %d, want 1", got)
+	}
+	if got := sampleCount(t, vec, "bad_tool", toolResultError); got != 1 {
+		t.Errorf("bad_tool error samples = %d, want 1", got)
+	}
+}
+
+func TestInternalProvider_ToolsAreInstrumented(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	tools, err := NewInternalProvider(clientset, nil).ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+
+	var restarts agent.Tool
+	for _, tool := range tools {
+		switch tool.(type) {
+		case *InstrumentedTool, *reversibleInstrumentedTool:
+		default:
+			t.Errorf("tool %q is not instrumented", tool.Name())
+		}
+		if tool.Name() == "get_container_restarts" {
+			restarts = tool
+		}
+	}
+	if restarts == nil {
+		t.Fatal("get_container_restarts not found")
+	}
+
+	before := sampleCount(t, ToolExecutionDuration, "get_container_restarts", toolResultError)
+	// The pod does not exist, so the fake API call fails and an error sample is recorded.
+	_, _ = restarts.Execute(context.Background(), `{"namespace":"default","pod_name":"missing"}`)
+	if got := sampleCount(t, ToolExecutionDuration, "get_container_restarts", toolResultError); got != before+1 {
+		t.Errorf("samples = %d, want %d", got, before+1)
+	}
+}
+
+func TestInternalProvider_ReversibleToolsRollBack(t *testing.T) {
+	replicas := int32(3)
+	clientset := fake.NewSimpleClientset(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	})
+	// The fake clientset does not implement the scale subresource; apply the writes instead.
+	clientset.PrependReactor("update", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		replicas = scale.Spec.Replicas
+		return true, scale, nil
+	})
+
+	tools, err := NewInternalProvider(clientset, nil).ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	var scale agent.ReversibleTool
+	for _, tool := range tools {
+		if tool.Name() == "scale_statefulset" {
+			reversible, ok := tool.(agent.ReversibleTool)
+			if !ok {
+				t.Fatal("instrumented scale_statefulset is not an agent.ReversibleTool")
+			}
+			scale = reversible
+		}
+	}
+	if scale == nil {
+		t.Fatal("scale_statefulset not found")
+	}
+
+	ctx := context.Background()
+	args := `{"namespace":"default","statefulset_name":"db","replicas":0}`
+	rollback, err := scale.RollbackArgs(ctx, args)
+	if err != nil {
+		t.Fatalf("RollbackArgs: %v", err)
+	}
+	if _, err := scale.Execute(ctx, args); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if replicas != 0 {
+		t.Fatalf("replicas after scaling = %d, want 0", replicas)
+	}
+	if _, err := scale.Execute(ctx, rollback); err != nil {
+		t.Fatalf("Execute rollback: %v", err)
+	}
+	if replicas != 3 {
+		t.Errorf("replicas after rollback = %d, want 3", replicas)
+	}
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"kubeminds/internal/agent"
+)
+
+// maxOwnerChainDepth bounds the ownerReferences walk in case of a reference cycle.
+const maxOwnerChainDepth = 10
+
+// GetOwnerChainTool implements the get_owner_chain tool
+type GetOwnerChainTool struct {
+	client kubernetes.Interface
+}
+
+func NewGetOwnerChainTool(client kubernetes.Interface) *GetOwnerChainTool {
+	return &GetOwnerChainTool{client: client}
+}
+
+func (t *GetOwnerChainTool) Name() string {
+	return "get_owner_chain"
+}
+
+func (t *GetOwnerChainTool) Description() string {
+	return "Get the ownership chain of a pod by following ownerReferences up to its top-level controller, e.g. Pod -> ReplicaSet -> Deployment. Use this to find which workload to inspect or fix instead of looking up each owner separately."
+}
+
+func (t *GetOwnerChainTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the pod"
+			},
+			"pod_name": {
+				"type": "string",
+				"description": "The name of the pod"
+			}
+		},
+		"required": ["namespace", "pod_name"]
+	}`
+}
+
+func (t *GetOwnerChainTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *GetOwnerChainTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs PodArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	pod, err := t.client.CoreV1().Pods(parsedArgs.Namespace).Get(ctx, parsedArgs.PodName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	chain := []string{"Pod " + pod.Name}
+	top := chain[0]
+	refs := pod.OwnerReferences
+	var note string
+	for depth := 0; len(refs) > 0; depth++ {
+		if depth == maxOwnerChainDepth {
+			note = fmt.Sprintf("stopped after %d owners", maxOwnerChainDepth)
+			break
+		}
+		ref := controllerRef(refs)
+		owner := ref.Kind + " " + ref.Name
+		chain = append(chain, owner)
+		top = owner
+
+		refs, err = t.ownerReferences(ctx, parsedArgs.Namespace, ref)
+		if apierrors.IsNotFound(err) {
+			note = fmt.Sprintf("%s no longer exists", owner)
+			break
+		}
+		if err != nil {
+			note = err.Error()
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Owner chain (namespace %s): %s\n", parsedArgs.Namespace, strings.Join(chain, " -> "))
+	if len(chain) == 1 {
+		b.WriteString("The pod has no owner (a bare pod).\n")
+	} else {
+		fmt.Fprintf(&b, "Top-level controller: %s\n", top)
+	}
+	if note != "" {
+		fmt.Fprintf(&b, "Note: %s\n", note)
+	}
+	return b.String(), nil
+}
+
+// controllerRef returns the managing controller among refs, or the first reference
+// when none is marked as controller.
+func controllerRef(refs []metav1.OwnerReference) metav1.OwnerReference {
+	for _, ref := range refs {
+		if ref.Controller != nil && *ref.Controller {
+			return ref
+		}
+	}
+	return refs[0]
+}
+
+// ownerReferences fetches the owner described by ref and returns its own owner
+// references. Kinds the tool cannot follow return an error naming the kind.
+func (t *GetOwnerChainTool) ownerReferences(ctx context.Context, namespace string, ref metav1.OwnerReference) ([]metav1.OwnerReference, error) {
+	var obj metav1.Object
+	var err error
+	switch ref.Kind {
+	case "ReplicaSet":
+		obj, err = t.client.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Deployment":
+		obj, err = t.client.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "StatefulSet":
+		obj, err = t.client.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "DaemonSet":
+		obj, err = t.client.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Job":
+		obj, err = t.client.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "CronJob":
+		obj, err = t.client.BatchV1().CronJobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "ReplicationController":
+		obj, err = t.client.CoreV1().ReplicationControllers(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Node":
+		// Static (mirror) pods are owned by their node, which has no owner itself.
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("owners of kind %s (%s) are not followed further", ref.Kind, ref.APIVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return obj.GetOwnerReferences(), nil
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedBy(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, Controller: &controller}}
+}
+
+func TestGetOwnerChainTool(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-7d9f8-abcde", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "web-7d9f8")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-7d9f8", Namespace: "default", OwnerReferences: ownedBy("Deployment", "web")}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "orphan-abcde", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "gone")}},
+	)
+	tool := NewGetOwnerChainTool(client)
+	ctx := context.Background()
+
+	t.Run("pod to replicaset to deployment", func(t *testing.T) {
+		out, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"web-7d9f8-abcde"}`)
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		for _, want := range []string{
+			"Pod web-7d9f8-abcde -> ReplicaSet web-7d9f8 -> Deployment web",
+			"Top-level controller: Deployment web",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("bare pod", func(t *testing.T) {
+		out, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"bare"}`)
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		if !strings.Contains(out, "no owner") {
+			t.Errorf("expected a bare pod note, got:\n%s", out)
+		}
+	})
+
+	t.Run("missing owner is reported", func(t *testing.T) {
+		out, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"orphan-abcde"}`)
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		if !strings.Contains(out, "ReplicaSet gone no longer exists") {
+			t.Errorf("expected a missing owner note, got:\n%s", out)
+		}
+	})
+
+	t.Run("missing pod returns error", func(t *testing.T) {
+		if _, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"missing"}`); err == nil {
+			t.Fatal("expected error for missing pod")
+		}
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,8 +18,15 @@ import (
 type PodArgs struct {
 	Namespace string `json:"namespace"`
 	PodName   string `json:"pod_name"`
+	// Container, Previous and TailLines are only used by get_pod_logs.
+	Container string `json:"container,omitempty"`
+	Previous  bool   `json:"previous,omitempty"`
+	TailLines int64  `json:"tail_lines,omitempty"`
 }
 
+// defaultLogTailLines is how many log lines get_pod_logs returns when tail_lines is unset.
+const defaultLogTailLines int64 = 100
+
 // GetPodLogsTool implements the get_pod_logs tool
 type GetPodLogsTool struct {
 	client kubernetes.Interface
@@ -32,7 +41,7 @@ func (t *GetPodLogsTool) Name() string {
 }
 
 func (t *GetPodLogsTool) Description() string {
-	return "Get logs from a specific pod in a namespace. Use this to analyze application errors and stack traces."
+	return "Get logs from a specific pod in a namespace. Use this to analyze application errors and stack traces. Set previous=true to read the last terminated instance of a restarting (e.g. CrashLoopBackOff) container, and container to pick one container of a multi-container pod."
 }
 
 func (t *GetPodLogsTool) Schema() string {
@@ -46,6 +55,18 @@ func (t *GetPodLogsTool) Schema() string {
 			"pod_name": {
 				"type": "string",
 				"description": "The name of the pod"
+			},
+			"container": {
+				"type": "string",
+				"description": "The container to read logs from. Required when the pod has more than one container"
+			},
+			"previous": {
+				"type": "boolean",
+				"description": "Read the logs of the previous, terminated container instance instead of the current one"
+			},
+			"tail_lines": {
+				"type": "integer",
+				"description": "Number of lines to return from the end of the log (default 100)"
 			}
 		},
 		"required": ["namespace", "pod_name"]
@@ -62,8 +83,31 @@ func (t *GetPodLogsTool) Execute(ctx context.Context, args string) (string, erro
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
+	if parsedArgs.Container == "" {
+		// The API rejects a log request without a container for multi-container pods
+		// with a terse error; list the choices so the agent can retry with one.
+		pod, err := t.client.CoreV1().Pods(parsedArgs.Namespace).Get(ctx, parsedArgs.PodName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get pod: %w", err)
+		}
+		if len(pod.Spec.Containers) > 1 {
+			names := make([]string, 0, len(pod.Spec.Containers))
+			for _, c := range pod.Spec.Containers {
+				names = append(names, c.Name)
+			}
+			return "", fmt.Errorf("pod %s/%s has %d containers, specify one with the container argument: %s",
+				parsedArgs.Namespace, parsedArgs.PodName, len(names), strings.Join(names, ", "))
+		}
+	}
+
+	tailLines := parsedArgs.TailLines
+	if tailLines <= 0 {
+		tailLines = defaultLogTailLines
+	}
 	req := t.client.CoreV1().Pods(parsedArgs.Namespace).GetLogs(parsedArgs.PodName, &corev1.PodLogOptions{
-		TailLines: func(i int64) *int64 { return &i }(100), // Default to last 100 lines for MVP
+		Container: parsedArgs.Container,
+		Previous:  parsedArgs.Previous,
+		TailLines: &tailLines,
 	})
 
 	podLogs, err := req.Stream(ctx)
@@ -81,6 +125,21 @@ func (t *GetPodLogsTool) Execute(ctx context.Context, args string) (string, erro
 	return buf.String(), nil
 }
 
+const (
+	// defaultPodEventsSince is how far back get_pod_events looks when since is unset.
+	defaultPodEventsSince = time.Hour
+	// defaultPodEventsMax is how many of the most recent events get_pod_events returns
+	// when max_events is unset.
+	defaultPodEventsMax = 50
+)
+
+type getPodEventsArgs struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+	Since     string `json:"since,omitempty"`
+	MaxEvents int    `json:"max_events,omitempty"`
+}
+
 // GetPodEventsTool implements the get_pod_events tool
 type GetPodEventsTool struct {
 	client kubernetes.Interface
@@ -95,7 +154,7 @@ func (t *GetPodEventsTool) Name() string {
 }
 
 func (t *GetPodEventsTool) Description() string {
-	return "Get events related to a specific pod. Use this to identify scheduling issues, image pull errors, or restart reasons."
+	return "Get recent events related to a specific pod, newest last (default: the last hour, at most 50 events). Use this to identify scheduling issues, image pull errors, or restart reasons."
 }
 
 func (t *GetPodEventsTool) Schema() string {
@@ -109,6 +168,14 @@ func (t *GetPodEventsTool) Schema() string {
 			"pod_name": {
 				"type": "string",
 				"description": "The name of the pod"
+			},
+			"since": {
+				"type": "string",
+				"description": "Only return events last seen within this duration, e.g. \"30m\" or \"24h\" (default 1h)"
+			},
+			"max_events": {
+				"type": "integer",
+				"description": "Maximum number of the most recent events to return (default 50)"
 			}
 		},
 		"required": ["namespace", "pod_name"]
@@ -120,10 +187,22 @@ func (t *GetPodEventsTool) SafetyLevel() agent.SafetyLevel {
 }
 
 func (t *GetPodEventsTool) Execute(ctx context.Context, args string) (string, error) {
-	var parsedArgs PodArgs
+	var parsedArgs getPodEventsArgs
 	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
+	since := defaultPodEventsSince
+	if parsedArgs.Since != "" {
+		d, err := time.ParseDuration(parsedArgs.Since)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("invalid since %q: must be a positive duration such as \"30m\"", parsedArgs.Since)
+		}
+		since = d
+	}
+	maxEvents := parsedArgs.MaxEvents
+	if maxEvents <= 0 {
+		maxEvents = defaultPodEventsMax
+	}
 
 	events, err := t.client.CoreV1().Events(parsedArgs.Namespace).List(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", parsedArgs.PodName),
@@ -136,11 +215,45 @@ func (t *GetPodEventsTool) Execute(ctx context.Context, args string) (string, er
 		return "No events found for this pod.", nil
 	}
 
-	var result string
+	cutoff := time.Now().Add(-since)
+	var recent []corev1.Event
 	for _, e := range events.Items {
-		result += fmt.Sprintf("[%s] %s: %s\n", e.Type, e.Reason, e.Message)
+		if !eventLastSeen(e).Before(cutoff) {
+			recent = append(recent, e)
+		}
 	}
-	return result, nil
+	if len(recent) == 0 {
+		return fmt.Sprintf("No events for this pod in the last %s (%d older events omitted).", since, len(events.Items)), nil
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return eventLastSeen(recent[i]).Before(eventLastSeen(recent[j]))
+	})
+
+	var result strings.Builder
+	if omitted := len(events.Items) - min(len(recent), maxEvents); omitted > 0 {
+		fmt.Fprintf(&result, "Showing the %d most recent events of the last %s (%d omitted).\n", min(len(recent), maxEvents), since, omitted)
+	}
+	if len(recent) > maxEvents {
+		recent = recent[len(recent)-maxEvents:]
+	}
+	for _, e := range recent {
+		fmt.Fprintf(&result, "%s [%s] %s: %s\n", eventLastSeen(e).Format(time.RFC3339), e.Type, e.Reason, e.Message)
+	}
+	return result.String(), nil
+}
+
+// eventLastSeen is when an event last occurred: its LastTimestamp, falling back to
+// EventTime (events.k8s.io clients) and then FirstTimestamp or creation time.
+func eventLastSeen(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	}
+	return e.CreationTimestamp.Time
 }
 
 // GetPodSpecTool implements the get_pod_spec tool
@@ -202,3 +315,106 @@ func (t *GetPodSpecTool) Execute(ctx context.Context, args string) (string, erro
 
 	return string(data), nil
 }
+
+// GetContainerRestartsTool implements the get_container_restarts tool
+type GetContainerRestartsTool struct {
+	client kubernetes.Interface
+}
+
+func NewGetContainerRestartsTool(client kubernetes.Interface) *GetContainerRestartsTool {
+	return &GetContainerRestartsTool{client: client}
+}
+
+func (t *GetContainerRestartsTool) Name() string {
+	return "get_container_restarts"
+}
+
+func (t *GetContainerRestartsTool) Description() string {
+	return "Get the restart history of each container in a pod: restart count, last exit code, last termination reason (e.g. OOMKilled, Error) and time since the last restart. Prefer this over get_pod_spec when diagnosing CrashLoopBackOff."
+}
+
+func (t *GetContainerRestartsTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the pod"
+			},
+			"pod_name": {
+				"type": "string",
+				"description": "The name of the pod"
+			}
+		},
+		"required": ["namespace", "pod_name"]
+	}`
+}
+
+func (t *GetContainerRestartsTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *GetContainerRestartsTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs PodArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	pod, err := t.client.CoreV1().Pods(parsedArgs.Namespace).Get(ctx, parsedArgs.PodName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	if len(statuses) == 0 {
+		return "No container statuses reported for this pod.", nil
+	}
+
+	var sb strings.Builder
+	for _, cs := range statuses {
+		fmt.Fprintf(&sb, "container=%s restarts=%d state=%s", cs.Name, cs.RestartCount, containerStateName(cs.State))
+		if term := cs.LastTerminationState.Terminated; term != nil {
+			fmt.Fprintf(&sb, " lastExitCode=%d lastReason=%s", term.ExitCode, term.Reason)
+		}
+		if since := timeSinceLastRestart(cs); since != "" {
+			fmt.Fprintf(&sb, " lastRestart=%s ago", since)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// containerStateName returns a short label for a container's current state,
+// including the waiting/terminated reason when present.
+func containerStateName(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "Running"
+	case state.Waiting != nil:
+		return "Waiting(" + state.Waiting.Reason + ")"
+	case state.Terminated != nil:
+		return "Terminated(" + state.Terminated.Reason + ")"
+	default:
+		return "Unknown"
+	}
+}
+
+// timeSinceLastRestart approximates when the container last restarted: the
+// start of the current run, or the end of the last run if it is not running.
+func timeSinceLastRestart(cs corev1.ContainerStatus) string {
+	if cs.RestartCount == 0 {
+		return ""
+	}
+	var at time.Time
+	switch {
+	case cs.State.Running != nil:
+		at = cs.State.Running.StartedAt.Time
+	case cs.LastTerminationState.Terminated != nil:
+		at = cs.LastTerminationState.Terminated.FinishedAt.Time
+	}
+	if at.IsZero() {
+		return ""
+	}
+	return time.Since(at).Round(time.Second).String()
+}
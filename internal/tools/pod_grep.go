@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubeminds/internal/agent"
+)
+
+const (
+	// defaultGrepTailLines is how many of the latest log lines grep_pod_logs searches.
+	defaultGrepTailLines int64 = 5000
+	// defaultGrepContextLines is how many lines around each match are returned.
+	defaultGrepContextLines = 2
+	// maxGrepContextLines bounds context_lines.
+	maxGrepContextLines = 10
+	// defaultGrepMaxMatches is how many matching lines are returned at most.
+	defaultGrepMaxMatches = 50
+	// maxGrepOutputBytes caps the tool output, context included.
+	maxGrepOutputBytes = 8000
+	// maxGrepLineBytes is how much of a single log line is searched and returned;
+	// the rest of a longer line is dropped.
+	maxGrepLineBytes = 1024 * 1024
+)
+
+type grepPodLogsArgs struct {
+	Namespace    string `json:"namespace"`
+	PodName      string `json:"pod_name"`
+	Pattern      string `json:"pattern"`
+	Container    string `json:"container,omitempty"`
+	Previous     bool   `json:"previous,omitempty"`
+	TailLines    int64  `json:"tail_lines,omitempty"`
+	ContextLines *int   `json:"context_lines,omitempty"`
+	MaxMatches   int    `json:"max_matches,omitempty"`
+}
+
+// GrepPodLogsTool implements the grep_pod_logs tool: it streams a pod's logs and
+// returns only the lines matching a pattern, which costs far fewer tokens than
+// reading whole logs with get_pod_logs.
+type GrepPodLogsTool struct {
+	client kubernetes.Interface
+	// openLogs opens the log stream; tests replace it with a fake stream.
+	openLogs func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+}
+
+func NewGrepPodLogsTool(client kubernetes.Interface) *GrepPodLogsTool {
+	t := &GrepPodLogsTool{client: client}
+	t.openLogs = func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		return t.client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	}
+	return t
+}
+
+func (t *GrepPodLogsTool) Name() string {
+	return "grep_pod_logs"
+}
+
+func (t *GrepPodLogsTool) Description() string {
+	return "Search a pod's logs and return only the lines matching a regular expression (e.g. \"(?i)error|panic\"), with a few lines of context around each match. Prefer this over get_pod_logs for large or noisy logs."
+}
+
+func (t *GrepPodLogsTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the pod"
+			},
+			"pod_name": {
+				"type": "string",
+				"description": "The name of the pod"
+			},
+			"pattern": {
+				"type": "string",
+				"description": "Regular expression (RE2 syntax) to match log lines against; prefix with (?i) to ignore case"
+			},
+			"container": {
+				"type": "string",
+				"description": "The container to read logs from. Required when the pod has more than one container"
+			},
+			"previous": {
+				"type": "boolean",
+				"description": "Search the logs of the previous, terminated container instance instead of the current one"
+			},
+			"tail_lines": {
+				"type": "integer",
+				"description": "Number of lines from the end of the log to search (default 5000)"
+			},
+			"context_lines": {
+				"type": "integer",
+				"description": "Lines of context returned before and after each match (default 2, max 10)"
+			},
+			"max_matches": {
+				"type": "integer",
+				"description": "Maximum number of matching lines to return (default 50)"
+			}
+		},
+		"required": ["namespace", "pod_name", "pattern"]
+	}`
+}
+
+func (t *GrepPodLogsTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *GrepPodLogsTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs grepPodLogsArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if parsedArgs.Pattern == "" {
+		return "", fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(parsedArgs.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	tailLines := parsedArgs.TailLines
+	if tailLines <= 0 {
+		tailLines = defaultGrepTailLines
+	}
+	contextLines := defaultGrepContextLines
+	if parsedArgs.ContextLines != nil {
+		contextLines = min(max(*parsedArgs.ContextLines, 0), maxGrepContextLines)
+	}
+	maxMatches := parsedArgs.MaxMatches
+	if maxMatches <= 0 {
+		maxMatches = defaultGrepMaxMatches
+	}
+
+	podLogs, err := t.openLogs(ctx, parsedArgs.Namespace, parsedArgs.PodName, &corev1.PodLogOptions{
+		Container: parsedArgs.Container,
+		Previous:  parsedArgs.Previous,
+		TailLines: &tailLines,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error in opening stream: %w", err)
+	}
+	defer podLogs.Close()
+
+	out, err := grepLines(podLogs, re, contextLines, maxMatches)
+	if err != nil {
+		return "", fmt.Errorf("error in reading stream: %w", err)
+	}
+	return out, nil
+}
+
+// grepLines returns the lines of r matching re, each prefixed with its line number,
+// plus contextLines lines before and after. Non-adjacent groups are separated by
+// "--". It stops at the match after maxMatches, or once the output reaches
+// maxGrepOutputBytes. Lines longer than maxGrepLineBytes are clipped.
+func grepLines(r io.Reader, re *regexp.Regexp, contextLines, maxMatches int) (string, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	var (
+		out       strings.Builder
+		before    []string // up to contextLines lines preceding the current one
+		after     int      // context lines still owed to the last match
+		lastLine  int      // number of the last line written, 0 for none
+		lineNo    int
+		matches   int
+		truncated bool
+	)
+	write := func(n int, line string) {
+		if lastLine != 0 && n > lastLine+1 {
+			out.WriteString("--\n")
+		}
+		fmt.Fprintf(&out, "%d: %s\n", n, line)
+		lastLine = n
+	}
+
+	for {
+		line, err := readLogLine(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		lineNo++
+		if out.Len() >= maxGrepOutputBytes {
+			truncated = true
+			break
+		}
+		if re.MatchString(line) {
+			if matches == maxMatches {
+				truncated = true
+				break
+			}
+			for i, b := range before {
+				write(lineNo-len(before)+i, b)
+			}
+			before = before[:0]
+			write(lineNo, line)
+			after = contextLines
+			matches++
+			continue
+		}
+		if after > 0 {
+			write(lineNo, line)
+			after--
+			continue
+		}
+		if contextLines > 0 {
+			if len(before) == contextLines {
+				before = before[1:]
+			}
+			before = append(before, line)
+		}
+	}
+
+	if matches == 0 {
+		return fmt.Sprintf("No log lines matched %q in the %d lines searched.", re.String(), lineNo), nil
+	}
+	result := out.String()
+	if len(result) > maxGrepOutputBytes {
+		result = truncateUTF8(result, maxGrepOutputBytes)
+		truncated = true
+	}
+	if truncated {
+		return fmt.Sprintf("First %d matching lines (output truncated; narrow the pattern or lower max_matches):\n%s", matches, result), nil
+	}
+	return fmt.Sprintf("%d matching lines in %d lines searched:\n%s", matches, lineNo, result), nil
+}
+
+// readLogLine returns the next line of r without its line ending, keeping at most
+// maxGrepLineBytes of it. It returns io.EOF only once no data is left.
+func readLogLine(r *bufio.Reader) (string, error) {
+	var (
+		line    []byte
+		clipped bool
+	)
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !clipped {
+			if room := maxGrepLineBytes - len(line); len(chunk) > room {
+				chunk = []byte(truncateUTF8(string(chunk), room))
+				clipped = true
+			}
+			line = append(line, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(line) > 0 {
+			err = nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		return string(bytes.TrimSuffix(line, []byte("\r"))), err
+	}
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
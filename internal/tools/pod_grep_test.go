@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const fakeLogStream = `starting server
+listening on :8080
+GET /healthz 200
+GET /api/orders 200
+connecting to db
+ERROR failed to connect to db: timeout
+retrying in 1s
+GET /healthz 200
+panic: nil pointer dereference
+goroutine 1 [running]
+GET /healthz 200
+GET /healthz 200
+`
+
+// fakeLogs makes tool read stream instead of the pod's logs and records the options.
+func fakeLogs(tool *GrepPodLogsTool, stream string) *corev1.PodLogOptions {
+	var got corev1.PodLogOptions
+	tool.openLogs = func(_ context.Context, _, _ string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		got = *opts
+		return io.NopCloser(strings.NewReader(stream)), nil
+	}
+	return &got
+}
+
+func TestGrepPodLogsTool(t *testing.T) {
+	tool := NewGrepPodLogsTool(fake.NewSimpleClientset())
+
+	t.Run("should return only matching lines", func(t *testing.T) {
+		opts := fakeLogs(tool, fakeLogStream)
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"(?i)error|panic","context_lines":0}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "2 matching lines in 12 lines searched:\n" +
+			"6: ERROR failed to connect to db: timeout\n" +
+			"--\n" +
+			"9: panic: nil pointer dereference\n"
+		if result != want {
+			t.Errorf("result =\n%s\nwant\n%s", result, want)
+		}
+		if opts.TailLines == nil || *opts.TailLines != defaultGrepTailLines {
+			t.Errorf("TailLines = %v, want %d", opts.TailLines, defaultGrepTailLines)
+		}
+	})
+
+	t.Run("should include surrounding context", func(t *testing.T) {
+		fakeLogs(tool, fakeLogStream)
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"panic","context_lines":1}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "1 matching lines in 12 lines searched:\n" +
+			"8: GET /healthz 200\n" +
+			"9: panic: nil pointer dereference\n" +
+			"10: goroutine 1 [running]\n"
+		if result != want {
+			t.Errorf("result =\n%s\nwant\n%s", result, want)
+		}
+	})
+
+	t.Run("should cap the number of matches", func(t *testing.T) {
+		fakeLogs(tool, fakeLogStream)
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"healthz","context_lines":0,"max_matches":2}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(result, "First 2 matching lines (output truncated") || strings.Count(result, "healthz") != 2 {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("should report no matches", func(t *testing.T) {
+		fakeLogs(tool, fakeLogStream)
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"OOMKilled"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(result, "No log lines matched") {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("should clip lines longer than the line limit", func(t *testing.T) {
+		long := "ERROR " + strings.Repeat("x", 2*maxGrepLineBytes)
+		fakeLogs(tool, "GET /healthz 200\n"+long+"\npanic: boom\n")
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"panic","context_lines":0}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "1 matching lines in 3 lines searched:\n3: panic: boom\n"; result != want {
+			t.Errorf("result =\n%s\nwant\n%s", result, want)
+		}
+	})
+
+	t.Run("should truncate output on a rune boundary", func(t *testing.T) {
+		fakeLogs(tool, "ERROR"+strings.Repeat("é", maxGrepOutputBytes)+"\n")
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"ERROR","context_lines":0}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, "output truncated") || !utf8.ValidString(result) {
+			t.Errorf("expected truncated valid UTF-8 output, got %d bytes (valid=%v)", len(result), utf8.ValidString(result))
+		}
+	})
+
+	t.Run("should reject an invalid pattern", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","pattern":"("}`); err == nil {
+			t.Error("expected an error for an invalid pattern")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
+	"kubeminds/internal/agent"
+)
+
+// GetPodMetricsTool implements the get_pod_metrics tool
+type GetPodMetricsTool struct {
+	client metricsclientset.Interface
+}
+
+// NewGetPodMetricsTool creates a get_pod_metrics tool backed by the metrics.k8s.io API
+// (served by metrics-server).
+func NewGetPodMetricsTool(client metricsclientset.Interface) *GetPodMetricsTool {
+	return &GetPodMetricsTool{client: client}
+}
+
+func (t *GetPodMetricsTool) Name() string {
+	return "get_pod_metrics"
+}
+
+func (t *GetPodMetricsTool) Description() string {
+	return "Get the current CPU and memory usage of each container in a pod, from metrics-server. Use this to compare actual usage with resource requests and limits, e.g. when diagnosing OOMKilled or CPU-throttled pods."
+}
+
+func (t *GetPodMetricsTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the pod"
+			},
+			"pod_name": {
+				"type": "string",
+				"description": "The name of the pod"
+			}
+		},
+		"required": ["namespace", "pod_name"]
+	}`
+}
+
+func (t *GetPodMetricsTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *GetPodMetricsTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs PodArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	podMetrics, err := t.client.MetricsV1beta1().PodMetricses(parsedArgs.Namespace).Get(ctx, parsedArgs.PodName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod metrics (is metrics-server installed?): %w", err)
+	}
+	if len(podMetrics.Containers) == 0 {
+		return fmt.Sprintf("No container metrics reported for pod %s/%s yet", parsedArgs.Namespace, parsedArgs.PodName), nil
+	}
+
+	var b strings.Builder
+	for _, c := range podMetrics.Containers {
+		fmt.Fprintf(&b, "container=%s cpu=%dm memory=%dMi\n",
+			c.Name, c.Usage.Cpu().MilliValue(), c.Usage.Memory().Value()/(1024*1024))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestGetPodMetricsTool(t *testing.T) {
+	podMetrics := &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Containers: []metricsv1beta1.ContainerMetrics{
+			{
+				Name: "app",
+				Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("120m"),
+					corev1.ResourceMemory: resource.MustParse("450Mi"),
+				},
+			},
+			{
+				Name: "sidecar",
+				Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+		},
+	}
+	// The fake object tracker stores PodMetrics under "podmetrics" while the typed
+	// client reads "pods", so serve the object from a reactor instead.
+	client := metricsfake.NewSimpleClientset()
+	client.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get := action.(k8stesting.GetAction)
+		if get.GetNamespace() == podMetrics.Namespace && get.GetName() == podMetrics.Name {
+			return true, podMetrics, nil
+		}
+		return true, nil, apierrors.NewNotFound(metricsv1beta1.Resource("pods"), get.GetName())
+	})
+	tool := NewGetPodMetricsTool(client)
+	ctx := context.Background()
+
+	t.Run("reports usage per container", func(t *testing.T) {
+		out, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"web-0"}`)
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		for _, want := range []string{
+			"container=app cpu=120m memory=450Mi",
+			"container=sidecar cpu=1000m memory=32Mi",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("missing pod metrics returns error", func(t *testing.T) {
+		if _, err := tool.Execute(ctx, `{"namespace":"default","pod_name":"missing"}`); err == nil {
+			t.Fatal("expected error for pod without metrics")
+		}
+	})
+
+	t.Run("invalid arguments returns error", func(t *testing.T) {
+		if _, err := tool.Execute(ctx, `not-json`); err == nil {
+			t.Fatal("expected error for invalid arguments")
+		}
+	})
+}
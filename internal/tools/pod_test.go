@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetPodLogsTool(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "prod"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-2", Namespace: "prod"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+		},
+	)
+	tool := NewGetPodLogsTool(client)
+
+	// logOptions returns the PodLogOptions of the most recent log request.
+	logOptions := func(t *testing.T) *corev1.PodLogOptions {
+		t.Helper()
+		actions := client.Actions()
+		for i := len(actions) - 1; i >= 0; i-- {
+			if action, ok := actions[i].(k8stesting.GenericAction); ok && action.GetSubresource() == "log" {
+				return action.GetValue().(*corev1.PodLogOptions)
+			}
+		}
+		t.Fatal("no log request was made")
+		return nil
+	}
+
+	t.Run("should default to the last 100 lines of the current instance", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != "fake logs" {
+			t.Errorf("unexpected result: %s", result)
+		}
+		opts := logOptions(t)
+		if opts.Previous || opts.Container != "" || opts.TailLines == nil || *opts.TailLines != 100 {
+			t.Errorf("unexpected log options: %+v", opts)
+		}
+	})
+
+	t.Run("should read the previous instance", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1","previous":true,"tail_lines":20}`); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		opts := logOptions(t)
+		if !opts.Previous || opts.TailLines == nil || *opts.TailLines != 20 {
+			t.Errorf("expected previous logs with 20 tail lines, got %+v", opts)
+		}
+	})
+
+	t.Run("should read the selected container", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-2","container":"sidecar"}`); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts := logOptions(t); opts.Container != "sidecar" {
+			t.Errorf("expected container sidecar, got %q", opts.Container)
+		}
+	})
+
+	t.Run("should list containers when a multi-container pod has none selected", func(t *testing.T) {
+		_, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-2"}`)
+		if err == nil {
+			t.Fatal("expected an error for a multi-container pod without container")
+		}
+		if !strings.Contains(err.Error(), "app, sidecar") {
+			t.Errorf("expected container names in error, got: %v", err)
+		}
+	})
+}
+
+func TestGetContainerRestartsTool(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "prod"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "app",
+						RestartCount: 5,
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{
+								StartedAt: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+							},
+						},
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: 137,
+								Reason:   "Error",
+							},
+						},
+					},
+					{
+						Name:  "sidecar",
+						State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					},
+				},
+			},
+		},
+	)
+
+	tool := NewGetContainerRestartsTool(client)
+
+	t.Run("should report last termination", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"app-1"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, "container=app restarts=5 state=Running lastExitCode=137 lastReason=Error lastRestart=") {
+			t.Errorf("unexpected result for app container: %s", result)
+		}
+		if !strings.Contains(result, "container=sidecar restarts=0 state=Running\n") {
+			t.Errorf("unexpected result for sidecar container: %s", result)
+		}
+	})
+
+	t.Run("should fail for non-existent pod", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"namespace":"prod","pod_name":"missing"}`); err == nil {
+			t.Fatal("expected error for non-existent pod")
+		}
+	})
+
+	t.Run("should have correct metadata", func(t *testing.T) {
+		if tool.Name() != "get_container_restarts" {
+			t.Errorf("expected name 'get_container_restarts', got %s", tool.Name())
+		}
+	})
+}
+
+func TestGetPodEventsTool_ReturnsOnlyRecentEvents(t *testing.T) {
+	now := time.Now()
+	event := func(name, reason string, lastSeen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "app-1", Namespace: "prod"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        reason + " message",
+			LastTimestamp:  metav1.NewTime(lastSeen),
+		}
+	}
+	client := fake.NewSimpleClientset(
+		event("old", "FailedScheduling", now.Add(-3*time.Hour)),
+		event("recent-1", "BackOff", now.Add(-10*time.Minute)),
+		event("recent-2", "Unhealthy", now.Add(-5*time.Minute)),
+		event("recent-3", "Killing", now.Add(-time.Minute)),
+	)
+	tool := NewGetPodEventsTool(client)
+
+	out, err := tool.Execute(context.Background(), `{"namespace": "prod", "pod_name": "app-1"}`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if strings.Contains(out, "FailedScheduling") {
+		t.Errorf("event older than the default hour was returned:\n%s", out)
+	}
+	backOff, killing := strings.Index(out, "BackOff"), strings.Index(out, "Killing")
+	if backOff < 0 || killing < 0 || !strings.Contains(out, "Unhealthy") {
+		t.Fatalf("recent events missing:\n%s", out)
+	}
+	if backOff > killing {
+		t.Errorf("events not sorted oldest to newest:\n%s", out)
+	}
+
+	out, err = tool.Execute(context.Background(), `{"namespace": "prod", "pod_name": "app-1", "max_events": 1}`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, "Killing") || strings.Contains(out, "BackOff") || strings.Contains(out, "Unhealthy") {
+		t.Errorf("max_events=1 should keep only the most recent event:\n%s", out)
+	}
+
+	out, err = tool.Execute(context.Background(), `{"namespace": "prod", "pod_name": "app-1", "since": "4h"}`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, "FailedScheduling") {
+		t.Errorf("since=4h should include the old event:\n%s", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), `{"namespace": "prod", "pod_name": "app-1", "since": "yesterday"}`); err == nil {
+		t.Error("expected an error for an invalid since")
+	}
+}
@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+const (
+	// promMaxSeries caps how many series are summarized in a single tool result.
+	promMaxSeries = 20
+	// promDefaultStep is the range query resolution used when the agent omits "step".
+	promDefaultStep = "1m"
+	// promMaxResponseBytes caps how much of a Prometheus response is read; a wide
+	// range query can return hundreds of MB that would only be summarized anyway.
+	promMaxResponseBytes = 10 << 20
+)
+
+type PromQLArgs struct {
+	Query string `json:"query"`
+	// Range turns the call into a range query over [now-Range, now], e.g. "30m" or "2h".
+	// Leave empty for an instant query.
+	Range string `json:"range,omitempty"`
+	// Step is the range query resolution, e.g. "30s". Defaults to 1m.
+	Step string `json:"step,omitempty"`
+}
+
+// PromQLTool implements the query_prometheus tool
+type PromQLTool struct {
+	baseURL    string
+	httpClient *http.Client
+	now        func() time.Time
+	maxBytes   int64 // see promMaxResponseBytes
+}
+
+// NewPromQLTool creates a PromQL tool for the Prometheus server at baseURL
+// (e.g. "http://prometheus.monitoring:9090"). A nil httpClient uses a 30s timeout client.
+func NewPromQLTool(baseURL string, httpClient *http.Client) *PromQLTool {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &PromQLTool{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		now:        time.Now,
+		maxBytes:   promMaxResponseBytes,
+	}
+}
+
+func (t *PromQLTool) Name() string {
+	return "query_prometheus"
+}
+
+func (t *PromQLTool) Description() string {
+	return "Run a PromQL query against Prometheus. Use an instant query to check a current value, or set 'range' (e.g. '1h') to see how a metric trended, such as container memory usage before an OOM kill."
+}
+
+func (t *PromQLTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"query": {
+				"type": "string",
+				"description": "The PromQL expression to evaluate"
+			},
+			"range": {
+				"type": "string",
+				"description": "Optional look-back window for a range query ending now (e.g. '30m', '2h'). Omit for an instant query."
+			},
+			"step": {
+				"type": "string",
+				"description": "Optional range query resolution (e.g. '30s'). Defaults to '1m'."
+			}
+		},
+		"required": ["query"]
+	}`
+}
+
+func (t *PromQLTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *PromQLTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs PromQLArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if parsedArgs.Query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	params := url.Values{}
+	params.Set("query", parsedArgs.Query)
+
+	endpoint := "/api/v1/query"
+	if parsedArgs.Range != "" {
+		window, err := time.ParseDuration(parsedArgs.Range)
+		if err != nil {
+			return "", fmt.Errorf("invalid range %q: %w", parsedArgs.Range, err)
+		}
+		step := parsedArgs.Step
+		if step == "" {
+			step = promDefaultStep
+		}
+		if _, err := time.ParseDuration(step); err != nil {
+			return "", fmt.Errorf("invalid step %q: %w", step, err)
+		}
+		end := t.now()
+		params.Set("start", strconv.FormatInt(end.Add(-window).Unix(), 10))
+		params.Set("end", strconv.FormatInt(end.Unix(), 10))
+		params.Set("step", step)
+		endpoint = "/api/v1/query_range"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build prometheus request: %w", err)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("prometheus request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read prometheus response: %w", err)
+	}
+	if int64(len(body)) > t.maxBytes {
+		// A cut-off response cannot be decoded, so only the truncation is reported.
+		return fmt.Sprintf("Prometheus response exceeded %d bytes (output truncated; narrow the query with label selectors or aggregation, shorten the range or raise the step).", t.maxBytes), nil
+	}
+
+	var parsed promResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to decode prometheus response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if parsed.Status != "success" {
+		return "", fmt.Errorf("prometheus query failed (HTTP %d): %s: %s", resp.StatusCode, parsed.ErrorType, parsed.Error)
+	}
+
+	return summarizePromResult(parsed.Data)
+}
+
+// promResponse is the envelope returned by the Prometheus HTTP API.
+type promResponse struct {
+	Status    string   `json:"status"`
+	Data      promData `json:"data"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+}
+
+type promData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]any            `json:"value"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]any          `json:"values"`
+}
+
+// summarizePromResult renders a query result as compact text: one line per
+// instant sample, or min/max/last per range series, so large matrices don't
+// flood the agent context.
+func summarizePromResult(data promData) (string, error) {
+	var sb strings.Builder
+
+	switch data.ResultType {
+	case "vector":
+		var samples []promSample
+		if err := json.Unmarshal(data.Result, &samples); err != nil {
+			return "", fmt.Errorf("failed to decode vector result: %w", err)
+		}
+		if len(samples) == 0 {
+			return "Query returned no data.", nil
+		}
+		for i, s := range samples {
+			if i == promMaxSeries {
+				fmt.Fprintf(&sb, "... %d more series omitted\n", len(samples)-promMaxSeries)
+				break
+			}
+			fmt.Fprintf(&sb, "%s => %s\n", formatPromLabels(s.Metric), promValueString(s.Value[1]))
+		}
+
+	case "matrix":
+		var series []promSeries
+		if err := json.Unmarshal(data.Result, &series); err != nil {
+			return "", fmt.Errorf("failed to decode matrix result: %w", err)
+		}
+		if len(series) == 0 {
+			return "Query returned no data.", nil
+		}
+		for i, s := range series {
+			if i == promMaxSeries {
+				fmt.Fprintf(&sb, "... %d more series omitted\n", len(series)-promMaxSeries)
+				break
+			}
+			fmt.Fprintf(&sb, "%s => %s\n", formatPromLabels(s.Metric), summarizePromValues(s.Values))
+		}
+
+	case "scalar", "string":
+		var v [2]any
+		if err := json.Unmarshal(data.Result, &v); err != nil {
+			return "", fmt.Errorf("failed to decode %s result: %w", data.ResultType, err)
+		}
+		fmt.Fprintf(&sb, "%s\n", promValueString(v[1]))
+
+	default:
+		return "", fmt.Errorf("unsupported prometheus result type %q", data.ResultType)
+	}
+
+	return sb.String(), nil
+}
+
+// summarizePromValues reduces a range series to its sample count, min, max,
+// first and last values.
+func summarizePromValues(values [][2]any) string {
+	if len(values) == 0 {
+		return "no samples"
+	}
+	var nums []float64
+	for _, v := range values {
+		f, err := strconv.ParseFloat(promValueString(v[1]), 64)
+		if err != nil {
+			continue
+		}
+		nums = append(nums, f)
+	}
+	if len(nums) == 0 {
+		return fmt.Sprintf("%d samples (non-numeric)", len(values))
+	}
+	lo, hi := nums[0], nums[0]
+	for _, n := range nums {
+		lo = min(lo, n)
+		hi = max(hi, n)
+	}
+	return fmt.Sprintf("samples=%d min=%g max=%g first=%g last=%g",
+		len(nums), lo, hi, nums[0], nums[len(nums)-1])
+}
+
+func promValueString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// formatPromLabels renders a label set as {k="v",...} with keys sorted.
+func formatPromLabels(metric map[string]string) string {
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// PrometheusProvider exposes the PromQL tool when a Prometheus URL is configured.
+type PrometheusProvider struct {
+	tool *PromQLTool
+}
+
+// NewPrometheusProvider creates a provider for the Prometheus server at baseURL.
+func NewPrometheusProvider(baseURL string) *PrometheusProvider {
+	return &PrometheusProvider{tool: NewPromQLTool(baseURL, nil)}
+}
+
+// ListTools returns the PromQL tool
+func (p *PrometheusProvider) ListTools(ctx context.Context) ([]agent.Tool, error) {
+	return []agent.Tool{p.tool}, nil
+}
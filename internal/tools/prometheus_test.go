@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+func newFakePrometheus(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query":
+			if r.URL.Query().Get("query") == "bad(" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"pod":"app-1","namespace":"prod"},"value":[1700000000,"524288000"]}
+			]}}`))
+		case "/api/v1/query_range":
+			q := r.URL.Query()
+			if q.Get("start") != "1699996400" || q.Get("end") != "1700000000" || q.Get("step") != "1m" {
+				t.Errorf("unexpected range params: %v", q)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"pod":"app-1"},"values":[[1699996400,"100"],[1699998200,"300"],[1700000000,"250"]]}
+			]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestPromQLTool(t *testing.T) {
+	srv := newFakePrometheus(t)
+	defer srv.Close()
+
+	tool := NewPromQLTool(srv.URL, srv.Client())
+	tool.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	t.Run("instant query", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"query":"container_memory_working_set_bytes"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, `{namespace="prod",pod="app-1"} => 524288000`) {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("range query is summarized", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"query":"container_memory_working_set_bytes","range":"1h"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, "samples=3 min=100 max=300 first=100 last=250") {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("query error is surfaced", func(t *testing.T) {
+		_, err := tool.Execute(context.Background(), `{"query":"bad("}`)
+		if err == nil || !strings.Contains(err.Error(), "parse error") {
+			t.Errorf("expected parse error, got %v", err)
+		}
+	})
+
+	t.Run("oversized response is reported as truncated", func(t *testing.T) {
+		small := NewPromQLTool(srv.URL, srv.Client())
+		small.now = tool.now
+		small.maxBytes = 64
+		result, err := small.Execute(context.Background(), `{"query":"container_memory_working_set_bytes","range":"1h"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, "exceeded 64 bytes (output truncated") {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"query":"up","range":"yesterday"}`); err == nil {
+			t.Error("expected error for invalid range")
+		}
+	})
+
+	t.Run("should have correct metadata", func(t *testing.T) {
+		if tool.Name() != "query_prometheus" {
+			t.Errorf("expected name 'query_prometheus', got %s", tool.Name())
+		}
+		if tool.SafetyLevel() != agent.SafetyLevelReadOnly {
+			t.Errorf("expected ReadOnly safety level, got %s", tool.SafetyLevel())
+		}
+	})
+}
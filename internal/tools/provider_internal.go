@@ -2,23 +2,32 @@ package tools
 
 import (
 	"context"
+
 	"k8s.io/client-go/kubernetes"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 	"kubeminds/internal/agent"
 )
 
 // InternalProvider provides built-in Kubernetes tools
 type InternalProvider struct {
-	client kubernetes.Interface
+	client        kubernetes.Interface
+	metricsClient metricsclientset.Interface
 }
 
-// NewInternalProvider creates a new internal tool provider
-func NewInternalProvider(client kubernetes.Interface) *InternalProvider {
+// NewInternalProvider creates a new internal tool provider.
+// metricsClient enables get_pod_metrics; pass nil when metrics-server is unavailable.
+func NewInternalProvider(client kubernetes.Interface, metricsClient metricsclientset.Interface) *InternalProvider {
 	return &InternalProvider{
-		client: client,
+		client:        client,
+		metricsClient: metricsClient,
 	}
 }
 
-// ListTools returns the list of internal tools
+// ListTools returns the list of internal tools, instrumented with execution latency metrics
 func (p *InternalProvider) ListTools(ctx context.Context) ([]agent.Tool, error) {
-	return ListTools(p.client), nil
+	tools := ListTools(p.client)
+	if p.metricsClient != nil {
+		tools = append(tools, NewGetPodMetricsTool(p.metricsClient))
+	}
+	return instrumentTools(tools), nil
 }
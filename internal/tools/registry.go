@@ -10,8 +10,11 @@ func ListTools(client kubernetes.Interface) []agent.Tool {
 	return []agent.Tool{
 		// Pod tools
 		NewGetPodLogsTool(client),
+		NewGrepPodLogsTool(client),
 		NewGetPodEventsTool(client),
 		NewGetPodSpecTool(client),
+		NewGetContainerRestartsTool(client),
+		NewGetOwnerChainTool(client),
 		// Node tools
 		NewGetNodeStatusTool(client),
 		NewGetNodeEventsTool(client),
@@ -21,9 +24,12 @@ func ListTools(client kubernetes.Interface) []agent.Tool {
 		// Volume tools
 		NewGetPVCStatusTool(client),
 		NewGetPVStatusTool(client),
+		// Secret tools (values redacted)
+		NewGetSecretMetadataTool(client),
 		// Write operation tools
 		NewDeletePodTool(client),
 		NewPatchDeploymentTool(client),
+		NewRolloutRestartDeploymentTool(client),
 		NewScaleStatefulSetTool(client),
 	}
 }
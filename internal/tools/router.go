@@ -2,14 +2,46 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"kubeminds/internal/agent"
 	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxProviderConcurrency is how many providers ListTools queries at once.
+	DefaultMaxProviderConcurrency = 4
+	// DefaultProviderQueryTimeout is the shared deadline for one ListTools fan-out.
+	DefaultProviderQueryTimeout = 10 * time.Second
 )
 
 // Router aggregates tools from multiple providers
 type Router struct {
 	providers []agent.ToolProvider
 	logger    *slog.Logger
+
+	// schemaErrs caches ValidateToolSchema results keyed by tool name + schema,
+	// so each schema is parsed once rather than on every ListTools call.
+	schemaErrs sync.Map // map[string]error
+
+	// maxConcurrency bounds concurrent provider queries; queryTimeout is the
+	// deadline shared by all providers in one ListTools call.
+	maxConcurrency int
+	queryTimeout   time.Duration
+
+	// disabled names tools dropped from every provider's list (see WithDisabledTools).
+	disabled map[string]bool
+
+	// cacheTTL keeps the aggregated tool list so agent runs don't re-query
+	// (e.g. re-handshake with) every provider. Zero disables caching.
+	cacheTTL    time.Duration
+	now         func() time.Time
+	cacheMu     sync.Mutex
+	cached      []agent.Tool
+	cachedUntil time.Time
 }
 
 // NewRouter creates a new tool router
@@ -18,26 +50,208 @@ func NewRouter(logger *slog.Logger) *Router {
 		logger = slog.Default()
 	}
 	return &Router{
-		logger: logger,
+		logger:         logger,
+		maxConcurrency: DefaultMaxProviderConcurrency,
+		queryTimeout:   DefaultProviderQueryTimeout,
+		now:            time.Now,
 	}
 }
 
+// WithMaxConcurrency sets how many providers are queried concurrently by ListTools.
+// n <= 0 keeps the default.
+func (r *Router) WithMaxConcurrency(n int) *Router {
+	if n > 0 {
+		r.maxConcurrency = n
+	}
+	return r
+}
+
+// WithQueryTimeout sets the deadline shared by all provider queries in one ListTools
+// call. Providers that have not answered by then are skipped. d <= 0 keeps the default.
+func (r *Router) WithQueryTimeout(d time.Duration) *Router {
+	if d > 0 {
+		r.queryTimeout = d
+	}
+	return r
+}
+
+// WithCacheTTL caches the aggregated tool list for d. Zero (default) disables caching.
+func (r *Router) WithCacheTTL(d time.Duration) *Router {
+	r.cacheTTL = d
+	return r
+}
+
+// Invalidate drops the cached tool list, e.g. after tool providers were reloaded,
+// so the next ListTools queries the providers again.
+func (r *Router) Invalidate() {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	r.cached = nil
+}
+
+// WithDisabledTools drops the named tools from ListTools whichever provider offers
+// them, so no agent is ever given them regardless of its skill's allowed tools.
+func (r *Router) WithDisabledTools(names []string) *Router {
+	r.disabled = toolNameSet(names)
+	return r
+}
+
 // AddProvider adds a tool provider to the router
 func (r *Router) AddProvider(provider agent.ToolProvider) {
 	r.providers = append(r.providers, provider)
 }
 
-// ListTools returns a list of all tools from all providers
+// ListTools returns a list of all tools from all providers.
+// Providers are queried concurrently (see WithMaxConcurrency) under a shared deadline,
+// and the result is served from cache while it is fresh (see WithCacheTTL).
 func (r *Router) ListTools(ctx context.Context) ([]agent.Tool, error) {
+	if r.cacheTTL > 0 {
+		r.cacheMu.Lock()
+		if r.cached != nil && r.now().Before(r.cachedUntil) {
+			tools := append([]agent.Tool(nil), r.cached...)
+			r.cacheMu.Unlock()
+			return tools, nil
+		}
+		r.cacheMu.Unlock()
+	}
+
 	var allTools []agent.Tool
+	for i, res := range r.queryProviders(ctx) {
+		if res.err != nil {
+			// External providers (MCP, gRPC) may not be ready — log as warn to avoid noise
+			r.logger.Warn("failed to list tools from provider, skipping", "provider_index", i, "error", res.err)
+			continue
+		}
+		for _, tool := range res.tools {
+			if r.disabled[tool.Name()] {
+				continue
+			}
+			if err := r.validate(tool); err != nil {
+				// A broken schema would fail every LLM call that includes it; drop the tool instead.
+				r.logger.Error("skipping tool with invalid schema", "tool", tool.Name(), "error", err)
+				continue
+			}
+			allTools = append(allTools, tool)
+		}
+	}
+
+	if r.cacheTTL > 0 {
+		r.cacheMu.Lock()
+		r.cached = append(make([]agent.Tool, 0, len(allTools)), allTools...)
+		r.cachedUntil = r.now().Add(r.cacheTTL)
+		r.cacheMu.Unlock()
+	}
+	return allTools, nil
+}
+
+// providerResult is one provider's answer to a ListTools fan-out.
+type providerResult struct {
+	tools []agent.Tool
+	err   error
+}
+
+// queryProviders lists tools from every provider, at most maxConcurrency at a time,
+// returning results in provider order. Providers still pending at the shared
+// deadline report the context error.
+func (r *Router) queryProviders(ctx context.Context) []providerResult {
+	results := make([]providerResult, len(r.providers))
+	if len(r.providers) == 0 {
+		return results
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	type indexed struct {
+		i int
+		providerResult
+	}
+	// Buffered so providers that answer after the deadline don't block forever.
+	done := make(chan indexed, len(r.providers))
+	sem := make(chan struct{}, r.maxConcurrency)
+	for i, provider := range r.providers {
+		go func() {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				done <- indexed{i, providerResult{err: ctx.Err()}}
+				return
+			}
+			tools, err := provider.ListTools(ctx)
+			done <- indexed{i, providerResult{tools: tools, err: err}}
+		}()
+	}
+
+	answered := make([]bool, len(r.providers))
+	for pending := len(r.providers); pending > 0; pending-- {
+		select {
+		case res := <-done:
+			results[res.i] = res.providerResult
+			answered[res.i] = true
+		case <-ctx.Done():
+			for i := range results {
+				if !answered[i] {
+					results[i].err = ctx.Err()
+				}
+			}
+			return results
+		}
+	}
+	return results
+}
+
+// Validate checks the schema of every tool currently offered by the providers and
+// returns an error naming each tool whose schema is invalid. Call it at startup so
+// a broken tool is caught before it is used in a diagnosis.
+// Providers that fail to list their tools are skipped, as in ListTools. Disabled
+// tool names no provider offers are logged as warnings, since they are likely typos;
+// they are not errors because an external provider may offer them once it is up.
+func (r *Router) Validate(ctx context.Context) error {
+	var errs []error
+	offered := make(map[string]bool)
 	for i, provider := range r.providers {
 		providerTools, err := provider.ListTools(ctx)
 		if err != nil {
-			// External providers (MCP, gRPC) may not be ready — log as warn to avoid noise
-			r.logger.Warn("failed to list tools from provider, skipping", "provider_index", i, "error", err)
+			r.logger.Warn("failed to list tools from provider, skipping validation", "provider_index", i, "error", err)
 			continue
 		}
-		allTools = append(allTools, providerTools...)
+		for _, tool := range providerTools {
+			offered[tool.Name()] = true
+			if err := r.validate(tool); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
-	return allTools, nil
+	for _, name := range slices.Sorted(maps.Keys(r.disabled)) {
+		if !offered[name] {
+			r.logger.Warn("disabled tool is not offered by any provider; check tools.disabled for typos", "tool", name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) validate(tool agent.Tool) error {
+	key := tool.Name() + "\x00" + tool.Schema()
+	if cached, ok := r.schemaErrs.Load(key); ok {
+		if cached == nil {
+			return nil
+		}
+		return cached.(error)
+	}
+	err := ValidateToolSchema(tool)
+	r.schemaErrs.Store(key, err)
+	return err
+}
+
+// toolNameSet returns names as a set, nil when empty.
+func toolNameSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
 }
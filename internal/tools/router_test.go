@@ -1,11 +1,18 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"k8s.io/client-go/kubernetes/fake"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
 	"kubeminds/internal/agent"
 )
 
@@ -30,6 +37,14 @@ func (t *stubTool) Execute(_ context.Context, _ string) (string, error) { return
 func (t *stubTool) Schema() string                                      { return "{}" }
 func (t *stubTool) SafetyLevel() agent.SafetyLevel                      { return agent.SafetyLevelReadOnly }
 
+// schemaTool is an agent.Tool with a configurable schema for validation tests
+type schemaTool struct {
+	stubTool
+	schema string
+}
+
+func (t *schemaTool) Schema() string { return t.schema }
+
 // TestRouter_NoProviders verifies the router returns an empty list when no providers are registered.
 func TestRouter_NoProviders(t *testing.T) {
 	r := NewRouter(nil)
@@ -104,17 +119,61 @@ func TestRouter_PartialFailure(t *testing.T) {
 	}
 }
 
-// TestInternalProvider_ListTools verifies InternalProvider returns all 12 K8s tools.
+// TestRouter_Validate_MalformedSchema verifies that a tool with a malformed schema fails
+// validation with an error naming the tool, and is excluded from ListTools.
+func TestRouter_Validate_MalformedSchema(t *testing.T) {
+	r := NewRouter(nil)
+	r.AddProvider(&stubProvider{tools: []agent.Tool{
+		&stubTool{name: "good_tool"},
+		&schemaTool{stubTool: stubTool{name: "broken_tool"}, schema: `{"type": "object", "properties": {`},
+		&schemaTool{stubTool: stubTool{name: "wrong_type_tool"}, schema: `{"type": "array"}`},
+		&schemaTool{stubTool: stubTool{name: "missing_required_tool"}, schema: `{"type": "object", "properties": {}, "required": ["pod_name"]}`},
+	}})
+
+	err := r.Validate(context.Background())
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+	for _, name := range []string{"broken_tool", "wrong_type_tool", "missing_required_tool"} {
+		if !contains(err.Error(), `tool "`+name+`" has an invalid schema`) {
+			t.Errorf("expected error to name %s, got: %v", name, err)
+		}
+	}
+	if contains(err.Error(), "good_tool") {
+		t.Errorf("valid tool should not be reported: %v", err)
+	}
+
+	tools, err := r.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name() != "good_tool" {
+		t.Errorf("expected only good_tool to be listed, got %d tools", len(tools))
+	}
+}
+
+// TestRouter_Validate_BuiltinTools verifies every built-in tool ships a valid schema.
+func TestRouter_Validate_BuiltinTools(t *testing.T) {
+	r := NewRouter(nil)
+	r.AddProvider(NewInternalProvider(fake.NewSimpleClientset(), nil))
+	r.AddProvider(NewPrometheusProvider("http://prometheus:9090"))
+
+	if err := r.Validate(context.Background()); err != nil {
+		t.Fatalf("expected built-in tools to validate, got: %v", err)
+	}
+}
+
+// TestInternalProvider_ListTools verifies InternalProvider returns all 17 K8s tools.
 func TestInternalProvider_ListTools(t *testing.T) {
 	client := fake.NewSimpleClientset()
-	p := NewInternalProvider(client)
+	p := NewInternalProvider(client, nil)
 
 	tools, err := p.ListTools(context.Background())
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if len(tools) != 12 {
-		t.Errorf("expected 12 tools, got %d", len(tools))
+	if len(tools) != 17 {
+		t.Errorf("expected 17 tools, got %d", len(tools))
 	}
 
 	// Verify all tools have non-empty names
@@ -125,6 +184,23 @@ func TestInternalProvider_ListTools(t *testing.T) {
 	}
 }
 
+// TestInternalProvider_ListTools_WithMetricsClient verifies get_pod_metrics is only
+// offered when a metrics client is supplied.
+func TestInternalProvider_ListTools_WithMetricsClient(t *testing.T) {
+	p := NewInternalProvider(fake.NewSimpleClientset(), metricsfake.NewSimpleClientset())
+
+	tools, err := p.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(tools) != 18 {
+		t.Fatalf("expected 18 tools, got %d", len(tools))
+	}
+	if name := tools[len(tools)-1].Name(); name != "get_pod_metrics" {
+		t.Errorf("expected get_pod_metrics to be registered, got %q", name)
+	}
+}
+
 // TestGRPCProvider_ListTools verifies the gRPC stub returns an empty list without error.
 func TestGRPCProvider_ListTools(t *testing.T) {
 	p := NewGRPCProvider()
@@ -148,3 +224,153 @@ func TestMCPProvider_ListTools(t *testing.T) {
 		t.Errorf("expected empty tool list from MCP stub, got %d", len(tools))
 	}
 }
+
+// countingProvider records how often and how concurrently it is queried.
+type countingProvider struct {
+	name     string
+	delay    time.Duration
+	calls    *atomic.Int64
+	inFlight *atomic.Int64
+	maxSeen  *atomic.Int64
+}
+
+func (p *countingProvider) ListTools(ctx context.Context) ([]agent.Tool, error) {
+	p.calls.Add(1)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		seen := p.maxSeen.Load()
+		if n <= seen || p.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(p.delay):
+		return []agent.Tool{&stubTool{name: p.name}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TestRouter_CacheTTL verifies the tool list is served from cache within the TTL.
+func TestRouter_CacheTTL(t *testing.T) {
+	var calls, inFlight, maxSeen atomic.Int64
+	now := time.Unix(0, 0)
+	r := NewRouter(nil).WithCacheTTL(30 * time.Second)
+	r.now = func() time.Time { return now }
+	r.AddProvider(&countingProvider{name: "mcp_tool", calls: &calls, inFlight: &inFlight, maxSeen: &maxSeen})
+
+	for i := 0; i < 3; i++ {
+		tools, err := r.ListTools(context.Background())
+		if err != nil || len(tools) != 1 {
+			t.Fatalf("ListTools = %d tools, %v", len(tools), err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("provider queried %d times within TTL, want 1", got)
+	}
+
+	now = now.Add(31 * time.Second)
+	if _, err := r.ListTools(context.Background()); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("provider queried %d times after TTL expiry, want 2", got)
+	}
+
+	r.Invalidate()
+	if _, err := r.ListTools(context.Background()); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("provider queried %d times after Invalidate, want 3", got)
+	}
+}
+
+// TestRouter_BoundedConcurrency verifies at most maxConcurrency providers are queried
+// at once and results keep provider order.
+func TestRouter_BoundedConcurrency(t *testing.T) {
+	var calls, inFlight, maxSeen atomic.Int64
+	r := NewRouter(nil).WithMaxConcurrency(2)
+	for i := 0; i < 8; i++ {
+		r.AddProvider(&countingProvider{
+			name:  fmt.Sprintf("tool_%d", i),
+			delay: 10 * time.Millisecond,
+			calls: &calls, inFlight: &inFlight, maxSeen: &maxSeen,
+		})
+	}
+
+	tools, err := r.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(tools) != 8 {
+		t.Fatalf("expected 8 tools, got %d", len(tools))
+	}
+	for i, tool := range tools {
+		if want := fmt.Sprintf("tool_%d", i); tool.Name() != want {
+			t.Errorf("tools[%d] = %s, want %s", i, tool.Name(), want)
+		}
+	}
+	if got := maxSeen.Load(); got > 2 {
+		t.Errorf("max concurrent provider queries = %d, want <= 2", got)
+	}
+}
+
+// TestRouter_QueryTimeout verifies a provider that misses the shared deadline is skipped.
+func TestRouter_QueryTimeout(t *testing.T) {
+	var calls, inFlight, maxSeen atomic.Int64
+	r := NewRouter(nil).WithQueryTimeout(50 * time.Millisecond)
+	r.AddProvider(&stubProvider{tools: []agent.Tool{&stubTool{name: "fast"}}})
+	r.AddProvider(&countingProvider{name: "slow", delay: time.Minute, calls: &calls, inFlight: &inFlight, maxSeen: &maxSeen})
+
+	start := time.Now()
+	tools, err := r.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ListTools took %v, want it bounded by the query timeout", elapsed)
+	}
+	if len(tools) != 1 || tools[0].Name() != "fast" {
+		t.Errorf("expected only the fast provider's tool, got %v", tools)
+	}
+}
+
+// TestRouter_DisabledTools verifies a disabled tool is never listed, even for a skill
+// whose allowed tools name it.
+func TestRouter_DisabledTools(t *testing.T) {
+	disabled := []string{"get_secret_metadata"}
+	r := NewRouter(nil).WithDisabledTools(disabled)
+	r.AddProvider(NewInternalProvider(fake.NewSimpleClientset(), nil))
+	r.AddProvider(&stubProvider{tools: []agent.Tool{&stubTool{name: "get_secret_metadata"}, &stubTool{name: "external_tool"}}})
+
+	tools, err := r.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	skill := agent.Skill{Name: "secrets", AllowedTools: []string{"get_secret_metadata", "get_pod_logs", "external_tool"}}
+	var offered []string
+	for _, tool := range agent.ToolsForSkill(tools, skill) {
+		offered = append(offered, tool.Name())
+	}
+	if fmt.Sprint(offered) != "[get_pod_logs external_tool]" {
+		t.Errorf("tools offered to skill = %v, want [get_pod_logs external_tool]", offered)
+	}
+}
+
+func TestRouter_Validate_WarnsOnUnknownDisabledTools(t *testing.T) {
+	var logs bytes.Buffer
+	r := NewRouter(slog.New(slog.NewTextHandler(&logs, nil))).WithDisabledTools([]string{"get_secret_metadata", "get_secrets"})
+	r.AddProvider(NewInternalProvider(fake.NewSimpleClientset(), nil))
+
+	if err := r.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !strings.Contains(logs.String(), "tool=get_secrets") {
+		t.Errorf("logs = %q, want a warning for the unknown get_secrets", logs.String())
+	}
+	if strings.Contains(logs.String(), "tool=get_secret_metadata") {
+		t.Errorf("logs = %q, want no warning for the existing get_secret_metadata", logs.String())
+	}
+}
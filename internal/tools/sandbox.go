@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"kubeminds/internal/agent"
+)
+
+// sandboxOutputs are the canned outputs returned by common read tools in sandbox
+// mode, shaped like the real tools' output so a demo diagnosis reads naturally.
+var sandboxOutputs = map[string]string{
+	"get_pod_logs": `2024-02-15T10:00:58Z INFO  starting worker pool size=8
+2024-02-15T10:00:59Z WARN  cache size 498Mi approaching limit
+2024-02-15T10:01:00Z ERROR allocation failed: cannot allocate memory`,
+	"get_pod_events": `Type=Warning Reason=OOMKilled Message=Container app exceeded its memory limit (512Mi)
+Type=Normal Reason=Pulled Message=Container image "app:1.4.2" already present on machine
+Type=Warning Reason=BackOff Message=Back-off restarting failed container app`,
+	"get_pod_spec": `Container: app
+  Image: app:1.4.2
+  Resources: limits(memory=512Mi cpu=500m) requests(memory=256Mi cpu=250m)`,
+	"get_container_restarts": `container=app restarts=7 lastState=Terminated reason=OOMKilled exitCode=137`,
+}
+
+// SandboxCall is a tool call recorded, but not executed, in sandbox mode.
+type SandboxCall struct {
+	Tool      string
+	Arguments string
+	Time      time.Time
+}
+
+// SandboxProvider exposes the tools of its inner providers without executing them:
+// every Execute records the intended call and returns a canned output, so a full
+// agent run can be demonstrated without touching a cluster. Tool names, schemas and
+// safety levels are unchanged, so approval gates still apply.
+type SandboxProvider struct {
+	providers []agent.ToolProvider
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	calls []SandboxCall
+}
+
+// NewSandboxProvider creates a SandboxProvider over the given providers.
+func NewSandboxProvider(logger *slog.Logger, providers ...agent.ToolProvider) *SandboxProvider {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SandboxProvider{providers: providers, logger: logger}
+}
+
+// ListTools returns the inner providers' tools wrapped so they never execute.
+// Providers that fail to list are skipped, as in Router.
+func (p *SandboxProvider) ListTools(ctx context.Context) ([]agent.Tool, error) {
+	var tools []agent.Tool
+	for i, provider := range p.providers {
+		inner, err := provider.ListTools(ctx)
+		if err != nil {
+			p.logger.Warn("sandbox: failed to list tools from provider, skipping", "provider_index", i, "error", err)
+			continue
+		}
+		for _, tool := range inner {
+			tools = append(tools, &sandboxTool{Tool: tool, provider: p})
+		}
+	}
+	return tools, nil
+}
+
+// Calls returns the tool calls recorded so far, oldest first.
+func (p *SandboxProvider) Calls() []SandboxCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SandboxCall(nil), p.calls...)
+}
+
+func (p *SandboxProvider) record(tool, args string) {
+	p.mu.Lock()
+	p.calls = append(p.calls, SandboxCall{Tool: tool, Arguments: args, Time: time.Now()})
+	p.mu.Unlock()
+	p.logger.Info("sandbox: recorded tool call without executing it", "tool", tool, "arguments", args)
+}
+
+// sandboxTool embeds only agent.Tool so optional interfaces such as ReversibleTool,
+// which would read from the cluster, are not exposed.
+type sandboxTool struct {
+	agent.Tool
+	provider *SandboxProvider
+}
+
+// Execute records the call and returns a canned output instead of running the tool.
+func (t *sandboxTool) Execute(ctx context.Context, args string) (string, error) {
+	t.provider.record(t.Name(), args)
+	if out, ok := sandboxOutputs[t.Name()]; ok {
+		return out, nil
+	}
+	if t.SafetyLevel() != agent.SafetyLevelReadOnly {
+		return fmt.Sprintf("[sandbox] %s would run with %s; no changes were made.", t.Name(), args), nil
+	}
+	return fmt.Sprintf("[sandbox] %s returned no data for %s.", t.Name(), args), nil
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kubeminds/internal/agent"
+)
+
+// TestSandboxProvider_AgentRunTouchesNoCluster runs a full diagnosis, including a
+// write action, against sandboxed built-in tools and checks that every call was
+// recorded while the Kubernetes client was never used.
+func TestSandboxProvider_AgentRunTouchesNoCluster(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		t.Errorf("unexpected cluster call in sandbox mode: %s %s", action.GetVerb(), action.GetResource().Resource)
+		return false, nil, nil
+	})
+	sandbox := NewSandboxProvider(nil, NewInternalProvider(clientset, nil))
+
+	tools, err := sandbox.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools(): %v", err)
+	}
+	if len(tools) != len(ListTools(clientset)) {
+		t.Errorf("sandbox exposes %d tools, want %d", len(tools), len(ListTools(clientset)))
+	}
+
+	llm := agent.NewMockLLMProvider()
+	llm.Responses[0] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{ID: "call-1", Function: agent.FunctionCall{
+			Name: "get_pod_logs", Arguments: `{"namespace":"default","pod_name":"app-1"}`,
+		}}},
+	}
+	llm.Responses[1] = &agent.Message{
+		Type: agent.MessageTypeAssistant,
+		ToolCalls: []agent.ToolCall{{ID: "call-2", Function: agent.FunctionCall{
+			Name: "delete_pod", Arguments: `{"namespace":"default","pod_name":"app-1"}`,
+		}}},
+	}
+	llm.Responses[2] = &agent.Message{
+		Type:    agent.MessageTypeAssistant,
+		Content: "Root Cause: the container exceeds its memory limit\nSuggestion: raise the limit",
+	}
+
+	ag := agent.NewAgent(llm, tools, 5, nil, nil, agent.Skill{})
+	result, err := ag.Run(context.Background(), "Diagnose pod app-1", true)
+	if err != nil {
+		t.Fatalf("Run(): %v", err)
+	}
+	if result.RootCause != "the container exceeds its memory limit" {
+		t.Errorf("RootCause = %q", result.RootCause)
+	}
+
+	calls := sandbox.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("recorded %d calls, want 2: %+v", len(calls), calls)
+	}
+	if calls[0].Tool != "get_pod_logs" || calls[1].Tool != "delete_pod" {
+		t.Errorf("recorded tools = %s, %s; want get_pod_logs, delete_pod", calls[0].Tool, calls[1].Tool)
+	}
+	if !strings.Contains(calls[1].Arguments, `"pod_name":"app-1"`) {
+		t.Errorf("delete_pod arguments = %s", calls[1].Arguments)
+	}
+	if n := len(clientset.Actions()); n != 0 {
+		t.Errorf("client saw %d actions, want none", n)
+	}
+}
+
+func TestSandboxTool_Outputs(t *testing.T) {
+	sandbox := NewSandboxProvider(nil, &stubProvider{tools: []agent.Tool{&stubTool{name: "get_pod_events"}, &stubTool{name: "list_things"}}})
+	tools, _ := sandbox.ListTools(context.Background())
+
+	out, err := tools[0].Execute(context.Background(), `{}`)
+	if err != nil || out != sandboxOutputs["get_pod_events"] {
+		t.Errorf("get_pod_events = %q, %v; want the canned output", out, err)
+	}
+	out, err = tools[1].Execute(context.Background(), `{"a":1}`)
+	if err != nil || !strings.HasPrefix(out, "[sandbox] list_things") {
+		t.Errorf("list_things = %q, %v; want a generic sandbox output", out, err)
+	}
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"kubeminds/internal/agent"
+)
+
+// ValidateToolSchema checks that a tool's Schema() is a JSON Schema object the LLM
+// providers can consume: valid JSON, an object, with "type": "object" (if set),
+// an object-valued "properties" (if set) and a string array "required" (if set).
+// The returned error names the offending tool.
+func ValidateToolSchema(tool agent.Tool) error {
+	var schema struct {
+		Type       *string                    `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal([]byte(tool.Schema()), &schema); err != nil {
+		return fmt.Errorf("tool %q has an invalid schema: %w", tool.Name(), err)
+	}
+	if schema.Type != nil && *schema.Type != "object" {
+		return fmt.Errorf("tool %q has an invalid schema: type must be \"object\", got %q", tool.Name(), *schema.Type)
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("tool %q has an invalid schema: required property %q is not defined", tool.Name(), name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"kubeminds/internal/agent"
+)
+
+type SecretArgs struct {
+	Namespace  string `json:"namespace"`
+	SecretName string `json:"secret_name"`
+}
+
+// secretMetadata is the redacted view of a Secret returned by get_secret_metadata.
+type secretMetadata struct {
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Type      string      `json:"type"`
+	Keys      []secretKey `json:"keys"`
+}
+
+// secretKey describes one data key by name and value size only.
+type secretKey struct {
+	Name  string `json:"name"`
+	Bytes int    `json:"bytes"`
+}
+
+// GetSecretMetadataTool implements the get_secret_metadata tool
+type GetSecretMetadataTool struct {
+	client kubernetes.Interface
+}
+
+func NewGetSecretMetadataTool(client kubernetes.Interface) *GetSecretMetadataTool {
+	return &GetSecretMetadataTool{client: client}
+}
+
+func (t *GetSecretMetadataTool) Name() string {
+	return "get_secret_metadata"
+}
+
+func (t *GetSecretMetadataTool) Description() string {
+	return "Get redacted metadata of a Kubernetes secret: whether it exists, its type, and the names of its data keys with each value's size in bytes. Secret values are always redacted and never returned. Use this to check that a referenced secret (e.g. an imagePullSecret of type kubernetes.io/dockerconfigjson) exists and has the expected keys."
+}
+
+func (t *GetSecretMetadataTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the secret"
+			},
+			"secret_name": {
+				"type": "string",
+				"description": "The name of the secret"
+			}
+		},
+		"required": ["namespace", "secret_name"]
+	}`
+}
+
+func (t *GetSecretMetadataTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelReadOnly
+}
+
+func (t *GetSecretMetadataTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs SecretArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	secret, err := t.client.CoreV1().Secrets(parsedArgs.Namespace).Get(ctx, parsedArgs.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// Build the output from key names and lengths only; annotations are left out
+	// too, since last-applied-configuration can embed the values.
+	meta := secretMetadata{
+		Name:      secret.Name,
+		Namespace: secret.Namespace,
+		Type:      string(secret.Type),
+		Keys:      make([]secretKey, 0, len(secret.Data)),
+	}
+	for name, value := range secret.Data {
+		meta.Keys = append(meta.Keys, secretKey{Name: name, Bytes: len(value)})
+	}
+	sort.Slice(meta.Keys, func(i, j int) bool { return meta.Keys[i].Name < meta.Keys[j].Name })
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal secret metadata: %w", err)
+	}
+
+	return string(data), nil
+}
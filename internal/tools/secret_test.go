@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetSecretMetadataTool(t *testing.T) {
+	dockerConfig := `{"auths":{"registry.example.com":{"auth":"dXNlcjpodW50ZXIy"}}}`
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "regcred",
+				Namespace: "default",
+				Annotations: map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": dockerConfig,
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(dockerConfig),
+				"password":                 []byte("hunter2"),
+			},
+		},
+	)
+
+	tool := NewGetSecretMetadataTool(client)
+
+	t.Run("should have ReadOnly safety level", func(t *testing.T) {
+		if tool.SafetyLevel() != "ReadOnly" {
+			t.Errorf("expected ReadOnly safety level, got %s", tool.SafetyLevel())
+		}
+		if !strings.Contains(tool.Description(), "redacted") {
+			t.Errorf("expected description to state values are redacted")
+		}
+	})
+
+	t.Run("should return type and key sizes", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"namespace":"default","secret_name":"regcred"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var meta secretMetadata
+		if err := json.Unmarshal([]byte(result), &meta); err != nil {
+			t.Fatalf("result is not valid JSON: %v", err)
+		}
+		if meta.Type != string(corev1.SecretTypeDockerConfigJson) {
+			t.Errorf("expected dockerconfigjson type, got %s", meta.Type)
+		}
+		want := []secretKey{
+			{Name: corev1.DockerConfigJsonKey, Bytes: len(dockerConfig)},
+			{Name: "password", Bytes: len("hunter2")},
+		}
+		if len(meta.Keys) != len(want) || meta.Keys[0] != want[0] || meta.Keys[1] != want[1] {
+			t.Errorf("keys = %+v, want %+v", meta.Keys, want)
+		}
+	})
+
+	t.Run("should never include secret values", func(t *testing.T) {
+		result, err := tool.Execute(context.Background(), `{"namespace":"default","secret_name":"regcred"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, value := range []string{
+			"hunter2",
+			base64.StdEncoding.EncodeToString([]byte("hunter2")),
+			"dXNlcjpodW50ZXIy",
+			base64.StdEncoding.EncodeToString([]byte(dockerConfig)),
+			"registry.example.com",
+		} {
+			if strings.Contains(result, value) {
+				t.Errorf("output leaks secret value %q: %s", value, result)
+			}
+		}
+	})
+
+	t.Run("should fail for non-existent secret", func(t *testing.T) {
+		if _, err := tool.Execute(context.Background(), `{"namespace":"default","secret_name":"missing"}`); err == nil {
+			t.Fatalf("expected error for non-existent secret")
+		}
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -15,6 +18,10 @@ import (
 type DeletePodArgs struct {
 	Namespace string `json:"namespace"`
 	PodName   string `json:"pod_name"`
+	// OwnerKind/OwnerName ("Deployment" or "ReplicaSet") let the tool resolve the
+	// current pod when PodName has already been replaced. Optional.
+	OwnerKind string `json:"owner_kind,omitempty"`
+	OwnerName string `json:"owner_name,omitempty"`
 }
 
 type PatchDeploymentArgs struct {
@@ -23,6 +30,11 @@ type PatchDeploymentArgs struct {
 	PatchJSON      string `json:"patch_json"`
 }
 
+type RolloutRestartDeploymentArgs struct {
+	Namespace      string `json:"namespace"`
+	DeploymentName string `json:"deployment_name"`
+}
+
 type ScaleStatefulSetArgs struct {
 	Namespace       string `json:"namespace"`
 	StatefulSetName string `json:"statefulset_name"`
@@ -57,6 +69,15 @@ func (t *DeletePodTool) Schema() string {
 			"pod_name": {
 				"type": "string",
 				"description": "The name of the pod to delete"
+			},
+			"owner_kind": {
+				"type": "string",
+				"enum": ["Deployment", "ReplicaSet"],
+				"description": "Optional. Kind of the pod's owner, used to find the current pod if pod_name no longer exists"
+			},
+			"owner_name": {
+				"type": "string",
+				"description": "Optional. Name of the pod's owner (required with owner_kind)"
 			}
 		},
 		"required": ["namespace", "pod_name"]
@@ -74,11 +95,95 @@ func (t *DeletePodTool) Execute(ctx context.Context, args string) (string, error
 	}
 
 	err := t.client.CoreV1().Pods(parsedArgs.Namespace).Delete(ctx, parsedArgs.PodName, metav1.DeleteOptions{})
-	if err != nil {
+	if err == nil {
+		return fmt.Sprintf("Successfully deleted pod '%s' in namespace '%s'", parsedArgs.PodName, parsedArgs.Namespace), nil
+	}
+	if !apierrors.IsNotFound(err) {
 		return "", fmt.Errorf("failed to delete pod: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully deleted pod '%s' in namespace '%s'", parsedArgs.PodName, parsedArgs.Namespace), nil
+	// The pod is gone, most likely replaced by its controller since the alert fired.
+	if parsedArgs.OwnerKind == "" || parsedArgs.OwnerName == "" {
+		return "", fmt.Errorf("pod '%s' no longer exists in namespace '%s' (it may have been replaced); "+
+			"pass owner_kind and owner_name to target its current replacement", parsedArgs.PodName, parsedArgs.Namespace)
+	}
+
+	pods, err := t.resolvePodsByOwner(ctx, parsedArgs.Namespace, parsedArgs.OwnerKind, parsedArgs.OwnerName)
+	if err != nil {
+		return "", fmt.Errorf("pod '%s' no longer exists and resolving %s '%s' failed: %w",
+			parsedArgs.PodName, parsedArgs.OwnerKind, parsedArgs.OwnerName, err)
+	}
+
+	switch len(pods) {
+	case 0:
+		return "", fmt.Errorf("pod '%s' no longer exists and %s '%s' has no current pods",
+			parsedArgs.PodName, parsedArgs.OwnerKind, parsedArgs.OwnerName)
+	case 1:
+		if err := t.client.CoreV1().Pods(parsedArgs.Namespace).Delete(ctx, pods[0], metav1.DeleteOptions{}); err != nil {
+			return "", fmt.Errorf("failed to delete pod: %w", err)
+		}
+		return fmt.Sprintf("Pod '%s' no longer exists; successfully deleted its replacement '%s' (owned by %s '%s') in namespace '%s'",
+			parsedArgs.PodName, pods[0], parsedArgs.OwnerKind, parsedArgs.OwnerName, parsedArgs.Namespace), nil
+	default:
+		// Deleting every replica is not what was approved; let the agent pick one.
+		return fmt.Sprintf("Pod '%s' no longer exists. %s '%s' currently owns %d pods: %s. No pod was deleted; call delete_pod again with one of these names.",
+			parsedArgs.PodName, parsedArgs.OwnerKind, parsedArgs.OwnerName, len(pods), strings.Join(pods, ", ")), nil
+	}
+}
+
+// resolvePodsByOwner returns the names of the pods currently controlled by the given
+// Deployment (via its ReplicaSets) or ReplicaSet.
+func (t *DeletePodTool) resolvePodsByOwner(ctx context.Context, namespace, kind, name string) ([]string, error) {
+	var replicaSets map[string]bool
+	var selector *metav1.LabelSelector
+
+	switch kind {
+	case "Deployment":
+		deploy, err := t.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = deploy.Spec.Selector
+		rsList, err := t.client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: metav1.FormatLabelSelector(selector),
+		})
+		if err != nil {
+			return nil, err
+		}
+		replicaSets = make(map[string]bool)
+		for _, rs := range rsList.Items {
+			if ref := metav1.GetControllerOf(&rs); ref != nil && ref.Kind == "Deployment" && ref.Name == name {
+				replicaSets[rs.Name] = true
+			}
+		}
+	case "ReplicaSet":
+		rs, err := t.client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = rs.Spec.Selector
+		replicaSets = map[string]bool{name: true}
+	default:
+		return nil, fmt.Errorf("unsupported owner_kind %q (expected Deployment or ReplicaSet)", kind)
+	}
+
+	podList, err := t.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(selector),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []string
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if ref := metav1.GetControllerOf(&pod); ref != nil && ref.Kind == "ReplicaSet" && replicaSets[ref.Name] {
+			pods = append(pods, pod.Name)
+		}
+	}
+	return pods, nil
 }
 
 // PatchDeploymentTool implements the patch_deployment tool
@@ -138,6 +243,76 @@ func (t *PatchDeploymentTool) Execute(ctx context.Context, args string) (string,
 	return fmt.Sprintf("Successfully patched deployment '%s' in namespace '%s'", parsedArgs.DeploymentName, parsedArgs.Namespace), nil
 }
 
+// restartedAtAnnotation is the pod template annotation `kubectl rollout restart` sets.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// RolloutRestartDeploymentTool implements the rollout_restart_deployment tool
+type RolloutRestartDeploymentTool struct {
+	client kubernetes.Interface
+}
+
+func NewRolloutRestartDeploymentTool(client kubernetes.Interface) *RolloutRestartDeploymentTool {
+	return &RolloutRestartDeploymentTool{client: client}
+}
+
+func (t *RolloutRestartDeploymentTool) Name() string {
+	return "rollout_restart_deployment"
+}
+
+func (t *RolloutRestartDeploymentTool) Description() string {
+	return "Trigger a rolling restart of a deployment, like `kubectl rollout restart`. Pods are replaced gradually according to the deployment's rollout strategy, so this is safer than deleting pods one by one. This is a high-risk operation and requires explicit approval. Use this to recover a deployment whose pods are stuck or need to reload configuration."
+}
+
+func (t *RolloutRestartDeploymentTool) Schema() string {
+	return `{
+		"type": "object",
+		"properties": {
+			"namespace": {
+				"type": "string",
+				"description": "The namespace of the deployment"
+			},
+			"deployment_name": {
+				"type": "string",
+				"description": "The name of the deployment to restart"
+			}
+		},
+		"required": ["namespace", "deployment_name"]
+	}`
+}
+
+func (t *RolloutRestartDeploymentTool) SafetyLevel() agent.SafetyLevel {
+	return agent.SafetyLevelHighRisk
+}
+
+func (t *RolloutRestartDeploymentTool) Execute(ctx context.Context, args string) (string, error) {
+	var parsedArgs RolloutRestartDeploymentArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Changing the pod template makes the deployment controller roll out new pods.
+	restartedAt := time.Now().Format(time.RFC3339)
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]string{restartedAtAnnotation: restartedAt},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = t.client.AppsV1().Deployments(parsedArgs.Namespace).Patch(ctx, parsedArgs.DeploymentName, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to restart deployment: %w", err)
+	}
+
+	return fmt.Sprintf("Successfully triggered rollout restart of deployment '%s' in namespace '%s' at %s", parsedArgs.DeploymentName, parsedArgs.Namespace, restartedAt), nil
+}
+
 // ScaleStatefulSetTool implements the scale_statefulset tool
 type ScaleStatefulSetTool struct {
 	client kubernetes.Interface
@@ -180,6 +355,34 @@ func (t *ScaleStatefulSetTool) SafetyLevel() agent.SafetyLevel {
 	return agent.SafetyLevelHighRisk
 }
 
+// RollbackArgs implements agent.ReversibleTool: it reads the StatefulSet's current
+// replica count so a rollback can scale it back.
+func (t *ScaleStatefulSetTool) RollbackArgs(ctx context.Context, args string) (string, error) {
+	var parsedArgs ScaleStatefulSetArgs
+	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	sts, err := t.client.AppsV1().StatefulSets(parsedArgs.Namespace).Get(ctx, parsedArgs.StatefulSetName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get statefulset: %w", err)
+	}
+	replicas := int32(1) // API default when spec.replicas is unset
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	previous, err := json.Marshal(ScaleStatefulSetArgs{
+		Namespace:       parsedArgs.Namespace,
+		StatefulSetName: parsedArgs.StatefulSetName,
+		Replicas:        replicas,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(previous), nil
+}
+
 func (t *ScaleStatefulSetTool) Execute(ctx context.Context, args string) (string, error) {
 	var parsedArgs ScaleStatefulSetArgs
 	if err := json.Unmarshal([]byte(args), &parsedArgs); err != nil {
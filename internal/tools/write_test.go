@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -62,6 +63,69 @@ func TestDeletePodTool(t *testing.T) {
 	})
 }
 
+func TestDeletePodTool_ResolveByOwner(t *testing.T) {
+	isController := true
+	labels := map[string]string{"app": "web"}
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-7d9f", Namespace: "default", Labels: labels,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &isController}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", Labels: labels,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d9f", Controller: &isController}},
+		}}
+	}
+
+	t.Run("should report stale pod clearly without owner", func(t *testing.T) {
+		tool := NewDeletePodTool(fake.NewSimpleClientset(deploy, rs, newPod("web-7d9f-new")))
+		_, err := tool.Execute(context.Background(), `{"namespace":"default","pod_name":"web-7d9f-old"}`)
+		if err == nil || !contains(err.Error(), "no longer exists") {
+			t.Fatalf("expected 'no longer exists' error, got %v", err)
+		}
+	})
+
+	t.Run("should delete the replacement pod via deployment", func(t *testing.T) {
+		client := fake.NewSimpleClientset(deploy, rs, newPod("web-7d9f-new"))
+		tool := NewDeletePodTool(client)
+		result, err := tool.Execute(context.Background(),
+			`{"namespace":"default","pod_name":"web-7d9f-old","owner_kind":"Deployment","owner_name":"web"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !contains(result, "deleted its replacement 'web-7d9f-new'") {
+			t.Errorf("unexpected result: %s", result)
+		}
+		if _, err := client.CoreV1().Pods("default").Get(context.Background(), "web-7d9f-new", metav1.GetOptions{}); err == nil {
+			t.Error("expected replacement pod to be deleted")
+		}
+	})
+
+	t.Run("should not delete when owner has several pods", func(t *testing.T) {
+		client := fake.NewSimpleClientset(deploy, rs, newPod("web-7d9f-a"), newPod("web-7d9f-b"))
+		tool := NewDeletePodTool(client)
+		result, err := tool.Execute(context.Background(),
+			`{"namespace":"default","pod_name":"web-7d9f-old","owner_kind":"ReplicaSet","owner_name":"web-7d9f"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !contains(result, "No pod was deleted") || !contains(result, "web-7d9f-a") || !contains(result, "web-7d9f-b") {
+			t.Errorf("unexpected result: %s", result)
+		}
+		pods, _ := client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+		if len(pods.Items) != 2 {
+			t.Errorf("expected 2 pods to remain, got %d", len(pods.Items))
+		}
+	})
+}
+
 func TestPatchDeploymentTool(t *testing.T) {
 	client := fake.NewSimpleClientset(
 		&appsv1.Deployment{
@@ -137,6 +201,72 @@ func TestPatchDeploymentTool(t *testing.T) {
 	})
 }
 
+func TestRolloutRestartDeploymentTool(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-deployment",
+				Namespace: "default",
+			},
+		},
+	)
+
+	tool := NewRolloutRestartDeploymentTool(client)
+
+	t.Run("should have HighRisk safety level", func(t *testing.T) {
+		if tool.SafetyLevel() != "HighRisk" {
+			t.Errorf("expected HighRisk safety level, got %s", tool.SafetyLevel())
+		}
+	})
+
+	t.Run("should have correct metadata", func(t *testing.T) {
+		if tool.Name() != "rollout_restart_deployment" {
+			t.Errorf("expected name 'rollout_restart_deployment', got %s", tool.Name())
+		}
+		if !json.Valid([]byte(tool.Schema())) {
+			t.Errorf("schema is not valid JSON")
+		}
+	})
+
+	t.Run("should set the restartedAt annotation", func(t *testing.T) {
+		args := RolloutRestartDeploymentArgs{
+			Namespace:      "default",
+			DeploymentName: "test-deployment",
+		}
+		argsJSON, _ := json.Marshal(args)
+		result, err := tool.Execute(context.Background(), string(argsJSON))
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !contains(result, "rollout restart") {
+			t.Fatalf("expected restart message in result, got %q", result)
+		}
+
+		deploy, err := client.AppsV1().Deployments("default").Get(context.Background(), "test-deployment", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get deployment: %v", err)
+		}
+		restartedAt := deploy.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"]
+		if _, err := time.Parse(time.RFC3339, restartedAt); err != nil {
+			t.Errorf("expected RFC3339 restartedAt annotation, got %q", restartedAt)
+		}
+	})
+
+	t.Run("should fail for non-existent deployment", func(t *testing.T) {
+		args := RolloutRestartDeploymentArgs{
+			Namespace:      "default",
+			DeploymentName: "non-existent",
+		}
+		argsJSON, _ := json.Marshal(args)
+		_, err := tool.Execute(context.Background(), string(argsJSON))
+
+		if err == nil {
+			t.Fatalf("expected error for non-existent deployment")
+		}
+	})
+}
+
 func TestScaleStatefulSetTool(t *testing.T) {
 	client := fake.NewSimpleClientset(
 		&appsv1.StatefulSet{
@@ -184,6 +314,27 @@ func TestScaleStatefulSetTool(t *testing.T) {
 		}
 	})
 
+	t.Run("should capture current replicas for rollback", func(t *testing.T) {
+		replicas := int32(4)
+		client := fake.NewSimpleClientset(&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		})
+		rollback, err := NewScaleStatefulSetTool(client).RollbackArgs(context.Background(),
+			`{"namespace":"default","statefulset_name":"db","replicas":0}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got ScaleStatefulSetArgs
+		if err := json.Unmarshal([]byte(rollback), &got); err != nil {
+			t.Fatalf("rollback args are not valid JSON: %v", err)
+		}
+		want := ScaleStatefulSetArgs{Namespace: "default", StatefulSetName: "db", Replicas: 4}
+		if got != want {
+			t.Errorf("rollback args = %+v, want %+v", got, want)
+		}
+	})
+
 	t.Run("should fail for non-existent statefulset", func(t *testing.T) {
 		args := ScaleStatefulSetArgs{
 			Namespace:       "default",